@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	driver "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"time"
+)
+
+// Bundle is the support bundle exported by neodiag
+type Bundle struct {
+	CapturedAt   time.Time     `json:"capturedAt"`
+	SelfTest     []Check       `json:"selfTest"`
+	Capabilities Capabilities  `json:"capabilities"`
+	Schema       SchemaInfo    `json:"schema"`
+	Routing      *RoutingTable `json:"routing,omitempty"`
+	Driver       DriverStats   `json:"driver"`
+}
+
+// Check is the outcome of a self-test step
+type Check struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Capabilities describes the server and the procedures it offers
+type Capabilities struct {
+	Version         string   `json:"version,omitempty"`
+	Edition         string   `json:"edition,omitempty"`
+	Agent           string   `json:"agent,omitempty"`
+	ProtocolVersion string   `json:"protocolVersion,omitempty"`
+	Address         string   `json:"address,omitempty"`
+	Plugins         []string `json:"plugins,omitempty"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// SchemaInfo is a snapshot of the indexes and constraints of the database
+type SchemaInfo struct {
+	Indexes     []driver.IndexInfo `json:"indexes"`
+	Constraints []ConstraintInfo   `json:"constraints"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// ConstraintInfo describes an existing constraint
+type ConstraintInfo struct {
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`
+	LabelsOrTypes []string `json:"labelsOrTypes"`
+	Properties    []string `json:"properties"`
+}
+
+// RoutingTable is the routing table the cluster serves for the database, only fetched for neo4j:// connection strings
+type RoutingTable struct {
+	TTL     int64           `json:"ttl"`
+	Servers []RoutingServer `json:"servers"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// RoutingServer lists the addresses serving a role, i.e. WRITE, READ or ROUTE
+type RoutingServer struct {
+	Role      string   `json:"role"`
+	Addresses []string `json:"addresses"`
+}
+
+// DriverStats is the state of the driver at the end of the export
+type DriverStats struct {
+	Scheme         string                           `json:"scheme"`
+	Host           string                           `json:"host"`
+	Port           int                              `json:"port"`
+	RoutingContext map[string]string                `json:"routingContext,omitempty"`
+	Identity       string                           `json:"identity,omitempty"`
+	State          string                           `json:"state"`
+	Concurrency    map[string]driver.SemaphoreUsage `json:"concurrency,omitempty"`
+	Recovery       driver.RecoveryUsage             `json:"recovery"`
+	Quotas         map[string]driver.QuotaUsage     `json:"quotas,omitempty"`
+	Usage          driver.DriverUsage               `json:"usage"`
+}
+
+// plugins are the procedure namespaces reported as capabilities when the server offers them
+var plugins = []string{"apoc", "gds", "db.index.fulltext", "db.index.vector"}
+
+func collect(ctx context.Context, neo4jDriver *driver.Driver, database string) Bundle {
+	var executor driver.QueryExecutor = neo4jDriver
+	var runner driver.QueryRunner = neo4jDriver
+	if database != "" {
+		runner = driver.OnDatabase(neo4jDriver, database)
+	}
+	bundle := Bundle{
+		CapturedAt:   time.Now(),
+		SelfTest:     selfTest(ctx, executor, runner),
+		Capabilities: capabilities(ctx, runner),
+		Schema:       schemaSnapshot(ctx, runner),
+	}
+	info := neo4jDriver.ConnectionInfo()
+	if info.Routed() {
+		routing := routingTable(ctx, neo4jDriver, info.RoutingContext, database)
+		bundle.Routing = &routing
+	}
+	bundle.Driver = driverStats(neo4jDriver)
+	return bundle
+}
+
+func selfTest(ctx context.Context, executor driver.QueryExecutor, runner driver.QueryRunner) []Check {
+	return []Check{
+		check("connectivity", func() error {
+			return executor.VerifyConnectivity(ctx)
+		}),
+		check("read", func() error {
+			return runner.ExecuteQuery(ctx, "RETURN 1", nil, consume(ctx), driver.WithQueryName("neodiag.read"), driver.WithAccessMode(neo4j.AccessModeRead))
+		}),
+		check("write", func() error {
+			// an empty write transaction reaches the leader without changing the graph
+			return runner.ExecuteQuery(ctx, "RETURN 1", nil, consume(ctx), driver.WithQueryName("neodiag.write"))
+		}),
+	}
+}
+
+func check(name string, fn func() error) Check {
+	start := time.Now()
+	err := fn()
+	result := Check{Name: name, Passed: err == nil, Duration: time.Since(start)}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func consume(ctx context.Context) driver.ResultsHookFn {
+	return func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(ctx)
+		return err
+	}
+}
+
+func capabilities(ctx context.Context, runner driver.QueryRunner) Capabilities {
+	var result Capabilities
+	var diagnostics driver.QueryDiagnostics
+	err := runner.ExecuteQuery(ctx, "CALL dbms.components() YIELD name, versions, edition WHERE name = 'Neo4j Kernel' RETURN versions[0] AS version, edition", nil, func(records neo4j.ResultWithContext) error {
+		record, err := records.Single(ctx)
+		if err != nil {
+			return err
+		}
+		result.Version, _ = record.Values[0].(string)
+		result.Edition, _ = record.Values[1].(string)
+		return nil
+	}, driver.WithQueryName("neodiag.components"), driver.WithAccessMode(neo4j.AccessModeRead), driver.WithDiagnostics(&diagnostics))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Agent = diagnostics.ServerAgent
+	result.ProtocolVersion = diagnostics.ProtocolVersion
+	result.Address = diagnostics.ServerAddress
+	err = runner.ExecuteQuery(ctx, "SHOW PROCEDURES YIELD name WITH [plugin IN $plugins WHERE name STARTS WITH plugin + '.'] AS matches UNWIND matches AS plugin RETURN DISTINCT plugin ORDER BY plugin",
+		map[string]interface{}{"plugins": plugins}, func(records neo4j.ResultWithContext) error {
+			var record *neo4j.Record
+			for records.NextRecord(ctx, &record) {
+				if plugin, ok := record.Values[0].(string); ok {
+					result.Plugins = append(result.Plugins, plugin)
+				}
+			}
+			return records.Err()
+		}, driver.WithQueryName("neodiag.plugins"), driver.WithAccessMode(neo4j.AccessModeRead))
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func schemaSnapshot(ctx context.Context, runner driver.QueryRunner) SchemaInfo {
+	var result SchemaInfo
+	indexes, err := driver.NewSchema(runner).ListIndexes(ctx)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Indexes = indexes
+	err = runner.ExecuteQuery(ctx, "SHOW CONSTRAINTS YIELD name, type, labelsOrTypes, properties RETURN name, type, labelsOrTypes, properties ORDER BY name", nil, func(records neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for records.NextRecord(ctx, &record) {
+			name, _ := record.Values[0].(string)
+			kind, _ := record.Values[1].(string)
+			result.Constraints = append(result.Constraints, ConstraintInfo{
+				Name:          name,
+				Type:          kind,
+				LabelsOrTypes: toStrings(record.Values[2]),
+				Properties:    toStrings(record.Values[3]),
+			})
+		}
+		return records.Err()
+	}, driver.WithQueryName("neodiag.constraints"), driver.WithAccessMode(neo4j.AccessModeRead))
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func routingTable(ctx context.Context, runner driver.QueryRunner, routingContext map[string]string, database string) RoutingTable {
+	var result RoutingTable
+	params := map[string]interface{}{"context": toAnyMap(routingContext), "database": nil}
+	if database != "" {
+		params["database"] = database
+	}
+	err := runner.ExecuteQuery(ctx, "CALL dbms.routing.getRoutingTable($context, $database) YIELD ttl, servers RETURN ttl, servers", params, func(records neo4j.ResultWithContext) error {
+		record, err := records.Single(ctx)
+		if err != nil {
+			return err
+		}
+		result.TTL, _ = record.Values[0].(int64)
+		servers, _ := record.Values[1].([]interface{})
+		for _, server := range servers {
+			fields, _ := server.(map[string]interface{})
+			role, _ := fields["role"].(string)
+			result.Servers = append(result.Servers, RoutingServer{Role: role, Addresses: toStrings(fields["addresses"])})
+		}
+		return nil
+	}, driver.WithQueryName("neodiag.routingTable"), driver.WithAccessMode(neo4j.AccessModeRead), driver.WithDatabase(driver.SystemDatabase))
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func driverStats(neo4jDriver *driver.Driver) DriverStats {
+	info := neo4jDriver.ConnectionInfo()
+	stats := DriverStats{
+		Scheme:         info.Scheme,
+		Host:           info.Host,
+		Port:           info.Port,
+		RoutingContext: info.RoutingContext,
+		Identity:       neo4jDriver.Identity(),
+		State:          neo4jDriver.State().String(),
+		Recovery:       neo4jDriver.RecoveryUsage(),
+		Quotas:         neo4jDriver.QuotaUsage(),
+		Usage:          neo4jDriver.Usage(),
+	}
+	if usage := neo4jDriver.ConcurrencyUsage(); len(usage) > 0 {
+		stats.Concurrency = make(map[string]driver.SemaphoreUsage, len(usage))
+		for mode, semaphore := range usage {
+			stats.Concurrency[accessModeName(mode)] = semaphore
+		}
+	}
+	return stats
+}
+
+func accessModeName(mode neo4j.AccessMode) string {
+	if mode == neo4j.AccessModeRead {
+		return "read"
+	}
+	return "write"
+}
+
+func toStrings(value interface{}) []string {
+	values, _ := value.([]interface{})
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
+
+func toAnyMap(values map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestBundle(t *testing.T) {
+	suite.Run(t, new(BundleTestSuite))
+}
+
+type BundleTestSuite struct {
+	suite.Suite
+	ctx  context.Context
+	mock *MockDriver
+}
+
+func (s *BundleTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mock = NewMockDriver()
+}
+
+func (s *BundleTestSuite) TestSelfTestReportsEveryCheck() {
+	s.mock.ExpectQuery(`^RETURN 1$`)
+	s.mock.ExpectQuery(`^RETURN 1$`).WillReturnError(errors.New("no leader"))
+
+	checks := selfTest(s.ctx, s.mock, s.mock)
+
+	s.Require().Len(checks, 3)
+	s.Equal("connectivity", checks[0].Name)
+	s.True(checks[0].Passed)
+	s.True(checks[1].Passed)
+	s.False(checks[2].Passed)
+	s.Equal("no leader", checks[2].Error)
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *BundleTestSuite) TestCollectsTheServerCapabilities() {
+	s.mock.ExpectQuery(`dbms\.components`).WillReturn([]string{"version", "edition"}, []any{"5.5.0", "enterprise"})
+	s.mock.ExpectQuery(`^SHOW PROCEDURES`).WillReturn([]string{"plugin"}, []any{"apoc"}, []any{"gds"})
+
+	capabilities := capabilities(s.ctx, s.mock)
+
+	s.Empty(capabilities.Error)
+	s.Equal("5.5.0", capabilities.Version)
+	s.Equal("enterprise", capabilities.Edition)
+	s.Equal([]string{"apoc", "gds"}, capabilities.Plugins)
+}
+
+func (s *BundleTestSuite) TestSnapshotsTheSchema() {
+	s.mock.ExpectQuery(`dbms\.components`).WillReturn([]string{"version"}, []any{"5.5.0"})
+	s.mock.ExpectQuery(`^SHOW INDEXES`).WillReturn([]string{"name", "type", "labelsOrTypes", "properties", "state"},
+		[]any{"person_name", "RANGE", []any{"Person"}, []any{"name"}, "ONLINE"})
+	s.mock.ExpectQuery(`^SHOW CONSTRAINTS`).WillReturn([]string{"name", "type", "labelsOrTypes", "properties"},
+		[]any{"person_id", "UNIQUENESS", []any{"Person"}, []any{"id"}})
+
+	schema := schemaSnapshot(s.ctx, s.mock)
+
+	s.Empty(schema.Error)
+	s.Equal([]IndexInfo{{Name: "person_name", Type: "RANGE", LabelsOrTypes: []string{"Person"}, Properties: []string{"name"}, State: "ONLINE"}}, schema.Indexes)
+	s.Equal([]ConstraintInfo{{Name: "person_id", Type: "UNIQUENESS", LabelsOrTypes: []string{"Person"}, Properties: []string{"id"}}}, schema.Constraints)
+}
+
+func (s *BundleTestSuite) TestReportsFailingSectionsInsteadOfAborting() {
+	s.mock.ExpectQuery(`dbms\.components`).WillReturnError(errors.New("permission denied"))
+
+	schema := schemaSnapshot(s.ctx, s.mock)
+
+	s.Equal("permission denied", schema.Error)
+	s.Empty(schema.Indexes)
+}
+
+func (s *BundleTestSuite) TestFetchesTheRoutingTableFromTheSystemDatabase() {
+	s.mock.ExpectQuery(`getRoutingTable`).OnDatabase(SystemDatabase).
+		WithParams(map[string]interface{}{"context": map[string]interface{}{"region": "eu"}, "database": "orders"}).
+		WillReturn([]string{"ttl", "servers"}, []any{int64(300), []any{
+			map[string]any{"role": "WRITE", "addresses": []any{"core-1:7687"}},
+			map[string]any{"role": "READ", "addresses": []any{"core-2:7687", "core-3:7687"}},
+		}})
+
+	table := routingTable(s.ctx, s.mock, map[string]string{"region": "eu"}, "orders")
+
+	s.Empty(table.Error)
+	s.Equal(int64(300), table.TTL)
+	s.Equal([]RoutingServer{
+		{Role: "WRITE", Addresses: []string{"core-1:7687"}},
+		{Role: "READ", Addresses: []string{"core-2:7687", "core-3:7687"}},
+	}, table.Servers)
+}
@@ -0,0 +1,67 @@
+// Command neodiag exports a support bundle describing a Neo4j deployment as seen by this driver,
+// to attach to support tickets with Neo4j.
+//
+// it connects with the same Settings as the applications, runs a self-test, then collects the server capabilities,
+// a schema snapshot, the routing table and the driver stats into a single JSON document:
+//
+//	neodiag -uri neo4j://localhost -user neo4j -password secret -out bundle.json
+//
+// the connection flags default to the NEO4J_URI, NEO4J_USER and NEO4J_PASSWORD environment variables.
+// a failing section is reported in the bundle instead of aborting the export
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	driver "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"io"
+	"os"
+	"time"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "neodiag:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("neodiag", flag.ContinueOnError)
+	uri := flags.String("uri", os.Getenv("NEO4J_URI"), "connection string, see driver.Settings")
+	user := flags.String("user", os.Getenv("NEO4J_USER"), "user to authenticate as")
+	password := flags.String("password", os.Getenv("NEO4J_PASSWORD"), "password of the user")
+	database := flags.String("database", "", "database to snapshot, defaults to the default database of the server")
+	out := flags.String("out", "", "file receiving the bundle, defaults to the standard output")
+	timeout := flags.Duration("timeout", time.Minute, "bound of the whole export")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *uri == "" {
+		return fmt.Errorf("missing connection string, set -uri or NEO4J_URI")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	neo4jDriver, err := driver.NewDriver(driver.Settings{ConnectionString: *uri, User: *user, Password: *password})
+	if err != nil {
+		return err
+	}
+	defer neo4jDriver.Close(ctx)
+
+	bundle := collect(ctx, neo4jDriver, *database)
+	writer := stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		writer = file
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bundle)
+}
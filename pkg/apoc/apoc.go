@@ -0,0 +1,260 @@
+// Package apoc wraps common APOC procedures with typed parameters and results:
+//
+//	client := apoc.New(d)
+//	result, err := client.PeriodicIterate(ctx, apoc.IterateConfig{
+//		Iterate:   "MATCH (u:User) WHERE u.legacy RETURN u",
+//		Action:    "SET u.legacy = false",
+//		BatchSize: 10_000,
+//	})
+//
+// ExecuteLargeMutation drives the same procedure for refactorings lasting long enough to need progress reports and cancellation.
+// APOC is a server plugin, calls fail with an error matching ErrNotInstalled when the server does not provide it,
+// see Client.Installed to check beforehand.
+package apoc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotInstalled is matched by the errors of the calls to APOC procedures and functions the server does not provide
+var ErrNotInstalled = errors.New("APOC is not installed")
+
+// ErrIterateFailed is returned by PeriodicIterate when some of its batches failed
+var ErrIterateFailed = errors.New("apoc.periodic.iterate failed")
+
+// NotInstalledError is returned for the calls to APOC procedures and functions the server does not provide,
+// either because APOC is not installed or because the procedure is not allowed by the server configuration
+type NotInstalledError struct {
+	Procedure string
+	Err       error
+}
+
+func (e *NotInstalledError) Error() string {
+	return fmt.Sprintf("%s: %s is not available: %v", ErrNotInstalled.Error(), e.Procedure, e.Err)
+}
+
+func (e *NotInstalledError) Is(target error) bool {
+	return target == ErrNotInstalled
+}
+
+func (e *NotInstalledError) Unwrap() error {
+	return e.Err
+}
+
+// Client calls APOC procedures through a runner, typically a *driver.Driver
+type Client struct {
+	runner  driver.QueryRunner
+	mutex   sync.Mutex
+	version *string
+}
+
+// New creates a client running its queries through runner
+func New(runner driver.QueryRunner) *Client {
+	return &Client{runner: runner}
+}
+
+// Version returns the APOC version installed on the server, querying apoc.version() the first time
+func (c *Client) Version(ctx context.Context) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.version != nil {
+		return *c.version, nil
+	}
+	var version string
+	err := c.runner.ExecuteQuery(ctx, "RETURN apoc.version() AS version", nil, func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(ctx)
+		if err != nil {
+			return err
+		}
+		version, _ = record.Values[0].(string)
+		return nil
+	}, driver.WithQueryName("apoc.version"), driver.WithAccessMode(neo4j.AccessModeRead))
+	if err != nil {
+		return "", notInstalled("apoc.version", err)
+	}
+	c.version = &version
+	return version, nil
+}
+
+// Installed reports whether the server provides APOC
+func (c *Client) Installed(ctx context.Context) (bool, error) {
+	_, err := c.Version(ctx)
+	if errors.Is(err, ErrNotInstalled) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// IterateConfig configures apoc.periodic.iterate
+type IterateConfig struct {
+	// Iterate is the query returning the items to process
+	Iterate string
+	// Action is the query run for each batch of items, it reads the items returned by Iterate by name
+	Action string
+	// Params are passed to both queries
+	Params map[string]interface{}
+	// BatchSize is the number of items processed per transaction, defaults to 1000
+	BatchSize int
+	// Parallel processes the batches concurrently, only safe when they cannot lock the same nodes
+	Parallel bool
+	// Retries is the number of times a failed batch is retried
+	Retries int
+}
+
+// IterateResult summarizes an apoc.periodic.iterate call
+type IterateResult struct {
+	Batches, Total                        int64
+	CommittedOperations, FailedOperations int64
+	FailedBatches, Retries                int64
+	// ErrorMessages counts the failed operations per error message
+	ErrorMessages map[string]int64
+	// BatchErrors counts the failed batches per error message
+	BatchErrors   map[string]int64
+	TimeTaken     time.Duration
+	WasTerminated bool
+}
+
+// PeriodicIterate runs config.Action for every batch of the items returned by config.Iterate, each batch in its own transaction,
+// so that large mutations do not build up a single huge transaction. the result is returned along with an error
+// matching ErrIterateFailed when some operations failed
+func (c *Client) PeriodicIterate(ctx context.Context, config IterateConfig) (IterateResult, error) {
+	return c.periodicIterate(ctx, config)
+}
+
+func (c *Client) periodicIterate(ctx context.Context, config IterateConfig, opts ...driver.QueryOption) (IterateResult, error) {
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	params := map[string]interface{}{
+		"iterate": config.Iterate,
+		"action":  config.Action,
+		"config": map[string]interface{}{
+			"batchSize": batchSize,
+			"parallel":  config.Parallel,
+			"retries":   config.Retries,
+			"params":    config.Params,
+		},
+	}
+	var result IterateResult
+	err := c.runner.ExecuteQuery(ctx, "CALL apoc.periodic.iterate($iterate, $action, $config) "+
+		"YIELD batches, total, timeTaken, committedOperations, failedOperations, failedBatches, retries, errorMessages, wasTerminated, batch "+
+		"RETURN batches, total, timeTaken, committedOperations, failedOperations, failedBatches, retries, errorMessages, wasTerminated, batch", params, func(records neo4j.ResultWithContext) error {
+		record, err := records.Single(ctx)
+		if err != nil {
+			return err
+		}
+		result = IterateResult{
+			Batches:             toInt(record.Values[0]),
+			Total:               toInt(record.Values[1]),
+			TimeTaken:           time.Duration(toInt(record.Values[2])) * time.Second,
+			CommittedOperations: toInt(record.Values[3]),
+			FailedOperations:    toInt(record.Values[4]),
+			FailedBatches:       toInt(record.Values[5]),
+			Retries:             toInt(record.Values[6]),
+			ErrorMessages:       toIntMap(record.Values[7]),
+		}
+		result.WasTerminated, _ = record.Values[8].(bool)
+		batch, _ := record.Values[9].(map[string]interface{})
+		result.BatchErrors = toIntMap(batch["errors"])
+		return nil
+	}, append([]driver.QueryOption{driver.WithQueryName("apoc.periodic.iterate")}, opts...)...)
+	if err != nil {
+		return IterateResult{}, notInstalled("apoc.periodic.iterate", err)
+	}
+	if result.FailedOperations > 0 || result.FailedBatches > 0 {
+		return result, fmt.Errorf("%w: %d failed operations in %d failed batches: %s",
+			ErrIterateFailed, result.FailedOperations, result.FailedBatches, strings.Join(sortedKeys(result.ErrorMessages, result.BatchErrors), "; "))
+	}
+	return result, nil
+}
+
+// ExportStats summarizes an apoc.export.json.query call
+type ExportStats struct {
+	Nodes, Relationships, Properties, Rows int64
+	TimeTaken                              time.Duration
+}
+
+// ExportJSON streams the results of query to w as JSON lines, the format of apoc.export.json.
+// the export is streamed back to the client, so it does not require apoc.export.file.enabled on the server
+func (c *Client) ExportJSON(ctx context.Context, query string, params map[string]interface{}, w io.Writer) (ExportStats, error) {
+	var stats ExportStats
+	exportParams := map[string]interface{}{
+		"query":  query,
+		"config": map[string]interface{}{"stream": true, "params": params},
+	}
+	err := c.runner.ExecuteQuery(ctx, "CALL apoc.export.json.query($query, null, $config) "+
+		"YIELD nodes, relationships, properties, rows, time, data "+
+		"RETURN nodes, relationships, properties, rows, time, data", exportParams, func(records neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for records.NextRecord(ctx, &record) {
+			stats.Nodes += toInt(record.Values[0])
+			stats.Relationships += toInt(record.Values[1])
+			stats.Properties += toInt(record.Values[2])
+			stats.Rows += toInt(record.Values[3])
+			stats.TimeTaken += time.Duration(toInt(record.Values[4])) * time.Millisecond
+			if data, ok := record.Values[5].(string); ok {
+				if _, err := io.WriteString(w, data); err != nil {
+					return err
+				}
+			}
+		}
+		return records.Err()
+	}, driver.WithQueryName("apoc.export.json.query"), driver.WithAccessMode(neo4j.AccessModeRead))
+	if err != nil {
+		return ExportStats{}, notInstalled("apoc.export.json.query", err)
+	}
+	return stats, nil
+}
+
+// notInstalled turns the errors of the server about unknown procedures and functions into a *NotInstalledError
+func notInstalled(procedure string, err error) error {
+	var neo4jErr *neo4j.Neo4jError
+	if !errors.As(err, &neo4jErr) {
+		return err
+	}
+	unknownFunction := neo4jErr.Code == "Neo.ClientError.Statement.SyntaxError" && strings.Contains(neo4jErr.Msg, "Unknown function")
+	if neo4jErr.Code == "Neo.ClientError.Procedure.ProcedureNotFound" || unknownFunction {
+		return &NotInstalledError{Procedure: procedure, Err: err}
+	}
+	return err
+}
+
+func toInt(value interface{}) int64 {
+	number, _ := value.(int64)
+	return number
+}
+
+func toIntMap(value interface{}) map[string]int64 {
+	values, _ := value.(map[string]interface{})
+	result := make(map[string]int64, len(values))
+	for key, value := range values {
+		result[key] = toInt(value)
+	}
+	return result
+}
+
+// sortedKeys returns the distinct keys of all maps, sorted
+func sortedKeys(maps ...map[string]int64) []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, values := range maps {
+		for key := range values {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,166 @@
+package apoc_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg/apoc"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestApoc(t *testing.T) {
+	suite.Run(t, new(ApocTestSuite))
+}
+
+type ApocTestSuite struct {
+	suite.Suite
+	ctx    context.Context
+	mock   *MockDriver
+	client *apoc.Client
+}
+
+func (s *ApocTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mock = NewMockDriver()
+	s.client = apoc.New(s.mock)
+}
+
+func (s *ApocTestSuite) TestDetectsAnInstalledApoc() {
+	s.mock.ExpectQuery(`apoc\.version\(\)`).WillReturn([]string{"version"}, []any{"5.5.0"})
+
+	installed, err := s.client.Installed(s.ctx)
+	s.Require().NoError(err)
+	version, err := s.client.Version(s.ctx)
+
+	s.Require().NoError(err)
+	s.True(installed)
+	s.Equal("5.5.0", version)
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *ApocTestSuite) TestDetectsAMissingApoc() {
+	s.mock.ExpectQuery(`apoc\.version\(\)`).WillReturnError(&neo4j.Neo4jError{
+		Code: "Neo.ClientError.Statement.SyntaxError",
+		Msg:  "Unknown function 'apoc.version'",
+	})
+
+	installed, err := s.client.Installed(s.ctx)
+
+	s.Require().NoError(err)
+	s.False(installed)
+}
+
+func (s *ApocTestSuite) TestReportsOtherFailuresOfTheDetection() {
+	s.mock.ExpectQuery(`apoc\.version\(\)`).WillReturnError(errors.New("server unreachable"))
+
+	installed, err := s.client.Installed(s.ctx)
+
+	s.EqualError(err, "server unreachable")
+	s.False(installed)
+}
+
+func (s *ApocTestSuite) TestRunsBatchedMutations() {
+	s.mock.ExpectQuery(`^CALL apoc\.periodic\.iterate`).WithParams(map[string]interface{}{
+		"iterate": "MATCH (u:User) RETURN u",
+		"action":  "SET u.active = true",
+		"config":  map[string]interface{}{"batchSize": 500, "parallel": false, "retries": 0, "params": map[string]interface{}(nil)},
+	}).WillReturn(
+		[]string{"batches", "total", "timeTaken", "committedOperations", "failedOperations", "failedBatches", "retries", "errorMessages", "wasTerminated", "batch"},
+		[]any{int64(3), int64(1200), int64(2), int64(1200), int64(0), int64(0), int64(0), map[string]any{}, false, map[string]any{"errors": map[string]any{}}},
+	)
+
+	result, err := s.client.PeriodicIterate(s.ctx, apoc.IterateConfig{
+		Iterate:   "MATCH (u:User) RETURN u",
+		Action:    "SET u.active = true",
+		BatchSize: 500,
+	})
+
+	s.Require().NoError(err)
+	s.Equal(int64(3), result.Batches)
+	s.Equal(int64(1200), result.CommittedOperations)
+	s.Equal(2*time.Second, result.TimeTaken)
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *ApocTestSuite) TestReportsFailedBatches() {
+	s.mock.ExpectQuery(`^CALL apoc\.periodic\.iterate`).WillReturn(
+		[]string{"batches", "total", "timeTaken", "committedOperations", "failedOperations", "failedBatches", "retries", "errorMessages", "wasTerminated", "batch"},
+		[]any{int64(2), int64(10), int64(0), int64(5), int64(5), int64(1), int64(0), map[string]any{"constraint violation": int64(5)}, false, map[string]any{"errors": map[string]any{"batch rolled back": int64(1)}}},
+	)
+
+	result, err := s.client.PeriodicIterate(s.ctx, apoc.IterateConfig{Iterate: "UNWIND range(1, 10) AS i RETURN i", Action: "CREATE (:Item {id: i})"})
+
+	s.ErrorIs(err, apoc.ErrIterateFailed)
+	s.ErrorContains(err, "batch rolled back; constraint violation")
+	s.Equal(int64(5), result.FailedOperations)
+	s.Equal(map[string]int64{"constraint violation": 5}, result.ErrorMessages)
+	s.Equal(map[string]int64{"batch rolled back": 1}, result.BatchErrors)
+}
+
+func (s *ApocTestSuite) TestFailsWithATypedErrorWhenTheProcedureIsMissing() {
+	s.mock.ExpectQuery(`^CALL apoc\.periodic\.iterate`).WillReturnError(&neo4j.Neo4jError{
+		Code: "Neo.ClientError.Procedure.ProcedureNotFound",
+		Msg:  "There is no procedure with the name `apoc.periodic.iterate` registered for this database instance",
+	})
+
+	_, err := s.client.PeriodicIterate(s.ctx, apoc.IterateConfig{Iterate: "RETURN 1 AS i", Action: "RETURN i"})
+
+	s.ErrorIs(err, apoc.ErrNotInstalled)
+	var notInstalled *apoc.NotInstalledError
+	s.Require().ErrorAs(err, &notInstalled)
+	s.Equal("apoc.periodic.iterate", notInstalled.Procedure)
+}
+
+func (s *ApocTestSuite) TestStreamsJsonExports() {
+	s.mock.ExpectQuery(`^CALL apoc\.export\.json\.query`).WillReturn(
+		[]string{"nodes", "relationships", "properties", "rows", "time", "data"},
+		[]any{int64(1), int64(0), int64(2), int64(1), int64(3), `{"type":"node","id":"1","labels":["User"]}` + "\n"},
+		[]any{int64(1), int64(0), int64(2), int64(1), int64(4), `{"type":"node","id":"2","labels":["User"]}` + "\n"},
+	)
+	var exported bytes.Buffer
+
+	stats, err := s.client.ExportJSON(s.ctx, "MATCH (u:User) RETURN u", nil, &exported)
+
+	s.Require().NoError(err)
+	s.Equal(int64(2), stats.Nodes)
+	s.Equal(int64(4), stats.Properties)
+	s.Equal(7*time.Millisecond, stats.TimeTaken)
+	s.Equal(`{"type":"node","id":"1","labels":["User"]}`+"\n"+`{"type":"node","id":"2","labels":["User"]}`+"\n", exported.String())
+}
+
+func (s *ApocTestSuite) TestDescribesTheMetaSchema() {
+	s.mock.ExpectQuery(`^CALL apoc\.meta\.schema`).WillReturn([]string{"value"}, []any{map[string]any{
+		"User": map[string]any{
+			"type":  "node",
+			"count": int64(42),
+			"properties": map[string]any{
+				"id": map[string]any{"type": "STRING", "indexed": true, "unique": true, "existence": false, "array": false},
+			},
+			"relationships": map[string]any{
+				"MEMBER_OF": map[string]any{"direction": "out", "count": int64(40), "labels": []any{"Team"}},
+			},
+		},
+		"MEMBER_OF": map[string]any{
+			"type":       "relationship",
+			"count":      int64(40),
+			"properties": map[string]any{},
+		},
+	}})
+
+	schema, err := s.client.MetaSchema(s.ctx)
+
+	s.Require().NoError(err)
+	s.Equal(apoc.MetaSchema{
+		"User": {
+			Kind:          apoc.KindNode,
+			Count:         42,
+			Properties:    map[string]apoc.PropertySchema{"id": {Type: "STRING", Indexed: true, Unique: true}},
+			Relationships: map[string]apoc.RelationshipSchema{"MEMBER_OF": {Direction: "out", Count: 40, Labels: []string{"Team"}}},
+		},
+		"MEMBER_OF": {Kind: apoc.KindRelationship, Count: 40, Properties: map[string]apoc.PropertySchema{}},
+	}, schema)
+}
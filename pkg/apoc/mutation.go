@@ -0,0 +1,164 @@
+package apoc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"time"
+)
+
+// mutationMetadataKey tags the transaction running a large mutation, so that it can be found in SHOW TRANSACTIONS
+const mutationMetadataKey = "largeMutation"
+
+const defaultProgressInterval = 5 * time.Second
+const terminationTimeout = 10 * time.Second
+
+// MutationProgress is the state of a running large mutation, see WithProgress
+type MutationProgress struct {
+	// Elapsed is the time since the mutation started
+	Elapsed time.Duration
+	// Status is the status of its transaction on the server, e.g. "Running" or "Blocked by: ...".
+	// empty when the transaction could not be found, e.g. before it started
+	Status string
+	// ActiveLocks is the number of locks its transaction holds
+	ActiveLocks int64
+	// Err is the error of the poll, the mutation keeps running
+	Err error
+}
+
+// ProgressFn receives the progress of a large mutation
+type ProgressFn func(progress MutationProgress)
+
+// MutationOption customizes ExecuteLargeMutation
+type MutationOption func(*mutationOptions)
+
+type mutationOptions struct {
+	params           map[string]interface{}
+	retries          int
+	progressInterval time.Duration
+	onProgress       ProgressFn
+}
+
+// WithMutationParams passes params to both the iterate and the action queries
+func WithMutationParams(params map[string]interface{}) MutationOption {
+	return func(options *mutationOptions) {
+		options.params = params
+	}
+}
+
+// WithBatchRetries retries every failed batch up to retries times
+func WithBatchRetries(retries int) MutationOption {
+	return func(options *mutationOptions) {
+		options.retries = retries
+	}
+}
+
+// WithProgress polls the progress of the mutation every interval (defaults to 5s) until it ends, and hands it to onProgress
+func WithProgress(interval time.Duration, onProgress ProgressFn) MutationOption {
+	return func(options *mutationOptions) {
+		options.progressInterval = interval
+		options.onProgress = onProgress
+	}
+}
+
+// ExecuteLargeMutation runs actionQuery for every batch of batchSize items returned by iterateQuery with apoc.periodic.iterate,
+// for graph refactorings too large for a single transaction. the batches run concurrently when parallel is set.
+// the batches that failed are reported with an error matching ErrIterateFailed, along with the result.
+// when ctx is done, the mutation is terminated on the server: the batches committed so far are kept
+func (c *Client) ExecuteLargeMutation(ctx context.Context, iterateQuery, actionQuery string, batchSize int, parallel bool, opts ...MutationOption) (IterateResult, error) {
+	options := &mutationOptions{progressInterval: defaultProgressInterval}
+	for _, opt := range opts {
+		opt(options)
+	}
+	id, err := newMutationID()
+	if err != nil {
+		return IterateResult{}, err
+	}
+	type outcome struct {
+		result IterateResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		result, err := c.periodicIterate(ctx, IterateConfig{
+			Iterate:   iterateQuery,
+			Action:    actionQuery,
+			Params:    options.params,
+			BatchSize: batchSize,
+			Parallel:  parallel,
+			Retries:   options.retries,
+		}, driver.WithTxMetadata(map[string]interface{}{mutationMetadataKey: id}))
+		done <- outcome{result: result, err: err}
+	}()
+
+	var ticks <-chan time.Time
+	if options.onProgress != nil {
+		ticker := time.NewTicker(options.progressInterval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+	for {
+		select {
+		case outcome := <-done:
+			return outcome.result, outcome.err
+		case <-ctx.Done():
+			if err := c.terminateMutation(id); err != nil {
+				return IterateResult{}, fmt.Errorf("%w, terminating the mutation on the server failed: %v", ctx.Err(), err)
+			}
+			return IterateResult{}, ctx.Err()
+		case <-ticks:
+			progress := c.mutationProgress(ctx, id)
+			progress.Elapsed = time.Since(start)
+			options.onProgress(progress)
+		}
+	}
+}
+
+func (c *Client) mutationProgress(ctx context.Context, id string) MutationProgress {
+	var progress MutationProgress
+	progress.Err = c.runner.ExecuteQuery(ctx, "SHOW TRANSACTIONS YIELD metaData, status, activeLockCount "+
+		"WHERE metaData."+mutationMetadataKey+" = $id RETURN status, activeLockCount", map[string]interface{}{"id": id}, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		if result.NextRecord(ctx, &record) {
+			progress.Status, _ = record.Values[0].(string)
+			progress.ActiveLocks = toInt(record.Values[1])
+		}
+		return result.Err()
+	}, driver.WithQueryName("apoc.largeMutation.progress"), driver.WithAccessMode(neo4j.AccessModeRead))
+	return progress
+}
+
+// terminateMutation terminates the transaction of the mutation, apoc.periodic.iterate stops scheduling batches once it is terminated.
+// it runs detached from the done ctx of the mutation
+func (c *Client) terminateMutation(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), terminationTimeout)
+	defer cancel()
+	var transactions []interface{}
+	err := c.runner.ExecuteQuery(ctx, "SHOW TRANSACTIONS YIELD transactionId, metaData "+
+		"WHERE metaData."+mutationMetadataKey+" = $id RETURN transactionId", map[string]interface{}{"id": id}, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for result.NextRecord(ctx, &record) {
+			transactions = append(transactions, record.Values[0])
+		}
+		return result.Err()
+	}, driver.WithQueryName("apoc.largeMutation.find"), driver.WithAccessMode(neo4j.AccessModeRead))
+	if err != nil || len(transactions) == 0 {
+		return err
+	}
+	return c.runner.ExecuteQuery(ctx, "TERMINATE TRANSACTIONS $ids", map[string]interface{}{"ids": transactions}, func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(ctx)
+		return err
+	}, driver.WithQueryName("apoc.largeMutation.terminate"))
+}
+
+func newMutationID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,90 @@
+package apoc_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg/apoc"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLargeMutation(t *testing.T) {
+	suite.Run(t, new(LargeMutationTestSuite))
+}
+
+type LargeMutationTestSuite struct {
+	suite.Suite
+	ctx    context.Context
+	mock   *MockDriver
+	runner *blockingRunner
+	client *apoc.Client
+}
+
+func (s *LargeMutationTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mock = NewMockDriver()
+	s.runner = &blockingRunner{runner: s.mock, release: make(chan struct{})}
+	s.client = apoc.New(s.runner)
+}
+
+var iterateColumns = []string{"batches", "total", "timeTaken", "committedOperations", "failedOperations", "failedBatches", "retries", "errorMessages", "wasTerminated", "batch"}
+
+func (s *LargeMutationTestSuite) TestReportsProgressUntilTheMutationEnds() {
+	s.mock.ExpectQuery(`^SHOW TRANSACTIONS`).WillReturn([]string{"status", "activeLockCount"}, []any{"Running", int64(12)}).Times(100)
+	s.mock.ExpectQuery(`^CALL apoc\.periodic\.iterate`).WillReturn(iterateColumns,
+		[]any{int64(10), int64(10_000), int64(1), int64(10_000), int64(0), int64(0), int64(0), map[string]any{}, false, map[string]any{}})
+	var mutex sync.Mutex
+	var reports []apoc.MutationProgress
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(s.runner.release)
+	}()
+
+	result, err := s.client.ExecuteLargeMutation(s.ctx, "MATCH (u:User) RETURN u", "SET u.migrated = true", 1000, true,
+		apoc.WithProgress(5*time.Millisecond, func(progress apoc.MutationProgress) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			reports = append(reports, progress)
+		}))
+
+	s.Require().NoError(err)
+	s.Equal(int64(10_000), result.CommittedOperations)
+	mutex.Lock()
+	defer mutex.Unlock()
+	s.Require().NotEmpty(reports)
+	s.Equal("Running", reports[0].Status)
+	s.Equal(int64(12), reports[0].ActiveLocks)
+	s.NoError(reports[0].Err)
+}
+
+func (s *LargeMutationTestSuite) TestTerminatesTheMutationOnCancellation() {
+	s.mock.ExpectQuery(`^SHOW TRANSACTIONS`).WillReturn([]string{"transactionId"}, []any{"neo4j-transaction-42"})
+	s.mock.ExpectQuery(`^TERMINATE TRANSACTIONS \$ids$`).WithParams(map[string]interface{}{"ids": []interface{}{"neo4j-transaction-42"}})
+	ctx, cancel := context.WithTimeout(s.ctx, 20*time.Millisecond)
+	defer cancel()
+
+	_, err := s.client.ExecuteLargeMutation(ctx, "MATCH (u:User) RETURN u", "DETACH DELETE u", 1000, false)
+
+	s.ErrorIs(err, context.DeadlineExceeded)
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+// blockingRunner holds the apoc.periodic.iterate calls until release is closed or their ctx is done
+type blockingRunner struct {
+	runner  QueryRunner
+	release chan struct{}
+}
+
+func (r *blockingRunner) ExecuteQuery(ctx context.Context, query string, params map[string]interface{}, onResults ResultsHookFn, opts ...QueryOption) error {
+	if strings.Contains(query, "apoc.periodic.iterate") {
+		select {
+		case <-r.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return r.runner.ExecuteQuery(ctx, query, params, onResults, opts...)
+}
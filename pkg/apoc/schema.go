@@ -0,0 +1,101 @@
+package apoc
+
+import (
+	"context"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// EntityKind tells whether a MetaSchema entry describes a label or a relationship type
+type EntityKind string
+
+const (
+	KindNode         EntityKind = "node"
+	KindRelationship EntityKind = "relationship"
+)
+
+// MetaSchema is the schema apoc.meta.schema samples from the graph, keyed by label and relationship type
+type MetaSchema map[string]EntitySchema
+
+// EntitySchema describes a label or a relationship type
+type EntitySchema struct {
+	Kind       EntityKind
+	Count      int64
+	Properties map[string]PropertySchema
+	// Relationships are the relationship types attached to the nodes of a label, keyed by type
+	Relationships map[string]RelationshipSchema
+}
+
+// PropertySchema describes a property of a label or a relationship type
+type PropertySchema struct {
+	// Type is the APOC type name of the property, e.g. STRING, INTEGER or LIST
+	Type                       string
+	Indexed, Unique, Existence bool
+	Array                      bool
+}
+
+// RelationshipSchema describes the relationships of a type attached to the nodes of a label
+type RelationshipSchema struct {
+	// Direction is "in" or "out", from the point of view of the label
+	Direction string
+	Count     int64
+	// Labels are the labels of the nodes at the other end
+	Labels []string
+}
+
+// MetaSchema samples the graph with apoc.meta.schema to describe its labels, relationship types and properties
+func (c *Client) MetaSchema(ctx context.Context) (MetaSchema, error) {
+	schema := MetaSchema{}
+	err := c.runner.ExecuteQuery(ctx, "CALL apoc.meta.schema() YIELD value RETURN value", nil, func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(ctx)
+		if err != nil {
+			return err
+		}
+		entities, _ := record.Values[0].(map[string]interface{})
+		for name, entity := range entities {
+			schema[name] = newEntitySchema(entity)
+		}
+		return nil
+	}, driver.WithQueryName("apoc.meta.schema"), driver.WithAccessMode(neo4j.AccessModeRead))
+	if err != nil {
+		return nil, notInstalled("apoc.meta.schema", err)
+	}
+	return schema, nil
+}
+
+func newEntitySchema(value interface{}) EntitySchema {
+	fields, _ := value.(map[string]interface{})
+	kind, _ := fields["type"].(string)
+	entity := EntitySchema{Kind: EntityKind(kind), Count: toInt(fields["count"]), Properties: map[string]PropertySchema{}}
+	properties, _ := fields["properties"].(map[string]interface{})
+	for name, property := range properties {
+		fields, _ := property.(map[string]interface{})
+		propertyType, _ := fields["type"].(string)
+		indexed, _ := fields["indexed"].(bool)
+		unique, _ := fields["unique"].(bool)
+		existence, _ := fields["existence"].(bool)
+		array, _ := fields["array"].(bool)
+		entity.Properties[name] = PropertySchema{Type: propertyType, Indexed: indexed, Unique: unique, Existence: existence, Array: array}
+	}
+	relationships, _ := fields["relationships"].(map[string]interface{})
+	if len(relationships) > 0 {
+		entity.Relationships = make(map[string]RelationshipSchema, len(relationships))
+	}
+	for name, relationship := range relationships {
+		fields, _ := relationship.(map[string]interface{})
+		direction, _ := fields["direction"].(string)
+		entity.Relationships[name] = RelationshipSchema{Direction: direction, Count: toInt(fields["count"]), Labels: toStrings(fields["labels"])}
+	}
+	return entity
+}
+
+func toStrings(value interface{}) []string {
+	values, _ := value.([]interface{})
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
@@ -0,0 +1,71 @@
+package driver
+
+import (
+	"context"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// defaultMaxAsyncQueries bounds ExecuteQueryAsync when Settings.MaxAsyncQueries is zero
+const defaultMaxAsyncQueries = 16
+
+// Future is the handle of a query executed by ExecuteQueryAsync
+type Future struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	records []*neo4j.Record
+	err     error
+}
+
+// Done is closed once the query completed, failed or was cancelled
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Result waits for the query and returns all its records
+func (f *Future) Result() ([]*neo4j.Record, error) {
+	<-f.done
+	return f.records, f.err
+}
+
+// Err waits for the query and returns its error, if any
+func (f *Future) Err() error {
+	<-f.done
+	return f.err
+}
+
+// Cancel cancels the query, or drops it if it is still queued. it fails with the error of the cancelled ctx
+func (f *Future) Cancel() {
+	f.cancel()
+}
+
+// ExecuteQueryAsync runs the query in the background and collects its records, so that callers can fan out queries
+// without managing goroutines around ExecuteQuery. at most Settings.MaxAsyncQueries run at once, the others are queued
+// in FIFO order. the query is cancelled with ctx.
+func (d *Driver) ExecuteQueryAsync(ctx context.Context, query string, params map[string]interface{}, opts ...QueryOption) *Future {
+	ctx, cancel := context.WithCancel(ctx)
+	future := &Future{done: make(chan struct{}), cancel: cancel}
+	go func() {
+		defer close(future.done)
+		defer cancel()
+		if future.err = d.asyncSlots.Acquire(ctx, 1); future.err != nil {
+			return
+		}
+		defer d.asyncSlots.Release(1)
+		// Acquire succeeds right away when a slot is free, even if ctx is done
+		if future.err = ctx.Err(); future.err != nil {
+			return
+		}
+		future.err = d.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) (err error) {
+			future.records, err = result.Collect(ctx)
+			return err
+		}, opts...)
+	}()
+	return future
+}
+
+func newAsyncSlots(settings Settings) *Semaphore {
+	if settings.MaxAsyncQueries > 0 {
+		return NewSemaphore(settings.MaxAsyncQueries)
+	}
+	return NewSemaphore(defaultMaxAsyncQueries)
+}
@@ -0,0 +1,69 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestAsync(t *testing.T) {
+	suite.Run(t, new(AsyncTestSuite))
+}
+
+type AsyncTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	restore func()
+	driver  *Driver
+}
+
+func (s *AsyncTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+	settings := connectionSettings
+	settings.MaxAsyncQueries = 2
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	s.driver = driver
+}
+
+func (s *AsyncTestSuite) TearDownTest() {
+	s.driver.Close(s.ctx)
+	s.restore()
+}
+
+func (s *AsyncTestSuite) TestFansOutQueries() {
+	futures := make([]*Future, 10)
+	for i := range futures {
+		futures[i] = s.driver.ExecuteQueryAsync(s.ctx, "RETURN true AS ok", nil)
+	}
+
+	for _, future := range futures {
+		records, err := future.Result()
+		s.Require().NoError(err)
+		s.Require().Len(records, 1)
+		s.Equal(true, records[0].Values[0])
+	}
+}
+
+func (s *AsyncTestSuite) TestDropsCancelledQueries() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	future := s.driver.ExecuteQueryAsync(ctx, "RETURN true AS ok", nil)
+
+	s.ErrorIs(future.Err(), context.Canceled)
+	s.Zero(s.cluster.sessions())
+}
+
+func (s *AsyncTestSuite) TestFailsOnceClosed() {
+	s.driver.Close(s.ctx)
+
+	future := s.driver.ExecuteQueryAsync(s.ctx, "RETURN true AS ok", nil)
+
+	<-future.Done()
+	s.ErrorIs(future.Err(), ErrDriverClosed)
+}
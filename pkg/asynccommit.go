@@ -0,0 +1,71 @@
+package driver
+
+import (
+	"context"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"time"
+)
+
+// CommitCallbackFn receives the outcome of a query executed WithAsyncCommit, nil once the server confirmed the commit
+type CommitCallbackFn func(err error)
+
+// WithAsyncCommit makes ExecuteQuery return as soon as the statement was accepted by the server,
+// for writes such as telemetry where latency matters more than synchronous confirmation.
+// the hook then runs in the background, and onCommit is called once the commit is confirmed or failed.
+// connectivity errors happening before the statement is sent are still recovered from and returned synchronously.
+// the audit record, the usage and the mirroring of Settings.DualWrite only follow the outcome of the commit.
+// pending commits delay Close, until its ctx is done, and Shutdown, which waits for them like for any in-flight query.
+func WithAsyncCommit(onCommit CommitCallbackFn) QueryOption {
+	return func(options *queryOptions) {
+		options.onCommit = onCommit
+	}
+}
+
+// completeAsync hands the result over to a goroutine confirming the commit, and reports whether it did.
+// it does not when the driver is closing, the commit is then confirmed synchronously. done is called once the session is closed
+func (d *Driver) completeAsync(ctx context.Context, session neo4j.SessionWithContext, result neo4j.ResultWithContext, onResults ResultsHookFn, options *queryOptions, done func()) bool {
+	if options.onCommit == nil || d.lifecycle.enterCommit() != nil {
+		return false
+	}
+	options.committing = true
+	ctx = detachedContext{ctx}
+	go func() {
+		defer d.lifecycle.exitCommit()
+		defer done()
+		defer d.CloseSession(ctx, session)
+		err := executeHook(onResults, result)
+		if err == nil {
+			_, err = result.Consume(ctx)
+		}
+		if err != nil {
+			d.events.recordError("async-commit", err)
+		}
+		if options.afterCommit != nil {
+			options.afterCommit(ctx, err)
+		}
+		options.onCommit(err)
+	}()
+	return true
+}
+
+// detachedContext keeps the values of its parent without its cancellation,
+// so that async commits outlive the context of the caller
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,107 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestAsyncCommit(t *testing.T) {
+	suite.Run(t, new(AsyncCommitTestSuite))
+}
+
+type AsyncCommitTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	restore func()
+	commits chan error
+	confirm chan struct{}
+}
+
+func (s *AsyncCommitTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+	s.commits = make(chan error, 1)
+	s.confirm = make(chan struct{})
+}
+
+func (s *AsyncCommitTestSuite) TearDownTest() {
+	s.restore()
+}
+
+// executeAsync executes a write whose commit is confirmed once s.confirm is closed
+func (s *AsyncCommitTestSuite) executeAsync(driver *Driver) error {
+	confirm, commits := s.confirm, s.commits
+	return driver.ExecuteQuery(s.ctx, "CREATE (:Event)", nil, func(neo4j.ResultWithContext) error {
+		<-confirm
+		return nil
+	}, WithAsyncCommit(func(err error) {
+		commits <- err
+	}))
+}
+
+func (s *AsyncCommitTestSuite) TestCloseWaitsForTheCommitsInFlight() {
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	s.Require().NoError(s.executeAsync(driver))
+	closed := make(chan struct{})
+
+	go func() {
+		driver.Close(s.ctx)
+		close(closed)
+	}()
+
+	s.Never(func() bool {
+		select {
+		case <-closed:
+			return true
+		default:
+			return false
+		}
+	}, 50*time.Millisecond, 5*time.Millisecond)
+	close(s.confirm)
+	s.NoError(<-s.commits)
+	<-closed
+}
+
+func (s *AsyncCommitTestSuite) TestCloseGivesUpOnTheCommitsOnceCancelled() {
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	s.Require().NoError(s.executeAsync(driver))
+	ctx, cancel := context.WithTimeout(s.ctx, 20*time.Millisecond)
+	defer cancel()
+
+	driver.Close(ctx)
+
+	s.ErrorIs(ctx.Err(), context.DeadlineExceeded)
+	close(s.confirm)
+	<-s.commits
+}
+
+func (s *AsyncCommitTestSuite) TestAuditsTheOutcomeOfTheCommit() {
+	s.cluster.resultErr = errors.New("Neo.ClientError.Schema.ConstraintValidationFailed")
+	var records []AuditRecord
+	settings := connectionSettings
+	settings.Audit = &AuditConfig{Sink: AuditFunc(func(_ context.Context, record AuditRecord) error {
+		records = append(records, record)
+		return nil
+	})}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	s.Require().NoError(s.executeAsync(driver))
+	s.Empty(records, "the commit is not confirmed yet")
+	close(s.confirm)
+
+	s.Error(<-s.commits)
+	s.Require().Len(records, 1)
+	s.False(records[0].Success)
+	s.Equal("Neo.ClientError.Schema.ConstraintValidationFailed", records[0].Error)
+}
@@ -0,0 +1,205 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditOperation is the kind of execution an audit record describes
+type AuditOperation string
+
+const (
+	// AuditQuery is a call to ExecuteQuery, or one of the two queries of ExecuteJoin
+	AuditQuery AuditOperation = "query"
+	// AuditScript is a call to ExecuteScript, the query of its record being the whole script
+	AuditScript AuditOperation = "script"
+	// AuditExport is a call to ExportConsistent, the query of its record being its queries separated by semicolons
+	AuditExport AuditOperation = "export"
+	// AuditRaw is a call to Raw. the queries fn runs are unknown to the driver, so its record has no query and no rows,
+	// and no context to extract the caller identity from
+	AuditRaw AuditOperation = "raw"
+)
+
+// AuditRecord describes a single execution for the audit trail: every call to ExecuteQuery, ExecuteJoin,
+// ExecuteScript, ExportConsistent and Raw is recorded
+type AuditRecord struct {
+	At        time.Time      `json:"at"`
+	Operation AuditOperation `json:"operation"`
+	// User is the database user the driver authenticates as
+	User string `json:"user"`
+	// Identity is the caller identity, see AuditConfig.Identity
+	Identity string `json:"identity,omitempty"`
+	// ImpersonatedUser is the user set with WithImpersonatedUser
+	ImpersonatedUser string `json:"impersonatedUser,omitempty"`
+	// QueryName is the logical query name set with WithQueryName
+	QueryName string `json:"queryName,omitempty"`
+	Query     string `json:"query"`
+	// Params are the query parameters hidden by Settings.ParamsRedactor
+	Params  map[string]interface{} `json:"params,omitempty"`
+	Success bool                   `json:"success"`
+	Error   string                 `json:"error,omitempty"`
+	// Rows is the number of records read by the results hook, or by the sink of an export
+	Rows     int           `json:"rows"`
+	Duration time.Duration `json:"duration"`
+}
+
+// AuditSink stores audit records, e.g. NewFileAuditSink, NewKafkaAuditSink or AuditFunc
+type AuditSink interface {
+	Audit(ctx context.Context, record AuditRecord) error
+}
+
+// AuditFunc is an AuditSink calling a custom function
+type AuditFunc func(ctx context.Context, record AuditRecord) error
+
+func (f AuditFunc) Audit(ctx context.Context, record AuditRecord) error {
+	return f(ctx, record)
+}
+
+// AuditConfig configures Settings.Audit
+type AuditConfig struct {
+	Sink AuditSink
+	// Identity extracts the caller identity, defaults to IdentityFromContext
+	Identity IdentityExtractorFn
+	// OnError is called when Sink fails to store a record, defaults to logging the failure.
+	// the query result is left untouched
+	OnError func(record AuditRecord, err error)
+}
+
+// WithImpersonatedUser executes the query on behalf of user, which must be allowed to the authenticated user,
+// and reports it in the audit records
+func WithImpersonatedUser(user string) QueryOption {
+	return func(options *queryOptions) {
+		options.impersonated = user
+	}
+}
+
+func (d *Driver) audit(ctx context.Context, operation AuditOperation, query string, params map[string]interface{}, options *queryOptions, err error) {
+	config := d.settings.Audit
+	if config == nil || config.Sink == nil {
+		return
+	}
+	identity := config.Identity
+	if identity == nil {
+		identity = IdentityFromContext
+	}
+	record := AuditRecord{
+		At:               options.start,
+		Operation:        operation,
+		User:             d.currentGeneration().config.user,
+		Identity:         identity(ctx),
+		ImpersonatedUser: options.impersonated,
+		QueryName:        options.name,
+		Query:            query,
+		Params:           d.redact(params),
+		Success:          err == nil,
+		Rows:             options.rows,
+		Duration:         time.Since(options.start),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if auditErr := config.Sink.Audit(ctx, record); auditErr != nil {
+		if config.OnError != nil {
+			config.OnError(record, auditErr)
+			return
+		}
+		d.logger().Printf("[neo4j audit] could not record %q: %v%s", displayName(record.QueryName, record.Query), auditErr, options.tagSuffix())
+	}
+}
+
+// FileAuditSink appends the audit records to a file, one JSON object per line
+type FileAuditSink struct {
+	mutex   sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// NewFileAuditSink opens path for appending, creating it if needed. Close it once the driver is closed
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileAuditSink{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+func (s *FileAuditSink) Audit(_ context.Context, record AuditRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.encoder.Encode(record)
+}
+
+// Close flushes the records to disk and closes the file
+func (s *FileAuditSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
+
+// KafkaProducer is the subset of a Kafka client NewKafkaAuditSink needs, so that any client library can be adapted to it
+type KafkaProducer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+}
+
+// NewKafkaAuditSink publishes the audit records as JSON messages to topic, keyed by user so that the records of a user stay ordered
+func NewKafkaAuditSink(producer KafkaProducer, topic string) AuditSink {
+	return AuditFunc(func(ctx context.Context, record AuditRecord) error {
+		value, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		return producer.Produce(ctx, topic, []byte(record.User), value)
+	})
+}
+
+// countingResult counts the records read through it
+type countingResult struct {
+	neo4j.ResultWithContext
+	rows *int
+}
+
+// countRows wraps onResults so that the records it reads are counted in rows
+func countRows(onResults ResultsHookFn, rows *int) ResultsHookFn {
+	return func(result neo4j.ResultWithContext) error {
+		*rows = 0
+		return onResults(&countingResult{ResultWithContext: result, rows: rows})
+	}
+}
+
+func (c *countingResult) NextRecord(ctx context.Context, record **neo4j.Record) bool {
+	if !c.ResultWithContext.NextRecord(ctx, record) {
+		return false
+	}
+	*c.rows++
+	return true
+}
+
+func (c *countingResult) Next(ctx context.Context) bool {
+	if !c.ResultWithContext.Next(ctx) {
+		return false
+	}
+	*c.rows++
+	return true
+}
+
+func (c *countingResult) Collect(ctx context.Context) ([]*neo4j.Record, error) {
+	records, err := c.ResultWithContext.Collect(ctx)
+	*c.rows += len(records)
+	return records, err
+}
+
+func (c *countingResult) Single(ctx context.Context) (*neo4j.Record, error) {
+	record, err := c.ResultWithContext.Single(ctx)
+	if record != nil {
+		*c.rows++
+	}
+	return record, err
+}
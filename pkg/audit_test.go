@@ -0,0 +1,204 @@
+package driver_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	suite.Run(t, new(AuditTestSuite))
+}
+
+type AuditTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	restore func()
+}
+
+func (s *AuditTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+}
+
+func (s *AuditTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *AuditTestSuite) TestRecordsSuccessfulExecutions() {
+	var records []AuditRecord
+	settings := connectionSettings
+	settings.Audit = &AuditConfig{Sink: AuditFunc(func(_ context.Context, record AuditRecord) error {
+		records = append(records, record)
+		return nil
+	})}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	err = driver.ExecuteQuery(WithIdentity(s.ctx, "billing"), "RETURN $id AS ok", map[string]interface{}{"id": 42}, func(result neo4j.ResultWithContext) error {
+		_, err := result.Collect(s.ctx)
+		return err
+	}, WithQueryName("ok"), WithImpersonatedUser("alice"))
+
+	s.Require().NoError(err)
+	s.Require().Len(records, 1)
+	record := records[0]
+	s.Equal(AuditQuery, record.Operation)
+	s.Equal(connectionSettings.User, record.User)
+	s.Equal("billing", record.Identity)
+	s.Equal("alice", record.ImpersonatedUser)
+	s.Equal("ok", record.QueryName)
+	s.Equal(map[string]interface{}{"id": RedactedValue}, record.Params)
+	s.True(record.Success)
+	s.Empty(record.Error)
+	s.Equal(1, record.Rows)
+	s.Positive(record.Duration)
+	s.False(record.At.IsZero())
+}
+
+func (s *AuditTestSuite) TestRecordsFailedExecutions() {
+	s.cluster.staleDrivers = 100
+	var records []AuditRecord
+	settings := connectionSettings
+	settings.MaxAttempts = 1
+	settings.Audit = &AuditConfig{Sink: AuditFunc(func(_ context.Context, record AuditRecord) error {
+		records = append(records, record)
+		return nil
+	})}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	err = executeSimpleQuery(s.ctx, driver)
+
+	s.Require().Error(err)
+	s.Require().Len(records, 1)
+	s.False(records[0].Success)
+	s.Equal(err.Error(), records[0].Error)
+	s.Zero(records[0].Rows)
+}
+
+func (s *AuditTestSuite) TestRecordsEveryKindOfExecution() {
+	var records []AuditRecord
+	settings := connectionSettings
+	settings.Audit = &AuditConfig{Sink: AuditFunc(func(_ context.Context, record AuditRecord) error {
+		records = append(records, record)
+		return nil
+	})}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	key := func(*neo4j.Record) (string, error) { return "k", nil }
+
+	s.Require().NoError(driver.ExecuteScript(s.ctx, "CREATE (:A); CREATE (:B)"))
+	s.Require().NoError(driver.ExportConsistent(s.ctx, []QuerySpec{{Name: "a", Query: "MATCH (a:A) RETURN a"}, {Name: "b", Query: "MATCH (b:B) RETURN b"}}, func(_ QuerySpec, records RecordIterator) error {
+		var record *neo4j.Record
+		for records.NextRecord(s.ctx, &record) {
+		}
+		return records.Err()
+	}))
+	s.Require().NoError(driver.Raw(func(neo4j.DriverWithContext) error { return nil }))
+	s.Require().NoError(driver.ExecuteJoin(s.ctx, JoinSide{Query: "RETURN 1 AS ok", Key: key}, JoinSide{Query: "RETURN 2 AS ok", Key: key}, func(_, _ *neo4j.Record) error { return nil }))
+
+	s.Require().Len(records, 5)
+	s.Equal(AuditScript, records[0].Operation)
+	s.Equal("CREATE (:A); CREATE (:B)", records[0].Query)
+	s.Equal(AuditExport, records[1].Operation)
+	s.Equal("MATCH (a:A) RETURN a;\nMATCH (b:B) RETURN b", records[1].Query)
+	s.Equal(2, records[1].Rows)
+	s.Equal(AuditRaw, records[2].Operation)
+	s.Empty(records[2].Query)
+	s.Equal([]AuditOperation{AuditQuery, AuditQuery}, []AuditOperation{records[3].Operation, records[4].Operation})
+	s.Equal([]string{"RETURN 2 AS ok", "RETURN 1 AS ok"}, []string{records[3].Query, records[4].Query}, "the right query completes within the left one")
+	for _, record := range records {
+		s.True(record.Success)
+	}
+}
+
+func (s *AuditTestSuite) TestReportsSinkFailuresWithoutFailingTheQuery() {
+	var failures []error
+	settings := connectionSettings
+	settings.Audit = &AuditConfig{
+		Sink: AuditFunc(func(context.Context, AuditRecord) error {
+			return errors.New("audit store unavailable")
+		}),
+		OnError: func(_ AuditRecord, err error) {
+			failures = append(failures, err)
+		},
+	}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver))
+
+	s.Require().Len(failures, 1)
+	s.EqualError(failures[0], "audit store unavailable")
+}
+
+func (s *AuditTestSuite) TestAppendsRecordsToAFileAsJsonLines() {
+	path := filepath.Join(s.T().TempDir(), "audit.jsonl")
+	sink, err := NewFileAuditSink(path)
+	s.Require().NoError(err)
+	settings := connectionSettings
+	settings.Audit = &AuditConfig{Sink: sink}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver))
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver))
+	driver.Close(s.ctx)
+	s.Require().NoError(sink.Close())
+
+	file, err := os.Open(path)
+	s.Require().NoError(err)
+	defer file.Close()
+	var records []AuditRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record AuditRecord
+		s.Require().NoError(json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	s.Require().Len(records, 2)
+	s.True(records[1].Success)
+}
+
+func (s *AuditTestSuite) TestPublishesRecordsToKafkaKeyedByUser() {
+	producer := &fakeProducer{}
+	sink := NewKafkaAuditSink(producer, "neo4j-audit")
+
+	err := sink.Audit(s.ctx, AuditRecord{User: "neo4j", Query: "RETURN 1", Success: true})
+
+	s.Require().NoError(err)
+	s.Require().Len(producer.messages, 1)
+	s.Equal("neo4j-audit", producer.messages[0].topic)
+	s.Equal("neo4j", string(producer.messages[0].key))
+	var record AuditRecord
+	s.Require().NoError(json.Unmarshal(producer.messages[0].value, &record))
+	s.Equal("RETURN 1", record.Query)
+}
+
+type fakeMessage struct {
+	topic      string
+	key, value []byte
+}
+
+type fakeProducer struct {
+	messages []fakeMessage
+}
+
+func (p *fakeProducer) Produce(_ context.Context, topic string, key, value []byte) error {
+	p.messages = append(p.messages, fakeMessage{topic: topic, key: key, value: value})
+	return nil
+}
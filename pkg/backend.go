@@ -0,0 +1,62 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"sync"
+)
+
+// ErrUnsupportedDriverVersion is returned for a Settings.DriverVersion without a registered backend
+var ErrUnsupportedDriverVersion = errors.New("unsupported neo4j driver version")
+
+// DriverVersion is the major version of the underlying neo4j driver
+type DriverVersion string
+
+const (
+	// DriverV5 is the neo4j driver this package is built with, it is the default
+	DriverV5 DriverVersion = "v5"
+	// DriverV6 is reserved for a compatibility module adapting the v6 driver. no such module ships with this package:
+	// selecting it fails with ErrUnsupportedDriverVersion until one registers its backend with RegisterDriverBackend
+	DriverV6 DriverVersion = "v6"
+)
+
+// DriverBackend creates the underlying drivers of a major version. the wrapper only depends on the behavior of
+// neo4j.DriverWithContext, so a backend of another major version adapts its driver, sessions and results to these
+// interfaces, keeping the API of this package unchanged while services migrate one at a time.
+// only the v5 backend is provided, the other ones are left to the compatibility modules
+type DriverBackend func(target string, auth neo4j.AuthToken, configurers ...func(*neo4j.Config)) (neo4j.DriverWithContext, error)
+
+var (
+	backendsLock sync.RWMutex
+	backends     = map[DriverVersion]DriverBackend{}
+)
+
+// RegisterDriverBackend makes version selectable with Settings.DriverVersion, typically from the init function of
+// a compatibility module. a nil backend unregisters version. the v5 backend is built in and cannot be replaced
+func RegisterDriverBackend(version DriverVersion, backend DriverBackend) {
+	if version == DriverV5 {
+		panic("the v5 driver backend is built in")
+	}
+	backendsLock.Lock()
+	defer backendsLock.Unlock()
+	if backend == nil {
+		delete(backends, version)
+		return
+	}
+	backends[version] = backend
+}
+
+// driverBackend returns the backend of version, the built-in one when version is empty
+func driverBackend(version DriverVersion) (DriverBackend, error) {
+	if version == "" || version == DriverV5 {
+		return newDriverWithContext, nil
+	}
+	backendsLock.RLock()
+	defer backendsLock.RUnlock()
+	backend, found := backends[version]
+	if !found {
+		return nil, fmt.Errorf("%w: %s, import its compatibility module to register it", ErrUnsupportedDriverVersion, version)
+	}
+	return backend, nil
+}
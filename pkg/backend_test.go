@@ -0,0 +1,47 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestBackend(t *testing.T) {
+	suite.Run(t, new(BackendTestSuite))
+}
+
+type BackendTestSuite struct {
+	suite.Suite
+}
+
+func (s *BackendTestSuite) TearDownTest() {
+	RegisterDriverBackend(DriverV6, nil)
+}
+
+func (s *BackendTestSuite) TestCreatesUnderlyingDriversWithTheSelectedBackend() {
+	cluster := &fakeCluster{}
+	RegisterDriverBackend(DriverV6, cluster.newDriver)
+	settings := connectionSettings
+	settings.DriverVersion = DriverV6
+
+	driver, err := NewDriver(settings)
+
+	s.Require().NoError(err)
+	defer driver.Close(context.Background())
+	s.Equal(1, cluster.drivers())
+}
+
+func (s *BackendTestSuite) TestRejectsUnregisteredVersions() {
+	settings := connectionSettings
+	settings.DriverVersion = DriverV6
+
+	_, err := NewDriver(settings)
+
+	s.ErrorIs(err, ErrUnsupportedDriverVersion)
+	s.ErrorIs(settings.Validate(), ErrUnsupportedDriverVersion)
+}
+
+func (s *BackendTestSuite) TestKeepsTheBuiltInBackend() {
+	s.Panics(func() { RegisterDriverBackend(DriverV5, nil) })
+}
@@ -0,0 +1,96 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"os"
+	"sync"
+	"testing"
+)
+
+// the benchmarks run against an in-memory server, so that they measure the locking, retry and bookkeeping overhead
+// of the driver rather than the network. BenchmarkConcurrentQueries also runs against the server of NEO4J_URI, if set.
+// compare two revisions with benchstat:
+//
+//	git checkout main && go test ./pkg -run '^$' -bench . -count 10 > old.txt
+//	git checkout - && go test ./pkg -run '^$' -bench . -count 10 > new.txt
+//	benchstat old.txt new.txt
+
+// BenchmarkConcurrentQueries measures the contention of concurrent queries on the locks of the driver
+func BenchmarkConcurrentQueries(b *testing.B) {
+	b.Run("unbounded", func(b *testing.B) {
+		benchmarkConcurrentQueries(b, connectionSettings)
+	})
+	b.Run("bounded", func(b *testing.B) {
+		settings := connectionSettings
+		settings.MaxConcurrentQueries = 4
+		benchmarkConcurrentQueries(b, settings)
+	})
+	b.Run("server", func(b *testing.B) {
+		uri := os.Getenv("NEO4J_URI")
+		if uri == "" {
+			b.Skip("NEO4J_URI is not set")
+		}
+		settings := Settings{ConnectionString: uri, User: os.Getenv("NEO4J_USER"), Password: os.Getenv("NEO4J_PASSWORD")}
+		benchmarkConcurrentQueriesOn(b, settings)
+	})
+}
+
+func benchmarkConcurrentQueries(b *testing.B, settings Settings) {
+	defer UseDriverFactory((&fakeCluster{}).newDriver)()
+	benchmarkConcurrentQueriesOn(b, settings)
+}
+
+func benchmarkConcurrentQueriesOn(b *testing.B, settings Settings) {
+	ctx := context.Background()
+	driver, err := NewDriver(settings)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer driver.Close(ctx)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := driver.ExecuteQuery(ctx, "RETURN true AS ok", nil, noopHook); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+// BenchmarkReconnectStorm measures the recovery of concurrent queries that all lose the connection at once,
+// each iteration being a connectivity loss hitting 32 queries, of which only one replaces the underlying driver
+func BenchmarkReconnectStorm(b *testing.B) {
+	const concurrency = 32
+	ctx := context.Background()
+	cluster := &fakeCluster{}
+	defer UseDriverFactory(cluster.newDriver)()
+	driver, err := NewDriver(connectionSettings)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer driver.Close(ctx)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cluster.disconnect()
+		var wg sync.WaitGroup
+		wg.Add(concurrency)
+		for j := 0; j < concurrency; j++ {
+			go func() {
+				defer wg.Done()
+				if err := driver.ExecuteQuery(ctx, "RETURN true AS ok", nil, noopHook, WithAccessMode(neo4j.AccessModeRead)); err != nil {
+					b.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(cluster.drivers()-1)/float64(b.N), "reconnects/op")
+}
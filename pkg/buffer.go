@@ -0,0 +1,281 @@
+package driver
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"errors"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"io"
+	"os"
+	"time"
+)
+
+func init() {
+	gob.Register(spilledTemporal{})
+}
+
+// BufferOption customizes Materialize
+type BufferOption func(*RecordBuffer)
+
+// WithMemoryBudget sets the estimated size in bytes of the records Materialize keeps in memory,
+// the following ones are spilled to a temporary file. unbounded when zero, the default
+func WithMemoryBudget(bytes int64) BufferOption {
+	return func(buffer *RecordBuffer) {
+		buffer.budget = bytes
+	}
+}
+
+// WithSpillDir sets the directory of the spill files, defaults to os.TempDir()
+func WithSpillDir(dir string) BufferOption {
+	return func(buffer *RecordBuffer) {
+		buffer.dir = dir
+	}
+}
+
+// RecordBuffer holds materialized records, in memory up to a budget and on disk beyond it.
+// it is a RecordIterator reading them back in order, and must be closed to remove its spill file.
+type RecordBuffer struct {
+	budget int64
+	dir    string
+
+	memory   []*neo4j.Record
+	size     int64
+	count    int
+	position int
+
+	file    *os.File
+	writer  *bufio.Writer
+	encoder *gob.Encoder
+	decoder *gob.Decoder
+	err     error
+}
+
+// Materialize reads all the remaining records of result into a RecordBuffer,
+// so that huge results do not exhaust the memory of services sized for small ones.
+// records spilled to disk must only hold values returned by the server.
+func Materialize(ctx context.Context, result RecordIterator, opts ...BufferOption) (*RecordBuffer, error) {
+	buffer := &RecordBuffer{}
+	for _, opt := range opts {
+		opt(buffer)
+	}
+	var record *neo4j.Record
+	for result.NextRecord(ctx, &record) {
+		if err := buffer.add(record); err != nil {
+			_ = buffer.Close()
+			return nil, err
+		}
+	}
+	if err := result.Err(); err != nil {
+		_ = buffer.Close()
+		return nil, err
+	}
+	if err := buffer.rewind(); err != nil {
+		_ = buffer.Close()
+		return nil, err
+	}
+	return buffer, nil
+}
+
+func (b *RecordBuffer) add(record *neo4j.Record) error {
+	b.count++
+	if b.file == nil {
+		b.size += estimateSize(record.Values)
+		if b.budget <= 0 || b.size <= b.budget {
+			b.memory = append(b.memory, record)
+			return nil
+		}
+		file, err := os.CreateTemp(b.dir, "neo4j-records-*.gob")
+		if err != nil {
+			return err
+		}
+		b.file, b.writer = file, bufio.NewWriter(file)
+		b.encoder = gob.NewEncoder(b.writer)
+	}
+	return b.encoder.Encode(spilledRecord{Keys: record.Keys, Values: toSpillable(record.Values).([]interface{})})
+}
+
+func (b *RecordBuffer) rewind() error {
+	if b.file == nil {
+		return nil
+	}
+	if err := b.writer.Flush(); err != nil {
+		return err
+	}
+	if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	b.decoder = gob.NewDecoder(bufio.NewReader(b.file))
+	return nil
+}
+
+// Len returns the number of materialized records
+func (b *RecordBuffer) Len() int {
+	return b.count
+}
+
+// Spilled reports whether records exceeded the memory budget and were written to disk
+func (b *RecordBuffer) Spilled() bool {
+	return b.file != nil
+}
+
+// NextRecord reads the next record, in the order of the original result
+func (b *RecordBuffer) NextRecord(_ context.Context, record **neo4j.Record) bool {
+	*record = nil
+	if b.err != nil || b.position >= b.count {
+		return false
+	}
+	b.position++
+	if b.position <= len(b.memory) {
+		*record = b.memory[b.position-1]
+		return true
+	}
+	spilled := spilledRecord{}
+	if b.decoder == nil {
+		b.err = errors.New("record buffer is closed")
+		return false
+	}
+	if b.err = b.decoder.Decode(&spilled); b.err != nil {
+		return false
+	}
+	*record = &neo4j.Record{Keys: spilled.Keys, Values: fromSpillable(spilled.Values).([]interface{})}
+	return true
+}
+
+// Err returns the error that stopped NextRecord, if any
+func (b *RecordBuffer) Err() error {
+	return b.err
+}
+
+// Close releases the records and removes the spill file
+func (b *RecordBuffer) Close() error {
+	b.memory, b.decoder = nil, nil
+	if b.file == nil {
+		return nil
+	}
+	file := b.file
+	b.file = nil
+	closeErr := file.Close()
+	if err := os.Remove(file.Name()); err != nil {
+		return err
+	}
+	return closeErr
+}
+
+type spilledRecord struct {
+	Keys   []string
+	Values []interface{}
+}
+
+// spilledTemporal holds the temporal values gob cannot encode, their types having no exported fields
+type spilledTemporal struct {
+	Kind string
+	Time time.Time
+}
+
+// toSpillable replaces the temporal values of value by spilledTemporal
+func toSpillable(value interface{}) interface{} {
+	switch value := value.(type) {
+	case neo4j.Date:
+		return spilledTemporal{Kind: "date", Time: value.Time()}
+	case neo4j.LocalTime:
+		return spilledTemporal{Kind: "localtime", Time: value.Time()}
+	case neo4j.LocalDateTime:
+		return spilledTemporal{Kind: "localdatetime", Time: value.Time()}
+	case neo4j.Time:
+		return spilledTemporal{Kind: "time", Time: value.Time()}
+	default:
+		return mapValues(value, toSpillable)
+	}
+}
+
+func fromSpillable(value interface{}) interface{} {
+	spilled, ok := value.(spilledTemporal)
+	if !ok {
+		return mapValues(value, fromSpillable)
+	}
+	switch spilled.Kind {
+	case "date":
+		return neo4j.DateOf(spilled.Time)
+	case "localtime":
+		return neo4j.LocalTimeOf(spilled.Time)
+	case "localdatetime":
+		return neo4j.LocalDateTimeOf(spilled.Time)
+	default:
+		return neo4j.OffsetTimeOf(spilled.Time)
+	}
+}
+
+// mapValues applies convert to the elements of lists and maps, and to the properties of entities
+func mapValues(value interface{}, convert func(interface{}) interface{}) interface{} {
+	switch value := value.(type) {
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, element := range value {
+			converted[i] = convert(element)
+		}
+		return converted
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for key, element := range value {
+			converted[key] = convert(element)
+		}
+		return converted
+	case neo4j.Node:
+		value.Props = mapValues(value.Props, convert).(map[string]interface{})
+		return value
+	case neo4j.Relationship:
+		value.Props = mapValues(value.Props, convert).(map[string]interface{})
+		return value
+	case neo4j.Path:
+		nodes := make([]neo4j.Node, len(value.Nodes))
+		for i, node := range value.Nodes {
+			nodes[i] = mapValues(node, convert).(neo4j.Node)
+		}
+		relationships := make([]neo4j.Relationship, len(value.Relationships))
+		for i, relationship := range value.Relationships {
+			relationships[i] = mapValues(relationship, convert).(neo4j.Relationship)
+		}
+		return neo4j.Path{Nodes: nodes, Relationships: relationships}
+	default:
+		return value
+	}
+}
+
+// estimateSize roughly estimates the memory held by a value
+func estimateSize(value interface{}) int64 {
+	const header = 16
+	switch value := value.(type) {
+	case string:
+		return header + int64(len(value))
+	case []byte:
+		return header + int64(len(value))
+	case []interface{}:
+		size := int64(header)
+		for _, element := range value {
+			size += estimateSize(element)
+		}
+		return size
+	case map[string]interface{}:
+		size := int64(header)
+		for key, element := range value {
+			size += header + int64(len(key)) + estimateSize(element)
+		}
+		return size
+	case neo4j.Node:
+		return header + int64(len(value.ElementId)) + estimateSize(value.Props) + int64(len(value.Labels))*header
+	case neo4j.Relationship:
+		return header + int64(len(value.ElementId)+len(value.Type)) + estimateSize(value.Props)
+	case neo4j.Path:
+		size := int64(header)
+		for _, node := range value.Nodes {
+			size += estimateSize(node)
+		}
+		for _, relationship := range value.Relationships {
+			size += estimateSize(relationship)
+		}
+		return size
+	default:
+		return header
+	}
+}
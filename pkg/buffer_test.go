@@ -0,0 +1,77 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRecordBuffer(t *testing.T) {
+	suite.Run(t, new(RecordBufferTestSuite))
+}
+
+type RecordBufferTestSuite struct {
+	suite.Suite
+	ctx context.Context
+	dir string
+}
+
+func (s *RecordBufferTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.dir = s.T().TempDir()
+}
+
+func (s *RecordBufferTestSuite) TestKeepsSmallResultsInMemory() {
+	buffer, err := Materialize(s.ctx, newFakeRecords([]string{"name"}, []any{"alice"}, []any{"bob"}), WithMemoryBudget(1024), WithSpillDir(s.dir))
+	s.Require().NoError(err)
+	defer buffer.Close()
+
+	records, err := ToMaps(s.ctx, buffer)
+
+	s.Require().NoError(err)
+	s.False(buffer.Spilled())
+	s.Equal(2, buffer.Len())
+	s.Equal([]map[string]any{{"name": "alice"}, {"name": "bob"}}, records)
+}
+
+func (s *RecordBufferTestSuite) TestSpillsBeyondTheBudget() {
+	day := neo4j.DateOf(time.Date(2023, 3, 14, 0, 0, 0, 0, time.UTC))
+	node := neo4j.Node{ElementId: "4:1", Labels: []string{"User"}, Props: map[string]any{"born": day}}
+	rows := make([][]any, 100)
+	for i := range rows {
+		rows[i] = []any{int64(i), node, []any{day, "text"}}
+	}
+	buffer, err := Materialize(s.ctx, newFakeRecords([]string{"i", "user", "list"}, rows...), WithMemoryBudget(256), WithSpillDir(s.dir))
+	s.Require().NoError(err)
+
+	s.True(buffer.Spilled())
+	s.Equal(100, buffer.Len())
+	var record *neo4j.Record
+	for i := 0; buffer.NextRecord(s.ctx, &record); i++ {
+		s.Equal([]any{int64(i), node, []any{day, "text"}}, record.Values)
+	}
+	s.NoError(buffer.Err())
+	s.NoError(buffer.Close())
+	s.Empty(s.spillFiles())
+}
+
+func (s *RecordBufferTestSuite) TestRemovesSpillFileOnResultErrors() {
+	result := newFakeRecords([]string{"name"}, []any{"alice"}, []any{"bob"})
+	result.err = errors.New("connection reset")
+
+	_, err := Materialize(s.ctx, result, WithMemoryBudget(1), WithSpillDir(s.dir))
+
+	s.EqualError(err, "connection reset")
+	s.Empty(s.spillFiles())
+}
+
+func (s *RecordBufferTestSuite) spillFiles() []os.DirEntry {
+	entries, err := os.ReadDir(s.dir)
+	s.Require().NoError(err)
+	return entries
+}
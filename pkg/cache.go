@@ -0,0 +1,247 @@
+package driver
+
+import (
+	"container/list"
+	"encoding/gob"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+func init() {
+	gob.Register(neo4j.Node{})
+	gob.Register(neo4j.Relationship{})
+	gob.Register(neo4j.Path{})
+	gob.Register(neo4j.Point2D{})
+	gob.Register(neo4j.Point3D{})
+	gob.Register(neo4j.Duration{})
+	gob.Register(time.Time{})
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+}
+
+// CacheStore stores query results for WithCache, MemoryCache is the default implementation
+type CacheStore interface {
+	// Get returns the records cached under key, if any and not expired
+	Get(key string) ([]*neo4j.Record, bool)
+	// Set caches records under key for ttl
+	Set(key string, records []*neo4j.Record, ttl time.Duration)
+	// Delete evicts the entry cached under key
+	Delete(key string)
+	// Clear evicts all entries
+	Clear()
+}
+
+// PersistentCacheStore is a CacheStore that can be persisted with Settings.CachePersistence
+type PersistentCacheStore interface {
+	CacheStore
+	PersistFile(path string, maxEntries int) error
+	RestoreFile(path string, validity time.Duration) (int, error)
+}
+
+// MemoryCache holds query results in memory, keyed by the caller.
+// when bounded, the least recently used entries are evicted first.
+type MemoryCache struct {
+	mutex      sync.Mutex
+	entries    map[string]*cacheEntry
+	recency    list.List
+	maxEntries int
+	now        func() time.Time
+}
+
+type cacheEntry struct {
+	Key       string
+	Records   []*neo4j.Record
+	ExpiresAt time.Time
+	Hits      int
+	element   *list.Element
+}
+
+// persistedCache is the on-disk format of a cache snapshot
+type persistedCache struct {
+	PersistedAt time.Time
+	Entries     []*cacheEntry
+}
+
+// NewMemoryCache creates an empty, unbounded cache
+func NewMemoryCache() *MemoryCache {
+	return NewBoundedMemoryCache(0)
+}
+
+// NewBoundedMemoryCache creates an empty cache holding at most maxEntries entries, unbounded when zero
+func NewBoundedMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{entries: map[string]*cacheEntry{}, maxEntries: maxEntries, now: time.Now}
+}
+
+// Get returns the records cached under key, if any and not expired
+func (c *MemoryCache) Get(key string) ([]*neo4j.Record, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, found := c.entries[key]
+	if !found {
+		return nil, false
+	}
+	if !c.now().Before(entry.ExpiresAt) {
+		c.evict(entry)
+		return nil, false
+	}
+	entry.Hits++
+	c.recency.MoveToFront(entry.element)
+	return entry.Records, true
+}
+
+// Set caches records under key for ttl
+func (c *MemoryCache) Set(key string, records []*neo4j.Record, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.add(&cacheEntry{Key: key, Records: records, ExpiresAt: c.now().Add(ttl)})
+}
+
+// Delete evicts the entry cached under key
+func (c *MemoryCache) Delete(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if entry, found := c.entries[key]; found {
+		c.evict(entry)
+	}
+}
+
+// Clear evicts all entries
+func (c *MemoryCache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = map[string]*cacheEntry{}
+	c.recency.Init()
+}
+
+// add must be called with the mutex held
+func (c *MemoryCache) add(entry *cacheEntry) {
+	if existing, found := c.entries[entry.Key]; found {
+		c.evict(existing)
+	}
+	entry.element = c.recency.PushFront(entry)
+	c.entries[entry.Key] = entry
+	for c.maxEntries > 0 && len(c.entries) > c.maxEntries {
+		c.evict(c.recency.Back().Value.(*cacheEntry))
+	}
+}
+
+// evict must be called with the mutex held
+func (c *MemoryCache) evict(entry *cacheEntry) {
+	c.recency.Remove(entry.element)
+	delete(c.entries, entry.Key)
+}
+
+// Len returns the number of cached entries, expired ones included until they are evicted
+func (c *MemoryCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.entries)
+}
+
+// Persist writes the hottest maxEntries live entries to w, all of them when maxEntries is zero.
+// entries holding values that cannot be serialized are left out.
+func (c *MemoryCache) Persist(w io.Writer, maxEntries int) error {
+	c.mutex.Lock()
+	now := c.now()
+	entries := make([]*cacheEntry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		if now.Before(entry.ExpiresAt) && gob.NewEncoder(io.Discard).Encode(entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	c.mutex.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Hits > entries[j].Hits
+	})
+	if maxEntries > 0 && len(entries) > maxEntries {
+		entries = entries[:maxEntries]
+	}
+	return gob.NewEncoder(w).Encode(persistedCache{PersistedAt: now, Entries: entries})
+}
+
+// Restore loads the entries persisted to r and returns how many were loaded.
+// the whole snapshot is discarded if it is older than validity, so that a stale cache is never served after a long downtime.
+// entries that expired in the meantime are discarded as well.
+func (c *MemoryCache) Restore(r io.Reader, validity time.Duration) (int, error) {
+	snapshot := persistedCache{}
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return 0, err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.now()
+	if validity > 0 && now.Sub(snapshot.PersistedAt) > validity {
+		return 0, nil
+	}
+	loaded := 0
+	for _, entry := range snapshot.Entries {
+		if now.Before(entry.ExpiresAt) {
+			c.add(entry)
+			loaded++
+		}
+	}
+	return loaded, nil
+}
+
+// CachePersistence configures how the query cache survives restarts
+type CachePersistence struct {
+	// Path is the file the cache is persisted to on Close and Shutdown, and reloaded from by NewDriver
+	Path string
+	// MaxEntries bounds the number of persisted entries, the most used ones are kept. unbounded when zero
+	MaxEntries int
+	// Validity is the maximum age of a snapshot to be reloaded. unbounded when zero
+	Validity time.Duration
+}
+
+// PersistFile persists the cache to path, see Persist
+func (c *MemoryCache) PersistFile(path string, maxEntries int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := c.Persist(file, maxEntries); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// RestoreFile reloads the cache from path, see Restore. a missing file loads nothing
+func (c *MemoryCache) RestoreFile(path string, validity time.Duration) (int, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return c.Restore(file, validity)
+}
+
+func (d *Driver) restoreCache() {
+	persistence := d.settings.CachePersistence
+	store, persistent := d.settings.QueryCache.(PersistentCacheStore)
+	if !persistent || persistence == nil {
+		return
+	}
+	if _, err := store.RestoreFile(persistence.Path, persistence.Validity); err != nil {
+		d.logger().Printf("[neo4j] could not restore query cache from %s: %v", persistence.Path, err)
+	}
+}
+
+func (d *Driver) persistCache() {
+	persistence := d.settings.CachePersistence
+	store, persistent := d.settings.QueryCache.(PersistentCacheStore)
+	if !persistent || persistence == nil {
+		return
+	}
+	if err := store.PersistFile(persistence.Path, persistence.MaxEntries); err != nil {
+		d.logger().Printf("[neo4j] could not persist query cache to %s: %v", persistence.Path, err)
+	}
+}
@@ -0,0 +1,89 @@
+package driver_test
+
+import (
+	"bytes"
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	suite.Run(t, new(CacheTestSuite))
+}
+
+type CacheTestSuite struct {
+	suite.Suite
+}
+
+var cachedRecords = []*neo4j.Record{{Keys: []string{"user"}, Values: []any{alice}}}
+
+func (s *CacheTestSuite) TestPersistsAndRestoresLiveEntries() {
+	cache := NewMemoryCache()
+	cache.Set("users", cachedRecords, time.Hour)
+	cache.Set("expired", cachedRecords, -time.Second)
+	buffer := &bytes.Buffer{}
+
+	s.Require().NoError(cache.Persist(buffer, 0))
+	restored := NewMemoryCache()
+	loaded, err := restored.Restore(buffer, time.Minute)
+
+	s.Require().NoError(err)
+	s.Equal(1, loaded)
+	records, found := restored.Get("users")
+	s.True(found)
+	s.Equal(cachedRecords, records)
+}
+
+func (s *CacheTestSuite) TestPersistsHottestEntriesFirst() {
+	cache := NewMemoryCache()
+	cache.Set("cold", cachedRecords, time.Hour)
+	cache.Set("hot", cachedRecords, time.Hour)
+	cache.Get("hot")
+	buffer := &bytes.Buffer{}
+
+	s.Require().NoError(cache.Persist(buffer, 1))
+	restored := NewMemoryCache()
+	_, err := restored.Restore(buffer, 0)
+
+	s.Require().NoError(err)
+	_, hot := restored.Get("hot")
+	_, cold := restored.Get("cold")
+	s.True(hot)
+	s.False(cold)
+}
+
+func (s *CacheTestSuite) TestLeavesOutEntriesThatCannotBeSerialized() {
+	cache := NewMemoryCache()
+	cache.Set("dates", []*neo4j.Record{{Keys: []string{"d"}, Values: []any{neo4j.DateOf(time.Now())}}}, time.Hour)
+	cache.Set("users", cachedRecords, time.Hour)
+	buffer := &bytes.Buffer{}
+
+	s.Require().NoError(cache.Persist(buffer, 0))
+	loaded, err := NewMemoryCache().Restore(buffer, 0)
+
+	s.Require().NoError(err)
+	s.Equal(1, loaded)
+}
+
+func (s *CacheTestSuite) TestDriverPersistsOnCloseAndRestoresOnStartup() {
+	ctx := context.Background()
+	settings := connectionSettings
+	settings.CachePersistence = &CachePersistence{Path: filepath.Join(s.T().TempDir(), "cache.gob"), Validity: time.Hour}
+	settings.QueryCache = NewMemoryCache()
+	settings.QueryCache.Set("users", cachedRecords, time.Hour)
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	driver.Close(ctx)
+
+	settings.QueryCache = NewMemoryCache()
+	driver, err = NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(ctx)
+
+	_, found := settings.QueryCache.Get("users")
+	s.True(found)
+}
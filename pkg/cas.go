@@ -0,0 +1,58 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"strings"
+)
+
+// NodeMatch identifies nodes by label and property values
+type NodeMatch struct {
+	Label string
+	Props map[string]interface{}
+}
+
+// CompareAndSetQuery generates the guarded update run by CompareAndSet.
+// the expected properties are compared once the write lock of the node is held, so that concurrent updates serialize
+func CompareAndSetQuery(match NodeMatch, expectProps, setProps map[string]interface{}) (string, map[string]interface{}) {
+	params := map[string]interface{}{"set": setProps}
+	predicates := propertyPredicates("n", "match", match.Props, params)
+	expected := propertyPredicates("n", "expect", expectProps, params)
+
+	query := strings.Builder{}
+	query.WriteString("MATCH (n")
+	if match.Label != "" {
+		query.WriteString(":" + QuoteIdentifier(match.Label))
+	}
+	query.WriteString(")")
+	if len(predicates) > 0 {
+		query.WriteString(" WHERE " + strings.Join(predicates, " AND "))
+	}
+	if len(expected) > 0 {
+		query.WriteString(lockedPredicates("n", expected))
+	}
+	query.WriteString(" SET n += $set RETURN count(n) AS applied")
+	return query.String(), params
+}
+
+// CompareAndSet sets setProps on the nodes identified by match, only if their current properties equal expectProps.
+// a nil expected value requires the property to be absent. it reports whether the update applied,
+// which makes concurrency-safe state transitions possible without optimistic-version plumbing.
+func (d *Driver) CompareAndSet(ctx context.Context, match NodeMatch, expectProps, setProps map[string]interface{}, opts ...QueryOption) (bool, error) {
+	if len(setProps) == 0 {
+		return false, errors.New("compare-and-set requires properties to set")
+	}
+	query, params := CompareAndSetQuery(match, expectProps, setProps)
+	applied := false
+	err := d.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(ctx)
+		if err != nil {
+			return err
+		}
+		count, _ := record.Values[0].(int64)
+		applied = count > 0
+		return nil
+	}, opts...)
+	return applied, err
+}
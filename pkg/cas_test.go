@@ -0,0 +1,46 @@
+package driver_test
+
+import (
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+)
+
+func TestCompareAndSet(t *testing.T) {
+	suite.Run(t, new(CompareAndSetTestSuite))
+}
+
+type CompareAndSetTestSuite struct {
+	suite.Suite
+}
+
+func (s *CompareAndSetTestSuite) TestGeneratesGuardedUpdate() {
+	query, params := CompareAndSetQuery(
+		NodeMatch{Label: "Order", Props: map[string]interface{}{"id": 42}},
+		map[string]interface{}{"status": "pending", "lockedBy": nil},
+		map[string]interface{}{"status": "paid"},
+	)
+
+	s.Equal("MATCH (n:`Order`) WHERE n.`id` = $match0 SET n._lock = true REMOVE n._lock WITH n WHERE n.`lockedBy` IS NULL AND n.`status` = $expect1 SET n += $set RETURN count(n) AS applied", query)
+	s.Equal(map[string]interface{}{"match0": 42, "expect1": "pending", "set": map[string]interface{}{"status": "paid"}}, params)
+}
+
+func (s *CompareAndSetTestSuite) TestComparesOnceTheNodeIsLocked() {
+	query, _ := CompareAndSetQuery(
+		NodeMatch{Label: "Order", Props: map[string]interface{}{"id": 42}},
+		map[string]interface{}{"status": "pending"},
+		map[string]interface{}{"status": "paid"},
+	)
+
+	lock := strings.Index(query, "SET n._lock = true")
+	s.Require().NotEqual(-1, lock)
+	s.Less(strings.Index(query, "n.`id` = $match0"), lock, "the node is identified before it is locked")
+	s.Less(lock, strings.Index(query, "n.`status` = $expect0"), "the expected properties are compared once the node is locked")
+}
+
+func (s *CompareAndSetTestSuite) TestEscapesIdentifiers() {
+	query, _ := CompareAndSetQuery(NodeMatch{Label: "We`ird"}, map[string]interface{}{"a b": 1}, map[string]interface{}{"x": 1})
+
+	s.Equal("MATCH (n:`We``ird`) SET n._lock = true REMOVE n._lock WITH n WHERE n.`a b` = $expect0 SET n += $set RETURN count(n) AS applied", query)
+}
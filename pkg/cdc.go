@@ -0,0 +1,322 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrCDCUnavailable is returned by the CDCConsumer when the server does not provide change data capture,
+// e.g. before Neo4j 5.13 or outside of the Enterprise Edition
+var ErrCDCUnavailable = errors.New("change data capture is not available")
+
+const defaultChangeBatchSize = 100
+
+// ChangeEntity is the kind of entity a ChangeEvent is about
+type ChangeEntity string
+
+const (
+	ChangedNode         ChangeEntity = "n"
+	ChangedRelationship ChangeEntity = "r"
+)
+
+// ChangeOperation is the operation recorded by a ChangeEvent
+type ChangeOperation string
+
+const (
+	ChangeCreated ChangeOperation = "c"
+	ChangeUpdated ChangeOperation = "u"
+	ChangeDeleted ChangeOperation = "d"
+)
+
+// ChangeEvent is a change of a node or a relationship captured by db.cdc.query
+type ChangeEvent struct {
+	// ID is the change identifier, the consumer resumes after it
+	ID         string
+	TxID, Seq  int64
+	CommitTime time.Time
+	Entity     ChangeEntity
+	Operation  ChangeOperation
+	ElementID  string
+	// Labels are the labels of a changed node
+	Labels []string
+	// Type, StartID and EndID describe a changed relationship
+	Type           string
+	StartID, EndID string
+	Before, After  map[string]interface{}
+	Metadata       map[string]interface{}
+}
+
+// ChangeHandlerFn processes a change, an error stops the consumption before the change is checkpointed
+type ChangeHandlerFn func(ctx context.Context, event ChangeEvent) error
+
+// CursorStore persists the change identifier each CDC consumer resumes after.
+// MemoryCursorStore and FileCursorStore implement it
+type CursorStore interface {
+	// Load returns the cursor of the consumer, "" if none was saved
+	Load(consumer string) (string, error)
+	Save(consumer, cursor string) error
+}
+
+// CDCConsumer polls the changes captured by Neo4j 5 (db.cdc.query) and hands them to a handler in order,
+// saving the identifier of the last handled change in a CursorStore so that it resumes after it across restarts.
+// changes are delivered at least once: a crash between handling a change and saving the cursor replays it.
+// the next batch is only read once the handler processed the current one, so a slow handler slows the polling down
+// instead of buffering changes
+type CDCConsumer struct {
+	runner       QueryRunner
+	name         string
+	store        CursorStore
+	batchSize    int
+	selectors    []interface{}
+	fromEarliest bool
+}
+
+// NewCDCConsumer returns the consumer of the given name, running its queries with runner, typically a *Driver.
+// every consumer has its own cursor in store
+func NewCDCConsumer(runner QueryRunner, name string, store CursorStore) *CDCConsumer {
+	return &CDCConsumer{runner: runner, name: name, store: store, batchSize: defaultChangeBatchSize, selectors: []interface{}{}}
+}
+
+// WithBatchSize sets the maximum number of changes read by every Poll, defaults to 100
+func (c *CDCConsumer) WithBatchSize(size int) *CDCConsumer {
+	c.batchSize = size
+	return c
+}
+
+// WithSelectors restricts the changes to the ones matching any of the selectors of db.cdc.query,
+// e.g. {"select": "n", "labels": ["Person"]}. all the changes are consumed by default
+func (c *CDCConsumer) WithSelectors(selectors ...map[string]interface{}) *CDCConsumer {
+	c.selectors = make([]interface{}, len(selectors))
+	for i, selector := range selectors {
+		c.selectors[i] = selector
+	}
+	return c
+}
+
+// FromEarliest makes a consumer without cursor start from the earliest change still available,
+// instead of the changes following its first Poll
+func (c *CDCConsumer) FromEarliest() *CDCConsumer {
+	c.fromEarliest = true
+	return c
+}
+
+// Poll hands the changes following the cursor to handler in order, saving the cursor once they are handled.
+// it returns the number of changes handled, fewer than the batch size when the consumer caught up
+func (c *CDCConsumer) Poll(ctx context.Context, handler ChangeHandlerFn) (int, error) {
+	cursor, err := c.cursor(ctx)
+	if err != nil {
+		return 0, err
+	}
+	events, err := c.next(ctx, cursor)
+	if err != nil {
+		return 0, err
+	}
+	for i, event := range events {
+		if err := handler(ctx, event); err != nil {
+			if i > 0 {
+				if saveErr := c.store.Save(c.name, events[i-1].ID); saveErr != nil {
+					return i, fmt.Errorf("saving CDC cursor of %s: %w", c.name, saveErr)
+				}
+			}
+			return i, fmt.Errorf("handling change %s of %s: %w", event.ElementID, event.ID, err)
+		}
+	}
+	if len(events) > 0 {
+		if err := c.store.Save(c.name, events[len(events)-1].ID); err != nil {
+			return len(events), fmt.Errorf("saving CDC cursor of %s: %w", c.name, err)
+		}
+	}
+	return len(events), nil
+}
+
+// Run polls the changes until ctx is done or handler fails, waiting for interval whenever the consumer caught up
+func (c *CDCConsumer) Run(ctx context.Context, interval time.Duration, handler ChangeHandlerFn) error {
+	for {
+		handled, err := c.Poll(ctx, handler)
+		if err != nil {
+			return err
+		}
+		if handled == c.batchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// cursor loads the cursor of the consumer, starting it at the current or earliest change on its first run
+func (c *CDCConsumer) cursor(ctx context.Context) (string, error) {
+	cursor, err := c.store.Load(c.name)
+	if err != nil || cursor != "" {
+		return cursor, err
+	}
+	procedure := "db.cdc.current"
+	if c.fromEarliest {
+		procedure = "db.cdc.earliest"
+	}
+	err = c.runner.ExecuteQuery(ctx, "CALL "+procedure+"() YIELD id RETURN id", nil, func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(ctx)
+		if err != nil {
+			return err
+		}
+		cursor, _ = record.Values[0].(string)
+		return nil
+	}, WithQueryName("cdc.cursor"), WithAccessMode(neo4j.AccessModeRead))
+	if err != nil {
+		return "", cdcUnavailable(err)
+	}
+	if err := c.store.Save(c.name, cursor); err != nil {
+		return "", fmt.Errorf("saving CDC cursor of %s: %w", c.name, err)
+	}
+	return cursor, nil
+}
+
+func (c *CDCConsumer) next(ctx context.Context, cursor string) ([]ChangeEvent, error) {
+	query := "CALL db.cdc.query($from, $selectors) YIELD id, txId, seq, metadata, event " +
+		"RETURN id, txId, seq, metadata, event LIMIT $limit"
+	params := map[string]interface{}{"from": cursor, "selectors": c.selectors, "limit": int64(c.batchSize)}
+	var events []ChangeEvent
+	err := c.runner.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for result.NextRecord(ctx, &record) {
+			events = append(events, changeEventOf(record))
+		}
+		return result.Err()
+	}, WithQueryName("cdc.query"), WithAccessMode(neo4j.AccessModeRead))
+	return events, cdcUnavailable(err)
+}
+
+func changeEventOf(record *neo4j.Record) ChangeEvent {
+	event := ChangeEvent{}
+	event.ID, _ = record.Values[0].(string)
+	event.TxID, _ = record.Values[1].(int64)
+	event.Seq, _ = record.Values[2].(int64)
+	event.Metadata, _ = record.Values[3].(map[string]interface{})
+	event.CommitTime, _ = event.Metadata["txCommitTime"].(time.Time)
+	change, _ := record.Values[4].(map[string]interface{})
+	event.ElementID, _ = change["elementId"].(string)
+	entity, _ := change["eventType"].(string)
+	event.Entity = ChangeEntity(entity)
+	operation, _ := change["operation"].(string)
+	event.Operation = ChangeOperation(operation)
+	if event.Entity == ChangedNode {
+		event.Labels = toStrings(change["labels"])
+	}
+	event.Type, _ = change["type"].(string)
+	if start, ok := change["start"].(map[string]interface{}); ok {
+		event.StartID, _ = start["elementId"].(string)
+	}
+	if end, ok := change["end"].(map[string]interface{}); ok {
+		event.EndID, _ = end["elementId"].(string)
+	}
+	if state, ok := change["state"].(map[string]interface{}); ok {
+		event.Before = stateProperties(state["before"])
+		event.After = stateProperties(state["after"])
+	}
+	return event
+}
+
+// stateProperties returns the properties of the before or after state of a change, nil when the entity did not exist
+func stateProperties(state interface{}) map[string]interface{} {
+	entries, ok := state.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	properties, _ := entries["properties"].(map[string]interface{})
+	return properties
+}
+
+func cdcUnavailable(err error) error {
+	var neo4jErr *neo4j.Neo4jError
+	if errors.As(err, &neo4jErr) && neo4jErr.Code == "Neo.ClientError.Procedure.ProcedureNotFound" {
+		return fmt.Errorf("%w: %s", ErrCDCUnavailable, neo4jErr.Msg)
+	}
+	return err
+}
+
+// MemoryCursorStore holds the cursors in memory, they are lost when the process exits
+type MemoryCursorStore struct {
+	mutex   sync.Mutex
+	cursors map[string]string
+}
+
+// NewMemoryCursorStore creates an empty MemoryCursorStore
+func NewMemoryCursorStore() *MemoryCursorStore {
+	return &MemoryCursorStore{cursors: map[string]string{}}
+}
+
+// Load returns the cursor of the consumer, "" if none was saved
+func (s *MemoryCursorStore) Load(consumer string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.cursors[consumer], nil
+}
+
+// Save stores the cursor of the consumer
+func (s *MemoryCursorStore) Save(consumer, cursor string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cursors[consumer] = cursor
+	return nil
+}
+
+// FileCursorStore holds the cursor of every consumer in its own file of a directory, synced to disk before Save returns
+type FileCursorStore struct {
+	mutex sync.Mutex
+	dir   string
+}
+
+// NewFileCursorStore creates the store of dir, creating dir if needed
+func NewFileCursorStore(dir string) (*FileCursorStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileCursorStore{dir: dir}, nil
+}
+
+// Load returns the cursor of the consumer, "" if none was saved
+func (s *FileCursorStore) Load(consumer string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	cursor, err := os.ReadFile(s.path(consumer))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	return string(cursor), err
+}
+
+// Save writes the cursor to a temporary file renamed once synced, so that a crash never leaves a partial cursor
+func (s *FileCursorStore) Save(consumer, cursor string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	temporary, err := os.CreateTemp(s.dir, "cursor-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+	_, err = temporary.WriteString(cursor)
+	if err == nil {
+		err = temporary.Sync()
+	}
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(temporary.Name(), s.path(consumer))
+}
+
+func (s *FileCursorStore) path(consumer string) string {
+	return filepath.Join(s.dir, url.PathEscape(consumer)+".cursor")
+}
@@ -0,0 +1,112 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestCDC(t *testing.T) {
+	suite.Run(t, new(CDCTestSuite))
+}
+
+type CDCTestSuite struct {
+	suite.Suite
+	ctx   context.Context
+	mock  *MockDriver
+	store *FileCursorStore
+}
+
+var changeKeys = []string{"id", "txId", "seq", "metadata", "event"}
+
+func (s *CDCTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mock = NewMockDriver()
+	store, err := NewFileCursorStore(s.T().TempDir())
+	s.Require().NoError(err)
+	s.store = store
+}
+
+func (s *CDCTestSuite) TestStartsAtTheCurrentChangeAndDeliversTypedEvents() {
+	committedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.mock.ExpectQuery(`^CALL db\.cdc\.current\(\)`).WillReturn([]string{"id"}, []any{"A0"})
+	s.mock.ExpectQuery(`^CALL db\.cdc\.query\(\$from, \$selectors\)`).WithParams(map[string]interface{}{
+		"from": "A0", "selectors": []interface{}{}, "limit": int64(100),
+	}).WillReturn(changeKeys,
+		[]any{"A1", int64(7), int64(0), map[string]any{"txCommitTime": committedAt}, map[string]any{
+			"elementId": "n1", "eventType": "n", "operation": "u", "labels": []any{"Person"},
+			"state": map[string]any{
+				"before": map[string]any{"labels": []any{"Person"}, "properties": map[string]any{"name": "ali"}},
+				"after":  map[string]any{"labels": []any{"Person"}, "properties": map[string]any{"name": "alice"}},
+			},
+		}},
+		[]any{"A2", int64(7), int64(1), map[string]any{}, map[string]any{
+			"elementId": "r1", "eventType": "r", "operation": "c", "type": "KNOWS",
+			"start": map[string]any{"elementId": "n1"}, "end": map[string]any{"elementId": "n2"},
+			"state": map[string]any{"before": nil, "after": map[string]any{"properties": map[string]any{"since": int64(2020)}}},
+		}},
+	)
+	var events []ChangeEvent
+
+	handled, err := NewCDCConsumer(s.mock, "search-index", s.store).Poll(s.ctx, func(_ context.Context, event ChangeEvent) error {
+		events = append(events, event)
+		return nil
+	})
+
+	s.Require().NoError(err)
+	s.Equal(2, handled)
+	s.Equal([]ChangeEvent{
+		{ID: "A1", TxID: 7, Seq: 0, CommitTime: committedAt, Entity: ChangedNode, Operation: ChangeUpdated, ElementID: "n1",
+			Labels: []string{"Person"}, Before: map[string]any{"name": "ali"}, After: map[string]any{"name": "alice"},
+			Metadata: map[string]any{"txCommitTime": committedAt}},
+		{ID: "A2", TxID: 7, Seq: 1, Entity: ChangedRelationship, Operation: ChangeCreated, ElementID: "r1",
+			Type: "KNOWS", StartID: "n1", EndID: "n2", After: map[string]any{"since": int64(2020)}, Metadata: map[string]any{}},
+	}, events)
+	s.Equal("A2", s.cursor("search-index"))
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *CDCTestSuite) TestResumesAfterTheLastHandledChange() {
+	s.Require().NoError(s.store.Save("search-index", "A1"))
+	s.mock.ExpectQuery(`^CALL db\.cdc\.query`).WithParams(map[string]interface{}{
+		"from": "A1", "selectors": []interface{}{map[string]interface{}{"select": "n"}}, "limit": int64(2),
+	}).WillReturn(changeKeys,
+		[]any{"A2", int64(8), int64(0), map[string]any{}, map[string]any{"elementId": "n1", "eventType": "n", "operation": "d"}},
+		[]any{"A3", int64(9), int64(0), map[string]any{}, map[string]any{"elementId": "n2", "eventType": "n", "operation": "d"}},
+	)
+	consumer := NewCDCConsumer(s.mock, "search-index", s.store).WithBatchSize(2).WithSelectors(map[string]interface{}{"select": "n"})
+
+	handled, err := consumer.Poll(s.ctx, func(_ context.Context, event ChangeEvent) error {
+		if event.ElementID == "n2" {
+			return errors.New("index unavailable")
+		}
+		return nil
+	})
+
+	s.EqualError(err, "handling change n2 of A3: index unavailable")
+	s.Equal(1, handled)
+	s.Equal("A2", s.cursor("search-index"))
+}
+
+func (s *CDCTestSuite) TestReportsAServerWithoutCDC() {
+	s.mock.ExpectQuery(`^CALL db\.cdc\.earliest\(\)`).WillReturnError(&neo4j.Neo4jError{
+		Code: "Neo.ClientError.Procedure.ProcedureNotFound",
+		Msg:  "There is no procedure with the name `db.cdc.earliest` registered for this database instance",
+	})
+
+	_, err := NewCDCConsumer(s.mock, "search-index", NewMemoryCursorStore()).FromEarliest().Poll(s.ctx, func(context.Context, ChangeEvent) error {
+		return nil
+	})
+
+	s.ErrorIs(err, ErrCDCUnavailable)
+}
+
+func (s *CDCTestSuite) cursor(consumer string) string {
+	cursor, err := s.store.Load(consumer)
+	s.Require().NoError(err)
+	return cursor
+}
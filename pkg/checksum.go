@@ -0,0 +1,59 @@
+package driver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"sort"
+)
+
+// ResultChecksum summarizes a result so that datasets can be compared across environments without transferring them
+type ResultChecksum struct {
+	// Sum is the hex-encoded SHA-256 of the result, independent of the order of its records
+	Sum string
+	// Records is the number of records of the result
+	Records int
+}
+
+// ChecksumRecords streams the remaining records and computes their checksum. every record is normalized first:
+// values are converted with ToPlainValue and WithTemporalAsString, element ids are ignored and labels sorted,
+// so that the same data yields the same checksum on another database, whatever the order of the records.
+// integers and floats of the same value are not told apart.
+func ChecksumRecords(ctx context.Context, result RecordIterator) (ResultChecksum, error) {
+	options := &mapOptions{temporalAsString: true, withoutIdentities: true}
+	var digests [][]byte
+	var record *neo4j.Record
+	for result.NextRecord(ctx, &record) {
+		// encoding/json sorts map keys, which makes the encoding canonical
+		encoded, err := json.Marshal(options.convert(record))
+		if err != nil {
+			return ResultChecksum{}, err
+		}
+		digest := sha256.Sum256(encoded)
+		digests = append(digests, digest[:])
+	}
+	if err := result.Err(); err != nil {
+		return ResultChecksum{}, err
+	}
+	sort.Slice(digests, func(i, j int) bool {
+		return bytes.Compare(digests[i], digests[j]) < 0
+	})
+	sum := sha256.New()
+	for _, digest := range digests {
+		sum.Write(digest)
+	}
+	return ResultChecksum{Sum: hex.EncodeToString(sum.Sum(nil)), Records: len(digests)}, nil
+}
+
+// Checksum runs a read query and computes the checksum of its results with ChecksumRecords, e.g. for reconciliation jobs
+func (d *Driver) Checksum(ctx context.Context, query string, params map[string]interface{}, opts ...QueryOption) (checksum ResultChecksum, err error) {
+	opts = append([]QueryOption{WithAccessMode(neo4j.AccessModeRead)}, opts...)
+	err = d.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) error {
+		checksum, err = ChecksumRecords(ctx, result)
+		return err
+	}, opts...)
+	return checksum, err
+}
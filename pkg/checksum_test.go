@@ -0,0 +1,65 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestChecksum(t *testing.T) {
+	suite.Run(t, new(ChecksumTestSuite))
+}
+
+type ChecksumTestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *ChecksumTestSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+func (s *ChecksumTestSuite) checksum(result RecordIterator) ResultChecksum {
+	checksum, err := ChecksumRecords(s.ctx, result)
+	s.Require().NoError(err)
+	return checksum
+}
+
+func (s *ChecksumTestSuite) TestIgnoresRecordOrderAndElementIds() {
+	since := neo4j.DateOf(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC))
+	production := newFakeRecords([]string{"user", "since"},
+		[]any{neo4j.Node{ElementId: "4:prod:1", Labels: []string{"User", "Admin"}, Props: map[string]any{"name": "alice"}}, since},
+		[]any{neo4j.Node{ElementId: "4:prod:2", Labels: []string{"User"}, Props: map[string]any{"name": "bob"}}, nil},
+	)
+	staging := newFakeRecords([]string{"user", "since"},
+		[]any{neo4j.Node{ElementId: "4:staging:7", Labels: []string{"User"}, Props: map[string]any{"name": "bob"}}, nil},
+		[]any{neo4j.Node{ElementId: "4:staging:3", Labels: []string{"Admin", "User"}, Props: map[string]any{"name": "alice"}}, since},
+	)
+
+	expected := s.checksum(production)
+
+	s.Equal(expected, s.checksum(staging))
+	s.Equal(2, expected.Records)
+	s.Len(expected.Sum, 64)
+}
+
+func (s *ChecksumTestSuite) TestDetectsDifferences() {
+	checksum := s.checksum(newFakeRecords([]string{"name"}, []any{"alice"}, []any{"bob"}))
+
+	s.NotEqual(checksum, s.checksum(newFakeRecords([]string{"name"}, []any{"alice"}, []any{"bobby"})))
+	s.NotEqual(checksum, s.checksum(newFakeRecords([]string{"login"}, []any{"alice"}, []any{"bob"})))
+	s.NotEqual(checksum, s.checksum(newFakeRecords([]string{"name"}, []any{"alice"}, []any{"bob"}, []any{"bob"})))
+}
+
+func (s *ChecksumTestSuite) TestReportsStreamErrors() {
+	result := newFakeRecords([]string{"name"}, []any{"alice"})
+	result.err = errors.New("connection reset")
+
+	_, err := ChecksumRecords(s.ctx, result)
+
+	s.EqualError(err, "connection reset")
+}
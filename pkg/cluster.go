@@ -0,0 +1,111 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ClusterTopology describes the servers of a deployment, e.g. for the health endpoints of the applications embedding the driver
+type ClusterTopology struct {
+	Version ServerVersion
+	// Standalone is set for the Neo4j 4 servers outside of a cluster, which list no member
+	Standalone bool
+	Members    []ClusterMember
+}
+
+// ClusterMember is a server of a deployment
+type ClusterMember struct {
+	ID        string
+	Name      string
+	Addresses []string
+	// State and Health are only reported since Neo4j 5, e.g. "Enabled" and "Available"
+	State, Health string
+	// Databases maps the databases hosted by the server to its role for each of them,
+	// e.g. "primary" or "secondary" since Neo4j 5, "LEADER", "FOLLOWER" or "READ_REPLICA" before
+	Databases map[string]string
+	// Groups are the server groups, only reported before Neo4j 5
+	Groups []string
+}
+
+// ClusterInfo describes the servers of the deployment, see CollectClusterInfo
+func (d *Driver) ClusterInfo(ctx context.Context) (ClusterTopology, error) {
+	return CollectClusterInfo(ctx, d)
+}
+
+// CollectClusterInfo describes the servers of the deployment with runner, reading SHOW SERVERS and SHOW DATABASES
+// since Neo4j 5, dbms.cluster.overview before
+func CollectClusterInfo(ctx context.Context, runner QueryRunner) (ClusterTopology, error) {
+	version, err := NewSchema(runner).Version(ctx)
+	if err != nil {
+		return ClusterTopology{}, err
+	}
+	topology := ClusterTopology{Version: version}
+	if version.AtLeast(5, 0) {
+		topology.Members, err = showServers(ctx, runner)
+		return topology, err
+	}
+	topology.Members, err = clusterOverview(ctx, runner)
+	var neo4jErr *neo4j.Neo4jError
+	if errors.As(err, &neo4jErr) && neo4jErr.Code == "Neo.ClientError.Procedure.ProcedureNotFound" {
+		return ClusterTopology{Version: version, Standalone: true}, nil
+	}
+	return topology, err
+}
+
+func showServers(ctx context.Context, runner QueryRunner) ([]ClusterMember, error) {
+	var members []ClusterMember
+	byID := map[string]int{}
+	err := runner.ExecuteQuery(ctx, "SHOW SERVERS YIELD serverId, name, address, state, health RETURN serverId, name, address, state, health", nil, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for result.NextRecord(ctx, &record) {
+			member := ClusterMember{Databases: map[string]string{}}
+			member.ID, _ = record.Values[0].(string)
+			member.Name, _ = record.Values[1].(string)
+			if address, ok := record.Values[2].(string); ok {
+				member.Addresses = []string{address}
+			}
+			member.State, _ = record.Values[3].(string)
+			member.Health, _ = record.Values[4].(string)
+			byID[member.ID] = len(members)
+			members = append(members, member)
+		}
+		return result.Err()
+	}, WithQueryName("cluster.servers"), WithDatabase(SystemDatabase), WithAccessMode(neo4j.AccessModeRead))
+	if err != nil {
+		return nil, err
+	}
+	err = runner.ExecuteQuery(ctx, "SHOW DATABASES YIELD name, serverID, role RETURN name, serverID, role", nil, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for result.NextRecord(ctx, &record) {
+			name, _ := record.Values[0].(string)
+			serverID, _ := record.Values[1].(string)
+			role, _ := record.Values[2].(string)
+			if i, found := byID[serverID]; found {
+				members[i].Databases[name] = role
+			}
+		}
+		return result.Err()
+	}, WithQueryName("cluster.databases"), WithDatabase(SystemDatabase), WithAccessMode(neo4j.AccessModeRead))
+	return members, err
+}
+
+func clusterOverview(ctx context.Context, runner QueryRunner) ([]ClusterMember, error) {
+	var members []ClusterMember
+	err := runner.ExecuteQuery(ctx, "CALL dbms.cluster.overview() YIELD id, addresses, databases, groups RETURN id, addresses, databases, groups", nil, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for result.NextRecord(ctx, &record) {
+			member := ClusterMember{Databases: map[string]string{}}
+			member.ID, _ = record.Values[0].(string)
+			member.Addresses = toStrings(record.Values[1])
+			databases, _ := record.Values[2].(map[string]interface{})
+			for name, role := range databases {
+				member.Databases[name], _ = role.(string)
+			}
+			member.Groups = toStrings(record.Values[3])
+			members = append(members, member)
+		}
+		return result.Err()
+	}, WithQueryName("cluster.overview"), WithAccessMode(neo4j.AccessModeRead))
+	return members, err
+}
@@ -0,0 +1,76 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestCluster(t *testing.T) {
+	suite.Run(t, new(ClusterTestSuite))
+}
+
+type ClusterTestSuite struct {
+	suite.Suite
+	ctx  context.Context
+	mock *MockDriver
+}
+
+func (s *ClusterTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mock = NewMockDriver()
+}
+
+func (s *ClusterTestSuite) TestShowsServersSinceNeo4j5() {
+	s.mock.ExpectQuery(`dbms\.components`).WillReturn([]string{"version"}, []any{"5.5.0"})
+	s.mock.ExpectQuery(`^SHOW SERVERS`).OnDatabase("system").WillReturn([]string{"serverId", "name", "address", "state", "health"},
+		[]any{"s1", "server-1", "core1:7687", "Enabled", "Available"},
+		[]any{"s2", "server-2", "core2:7687", "Cordoned", "Unavailable"},
+	)
+	s.mock.ExpectQuery(`^SHOW DATABASES`).OnDatabase("system").WillReturn([]string{"name", "serverID", "role"},
+		[]any{"neo4j", "s1", "primary"},
+		[]any{"neo4j", "s2", "secondary"},
+		[]any{"system", "s1", "primary"},
+	)
+
+	topology, err := CollectClusterInfo(s.ctx, s.mock)
+
+	s.Require().NoError(err)
+	s.Equal(ClusterTopology{Version: ServerVersion{Major: 5, Minor: 5}, Members: []ClusterMember{
+		{ID: "s1", Name: "server-1", Addresses: []string{"core1:7687"}, State: "Enabled", Health: "Available",
+			Databases: map[string]string{"neo4j": "primary", "system": "primary"}},
+		{ID: "s2", Name: "server-2", Addresses: []string{"core2:7687"}, State: "Cordoned", Health: "Unavailable",
+			Databases: map[string]string{"neo4j": "secondary"}},
+	}}, topology)
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *ClusterTestSuite) TestReadsTheClusterOverviewBeforeNeo4j5() {
+	s.mock.ExpectQuery(`dbms\.components`).WillReturn([]string{"version"}, []any{"4.4.12"})
+	s.mock.ExpectQuery(`dbms\.cluster\.overview`).WillReturn([]string{"id", "addresses", "databases", "groups"},
+		[]any{"c1", []any{"bolt://core1:7687", "http://core1:7474"}, map[string]any{"neo4j": "LEADER", "system": "FOLLOWER"}, []any{"eu"}},
+	)
+
+	topology, err := CollectClusterInfo(s.ctx, s.mock)
+
+	s.Require().NoError(err)
+	s.Equal([]ClusterMember{{ID: "c1", Addresses: []string{"bolt://core1:7687", "http://core1:7474"},
+		Databases: map[string]string{"neo4j": "LEADER", "system": "FOLLOWER"}, Groups: []string{"eu"}}}, topology.Members)
+	s.False(topology.Standalone)
+}
+
+func (s *ClusterTestSuite) TestReportsStandaloneNeo4j4Servers() {
+	s.mock.ExpectQuery(`dbms\.components`).WillReturn([]string{"version"}, []any{"4.4.12"})
+	s.mock.ExpectQuery(`dbms\.cluster\.overview`).WillReturnError(&neo4j.Neo4jError{
+		Code: "Neo.ClientError.Procedure.ProcedureNotFound",
+		Msg:  "There is no procedure with the name `dbms.cluster.overview` registered for this database instance",
+	})
+
+	topology, err := CollectClusterInfo(s.ctx, s.mock)
+
+	s.Require().NoError(err)
+	s.True(topology.Standalone)
+	s.Empty(topology.Members)
+}
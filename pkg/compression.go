@@ -0,0 +1,115 @@
+package driver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+const defaultCompressionThreshold = 64 * 1024
+
+// decompressFunction is the APOC function the compressed parameters are wrapped with
+const decompressFunction = "apoc.util.decompress"
+
+// ParamCompressionConfig enables the compression of large string parameters, e.g. documents, to cut the size of the
+// Bolt messages. they are sent gzipped and decompressed by the server with apoc.util.decompress, which the driver
+// detects once: parameters are sent as is when APOC is not installed.
+// compressed parameters are sent as byte arrays, which Bolt carries natively, instead of base64 strings which would grow them back
+type ParamCompressionConfig struct {
+	// Threshold is the size in bytes from which string parameters are compressed, defaults to 64KiB
+	Threshold int
+}
+
+// compressionSupport caches whether the server can decompress parameters, detection failures are retried on the next query
+type compressionSupport struct {
+	mutex     sync.Mutex
+	detected  bool
+	supported bool
+}
+
+// compressParams compresses the large string parameters and wraps their references in query with the decompression function.
+// the query and the parameters are returned unchanged when there is nothing to compress or the server cannot decompress
+func (d *Driver) compressParams(ctx context.Context, query string, params map[string]interface{}) (string, map[string]interface{}, error) {
+	config := d.settings.ParamCompression
+	if config == nil {
+		return query, params, nil
+	}
+	names := CompressibleParams(params, config.Threshold)
+	if len(names) == 0 {
+		return query, params, nil
+	}
+	supported, err := d.supportsCompression(ctx)
+	if err != nil || !supported {
+		return query, params, err
+	}
+	return CompressParams(query, params, names)
+}
+
+// CompressibleParams returns the names of the string parameters of at least threshold bytes, in sorted order
+func CompressibleParams(params map[string]interface{}, threshold int) []string {
+	if threshold <= 0 {
+		threshold = defaultCompressionThreshold
+	}
+	var names []string
+	for name, value := range params {
+		if value, ok := value.(string); ok && len(value) >= threshold {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CompressParams gzips the given string parameters and wraps their references in query, e.g. $body becomes
+// apoc.util.decompress($body, {compression: 'GZIP'})
+func CompressParams(query string, params map[string]interface{}, names []string) (string, map[string]interface{}, error) {
+	compressed := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		compressed[key] = value
+	}
+	for _, name := range names {
+		value, _ := params[name].(string)
+		buffer := bytes.Buffer{}
+		writer := gzip.NewWriter(&buffer)
+		if _, err := writer.Write([]byte(value)); err != nil {
+			return "", nil, fmt.Errorf("compressing parameter %s: %w", name, err)
+		}
+		if err := writer.Close(); err != nil {
+			return "", nil, fmt.Errorf("compressing parameter %s: %w", name, err)
+		}
+		compressed[name] = buffer.Bytes()
+		reference := regexp.MustCompile(`\$` + regexp.QuoteMeta(name) + `\b`)
+		query = reference.ReplaceAllLiteralString(query, fmt.Sprintf("%s($%s, {compression: 'GZIP'})", decompressFunction, name))
+	}
+	return query, compressed, nil
+}
+
+func (d *Driver) supportsCompression(ctx context.Context) (bool, error) {
+	d.compression.mutex.Lock()
+	defer d.compression.mutex.Unlock()
+	if d.compression.detected {
+		return d.compression.supported, nil
+	}
+	query := "SHOW FUNCTIONS YIELD name WHERE name = $name RETURN count(*) > 0 AS supported"
+	err := d.ExecuteQuery(ctx, query, map[string]interface{}{"name": decompressFunction}, func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(ctx)
+		if err != nil {
+			return err
+		}
+		d.compression.supported, _ = record.Values[0].(bool)
+		return nil
+	}, WithQueryName("compression.detect"), WithAccessMode(neo4j.AccessModeRead))
+	if err != nil {
+		return false, fmt.Errorf("detecting parameter decompression support: %w", err)
+	}
+	d.compression.detected = true
+	if !d.compression.supported {
+		d.logger().Printf("[neo4j] %s is not available, large parameters are sent uncompressed", decompressFunction)
+	}
+	return d.compression.supported, nil
+}
@@ -0,0 +1,45 @@
+package driver_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	suite.Run(t, new(CompressionTestSuite))
+}
+
+type CompressionTestSuite struct {
+	suite.Suite
+}
+
+func (s *CompressionTestSuite) TestSelectsLargeStringParams() {
+	params := map[string]interface{}{"body": strings.Repeat("a", 10), "title": "short", "size": 1 << 20}
+
+	s.Equal([]string{"body"}, CompressibleParams(params, 10))
+	s.Empty(CompressibleParams(params, 0))
+}
+
+func (s *CompressionTestSuite) TestWrapsCompressedParamsWithTheDecompression() {
+	body := strings.Repeat("lorem ipsum ", 1000)
+	params := map[string]interface{}{"body": body, "bodyHash": "abc"}
+
+	query, compressed, err := CompressParams("CREATE (:Doc {body: $body, hash: $bodyHash})", params, []string{"body"})
+
+	s.Require().NoError(err)
+	s.Equal("CREATE (:Doc {body: apoc.util.decompress($body, {compression: 'GZIP'}), hash: $bodyHash})", query)
+	s.Equal("abc", compressed["bodyHash"])
+	s.Equal(body, params["body"])
+	gzipped := compressed["body"].([]byte)
+	s.Less(len(gzipped), len(body)/10)
+	reader, err := gzip.NewReader(bytes.NewReader(gzipped))
+	s.Require().NoError(err)
+	decompressed, err := io.ReadAll(reader)
+	s.Require().NoError(err)
+	s.Equal(body, string(decompressed))
+}
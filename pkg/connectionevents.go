@@ -0,0 +1,74 @@
+package driver
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnectionEvent describes an outage of the server, as seen by the driver
+type ConnectionEvent struct {
+	// Reason is the error that revealed the outage. for OnReconnect, it is the error of the attempt, nil when it succeeded
+	Reason error
+	// Attempts is the number of reconnect attempts since the connection was lost
+	Attempts int
+	// Downtime is the time elapsed since the connection was lost
+	Downtime time.Duration
+}
+
+// ConnectionEventFn is called on connectivity changes, e.g. to alert, flip health endpoints or pause consumers.
+// it is called synchronously by the query that observed the change and should not block
+type ConnectionEventFn func(event ConnectionEvent)
+
+// outage tracks the current loss of connectivity, if any
+type outage struct {
+	active   atomic.Bool
+	mutex    sync.Mutex
+	lostAt   time.Time
+	reason   error
+	attempts int
+}
+
+// connectionLost starts an outage, unless one is already ongoing
+func (d *Driver) connectionLost(reason error) {
+	d.outage.mutex.Lock()
+	if d.outage.active.Load() {
+		d.outage.mutex.Unlock()
+		return
+	}
+	d.outage.lostAt, d.outage.reason, d.outage.attempts = time.Now(), reason, 0
+	d.outage.active.Store(true)
+	d.outage.mutex.Unlock()
+	if d.settings.OnConnectionLost != nil {
+		d.settings.OnConnectionLost(ConnectionEvent{Reason: reason})
+	}
+}
+
+// reconnectAttempted reports a reconnect attempt of the ongoing outage
+func (d *Driver) reconnectAttempted(err error) {
+	d.outage.mutex.Lock()
+	d.outage.attempts++
+	event := ConnectionEvent{Reason: err, Attempts: d.outage.attempts, Downtime: time.Since(d.outage.lostAt)}
+	d.outage.mutex.Unlock()
+	if d.settings.OnReconnect != nil {
+		d.settings.OnReconnect(event)
+	}
+}
+
+// connectionRecovered ends the ongoing outage, if any. it is called after every successful query, hence the lock-free check
+func (d *Driver) connectionRecovered() {
+	if !d.outage.active.Load() {
+		return
+	}
+	d.outage.mutex.Lock()
+	if !d.outage.active.Load() {
+		d.outage.mutex.Unlock()
+		return
+	}
+	d.outage.active.Store(false)
+	event := ConnectionEvent{Reason: d.outage.reason, Attempts: d.outage.attempts, Downtime: time.Since(d.outage.lostAt)}
+	d.outage.mutex.Unlock()
+	if d.settings.OnRecovered != nil {
+		d.settings.OnRecovered(event)
+	}
+}
@@ -0,0 +1,74 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestConnectionEvents(t *testing.T) {
+	suite.Run(t, new(ConnectionEventsTestSuite))
+}
+
+type ConnectionEventsTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	restore func()
+	events  []string
+}
+
+func (s *ConnectionEventsTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+	s.events = nil
+}
+
+func (s *ConnectionEventsTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *ConnectionEventsTestSuite) TestReportsOutagesFromLossToRecovery() {
+	s.cluster.unreachableDrivers = 2
+	var recovered ConnectionEvent
+	settings := connectionSettings
+	settings.OnConnectionLost = func(event ConnectionEvent) {
+		s.events = append(s.events, "lost")
+		s.ErrorContains(event.Reason, "ConnectivityError")
+	}
+	settings.OnReconnect = func(event ConnectionEvent) {
+		s.events = append(s.events, "reconnect")
+		s.NoError(event.Reason)
+	}
+	settings.OnRecovered = func(event ConnectionEvent) {
+		s.events = append(s.events, "recovered")
+		recovered = event
+	}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver))
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver))
+
+	s.Equal([]string{"lost", "reconnect", "reconnect", "recovered"}, s.events)
+	s.Equal(2, recovered.Attempts)
+	s.ErrorContains(recovered.Reason, "ConnectivityError")
+	s.Positive(recovered.Downtime)
+}
+
+func (s *ConnectionEventsTestSuite) TestStaysQuietWithoutOutages() {
+	settings := connectionSettings
+	settings.OnRecovered = func(ConnectionEvent) {
+		s.events = append(s.events, "recovered")
+	}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver))
+
+	s.Empty(s.events)
+}
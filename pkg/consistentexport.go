@@ -0,0 +1,87 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"strings"
+	"time"
+)
+
+// QuerySpec describes one of the queries of a consistent export
+type QuerySpec struct {
+	// Name identifies the query in the sink and in the errors, e.g. "users"
+	Name   string
+	Query  string
+	Params map[string]interface{}
+}
+
+// ExportSinkFn receives the records of every export query, in the order of the specs, e.g. to WriteJSON them.
+// the records must be consumed before returning, they are discarded once the next query runs
+type ExportSinkFn func(spec QuerySpec, records RecordIterator) error
+
+// ExportConsistent runs the export queries in order within a single read transaction, so that their results
+// are mutually consistent: they all observe the same snapshot of the database, whatever is written meanwhile.
+// the access mode of the options is ignored. the queries go through the checks of ExecuteQuery before the transaction begins.
+// like ExecuteScript, the export is not retried on connectivity errors, since the sink may already have received records.
+func (d *Driver) ExportConsistent(ctx context.Context, queries []QuerySpec, sink ExportSinkFn, opts ...QueryOption) (err error) {
+	if err := d.lifecycle.enter(); err != nil {
+		return err
+	}
+	defer d.lifecycle.exit()
+	options := newQueryOptions(append(opts, WithAccessMode(neo4j.AccessModeRead)))
+	d.tagQuery(ctx, options)
+	for _, spec := range queries {
+		if err := d.screen(ctx, spec.Query, options); err != nil {
+			return fmt.Errorf("export query %q: %w", spec.Name, err)
+		}
+	}
+	d.accessLock.RLock()
+	defer d.accessLock.RUnlock()
+	defer func() {
+		options.report()
+		d.audit(ctx, AuditExport, exportStatement(queries), nil, options, err)
+		if instrumented {
+			d.notifyObserver(ctx, exportStatement(queries), options, time.Since(options.start), err)
+		}
+	}()
+
+	generation, done := d.acquireGeneration()
+	defer done()
+	session := d.newSessionOn(ctx, generation, options)
+	defer d.CloseSession(ctx, session)
+	options.stats.Attempts++
+	tx, err := session.BeginTransaction(ctx, options.txConfigurers()...)
+	if err != nil {
+		return err
+	}
+	defer tx.Close(ctx)
+	for _, spec := range queries {
+		if err := exportQuery(ctx, tx, spec, sink, &options.rows); err != nil {
+			return fmt.Errorf("export query %q: %w", spec.Name, err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// exportQuery hands the records of the query to the sink, adding the ones it reads to rows
+func exportQuery(ctx context.Context, tx neo4j.ExplicitTransaction, spec QuerySpec, sink ExportSinkFn, rows *int) error {
+	result, err := tx.Run(ctx, spec.Query, CoerceParams(spec.Params))
+	if err != nil {
+		return err
+	}
+	if err := sink(spec, &countingResult{ResultWithContext: result, rows: rows}); err != nil {
+		return err
+	}
+	_, err = result.Consume(ctx)
+	return err
+}
+
+// exportStatement is the statement reported to the observer for an export
+func exportStatement(queries []QuerySpec) string {
+	statements := make([]string, len(queries))
+	for i, spec := range queries {
+		statements[i] = spec.Query
+	}
+	return strings.Join(statements, ";\n")
+}
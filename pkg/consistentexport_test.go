@@ -0,0 +1,92 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestConsistentExport(t *testing.T) {
+	suite.Run(t, new(ConsistentExportTestSuite))
+}
+
+type ConsistentExportTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	driver  *Driver
+	restore func()
+}
+
+func (s *ConsistentExportTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	s.driver = driver
+}
+
+func (s *ConsistentExportTestSuite) TearDownTest() {
+	s.driver.Close(s.ctx)
+	s.restore()
+}
+
+func (s *ConsistentExportTestSuite) TestRunsAllQueriesInOneReadTransaction() {
+	queries := []QuerySpec{
+		{Name: "users", Query: "MATCH (u:User) RETURN u"},
+		{Name: "orders", Query: "MATCH (o:Order) RETURN o"},
+	}
+	var exported []string
+
+	err := s.driver.ExportConsistent(s.ctx, queries, func(spec QuerySpec, records RecordIterator) error {
+		maps, err := ToMaps(s.ctx, records)
+		for _, row := range maps {
+			exported = append(exported, spec.Name+": "+row["query"].(string))
+		}
+		return err
+	})
+
+	s.Require().NoError(err)
+	s.Equal([]string{"users: MATCH (u:User) RETURN u", "orders: MATCH (o:Order) RETURN o"}, exported)
+	s.Equal([]neo4j.AccessMode{neo4j.AccessModeRead}, s.cluster.modes)
+	s.Require().Len(s.cluster.transactions, 1)
+	s.True(s.cluster.transactions[0].committed)
+}
+
+func (s *ConsistentExportTestSuite) TestStopsAtTheFirstFailingSink() {
+	queries := []QuerySpec{{Name: "users", Query: "MATCH (u:User) RETURN u"}, {Name: "orders", Query: "MATCH (o:Order) RETURN o"}}
+
+	err := s.driver.ExportConsistent(s.ctx, queries, func(QuerySpec, RecordIterator) error {
+		return errors.New("disk full")
+	})
+
+	s.EqualError(err, `export query "users": disk full`)
+	s.Require().Len(s.cluster.transactions, 1)
+	s.Equal([]string{"MATCH (u:User) RETURN u"}, s.cluster.transactions[0].queries)
+	s.False(s.cluster.transactions[0].committed)
+}
+
+func (s *ConsistentExportTestSuite) TestScreensEveryQueryBeforeBeginningTheTransaction() {
+	settings := connectionSettings
+	settings.InjectionGuard = &InjectionGuardConfig{}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	queries := []QuerySpec{
+		{Name: "users", Query: "MATCH (u:User) RETURN u"},
+		{Name: "orders", Query: "MATCH (o:Order) WHERE o.customer = 'alice@example.com' RETURN o"},
+	}
+
+	err = driver.ExportConsistent(s.ctx, queries, func(QuerySpec, RecordIterator) error {
+		s.Fail("the sink must not be called")
+		return nil
+	})
+
+	s.ErrorIs(err, ErrSuspectedInjection)
+	s.ErrorContains(err, `export query "orders"`)
+	s.Empty(s.cluster.transactions)
+}
@@ -0,0 +1,27 @@
+package driver
+
+import (
+	"context"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// WithConsumeGuard guards the consumption of the result of the query, see Settings.ConsumeGuard
+func WithConsumeGuard() QueryOption {
+	return func(options *queryOptions) {
+		options.consumeGuard = true
+	}
+}
+
+// guardConsumption consumes what the hook left of the result, so that the errors the server raises while streaming
+// fail the query instead of being lost, and warns about the records the hook did not read
+func (d *Driver) guardConsumption(ctx context.Context, query string, result neo4j.ResultWithContext, options *queryOptions) error {
+	if result.Peek(ctx) {
+		d.logger().Printf("[neo4j] the results hook of %q returned without consuming all the records, the remaining ones are discarded", displayName(options.name, query))
+	}
+	summary, err := result.Consume(ctx)
+	if err != nil {
+		return err
+	}
+	options.summary, options.summaryFetched = summary, true
+	return nil
+}
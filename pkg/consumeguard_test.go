@@ -0,0 +1,72 @@
+package driver_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"log"
+	"testing"
+)
+
+func TestConsumeGuard(t *testing.T) {
+	suite.Run(t, new(ConsumeGuardTestSuite))
+}
+
+type ConsumeGuardTestSuite struct {
+	suite.Suite
+	ctx      context.Context
+	cluster  *fakeCluster
+	restore  func()
+	logs     bytes.Buffer
+	settings Settings
+}
+
+func (s *ConsumeGuardTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+	s.logs.Reset()
+	s.settings = connectionSettings
+	s.settings.Logger = log.New(&s.logs, "", 0)
+}
+
+func (s *ConsumeGuardTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *ConsumeGuardTestSuite) TestSurfacesTheErrorsRaisedWhileStreaming() {
+	s.cluster.resultErr = errors.New("Neo.TransientError.General.MemoryPoolOutOfMemoryError")
+	driver, err := NewDriver(s.settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	ignoreResult := func(neo4j.ResultWithContext) error {
+		return nil
+	}
+
+	unguarded := driver.ExecuteQuery(s.ctx, "MATCH (n) RETURN n", nil, ignoreResult)
+	guarded := driver.ExecuteQuery(s.ctx, "MATCH (n) RETURN n", nil, ignoreResult, WithConsumeGuard())
+
+	s.NoError(unguarded)
+	s.EqualError(guarded, "Neo.TransientError.General.MemoryPoolOutOfMemoryError")
+}
+
+func (s *ConsumeGuardTestSuite) TestWarnsAboutUnconsumedRecords() {
+	s.settings.ConsumeGuard = true
+	driver, err := NewDriver(s.settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	s.Require().NoError(driver.ExecuteQuery(s.ctx, "MATCH (n) RETURN n", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	}, WithQueryName("nodes")))
+	s.Require().NoError(driver.ExecuteQuery(s.ctx, "MATCH (n) RETURN n", nil, func(result neo4j.ResultWithContext) error {
+		_, err := result.Collect(s.ctx)
+		return err
+	}, WithQueryName("all-nodes")))
+
+	s.Contains(s.logs.String(), `the results hook of "nodes" returned without consuming all the records`)
+	s.NotContains(s.logs.String(), `"all-nodes"`)
+}
@@ -0,0 +1,43 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ContextTaggerFn extracts correlation tags from the context of a query, e.g. request, user or trace identifiers
+type ContextTaggerFn func(ctx context.Context) map[string]interface{}
+
+// tagQuery adds the tags of ctx to the transaction metadata of the query, so that they show up in Neo4j's query log,
+// and keeps them for the log entries of the driver. the metadata given WithTxMetadata wins over tags of the same key
+func (d *Driver) tagQuery(ctx context.Context, options *queryOptions) {
+	if d.settings.ContextTagger == nil {
+		return
+	}
+	tags := d.settings.ContextTagger(ctx)
+	if len(tags) == 0 {
+		return
+	}
+	options.tags = tags
+	if options.txMetadata == nil {
+		options.txMetadata = make(map[string]interface{}, len(tags))
+	}
+	for key, value := range tags {
+		if _, found := options.txMetadata[key]; !found {
+			options.txMetadata[key] = value
+		}
+	}
+}
+
+// tagSuffix formats the tags of the query for the log entries, e.g. " [requestId=42 userId=alice]", empty without tags
+func (o *queryOptions) tagSuffix() string {
+	if len(o.tags) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(o.tags))
+	for _, key := range sortedKeys(o.tags) {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, o.tags[key]))
+	}
+	return " [" + strings.Join(pairs, " ") + "]"
+}
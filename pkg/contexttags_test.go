@@ -0,0 +1,77 @@
+package driver_test
+
+import (
+	"bytes"
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestContextTags(t *testing.T) {
+	suite.Run(t, new(ContextTagsTestSuite))
+}
+
+type ContextTagsTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	restore func()
+}
+
+type requestIDKey struct{}
+
+func (s *ContextTagsTestSuite) SetupTest() {
+	s.ctx = context.WithValue(context.Background(), requestIDKey{}, "req-42")
+	s.cluster = &fakeCluster{}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+}
+
+func (s *ContextTagsTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *ContextTagsTestSuite) TestTagsTheTransactionMetadataAndTheLogs() {
+	var logs bytes.Buffer
+	settings := connectionSettings
+	settings.Logger = log.New(&logs, "", 0)
+	settings.SlowQueryThreshold = time.Nanosecond
+	settings.ContextTagger = func(ctx context.Context) map[string]interface{} {
+		return map[string]interface{}{"requestId": ctx.Value(requestIDKey{}), "app": "tagger"}
+	}
+	var slowQueries []SlowQuery
+	settings.OnSlowQuery = func(_ context.Context, slowQuery SlowQuery) {
+		slowQueries = append(slowQueries, slowQuery)
+	}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	err = driver.ExecuteQuery(s.ctx, "RETURN true AS ok", nil, func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(s.ctx)
+		return err
+	}, WithTxMetadata(map[string]interface{}{"app": "orders"}))
+
+	s.Require().NoError(err)
+	s.Equal([]map[string]any{{"requestId": "req-42", "app": "orders"}}, s.cluster.metadata)
+	s.Require().Len(slowQueries, 1)
+	s.Equal(map[string]interface{}{"requestId": "req-42", "app": "tagger"}, slowQueries[0].Tags)
+	s.Contains(logs.String(), "[app=tagger requestId=req-42]")
+}
+
+func (s *ContextTagsTestSuite) TestLeavesTheMetadataAloneWithoutTags() {
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	err = driver.ExecuteQuery(s.ctx, "RETURN true AS ok", nil, func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(s.ctx)
+		return err
+	})
+
+	s.Require().NoError(err)
+	s.Equal([]map[string]any{nil}, s.cluster.metadata)
+}
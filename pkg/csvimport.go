@@ -0,0 +1,229 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"strings"
+	"unicode/utf8"
+)
+
+const defaultCSVBatchSize = 1000
+const defaultCSVListSeparator = ";"
+
+// CSVType is the type the values of a CSV column are converted to
+type CSVType int
+
+const (
+	CSVString CSVType = iota
+	CSVInteger
+	CSVFloat
+	CSVBoolean
+	CSVDate
+	CSVDateTime
+	// CSVList splits the value with CSVField.ListSeparator into a list of strings
+	CSVList
+)
+
+// CSVField maps a CSV column to a node property
+type CSVField struct {
+	Column string
+	// Property defaults to Column
+	Property string
+	Type     CSVType
+	// Required rejects the rows where the column is empty or cannot be converted to Type. the key fields are always required
+	Required bool
+	// ListSeparator splits CSVList values, defaults to ";"
+	ListSeparator string
+}
+
+func (f CSVField) property() string {
+	if f.Property == "" {
+		return f.Column
+	}
+	return f.Property
+}
+
+// CSVImportProgress is reported to CSVImportSpec.OnProgress while the rows are imported
+type CSVImportProgress struct {
+	Rows, Imported, Rejected int
+}
+
+// CSVImportSpec describes a CSV file with headers and the nodes created from its rows
+type CSVImportSpec struct {
+	// URL is read by the server, e.g. file:///users.csv from its import directory or an https:// URL
+	URL   string
+	Label string
+	// Key lists the properties the rows are merged on, so that importing a file twice does not duplicate its nodes.
+	// the nodes are created when empty
+	Key    []string
+	Fields []CSVField
+	// FieldTerminator is the single character separating the fields, defaults to ","
+	FieldTerminator string
+	// BatchSize is the number of rows committed per transaction, defaults to 1000
+	BatchSize int
+	// OnProgress, if set, is called after every BatchSize rows and once all the rows are imported
+	OnProgress func(progress CSVImportProgress)
+}
+
+// CSVRowError describes a row rejected by ImportCSV
+type CSVRowError struct {
+	// Line is the line number of the row in the file
+	Line int64
+	// Invalid lists the required properties that were empty or could not be converted
+	Invalid []string
+	Row     map[string]interface{}
+}
+
+// CSVImportResult summarizes an ImportCSV call
+type CSVImportResult struct {
+	Rows, Imported int
+	Rejected       []CSVRowError
+}
+
+// ImportCSVQuery generates the LOAD CSV query run by ImportCSV. the rows are batched with CALL { } IN TRANSACTIONS
+// from Neo4j 5.0, with USING PERIODIC COMMIT before
+func ImportCSVQuery(spec CSVImportSpec, version ServerVersion) (string, map[string]interface{}, error) {
+	if spec.URL == "" || spec.Label == "" || len(spec.Fields) == 0 {
+		return "", nil, errors.New("CSV import requires a URL, a label and fields")
+	}
+	batchSize := spec.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultCSVBatchSize
+	}
+	params := map[string]interface{}{"url": spec.URL}
+	keys := make(map[string]bool, len(spec.Key))
+	for _, key := range spec.Key {
+		keys[key] = true
+	}
+	properties := make([]string, len(spec.Fields))
+	required := []interface{}{}
+	for i, field := range spec.Fields {
+		property := field.property()
+		properties[i] = QuoteIdentifier(property) + ": " + csvConversion(field, i, params)
+		if field.Required || keys[property] {
+			required = append(required, property)
+			delete(keys, property)
+		}
+	}
+	for _, key := range spec.Key {
+		if keys[key] {
+			return "", nil, fmt.Errorf("CSV import key %q is not mapped by any field", key)
+		}
+	}
+	params["required"] = required
+
+	load := "LOAD CSV WITH HEADERS FROM $url AS row"
+	if spec.FieldTerminator != "" {
+		if utf8.RuneCountInString(spec.FieldTerminator) != 1 {
+			return "", nil, fmt.Errorf("CSV field terminator %q is not a single character", spec.FieldTerminator)
+		}
+		load += " FIELDTERMINATOR " + quoteString(spec.FieldTerminator)
+	}
+	write := "CREATE (n:" + QuoteIdentifier(spec.Label) + ") SET n = props"
+	if len(spec.Key) > 0 {
+		matches := make([]string, len(spec.Key))
+		for i, key := range spec.Key {
+			matches[i] = QuoteIdentifier(key) + ": props." + QuoteIdentifier(key)
+		}
+		write = fmt.Sprintf("MERGE (n:%s {%s}) SET n += props", QuoteIdentifier(spec.Label), strings.Join(matches, ", "))
+	}
+	body := "WITH row, line, {" + strings.Join(properties, ", ") + "} AS props " +
+		"WITH row, line, props, [property IN $required WHERE props[property] IS NULL] AS invalid " +
+		"FOREACH (_ IN CASE WHEN size(invalid) = 0 THEN [1] ELSE [] END | " + write + ") " +
+		"RETURN line, invalid, CASE WHEN size(invalid) > 0 THEN row END AS rejected"
+	if version.AtLeast(5, 0) {
+		return fmt.Sprintf("%s WITH row, linenumber() AS line CALL { WITH row, line %s } IN TRANSACTIONS OF %d ROWS RETURN line, invalid, rejected",
+			load, body, batchSize), params, nil
+	}
+	return fmt.Sprintf("USING PERIODIC COMMIT %d %s WITH row, linenumber() AS line %s", batchSize, load, body), params, nil
+}
+
+// csvConversion returns the expression converting the column of field to its type
+func csvConversion(field CSVField, index int, params map[string]interface{}) string {
+	value := "row." + QuoteIdentifier(field.Column)
+	switch field.Type {
+	case CSVInteger:
+		return "toInteger(" + value + ")"
+	case CSVFloat:
+		return "toFloat(" + value + ")"
+	case CSVBoolean:
+		return "toBoolean(" + value + ")"
+	case CSVDate:
+		return "CASE WHEN " + value + " IS NULL THEN null ELSE date(" + value + ") END"
+	case CSVDateTime:
+		return "CASE WHEN " + value + " IS NULL THEN null ELSE datetime(" + value + ") END"
+	case CSVList:
+		separator := field.ListSeparator
+		if separator == "" {
+			separator = defaultCSVListSeparator
+		}
+		param := fmt.Sprintf("separator%d", index)
+		params[param] = separator
+		return "split(" + value + ", $" + param + ")"
+	default:
+		return value
+	}
+}
+
+// ImportCSV imports the rows of a CSV file read by the server as nodes, see CSVImportSpec.
+// the rows missing required values are not imported and returned in CSVImportResult.Rejected.
+// values that cannot be parsed as dates fail the import, along with its current batch: the previous batches are kept
+func (d *Driver) ImportCSV(ctx context.Context, spec CSVImportSpec, opts ...QueryOption) (CSVImportResult, error) {
+	return ImportCSVWith(ctx, d, spec, opts...)
+}
+
+// ImportCSVWith imports the rows of a CSV file with runner, see Driver.ImportCSV
+func ImportCSVWith(ctx context.Context, runner QueryRunner, spec CSVImportSpec, opts ...QueryOption) (CSVImportResult, error) {
+	version, err := NewSchema(runner).Version(ctx)
+	if err != nil {
+		return CSVImportResult{}, err
+	}
+	query, params, err := ImportCSVQuery(spec, version)
+	if err != nil {
+		return CSVImportResult{}, err
+	}
+	batchSize := spec.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultCSVBatchSize
+	}
+	var result CSVImportResult
+	report := func() {
+		if spec.OnProgress != nil {
+			spec.OnProgress(CSVImportProgress{Rows: result.Rows, Imported: result.Imported, Rejected: len(result.Rejected)})
+		}
+	}
+	err = runner.ExecuteQuery(ctx, query, params, func(records neo4j.ResultWithContext) error {
+		// the rows of a failed attempt are counted again when it is retried
+		result = CSVImportResult{}
+		var record *neo4j.Record
+		for records.NextRecord(ctx, &record) {
+			result.Rows++
+			invalid := toStrings(record.Values[1])
+			if len(invalid) == 0 {
+				result.Imported++
+			} else {
+				line, _ := record.Values[0].(int64)
+				row, _ := record.Values[2].(map[string]interface{})
+				result.Rejected = append(result.Rejected, CSVRowError{Line: line, Invalid: invalid, Row: row})
+			}
+			if result.Rows%batchSize == 0 {
+				report()
+			}
+		}
+		return records.Err()
+	}, append([]QueryOption{WithQueryName("importCSV")}, opts...)...)
+	if err != nil {
+		return result, err
+	}
+	if result.Rows == 0 || result.Rows%batchSize != 0 {
+		report()
+	}
+	return result, nil
+}
+
+// quoteString returns value as a Cypher string literal
+func quoteString(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
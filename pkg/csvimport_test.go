@@ -0,0 +1,93 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestCSVImport(t *testing.T) {
+	suite.Run(t, new(CSVImportTestSuite))
+}
+
+type CSVImportTestSuite struct {
+	suite.Suite
+	ctx  context.Context
+	spec CSVImportSpec
+}
+
+func (s *CSVImportTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.spec = CSVImportSpec{
+		URL:   "file:///users.csv",
+		Label: "User",
+		Key:   []string{"id"},
+		Fields: []CSVField{
+			{Column: "user_id", Property: "id", Type: CSVInteger},
+			{Column: "name"},
+			{Column: "tags", Type: CSVList, ListSeparator: "|"},
+		},
+		BatchSize: 2,
+	}
+}
+
+func (s *CSVImportTestSuite) TestBatchesRowsInTransactionsOnNeo4j5() {
+	query, params, err := ImportCSVQuery(s.spec, ServerVersion{Major: 5})
+
+	s.Require().NoError(err)
+	s.Equal("LOAD CSV WITH HEADERS FROM $url AS row WITH row, linenumber() AS line CALL { WITH row, line "+
+		"WITH row, line, {`id`: toInteger(row.`user_id`), `name`: row.`name`, `tags`: split(row.`tags`, $separator2)} AS props "+
+		"WITH row, line, props, [property IN $required WHERE props[property] IS NULL] AS invalid "+
+		"FOREACH (_ IN CASE WHEN size(invalid) = 0 THEN [1] ELSE [] END | MERGE (n:`User` {`id`: props.`id`}) SET n += props) "+
+		"RETURN line, invalid, CASE WHEN size(invalid) > 0 THEN row END AS rejected } IN TRANSACTIONS OF 2 ROWS RETURN line, invalid, rejected", query)
+	s.Equal(map[string]interface{}{"url": "file:///users.csv", "separator2": "|", "required": []interface{}{"id"}}, params)
+}
+
+func (s *CSVImportTestSuite) TestUsesPeriodicCommitBeforeNeo4j5() {
+	s.spec.Key = nil
+	s.spec.FieldTerminator = ";"
+
+	query, _, err := ImportCSVQuery(s.spec, ServerVersion{Major: 4, Minor: 4})
+
+	s.Require().NoError(err)
+	s.Equal("USING PERIODIC COMMIT 2 LOAD CSV WITH HEADERS FROM $url AS row FIELDTERMINATOR ';' WITH row, linenumber() AS line "+
+		"WITH row, line, {`id`: toInteger(row.`user_id`), `name`: row.`name`, `tags`: split(row.`tags`, $separator2)} AS props "+
+		"WITH row, line, props, [property IN $required WHERE props[property] IS NULL] AS invalid "+
+		"FOREACH (_ IN CASE WHEN size(invalid) = 0 THEN [1] ELSE [] END | CREATE (n:`User`) SET n = props) "+
+		"RETURN line, invalid, CASE WHEN size(invalid) > 0 THEN row END AS rejected", query)
+}
+
+func (s *CSVImportTestSuite) TestRejectsInvalidSpecs() {
+	s.spec.Key = []string{"email"}
+	_, _, err := ImportCSVQuery(s.spec, ServerVersion{Major: 5})
+	s.EqualError(err, `CSV import key "email" is not mapped by any field`)
+
+	s.spec.Key = nil
+	s.spec.FieldTerminator = ";;"
+	_, _, err = ImportCSVQuery(s.spec, ServerVersion{Major: 5})
+	s.EqualError(err, `CSV field terminator ";;" is not a single character`)
+}
+
+func (s *CSVImportTestSuite) TestReportsProgressAndRejectedRows() {
+	mock := NewMockDriver()
+	mock.ExpectQuery(`dbms\.components`).WillReturn([]string{"version"}, []any{"5.5.0"})
+	mock.ExpectQuery(`^LOAD CSV`).WillReturn([]string{"line", "invalid", "rejected"},
+		[]any{int64(2), []any{}, nil},
+		[]any{int64(3), []any{"id"}, map[string]any{"user_id": "abc", "name": "bob"}},
+		[]any{int64(4), []any{}, nil},
+	)
+	var progress []CSVImportProgress
+	s.spec.OnProgress = func(p CSVImportProgress) {
+		progress = append(progress, p)
+	}
+
+	result, err := ImportCSVWith(s.ctx, mock, s.spec)
+
+	s.Require().NoError(err)
+	s.Equal(3, result.Rows)
+	s.Equal(2, result.Imported)
+	s.Equal([]CSVRowError{{Line: 3, Invalid: []string{"id"}, Row: map[string]interface{}{"user_id": "abc", "name": "bob"}}}, result.Rejected)
+	s.Equal([]CSVImportProgress{{Rows: 2, Imported: 1, Rejected: 1}, {Rows: 3, Imported: 2, Rejected: 1}}, progress)
+	s.NoError(mock.ExpectationsWereMet())
+}
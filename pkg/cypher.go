@@ -0,0 +1,46 @@
+package driver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// QuoteIdentifier escapes a label, relationship type or property name so that it can be safely embedded in Cypher
+func QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// sortedKeys returns the keys of props in a stable order, so that generated Cypher is deterministic
+func sortedKeys(props map[string]interface{}) []string {
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// propertyPredicates returns the predicates matching variable properties against props, with one parameter per property.
+// nil values are matched with IS NULL.
+func propertyPredicates(variable, paramPrefix string, props map[string]interface{}, params map[string]interface{}) []string {
+	predicates := make([]string, 0, len(props))
+	for i, key := range sortedKeys(props) {
+		property := variable + "." + QuoteIdentifier(key)
+		if props[key] == nil {
+			predicates = append(predicates, property+" IS NULL")
+			continue
+		}
+		param := fmt.Sprintf("%s%d", paramPrefix, i)
+		params[param] = props[key]
+		predicates = append(predicates, property+" = $"+param)
+	}
+	return predicates
+}
+
+// lockedPredicates filters the rows of variable on predicates once its write lock is held.
+// neo4j reads committed values without locking them, so concurrent transactions comparing them first would both pass
+// the comparison: setting and removing a placeholder property takes the lock until the transaction ends.
+func lockedPredicates(variable string, predicates []string) string {
+	return fmt.Sprintf(" SET %[1]s._lock = true REMOVE %[1]s._lock WITH %[1]s WHERE %[2]s", variable, strings.Join(predicates, " AND "))
+}
@@ -0,0 +1,37 @@
+package driver
+
+import (
+	"context"
+)
+
+// SystemDatabase is the database administration commands such as CREATE DATABASE are executed against
+const SystemDatabase = "system"
+
+// WithDatabase executes the query against the named database instead of the default database of the server.
+// the results it caches WithCache are kept apart from the ones of the other databases
+func WithDatabase(name string) QueryOption {
+	return func(options *queryOptions) {
+		options.database = name
+	}
+}
+
+func (o *queryOptions) sessionKey() sessionKey {
+	return sessionKey{mode: o.accessMode, database: o.database, impersonated: o.impersonated, fetchSize: o.sessionFetchSize()}
+}
+
+// databaseRunner executes the queries of its runner against a database, see OnDatabase
+type databaseRunner struct {
+	runner   QueryRunner
+	database string
+}
+
+// OnDatabase returns a QueryRunner executing all its queries against the named database,
+// e.g. to hand the helpers built on QueryRunner (migrations, Schema...) a database other than the default one.
+// a WithDatabase option passed to a query still takes precedence
+func OnDatabase(runner QueryRunner, database string) QueryRunner {
+	return databaseRunner{runner: runner, database: database}
+}
+
+func (r databaseRunner) ExecuteQuery(ctx context.Context, query string, params map[string]interface{}, onResults ResultsHookFn, opts ...QueryOption) error {
+	return r.runner.ExecuteQuery(ctx, query, params, onResults, append([]QueryOption{WithDatabase(r.database)}, opts...)...)
+}
@@ -0,0 +1,35 @@
+package driver
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// DeadLetter is an operation that failed for good inside the write-behind queue or the dual writer,
+// because its error was not retryable or its attempts were exhausted
+type DeadLetter struct {
+	Query  string
+	Params map[string]interface{}
+	Err    error
+	// Attempts is the number of times the operation was executed, zero when it was never executed, e.g. dropped from a full queue
+	Attempts int
+	At       time.Time
+}
+
+// DeadLetterHandler receives the dead letters, so that applications can persist and replay them instead of losing them in logs.
+// it is called from the background goroutine of the queue and should not block for long
+type DeadLetterHandler func(ctx context.Context, letter DeadLetter)
+
+// DeadLettersTo persists the dead letters in store, they can be replayed later with a WriteBehindQueue using that store.
+// storage failures are logged with logger, the standard logger when nil
+func DeadLettersTo(store WriteStore, logger Logger) DeadLetterHandler {
+	return func(_ context.Context, letter DeadLetter) {
+		if _, err := store.Append(QueuedWrite{Query: letter.Query, Params: letter.Params, EnqueuedAt: letter.At}); err != nil {
+			if logger == nil {
+				logger = log.Default()
+			}
+			logger.Printf("[neo4j] could not store dead letter %q: %v (query failed with: %v)", letter.Query, err, letter.Err)
+		}
+	}
+}
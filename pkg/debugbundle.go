@@ -0,0 +1,241 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const defaultDebugEventCapacity = 100
+
+// debugRoutingTableTimeout bounds the retrieval of the routing table of a bundle, the cluster being likely unhealthy
+const debugRoutingTableTimeout = 2 * time.Second
+
+// DebugEvent is a notable driver event kept for debug bundles
+type DebugEvent struct {
+	At      time.Time `json:"at"`
+	Kind    string    `json:"kind"`
+	Message string    `json:"message"`
+}
+
+// DebugBundle gathers what is needed to investigate a query that failed persistently, for bug reports against this package
+type DebugBundle struct {
+	CapturedAt time.Time `json:"capturedAt"`
+	Query      string    `json:"query"`
+	QueryName  string    `json:"queryName,omitempty"`
+	// Params are redacted with Settings.ParamsRedactor
+	Params map[string]interface{} `json:"params"`
+	Error  string                 `json:"error"`
+	Stats  QueryStats             `json:"stats"`
+	State  string                 `json:"state"`
+	// Target is the URL the underlying driver is bootstrapped with, routing context included
+	Target string `json:"target"`
+	// InFlight is the number of queries executing when the bundle was captured
+	InFlight int `json:"inFlight"`
+	// RoutingTable is the routing table the cluster advertised for the database of the query once it failed,
+	// nil when it could not be retrieved, see RoutingTableError
+	RoutingTable      *DebugRoutingTable `json:"routingTable,omitempty"`
+	RoutingTableError string             `json:"routingTableError,omitempty"`
+	Pool              DebugPoolStats     `json:"pool"`
+	RecentEvents      []DebugEvent       `json:"recentEvents"`
+	LastErrors        []DebugEvent       `json:"lastErrors"`
+}
+
+// DebugRoutingTable lists the cluster members serving each role, as returned by dbms.routing.getRoutingTable
+type DebugRoutingTable struct {
+	Database string   `json:"database,omitempty"`
+	TTL      int64    `json:"ttl"`
+	Routers  []string `json:"routers"`
+	Readers  []string `json:"readers"`
+	Writers  []string `json:"writers"`
+}
+
+// DebugPoolStats describes the connections and sessions of the driver when the bundle was captured
+type DebugPoolStats struct {
+	// Generation counts the underlying drivers, and their connection pools, created by the recoveries since NewDriver
+	Generation uint64 `json:"generation"`
+	// GenerationInFlight is the number of queries using the connection pool of the current underlying driver
+	GenerationInFlight int64 `json:"generationInFlight"`
+	// IdleSessions is the number of sessions kept for reuse, see Settings.MaxIdleSessions
+	IdleSessions int `json:"idleSessions"`
+	// Concurrency is the usage of the slots bounding the queries of each access mode, "read" and "write"
+	Concurrency map[string]SemaphoreUsage `json:"concurrency,omitempty"`
+}
+
+// DebugBundleConfig enables debug bundles, captured whenever a query fails after going through connection recovery
+type DebugBundleConfig struct {
+	// Dir receives one JSON file per bundle, ignored when Writer is set
+	Dir string
+	// Writer receives the bundles as JSON documents
+	Writer io.Writer
+	// EventCapacity is the number of recent events kept, defaults to 100
+	EventCapacity int
+	// mutex serializes the bundles of the concurrent failures written to Writer
+	mutex sync.Mutex
+}
+
+// eventLog is a bounded log of the recent driver events and errors
+type eventLog struct {
+	mutex    sync.Mutex
+	capacity int
+	events   []DebugEvent
+	errors   []DebugEvent
+}
+
+func newEventLog(capacity int) *eventLog {
+	if capacity <= 0 {
+		capacity = defaultDebugEventCapacity
+	}
+	return &eventLog{capacity: capacity}
+}
+
+func (l *eventLog) record(kind, format string, args ...interface{}) {
+	if l == nil {
+		return
+	}
+	event := DebugEvent{At: time.Now(), Kind: kind, Message: fmt.Sprintf(format, args...)}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.events = appendBounded(l.events, event, l.capacity)
+}
+
+func (l *eventLog) recordError(kind string, err error) {
+	if l == nil {
+		return
+	}
+	event := DebugEvent{At: time.Now(), Kind: kind, Message: err.Error()}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.events = appendBounded(l.events, event, l.capacity)
+	l.errors = appendBounded(l.errors, event, l.capacity)
+}
+
+func (l *eventLog) snapshot() (events, errors []DebugEvent) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]DebugEvent{}, l.events...), append([]DebugEvent{}, l.errors...)
+}
+
+func appendBounded(events []DebugEvent, event DebugEvent, capacity int) []DebugEvent {
+	events = append(events, event)
+	if len(events) > capacity {
+		events = events[len(events)-capacity:]
+	}
+	return events
+}
+
+// captureDebugBundle writes a bundle if the query failed after going through connection recovery
+func (d *Driver) captureDebugBundle(query string, params map[string]interface{}, options *queryOptions, err error) {
+	config := d.settings.DebugBundle
+	if config == nil || err == nil || (options.stats.Reconnects == 0 && !options.recoveryFailed) {
+		return
+	}
+	events, errors := d.events.snapshot()
+	bundle := DebugBundle{
+		CapturedAt:   time.Now(),
+		Query:        query,
+		QueryName:    options.name,
+		Params:       d.redact(params),
+		Error:        err.Error(),
+		Stats:        options.stats,
+		State:        d.State().String(),
+		Target:       redactConnectionString(d.currentGeneration().config.connectionString),
+		InFlight:     d.lifecycle.inFlightCount(),
+		Pool:         d.poolStats(),
+		RecentEvents: events,
+		LastErrors:   errors,
+	}
+	if table, routingErr := d.debugRoutingTable(options.sessionConfig().DatabaseName); routingErr != nil {
+		bundle.RoutingTableError = routingErr.Error()
+	} else {
+		bundle.RoutingTable = table
+	}
+	if writeErr := writeDebugBundle(config, bundle); writeErr != nil {
+		d.logger().Printf("[neo4j] could not write debug bundle: %v", writeErr)
+	}
+}
+
+// debugRoutingTable asks the current underlying driver for the routing table of database, the default one if empty
+func (d *Driver) debugRoutingTable(database string) (*DebugRoutingTable, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), debugRoutingTableTimeout)
+	defer cancel()
+	var target interface{}
+	if database != "" {
+		target = database
+	}
+	session := d.currentGeneration().driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead, DatabaseName: SystemDatabase})
+	defer session.Close(ctx)
+	result, err := session.Run(ctx, "CALL dbms.routing.getRoutingTable({}, $database) YIELD ttl, servers RETURN ttl, servers",
+		map[string]interface{}{"database": target})
+	if err != nil {
+		return nil, err
+	}
+	record, err := result.Single(ctx)
+	if err != nil {
+		return nil, err
+	}
+	table := &DebugRoutingTable{Database: database}
+	ttl, _ := record.Get("ttl")
+	table.TTL, _ = ttl.(int64)
+	value, _ := record.Get("servers")
+	servers, _ := value.([]interface{})
+	for _, server := range servers {
+		server, _ := server.(map[string]interface{})
+		addresses := toStrings(server["addresses"])
+		switch server["role"] {
+		case "ROUTE":
+			table.Routers = append(table.Routers, addresses...)
+		case "READ":
+			table.Readers = append(table.Readers, addresses...)
+		case "WRITE":
+			table.Writers = append(table.Writers, addresses...)
+		}
+	}
+	return table, nil
+}
+
+// poolStats describes the underlying driver and the sessions the queries are currently executed with
+func (d *Driver) poolStats() DebugPoolStats {
+	generation := d.currentGeneration()
+	stats := DebugPoolStats{Generation: generation.number, GenerationInFlight: generation.inFlight.Load()}
+	if d.sessionPool != nil {
+		stats.IdleSessions = d.sessionPool.size()
+	}
+	for mode, usage := range d.ConcurrencyUsage() {
+		if stats.Concurrency == nil {
+			stats.Concurrency = map[string]SemaphoreUsage{}
+		}
+		name := "write"
+		if mode == neo4j.AccessModeRead {
+			name = "read"
+		}
+		stats.Concurrency[name] = usage
+	}
+	return stats
+}
+
+func writeDebugBundle(config *DebugBundleConfig, bundle DebugBundle) error {
+	if config.Writer != nil {
+		config.mutex.Lock()
+		defer config.mutex.Unlock()
+		return json.NewEncoder(config.Writer).Encode(bundle)
+	}
+	name := fmt.Sprintf("neo4j-debug-%s.json", bundle.CapturedAt.UTC().Format("20060102T150405.000000000"))
+	file, err := os.Create(filepath.Join(config.Dir, name))
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(bundle); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,120 @@
+package driver_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDebugBundle(t *testing.T) {
+	suite.Run(t, new(DebugBundleTestSuite))
+}
+
+type DebugBundleTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	restore func()
+}
+
+func (s *DebugBundleTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+}
+
+func (s *DebugBundleTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *DebugBundleTestSuite) TestCapturesTheRoutingTableAndThePoolStats() {
+	s.cluster.unreachableDrivers = 1
+	s.cluster.resultErr = &neo4j.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError", Msg: "Invalid input"}
+	bundles := &bytes.Buffer{}
+	settings := connectionSettings
+	settings.MaxConcurrentReads = 2
+	settings.DebugBundle = &DebugBundleConfig{Writer: bundles}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	s.Require().Error(executeSimpleQuery(s.ctx, driver, WithConsumeGuard(), WithDatabase("movies")))
+
+	bundle := DebugBundle{}
+	s.Require().NoError(json.NewDecoder(bundles).Decode(&bundle))
+	s.Empty(bundle.RoutingTableError)
+	s.Equal(&DebugRoutingTable{
+		Database: "movies",
+		TTL:      300,
+		Routers:  []string{"core-1:7687", "core-2:7687", "core-3:7687"},
+		Readers:  []string{"core-2:7687", "core-3:7687"},
+		Writers:  []string{"core-1:7687"},
+	}, bundle.RoutingTable)
+	s.Equal(uint64(1), bundle.Pool.Generation, "the driver reconnected once")
+	s.Equal(int64(2), bundle.Pool.Concurrency["read"].Capacity)
+	s.NotContains(bundle.Pool.Concurrency, "write", "the writes are not bounded")
+}
+
+func (s *DebugBundleTestSuite) TestSerializesTheBundlesOfConcurrentFailures() {
+	s.cluster.unreachableDrivers = 100
+	writer := &exclusiveWriter{}
+	settings := connectionSettings
+	settings.DebugBundle = &DebugBundleConfig{Writer: writer}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	const queries = 8
+	wg := sync.WaitGroup{}
+	wg.Add(queries)
+
+	for i := 0; i < queries; i++ {
+		go func() {
+			defer wg.Done()
+			_ = executeSimpleQuery(s.ctx, driver, WithMaxAttempts(1))
+		}()
+	}
+	wg.Wait()
+
+	s.False(writer.overlapped.Load(), "the bundles must not be written concurrently")
+	decoder := json.NewDecoder(bytes.NewReader(writer.written()))
+	for i := 0; i < queries; i++ {
+		bundle := DebugBundle{}
+		s.Require().NoError(decoder.Decode(&bundle))
+		s.Nil(bundle.RoutingTable)
+		s.Contains(bundle.RoutingTableError, "ConnectivityError", "the routing table is unavailable while the cluster is unreachable")
+	}
+}
+
+// exclusiveWriter records whether it was written to concurrently
+type exclusiveWriter struct {
+	mutex      sync.Mutex
+	buffer     bytes.Buffer
+	writing    atomic.Bool
+	overlapped atomic.Bool
+}
+
+func (w *exclusiveWriter) Write(p []byte) (int, error) {
+	if !w.writing.CompareAndSwap(false, true) {
+		w.overlapped.Store(true)
+		return 0, errors.New("concurrent write")
+	}
+	defer w.writing.Store(false)
+	time.Sleep(time.Millisecond)
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.buffer.Write(p)
+}
+
+func (w *exclusiveWriter) written() []byte {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.buffer.Bytes()
+}
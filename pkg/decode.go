@@ -0,0 +1,293 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// FieldTag is the struct tag naming the record column, map key or property a field is decoded from
+const FieldTag = "neo4j"
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// DecodeError reports a value that cannot be decoded into its destination
+type DecodeError struct {
+	// Path locates the value, e.g. "friends[2].name"
+	Path  string
+	Value interface{}
+	Type  reflect.Type
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("cannot decode %T into %s at %s", e.Value, e.Type, e.Path)
+}
+
+// DecodeOption customizes the decoding done by DecodeRecord, DecodeValue and CollectAs
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	strictFields bool
+}
+
+// WithStrictFields fails the decoding of columns, map keys and properties matching no struct field, instead of ignoring them
+func WithStrictFields() DecodeOption {
+	return func(options *decodeOptions) {
+		options.strictFields = true
+	}
+}
+
+// CollectAs reads all the remaining records and decodes each of them into a T with DecodeRecord
+func CollectAs[T any](ctx context.Context, result RecordIterator, opts ...DecodeOption) ([]T, error) {
+	values := []T{}
+	var record *neo4j.Record
+	for result.NextRecord(ctx, &record) {
+		var value T
+		if err := DecodeRecord(record, &value, opts...); err != nil {
+			return values, err
+		}
+		values = append(values, value)
+	}
+	return values, result.Err()
+}
+
+// DecodeRecord decodes the columns of a record into dest, a pointer to a struct or to a map, see DecodeValue
+func DecodeRecord(record *neo4j.Record, dest interface{}, opts ...DecodeOption) error {
+	values := make(map[string]interface{}, len(record.Keys))
+	for i, key := range record.Keys {
+		values[key] = record.Values[i]
+	}
+	return DecodeValue(values, dest, opts...)
+}
+
+// DecodeValue decodes a value returned by the driver into dest, which must be a non-nil pointer.
+// the decoding rules are:
+//   - lists decode into slices and arrays, maps into maps keyed by strings, their elements being decoded recursively
+//   - maps, nodes and relationships decode into structs: their keys or properties are matched against the FieldTag tag
+//     of the fields, or else their names case-insensitively. fields tagged "-" are skipped, embedded structs are flattened
+//   - integers decode into any integer or float type they fit in, floats into float types
+//   - temporal values decode into time.Time, durations into neo4j.Duration or time.Duration (see ToDuration)
+//   - nil decodes into the zero value, and pointers are allocated as needed
+//   - interface{} destinations receive the value as is, other values must be assignable to their destination
+func DecodeValue(value interface{}, dest interface{}, opts ...DecodeOption) error {
+	target := reflect.ValueOf(dest)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return fmt.Errorf("decoding destination must be a non-nil pointer, got %T", dest)
+	}
+	options := &decodeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options.decode(value, target.Elem(), "$")
+}
+
+func (o *decodeOptions) decode(value interface{}, target reflect.Value, path string) error {
+	if value == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+	source := reflect.ValueOf(value)
+	targetType := target.Type()
+	mismatch := &DecodeError{Path: path, Value: value, Type: targetType}
+	switch {
+	case targetType.Kind() == reflect.Interface && source.Type().Implements(targetType):
+		target.Set(source)
+		return nil
+	case targetType.Kind() == reflect.Pointer:
+		if target.IsNil() {
+			target.Set(reflect.New(targetType.Elem()))
+		}
+		return o.decode(value, target.Elem(), path)
+	case targetType == durationType:
+		duration, ok := value.(neo4j.Duration)
+		if !ok {
+			return mismatch
+		}
+		converted, err := ToDuration(duration)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		target.SetInt(int64(converted))
+		return nil
+	case targetType == timeType:
+		if !isTemporal(value) {
+			return mismatch
+		}
+		target.Set(source.Convert(timeType))
+		return nil
+	case source.Type().AssignableTo(targetType):
+		target.Set(source)
+		return nil
+	}
+	switch targetType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		integer, ok := value.(int64)
+		if !ok || target.OverflowInt(integer) {
+			return mismatch
+		}
+		target.SetInt(integer)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		integer, ok := value.(int64)
+		if !ok || integer < 0 || target.OverflowUint(uint64(integer)) {
+			return mismatch
+		}
+		target.SetUint(uint64(integer))
+	case reflect.Float32, reflect.Float64:
+		switch number := value.(type) {
+		case float64:
+			target.SetFloat(number)
+		case int64:
+			target.SetFloat(float64(number))
+		default:
+			return mismatch
+		}
+	case reflect.String:
+		text, ok := value.(string)
+		if !ok {
+			return mismatch
+		}
+		target.SetString(text)
+	case reflect.Bool:
+		boolean, ok := value.(bool)
+		if !ok {
+			return mismatch
+		}
+		target.SetBool(boolean)
+	case reflect.Slice, reflect.Array:
+		return o.decodeList(value, target, path, mismatch)
+	case reflect.Map:
+		entries, ok := decodableEntries(value)
+		if !ok || targetType.Key().Kind() != reflect.String {
+			return mismatch
+		}
+		decoded := reflect.MakeMapWithSize(targetType, len(entries))
+		for key, entry := range entries {
+			element := reflect.New(targetType.Elem()).Elem()
+			if err := o.decode(entry, element, path+"."+key); err != nil {
+				return err
+			}
+			decoded.SetMapIndex(reflect.ValueOf(key).Convert(targetType.Key()), element)
+		}
+		target.Set(decoded)
+	case reflect.Struct:
+		entries, ok := decodableEntries(value)
+		if !ok {
+			return mismatch
+		}
+		return o.decodeStruct(entries, target, path)
+	default:
+		return mismatch
+	}
+	return nil
+}
+
+func (o *decodeOptions) decodeList(value interface{}, target reflect.Value, path string, mismatch error) error {
+	list, ok := value.([]interface{})
+	if !ok {
+		return mismatch
+	}
+	if target.Kind() == reflect.Array {
+		if len(list) != target.Len() {
+			return mismatch
+		}
+	} else {
+		target.Set(reflect.MakeSlice(target.Type(), len(list), len(list)))
+	}
+	for i, element := range list {
+		if err := o.decode(element, target.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *decodeOptions) decodeStruct(entries map[string]interface{}, target reflect.Value, path string) error {
+	fields := structFields(target.Type())
+	for key, entry := range entries {
+		index, found := fields[key]
+		if !found {
+			index, found = fields[strings.ToLower(key)]
+		}
+		if !found {
+			if o.strictFields {
+				return fmt.Errorf("%s: no field of %s matches %q", path, target.Type(), key)
+			}
+			continue
+		}
+		field, err := target.FieldByIndexErr(index)
+		if err != nil {
+			// the embedded struct pointer is nil
+			allocateEmbedded(target, index)
+			field = target.FieldByIndex(index)
+		}
+		if err := o.decode(entry, field, path+"."+key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structFields indexes the decodable fields of a struct by their tag name and their lowercase name
+func structFields(structType reflect.Type) map[string][]int {
+	fields := map[string][]int{}
+	for _, field := range reflect.VisibleFields(structType) {
+		name, tagged := field.Tag.Lookup(FieldTag)
+		name, _, _ = strings.Cut(name, ",")
+		switch {
+		case !field.IsExported() || name == "-":
+			continue
+		case field.Anonymous && !tagged && indirect(field.Type).Kind() == reflect.Struct:
+			continue
+		case name != "":
+			fields[name] = field.Index
+		default:
+			if _, taken := fields[strings.ToLower(field.Name)]; !taken {
+				fields[strings.ToLower(field.Name)] = field.Index
+			}
+		}
+	}
+	return fields
+}
+
+func allocateEmbedded(target reflect.Value, index []int) {
+	for i := 1; i < len(index); i++ {
+		embedded := target.FieldByIndex(index[:i])
+		if embedded.Kind() == reflect.Pointer && embedded.IsNil() {
+			embedded.Set(reflect.New(embedded.Type().Elem()))
+		}
+	}
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
+
+// decodableEntries returns the entries of the values decoding into structs and maps
+func decodableEntries(value interface{}) (map[string]interface{}, bool) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return value, true
+	case neo4j.Node:
+		return value.Props, true
+	case neo4j.Relationship:
+		return value.Props, true
+	}
+	return nil, false
+}
+
+func isTemporal(value interface{}) bool {
+	switch value.(type) {
+	case time.Time, neo4j.Date, neo4j.LocalDateTime, neo4j.LocalTime, neo4j.Time:
+		return true
+	}
+	return false
+}
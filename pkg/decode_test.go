@@ -0,0 +1,106 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	suite.Run(t, new(DecodeTestSuite))
+}
+
+type DecodeTestSuite struct {
+	suite.Suite
+}
+
+type decodedAudit struct {
+	CreatedAt time.Time `neo4j:"created_at"`
+}
+
+type decodedUser struct {
+	decodedAudit
+	Name    string
+	Age     int      `neo4j:"age"`
+	Emails  []string `neo4j:"emails"`
+	Secret  string   `neo4j:"-"`
+	Manager *decodedUser
+}
+
+type decodedTeam struct {
+	Name    string                   `neo4j:"name"`
+	Members []decodedUser            `neo4j:"members"`
+	Scores  map[string]float64       `neo4j:"scores"`
+	Tags    map[string][]string      `neo4j:"tags"`
+	Raw     []map[string]interface{} `neo4j:"raw"`
+	TTL     time.Duration            `neo4j:"ttl"`
+	Leader  neo4j.Node               `neo4j:"leader"`
+}
+
+func (s *DecodeTestSuite) TestDecodesNestedListsAndMaps() {
+	created := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	alice := neo4j.Node{ElementId: "4:1", Labels: []string{"User"}, Props: map[string]any{"name": "alice", "age": int64(42), "emails": []any{"alice@example.com"}, "created_at": created}}
+	bob := neo4j.Node{ElementId: "4:2", Labels: []string{"User"}, Props: map[string]any{"name": "bob", "age": int64(37), "manager": map[string]any{"name": "alice"}, "secret": "hunter2"}}
+	record := &neo4j.Record{
+		Keys: []string{"name", "members", "scores", "tags", "raw", "ttl", "leader"},
+		Values: []any{
+			"core",
+			[]any{alice, bob},
+			map[string]any{"q1": int64(3), "q2": 4.5},
+			map[string]any{"alice": []any{"go", "cypher"}},
+			[]any{map[string]any{"id": int64(1)}},
+			neo4j.DurationOf(0, 0, 90, 0),
+			alice,
+		},
+	}
+	team := decodedTeam{}
+
+	s.Require().NoError(DecodeRecord(record, &team))
+
+	s.Equal(decodedTeam{
+		Name: "core",
+		Members: []decodedUser{
+			{decodedAudit: decodedAudit{CreatedAt: created}, Name: "alice", Age: 42, Emails: []string{"alice@example.com"}},
+			{Name: "bob", Age: 37, Manager: &decodedUser{Name: "alice"}},
+		},
+		Scores: map[string]float64{"q1": 3, "q2": 4.5},
+		Tags:   map[string][]string{"alice": {"go", "cypher"}},
+		Raw:    []map[string]interface{}{{"id": int64(1)}},
+		TTL:    90 * time.Second,
+		Leader: alice,
+	}, team)
+}
+
+func (s *DecodeTestSuite) TestReportsThePathOfMismatches() {
+	var users []decodedUser
+
+	err := DecodeValue([]any{map[string]any{"name": "alice"}, map[string]any{"age": "old"}}, &users)
+
+	decodeErr := &DecodeError{}
+	s.Require().ErrorAs(err, &decodeErr)
+	s.Equal("$[1].age", decodeErr.Path)
+	s.EqualError(err, "cannot decode string into int at $[1].age")
+	s.Error(DecodeValue(int64(300), new(int8)))
+	s.Error(DecodeValue([]any{"a"}, new(decodedUser)))
+}
+
+func (s *DecodeTestSuite) TestStrictFieldsRejectUnknownKeys() {
+	user := decodedUser{}
+
+	err := DecodeValue(map[string]any{"name": "alice", "nickname": "al"}, &user, WithStrictFields())
+
+	s.ErrorContains(err, `no field of driver_test.decodedUser matches "nickname"`)
+	s.NoError(DecodeValue(map[string]any{"name": "alice", "nickname": "al"}, &user))
+}
+
+func (s *DecodeTestSuite) TestCollectsTypedRecords() {
+	result := newFakeRecords([]string{"name", "age"}, []any{"alice", int64(42)}, []any{"bob", nil})
+
+	users, err := CollectAs[decodedUser](context.Background(), result)
+
+	s.Require().NoError(err)
+	s.Equal([]decodedUser{{Name: "alice", Age: 42}, {Name: "bob"}}, users)
+}
@@ -0,0 +1,53 @@
+package driver
+
+import (
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"time"
+)
+
+// QueryDiagnostics reports the low-level metadata of a query execution, to investigate latencies without packet captures
+type QueryDiagnostics struct {
+	// ServerAddress is the address of the cluster member that executed the query
+	ServerAddress string
+	// ServerAgent is the product and version of that server, e.g. Neo4j/5.5.0
+	ServerAgent string
+	// ProtocolVersion is the Bolt version negotiated with that server, e.g. 5.0
+	ProtocolVersion string
+	// Database is the database that executed the query
+	Database string
+	// ResultAvailableAfter is the server-side time until the first record was available (t_first), negative when not reported
+	ResultAvailableAfter time.Duration
+	// ResultConsumedAfter is the server-side time until the last record was consumed (t_last), negative when not reported
+	ResultConsumedAfter time.Duration
+	// Elapsed is the client-side duration of the execution, queueing and retries included
+	Elapsed time.Duration
+}
+
+// WithDiagnostics fills diagnostics once the query execution ends, whether it succeeded or not.
+// like WithResultSummary, whatever the hook left unconsumed in the result is discarded to retrieve the server metadata,
+// which is left empty when the query failed
+func WithDiagnostics(diagnostics *QueryDiagnostics) QueryOption {
+	return func(options *queryOptions) {
+		options.diagnosticsSink = diagnostics
+	}
+}
+
+func newQueryDiagnostics(summary neo4j.ResultSummary, elapsed time.Duration) QueryDiagnostics {
+	diagnostics := QueryDiagnostics{Elapsed: elapsed}
+	if summary == nil {
+		return diagnostics
+	}
+	diagnostics.ResultAvailableAfter = summary.ResultAvailableAfter()
+	diagnostics.ResultConsumedAfter = summary.ResultConsumedAfter()
+	if server := summary.Server(); server != nil {
+		version := server.ProtocolVersion()
+		diagnostics.ServerAddress = server.Address()
+		diagnostics.ServerAgent = server.Agent()
+		diagnostics.ProtocolVersion = fmt.Sprintf("%d.%d", version.Major, version.Minor)
+	}
+	if database := summary.Database(); database != nil {
+		diagnostics.Database = database.Name()
+	}
+	return diagnostics
+}
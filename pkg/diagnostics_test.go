@@ -0,0 +1,73 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/db"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestDiagnostics(t *testing.T) {
+	suite.Run(t, new(DiagnosticsTestSuite))
+}
+
+type DiagnosticsTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	restore func()
+}
+
+func (s *DiagnosticsTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{summary: &fakeSummary{
+		server:         fakeServerInfo{address: "core-2:7687", agent: "Neo4j/5.5.0", version: db.ProtocolVersion{Major: 5, Minor: 0}},
+		database:       "orders",
+		availableAfter: 3 * time.Millisecond,
+		consumedAfter:  12 * time.Millisecond,
+	}}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+}
+
+func (s *DiagnosticsTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *DiagnosticsTestSuite) TestReportsTheServerMetadataOfTheExecution() {
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	var diagnostics QueryDiagnostics
+	err = driver.ExecuteQuery(s.ctx, "RETURN true AS ok", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	}, WithDiagnostics(&diagnostics))
+
+	s.Require().NoError(err)
+	s.Equal("core-2:7687", diagnostics.ServerAddress)
+	s.Equal("Neo4j/5.5.0", diagnostics.ServerAgent)
+	s.Equal("5.0", diagnostics.ProtocolVersion)
+	s.Equal("orders", diagnostics.Database)
+	s.Equal(3*time.Millisecond, diagnostics.ResultAvailableAfter)
+	s.Equal(12*time.Millisecond, diagnostics.ResultConsumedAfter)
+	s.Positive(diagnostics.Elapsed)
+}
+
+func (s *DiagnosticsTestSuite) TestOnlyReportsTheElapsedTimeOfFailedExecutions() {
+	s.cluster.staleDrivers = 100
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	var diagnostics QueryDiagnostics
+	err = driver.ExecuteQuery(s.ctx, "RETURN true AS ok", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	}, WithDiagnostics(&diagnostics))
+
+	s.Require().Error(err)
+	s.Empty(diagnostics.ServerAddress)
+	s.Positive(diagnostics.Elapsed)
+}
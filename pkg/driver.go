@@ -7,19 +7,150 @@ import (
 	"runtime/debug"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-var accessLock sync.RWMutex
-var recoveryLock sync.Mutex
+// newDriverWithContext creates the underlying drivers, replaced in tests to simulate cluster behaviors
+var newDriverWithContext = neo4j.NewDriverWithContext
 
 type Driver struct {
-	driver                neo4j.DriverWithContext
-	dbURI, user, password string
+	current      atomic.Pointer[driverGeneration]
+	settings     Settings
+	dualWriter   *DualWriter
+	writeBehind  *WriteBehindQueue
+	readShadower *ReadShadower
+	quotas       *QuotaLimiter
+	lifecycle    *lifecycle
+	concurrency  map[neo4j.AccessMode]*Semaphore
+	asyncSlots   *Semaphore
+	sessionPool  *sessionPool
+	events       *eventLog
+	outage       outage
+	recovery     recoveryStats
+	usage        usageStats
+	compression  compressionSupport
+	identity     string
+	standby      standby
+	// accessLock is held in read mode by the executions and in write mode by Close, so that Close waits for them
+	accessLock sync.RWMutex
+	// recoveryLock serializes the replacements of the underlying driver
+	recoveryLock recoveryMutex
 }
 
 // Settings holds the driver settings
 type Settings struct {
+	// ConnectionString uses one of the neo4j, neo4j+s, neo4j+ssc, bolt, bolt+s and bolt+ssc schemes, see ParseConnectionInfo.
+	// it may embed driver options as query parameters, e.g. neo4j://host?connection_timeout=5s&policy=eu,
+	// and credentials as user info, used when User is empty.
+	// supported options: connection_timeout, connection_acquisition_timeout, max_connection_lifetime, max_transaction_retry_time,
+	// max_connection_pool_size, fetch_size, socket_keepalive and user_agent. other parameters form the routing context
 	ConnectionString, User, Password string
+	// RoutingContext is sent to the cluster when fetching routing tables, so that server policies can restrict the members
+	// used by this driver, e.g. {"region": "eu"} to pin reads to region-tagged members.
+	// it overrides the routing context parameters of ConnectionString, and requires a neo4j:// scheme
+	RoutingContext map[string]string
+	// Configurers customize the configuration of the underlying neo4j driver, e.g. pool limits, TLS, logging or address resolver.
+	// they are applied after the options of ConnectionString, to every underlying driver including the ones created on reconnects
+	Configurers []func(*neo4j.Config)
+	// QueryObserver, if set, is notified after every ExecuteQuery call
+	QueryObserver QueryObserverFn
+	// SlowQueryThreshold enables slow query detection: executions lasting longer, retries included, are logged
+	// and reported to OnSlowQuery. disabled when zero
+	SlowQueryThreshold time.Duration
+	OnSlowQuery        SlowQueryHandlerFn
+	// ParamsRedactor hides sensitive parameters whenever they are reported, defaults to RedactAllParams
+	ParamsRedactor ParamsRedactorFn
+	// Logger receives the driver log entries, defaults to the standard logger
+	Logger Logger
+	// Quotas, if set, limits the queries of every caller identity
+	Quotas *QuotaConfig
+	// MaxConcurrentQueries bounds the number of queries executing at once, weighted with WithQueryWeight.
+	// queries beyond it wait in FIFO order until their ctx is done. unbounded when zero
+	MaxConcurrentQueries int64
+	// MaxConcurrentReads and MaxConcurrentWrites give the queries of each access mode (see WithAccessMode) their own
+	// bound and queue instead of sharing MaxConcurrentQueries, so that slow writes cannot delay reads. shared when zero
+	MaxConcurrentReads, MaxConcurrentWrites int64
+	// MaxAsyncQueries bounds the number of queries ExecuteQueryAsync runs at once, defaults to 16
+	MaxAsyncQueries int64
+	// DebugBundle, if set, captures a debug bundle whenever a query fails after going through connection recovery
+	DebugBundle *DebugBundleConfig
+	// DeadLetterHandler, if set, receives the operations the write-behind queue and the dual writer gave up on,
+	// unless their config sets their own handler
+	DeadLetterHandler DeadLetterHandler
+	// QueryCache stores the results of the queries executed WithCache, e.g. a bounded MemoryCache
+	QueryCache CacheStore
+	// CachePersistence, if set and QueryCache is a PersistentCacheStore, persists the hot entries of QueryCache on Close and Shutdown, and reloads them in NewDriver
+	CachePersistence *CachePersistence
+	// InstanceID identifies this service instance in the user agent of the connections, along with the pod name of PodNameEnv,
+	// so that the server query logs attribute the traffic to it. defaults to an identifier generated per process
+	InstanceID string
+	// DisableIdentityStamp keeps the user agent of the connections as is, see InstanceID
+	DisableIdentityStamp bool
+	// Namespace prefixes the labels and relationship types of the queries built for this driver, see Driver.Namespace
+	Namespace Namespace
+	// ReconnectVerification, if set, vets the server reached after every reconnect before resuming traffic
+	ReconnectVerification *ReconnectVerification
+	// SafeMode, if set, blocks destructive statements unless they are explicitly overridden
+	SafeMode *SafeModeConfig
+	// Audit, if set, records every ExecuteQuery call to an audit sink, e.g. to comply with regulations
+	Audit *AuditConfig
+	// InjectionGuard, if set, rejects the queries that look built by string interpolation, see LintQuery
+	InjectionGuard *InjectionGuardConfig
+	// DriverVersion selects the major version of the underlying neo4j driver, see RegisterDriverBackend. defaults to DriverV5,
+	// the only version with a built-in backend
+	DriverVersion DriverVersion
+	// OnConnectionLost is called when a query finds the server unreachable, once per outage
+	OnConnectionLost ConnectionEventFn
+	// OnReconnect is called after every attempt to replace the underlying driver during an outage
+	OnReconnect ConnectionEventFn
+	// OnRecovered is called when a query succeeds after an outage, with its total downtime
+	OnRecovered ConnectionEventFn
+	// ParamCompression, if set, compresses the large string parameters, see ParamCompressionConfig
+	ParamCompression *ParamCompressionConfig
+	// MaxAttempts bounds the attempts of a query, retried after every reconnect or routing refresh, defaults to 5.
+	// the query fails with a *MaxRetriesError beyond it. see WithMaxAttempts and WithNoRetry to override it per query
+	MaxAttempts int
+	// MaxIdleSessions enables session reuse: up to MaxIdleSessions sessions per access mode are kept after successful queries
+	// for the next ones, instead of creating and closing a session per query. sessions are never reused after an error,
+	// and the idle ones are closed when the underlying driver is replaced. as reused sessions chain their bookmarks,
+	// queries sharing a session also wait for the writes of the previous ones. disabled when zero
+	MaxIdleSessions int
+	// WarmUp, if set, makes NewDriver establish connections before returning, and fail if the server cannot be reached
+	WarmUp *WarmUpConfig
+	// ContextTagger, if set, extracts tags from the context of every query, e.g. request and trace identifiers.
+	// they are attached to the transaction metadata, see WithTxMetadata, and to the log entries of the driver,
+	// correlating the traces of the application with Neo4j's query log
+	ContextTagger ContextTaggerFn
+	// NotificationHandler, if set, receives the notifications the server returns with the results, e.g. about deprecated syntax,
+	// missing indexes for hints or cartesian products, instead of them being discarded with the summary.
+	// whatever the hook left unconsumed in the result is discarded to retrieve them
+	NotificationHandler NotificationHandlerFn
+	// MinNotificationSeverity filters the notifications handed to NotificationHandler, see WithNotificationSeverity.
+	// defaults to NotificationInformation, i.e. all of them
+	MinNotificationSeverity NotificationSeverity
+	// ConsumeGuard consumes what the hook of every query left of its result, see WithConsumeGuard for a single query.
+	// the errors the server raises while streaming then fail the query instead of being lost, and leaving records unread
+	// is logged as a warning
+	ConsumeGuard bool
+	// AddressResolver, if set, resolves the address of the connection string of the neo4j schemes into the routers to try,
+	// e.g. the members of a cluster behind a single service name. see neo4j.Config.AddressResolver
+	AddressResolver neo4j.ServerAddressResolver
+	// ResolveOnReconnect makes every reconnect of the neo4j schemes resolve the routers anew through DNS, after AddressResolver,
+	// and connect to the resolved IP addresses only, e.g. when the IP of a Kubernetes service changes after the rescheduling
+	// of its pods. with the encrypted schemes, the certificates of the servers must then be valid for their IP addresses.
+	// the bolt schemes resolve their host on every connection and need no such option
+	ResolveOnReconnect bool
+	// Transport selects how the driver talks to the server, TransportBolt by default. TransportHTTP sends the queries
+	// to the HTTP Query API instead, for the environments where the Bolt ports are blocked
+	Transport Transport
+	// QueryAPIURL is the base URL of the Query API with TransportHTTP, e.g. https://neo4j.example.com:7473.
+	// defaults to the host of ConnectionString, with http on port 7474, or https on port 7473 for the encrypted schemes
+	QueryAPIURL string
+	// FetchSize is the number of records pulled per batch while the hooks stream the results, overriding the fetch_size
+	// option of ConnectionString, see WithFetchSize to override it per query. FetchSizeAll pulls the results at once.
+	// defaults to FetchSizeDefault
+	FetchSize int
 }
 
 func executeHook(onResults ResultsHookFn, result neo4j.ResultWithContext) (err error) {
@@ -35,61 +166,277 @@ func executeHook(onResults ResultsHookFn, result neo4j.ResultWithContext) (err e
 	return nil
 }
 
+// Validate checks the connection string, the routing context (see ParseConnectionInfo) and the driver version
+func (s Settings) Validate() error {
+	if _, err := ParseConnectionInfo(s.ConnectionString, s.RoutingContext); err != nil {
+		return err
+	}
+	if err := validateTransport(s.Transport); err != nil {
+		return err
+	}
+	_, err := driverBackend(s.DriverVersion)
+	return err
+}
+
 func NewDriver(settings Settings) (*Driver, error) {
-	driver, err := neo4j.NewDriverWithContext(settings.ConnectionString, neo4j.BasicAuth(settings.User, settings.Password, ""))
+	identity := ""
+	if !settings.DisableIdentityStamp {
+		identity = connectionIdentity(settings)
+	}
+	underlying, err := newUnderlyingConfig(settings, identity)
+	if err != nil {
+		return nil, err
+	}
+	driver, err := underlying.newDriver()
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &Driver{driver: driver, dbURI: settings.ConnectionString, user: settings.User, password: settings.Password}, nil
+	result := &Driver{settings: settings, lifecycle: newLifecycle(), identity: identity, recoveryLock: newRecoveryMutex()}
+	if settings.Quotas != nil {
+		result.quotas = NewQuotaLimiter(*settings.Quotas)
+	}
+	if instrumented && settings.DebugBundle != nil {
+		result.events = newEventLog(settings.DebugBundle.EventCapacity)
+	}
+	result.concurrency = newExecutorPartitions(settings)
+	result.asyncSlots = newAsyncSlots(settings)
+	result.sessionPool = newSessionPool(settings)
+	result.current.Store(&driverGeneration{driver: driver, config: underlying})
+	if settings.WarmUp != nil {
+		if err := result.warmUp(*settings.WarmUp); err != nil {
+			driver.Close(context.Background())
+			return nil, err
+		}
+	}
+	result.restoreCache()
+	return result, nil
 }
 
 // ResultsHookFn allows the caller to parse the query results safely
 type ResultsHookFn func(result neo4j.ResultWithContext) error
 
 // ExecuteQuery runs a query an ensured connected driver via Bolt. it it used with a hook of the original neo4j.Result object for a convenient usage
-func (d *Driver) ExecuteQuery(ctx context.Context, query string, params map[string]interface{}, onResults ResultsHookFn) (err error) {
-	accessLock.RLock()
-	defer accessLock.RUnlock()
-	return d.nonblockExecuteQuery(ctx, query, params, onResults)
-
+// params are converted with CoerceParams first.
+func (d *Driver) ExecuteQuery(ctx context.Context, query string, params map[string]interface{}, onResults ResultsHookFn, opts ...QueryOption) (err error) {
+	if err := d.lifecycle.enter(); err != nil {
+		return err
+	}
+	defer d.lifecycle.exit()
+	d.usage.inFlight.Add(1)
+	defer d.usage.inFlight.Add(-1)
+	if d.quotas != nil {
+		release, err := d.quotas.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	options := newQueryOptions(opts)
+	if err := d.screen(ctx, query, options); err != nil {
+		return err
+	}
+	params = CoerceParams(params)
+	release, err := d.acquireConcurrency(ctx, options)
+	if err != nil {
+		return err
+	}
+	defer release()
+	d.accessLock.RLock()
+	defer d.accessLock.RUnlock()
+	return d.executeAdmitted(ctx, query, params, onResults, options)
 }
 
-// nonblockExecuteQuery makes sure that a recursive retry to execute a query doesn't create a more mutexes and thus a deadlock
-// example is when a query executed, Rlock acquired, than Close function called, trying to aquire Lock, blocked, and then
-// the function calls itself again for retry, trying to acquire Rlock, but is blocked by Lock that is blocked by previous Rlock
-func (d *Driver) nonblockExecuteQuery(ctx context.Context, query string, params map[string]interface{}, onResults ResultsHookFn) (err error) {
+// screen applies the checks of the settings to a query before it is admitted, see Settings.SafeMode and Settings.InjectionGuard
+func (d *Driver) screen(ctx context.Context, query string, options *queryOptions) error {
+	d.tagQuery(ctx, options)
+	if err := d.checkSafeMode(query, options); err != nil {
+		return err
+	}
+	return d.checkInjection(query, options)
+}
 
-	session, err := d.NewSession(ctx)
+// executeAdmitted executes a screened query, once its quota and concurrency slots are acquired and under the access lock,
+// and reports its execution to the usage, the audit log, the observer, the dual writer and the read shadower
+func (d *Driver) executeAdmitted(ctx context.Context, query string, params map[string]interface{}, onResults ResultsHookFn, options *queryOptions) (err error) {
+	var shadowedRecords []*neo4j.Record
+	shadowed := d.readShadower != nil && d.readShadower.designated(options.name, query, options)
+	if shadowed {
+		onResults = d.readShadower.tee(ctx, onResults, &shadowedRecords)
+	}
+	// the outcome of the async commits is only known once they are confirmed in the background
+	options.afterCommit = func(ctx context.Context, err error) {
+		d.usage.record(options, err)
+		d.audit(ctx, AuditQuery, query, params, options, err)
+		if instrumented {
+			d.captureDebugBundle(query, params, options, err)
+		}
+		if err == nil && d.dualWriter != nil && d.dualWriter.designated(options.name, query, options) {
+			d.dualWriter.Mirror(options.name, query, params)
+		}
+		if err == nil && shadowed {
+			d.readShadower.Shadow(options.name, query, params, shadowedRecords)
+		}
+	}
+	defer func() {
+		options.report()
+		if instrumented {
+			d.notifyObserver(ctx, query, options, time.Since(options.start), err)
+		}
+		if !options.committing {
+			options.afterCommit(ctx, err)
+		}
+	}()
+	if d.settings.Audit != nil {
+		onResults = countRows(onResults, &options.rows)
+	}
+	hit, onResults, err := d.cachedResults(ctx, query, params, onResults, options)
+	if hit {
+		return err
+	}
+	sentQuery, sentParams, err := d.compressParams(ctx, query, params)
 	if err != nil {
 		return err
 	}
-	defer d.CloseSession(ctx, session)
+	return d.nonblockExecuteQuery(ctx, sentQuery, sentParams, onResults, options)
+}
+
+// nonblockExecuteQuery makes sure that retrying a query after a recovery doesn't create more mutexes and thus a deadlock
+// example is when a query executed, Rlock acquired, than Close function called, trying to aquire Lock, blocked, and then
+// the retry tries to acquire Rlock, but is blocked by Lock that is blocked by previous Rlock.
+// retries loop instead of recursing, so that flapping connectivity cannot grow the stack, and are bounded by Settings.MaxAttempts
+func (d *Driver) nonblockExecuteQuery(ctx context.Context, query string, params map[string]interface{}, onResults ResultsHookFn, options *queryOptions) error {
+	maxAttempts := d.settings.MaxAttempts
+	if options.maxAttempts > 0 {
+		maxAttempts = options.maxAttempts
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		options.lastAttempt = attempt >= maxAttempts
+		retry, err := d.attemptQuery(ctx, query, params, onResults, options)
+		if !retry {
+			return err
+		}
+		if attempt >= maxAttempts {
+			options.recoveryFailed = true
+			return &MaxRetriesError{Attempts: attempt, Err: err}
+		}
+		if err := sleepWithJitter(ctx, attempt); err != nil {
+			d.recovery.cancelled.Add(1)
+			return err
+		}
+	}
+}
+
+// attemptQuery runs the query once. it reports whether the query must be retried after recovering from err.
+// the last attempt leaves the recovery to the background instead of waiting for a recovery no attempt would use
+func (d *Driver) attemptQuery(ctx context.Context, query string, params map[string]interface{}, onResults ResultsHookFn, options *queryOptions) (retry bool, err error) {
+	current, done := d.acquireGeneration()
+	session := d.newSessionOn(ctx, current, options)
+	async, reusable := false, false
+	defer func() {
+		if !async {
+			d.releaseSession(ctx, current.driver, session, options, reusable)
+			done()
+		}
+	}()
 
-	result, err := session.Run(ctx, query, params)
+	options.stats.Attempts++
+	result, err := session.Run(ctx, query, params, options.txConfigurers()...)
 	if err != nil {
-		if err.Error() == "Trying to create session on closed driver" || strings.HasPrefix(err.Error(), "ConnectivityError") {
-			err = d.reconnect(ctx)
-			if err != nil {
-				return err
+		if err.Error() == sessionOnClosedDriver || strings.HasPrefix(err.Error(), "ConnectivityError") {
+			if d.lifecycle.isClosed() {
+				return false, ErrDriverClosed
 			}
-			return d.nonblockExecuteQuery(ctx, query, params, onResults)
+			d.lifecycle.transition(StateDegraded)
+			d.events.recordError("connectivity", err)
+			d.connectionLost(err)
+			if options.noRetry || options.lastAttempt {
+				d.recoverInBackground("reconnect", func(ctx context.Context) error {
+					return d.reconnect(ctx, current)
+				})
+				return !options.noRetry, err
+			}
+			if err := d.reconnect(ctx, current); err != nil {
+				options.recoveryFailed = true
+				d.events.recordError("reconnect", err)
+				return false, err
+			}
+			d.events.record("reconnect", "recovered connectivity for %q", displayName(options.name, query))
+			options.stats.Reconnects++
+			return true, err
 		}
-		return err
+		if isTopologyChange(err) && options.stats.RoutingRefreshes < maxRoutingRefreshes {
+			d.events.recordError("topology", err)
+			if options.noRetry || options.lastAttempt {
+				d.recoverInBackground("routing", func(ctx context.Context) error {
+					return d.refreshRouting(ctx, current)
+				})
+				return !options.noRetry, err
+			}
+			if err := d.refreshRouting(ctx, current); err != nil {
+				options.recoveryFailed = true
+				d.events.recordError("routing", err)
+				return false, err
+			}
+			d.events.record("routing", "refreshed routing for %q", displayName(options.name, query))
+			options.stats.RoutingRefreshes++
+			return true, err
+		}
+		d.events.recordError("query", err)
+		return false, err
+	}
+	d.lifecycle.transition(StateConnected)
+	d.connectionRecovered()
+	if async = d.completeAsync(ctx, session, result, onResults, options, done); async {
+		return false, nil
 	}
 	err = executeHook(onResults, result) //<-- reporting metrics inside
 	if err != nil {
-		return err
+		return false, err
 	}
-	return nil
+	if d.settings.ConsumeGuard || options.consumeGuard {
+		if err := d.guardConsumption(ctx, query, result, options); err != nil {
+			return false, err
+		}
+	}
+	if options.summarySink != nil || options.diagnosticsSink != nil {
+		options.resultSummary(ctx, result)
+	}
+	d.notify(ctx, query, result, options)
+	if instrumented {
+		d.detectSlowQuery(ctx, query, params, result, options)
+	}
+	if d.sessionPool != nil {
+		_, consumeErr := result.Consume(ctx)
+		reusable = consumeErr == nil
+	}
+	return false, nil
 }
 
 // NewSession returns a new *connected* session only after ensuring the underlying connection is alive.
 // it ensures liveliness by re-creating a new driver in case of connectivity issues.
 // it returns an error in case any connectivity issue could not be resolved even after re-creating the driver.
 func (d *Driver) NewSession(ctx context.Context) (neo4j.SessionWithContext, error) {
-	return d.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite}), nil
+	return d.currentGeneration().driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite}), nil
+}
+
+// ConnectionInfo returns the components of the connection string, e.g. to label logs and metrics
+func (d *Driver) ConnectionInfo() ConnectionInfo {
+	return d.currentGeneration().config.info
+}
+
+// VerifyConnectivity checks that the server is reachable with the current underlying driver, without reconnecting
+func (d *Driver) VerifyConnectivity(ctx context.Context) error {
+	if d.lifecycle.isClosed() {
+		return ErrDriverClosed
+	}
+	d.accessLock.RLock()
+	defer d.accessLock.RUnlock()
+	return d.currentGeneration().driver.VerifyConnectivity(ctx)
 }
 
 // CloseSession closes any open resources and marks this session as unusable.
@@ -98,36 +445,142 @@ func (d *Driver) CloseSession(ctx context.Context, session neo4j.SessionWithCont
 	session.Close(ctx)
 }
 
-// reconnect will create a new driver if current one is not connected
+// reconnect will create a new driver if failed, the generation of the driver that failed the query, is not connected
 // it uses double verification, as two queries might both get an error and try to reconnect, one will fix the connection
-// the other doesn't need to reconnect
-func (d *Driver) reconnect(ctx context.Context) error {
-	recoveryLock.Lock()
-	defer recoveryLock.Unlock()
-	if err := d.driver.VerifyConnectivity(ctx); err == nil {
+// the other doesn't need to reconnect: it finds a newer generation without even verifying it.
+// queries whose ctx is done while waiting for another reconnect give up with its error
+func (d *Driver) reconnect(ctx context.Context, failed *driverGeneration) error {
+	if err := d.lockRecovery(ctx); err != nil {
+		return err
+	}
+	defer d.recoveryLock.Unlock()
+	if d.lifecycle.isClosed() {
+		return ErrDriverClosed
+	}
+	if d.currentGeneration() != failed {
+		return nil
+	}
+	if err := failed.driver.VerifyConnectivity(ctx); err == nil {
 		return nil
+	}
+
+	err := d.replaceUnderlying(ctx)
+	d.reconnectAttempted(err)
+	return err
+}
 
+// underlyingConfig is everything the underlying drivers are created with, captured once by NewDriver
+// so that the drivers replacing them on reconnects are configured the same way
+type underlyingConfig struct {
+	connectionString string
+	info             ConnectionInfo
+	backend          DriverBackend
+	target           string
+	user             string
+	auth             neo4j.AuthToken
+	configurers      []func(*neo4j.Config)
+}
+
+// newUnderlyingConfig derives the configuration of the underlying drivers from settings, stamping identity on their user agent
+func newUnderlyingConfig(settings Settings, identity string) (underlyingConfig, error) {
+	info, err := ParseConnectionInfo(settings.ConnectionString, settings.RoutingContext)
+	if err != nil {
+		return underlyingConfig{}, err
+	}
+	target, configurers, credentials, err := parseConnectionString(settings.ConnectionString, settings.RoutingContext)
+	if err != nil {
+		return underlyingConfig{}, err
+	}
+	user, password := settings.User, settings.Password
+	if user == "" && credentials != nil {
+		user = credentials.Username()
+		password, _ = credentials.Password()
+	}
+	if settings.AddressResolver != nil {
+		configurers = append(configurers, withAddressResolver(settings.AddressResolver))
+	}
+	if settings.FetchSize != FetchSizeDefault {
+		configurers = append(configurers, withFetchSize(settings.FetchSize))
+	}
+	configurers = append(configurers, settings.Configurers...)
+	if identity != "" {
+		configurers = append(configurers, stampIdentity(identity))
+	}
+	backend, err := driverBackend(settings.DriverVersion)
+	if err != nil {
+		return underlyingConfig{}, err
+	}
+	if err := validateTransport(settings.Transport); err != nil {
+		return underlyingConfig{}, err
+	}
+	if settings.Transport == TransportHTTP {
+		base, err := queryAPIURL(settings.QueryAPIURL, info)
+		if err != nil {
+			return underlyingConfig{}, err
+		}
+		backend = queryAPIBackend(base, user, password, strings.HasSuffix(info.Scheme, "+ssc"))
 	}
+	return underlyingConfig{
+		connectionString: settings.ConnectionString,
+		info:             info,
+		backend:          backend,
+		target:           target,
+		user:             user,
+		auth:             neo4j.BasicAuth(user, password, ""),
+		configurers:      configurers,
+	}, nil
+}
+
+func (c underlyingConfig) newDriver(extra ...func(*neo4j.Config)) (neo4j.DriverWithContext, error) {
+	return c.backend(c.target, c.auth, append(c.configurers[:len(c.configurers):len(c.configurers)], extra...)...)
+}
 
-	driver, err := NewDriver(Settings{d.dbURI, d.user, d.password})
+// replaceUnderlying creates a new underlying driver with the same configuration and closes the previous one once
+// the queries moved to the new one, recoveryLock must be held.
+// the current one is kept when the new one fails Settings.ReconnectVerification
+func (d *Driver) replaceUnderlying(ctx context.Context) error {
+	config := d.currentGeneration().config
+	resolved, err := d.reresolve(ctx, config)
+	if err != nil {
+		return err
+	}
+	driver, err := config.newDriver(resolved...)
 	if err != nil {
 		return err
 	}
-	d.nonblockClose(ctx) //close old driver
-	d.driver = driver.driver
+	if err := d.verifyReconnect(ctx, driver); err != nil {
+		driver.Close(ctx)
+		return err
+	}
+	previous := d.swapDriver(driver, config)
+	d.closeGeneration(ctx, previous)
 	return nil
 }
 
 func (d *Driver) nonblockClose(ctx context.Context) {
-	if d.driver == nil {
+	d.closeGeneration(ctx, d.currentGeneration())
+}
+
+func (d *Driver) closeGeneration(ctx context.Context, generation *driverGeneration) {
+	if generation == nil || generation.driver == nil {
 		return
 	}
-	d.driver.Close(ctx)
+	if d.sessionPool != nil {
+		d.sessionPool.clear(ctx)
+	}
+	generation.driver.Close(ctx)
 }
 
-// Close safely closes the underlying open connections to the DB.
+// Close safely closes the underlying open connections to the DB, once the async commits in flight are confirmed
+// or ctx is done. queries executed afterwards fail fast with ErrDriverClosed instead of reconnecting.
 func (d *Driver) Close(ctx context.Context) {
-	accessLock.Lock()
-	defer accessLock.Unlock()
+	d.lifecycle.close()
+	if err := d.lifecycle.awaitCommits(ctx); err != nil {
+		d.logger().Printf("[neo4j] closing with async commits in flight: %v", err)
+	}
+	d.accessLock.Lock()
+	defer d.accessLock.Unlock()
 	d.nonblockClose(ctx)
+	d.closeStandby(ctx)
+	d.persistCache()
 }
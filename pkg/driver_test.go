@@ -1,37 +1,60 @@
 package driver_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg/drivertest"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg/neotest"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/stretchr/testify/suite"
+	"os"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestIssue451(t *testing.T) {
 	suite.Run(t, new(DriverTestSuite))
 }
 
+// connectionSettings are used by the tests that do not need a server as is.
+// SetupSuite points them to the server of NEO4J_URI, NEO4J_USER and NEO4J_PASSWORD when set, or to a server started in Docker otherwise
 var connectionSettings = Settings{
 	ConnectionString: "neo4j://localhost",
 	User:             "neo4j",
-	Password:         "letmein!",
+	Password:         neotest.DefaultPassword,
 }
 
 type DriverTestSuite struct {
 	suite.Suite
 	ctx    context.Context
 	driver *Driver
+	server *neotest.Server
 }
 
 func (s *DriverTestSuite) SetupSuite() {
 	s.ctx = context.Background()
+	if uri := os.Getenv("NEO4J_URI"); uri != "" {
+		connectionSettings = Settings{ConnectionString: uri, User: os.Getenv("NEO4J_USER"), Password: os.Getenv("NEO4J_PASSWORD")}
+	} else {
+		server, err := neotest.Start(s.ctx, neotest.Config{})
+		s.Require().NoError(err)
+		s.server = server
+		connectionSettings = server.Settings
+	}
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	s.Require().NoError(drivertest.WaitForNeo4j(s.ctx, driver, 30*time.Second))
 }
 
 func (s *DriverTestSuite) TearDownSuite() {
-	s.ctx.Done()
+	if s.server != nil {
+		s.NoError(s.server.Terminate(s.ctx))
+	}
 }
 
 func (s *DriverTestSuite) connectToNeo() {
@@ -45,8 +68,12 @@ func (s *DriverTestSuite) SetupTest() {
 	s.connectToNeo()
 }
 
-func (s *DriverTestSuite) TestMultithreadedQueryRequestsWithConnectionRecovery() {
+func (s *DriverTestSuite) TearDownTest() {
 	s.driver.Close(s.ctx)
+}
+
+func (s *DriverTestSuite) TestMultithreadedQueryRequestsWithConnectionRecovery() {
+	s.driver.CloseUnderlying(s.ctx)
 	count := 1000
 	wg := &sync.WaitGroup{}
 	wg.Add(count)
@@ -55,7 +82,7 @@ func (s *DriverTestSuite) TestMultithreadedQueryRequestsWithConnectionRecovery()
 
 		go func(wg *sync.WaitGroup, i int, s *DriverTestSuite) {
 			defer wg.Done()
-			s.driver.Close(s.ctx)
+			s.driver.CloseUnderlying(s.ctx)
 			err := s.executeSimpleQuery()
 			s.Require().NoError(err)
 
@@ -65,7 +92,221 @@ func (s *DriverTestSuite) TestMultithreadedQueryRequestsWithConnectionRecovery()
 
 }
 
-func executeSimpleQuery(ctx context.Context, driver *Driver) error {
+func (s *DriverTestSuite) TestQuerySucceedsOnFirstAttempt() {
+	stats := QueryStats{}
+
+	err := executeSimpleQuery(s.ctx, s.driver, WithQueryStats(&stats))
+
+	s.Require().NoError(err)
+	s.Equal(QueryStats{Attempts: 1, Reconnects: 0}, stats)
+}
+
+func (s *DriverTestSuite) TestQueryRequiresExactlyOneReconnectAfterConnectionLoss() {
+	stats := QueryStats{}
+	s.driver.CloseUnderlying(s.ctx)
+
+	err := executeSimpleQuery(s.ctx, s.driver, WithQueryStats(&stats))
+
+	s.Require().NoError(err)
+	s.Equal(QueryStats{Attempts: 2, Reconnects: 1}, stats)
+	s.Equal(StateConnected, s.driver.State())
+}
+
+func (s *DriverTestSuite) TestObserverGroupsQueriesByName() {
+	var events []QueryEvent
+	settings := connectionSettings
+	settings.QueryObserver = func(_ context.Context, event QueryEvent) {
+		events = append(events, event)
+	}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	err = executeSimpleQuery(s.ctx, driver, WithQueryName("create-test"), WithQueryLabels(map[string]string{"team": "core"}))
+
+	s.Require().NoError(err)
+	s.Require().Len(events, 1)
+	s.Equal("create-test", events[0].DisplayName())
+	s.Equal(map[string]string{"team": "core"}, events[0].Labels)
+	s.Equal(1, events[0].Stats.Attempts)
+}
+
+func (s *DriverTestSuite) TestSlowQueriesAreReported() {
+	var slowQueries []SlowQuery
+	settings := connectionSettings
+	settings.SlowQueryThreshold = time.Nanosecond
+	settings.OnSlowQuery = func(_ context.Context, slowQuery SlowQuery) {
+		slowQueries = append(slowQueries, slowQuery)
+	}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	err = driver.ExecuteQuery(s.ctx, "RETURN $value", map[string]interface{}{"value": "secret"}, func(result neo4j.ResultWithContext) error {
+		return nil
+	}, WithQueryName("return-value"))
+
+	s.Require().NoError(err)
+	s.Require().Len(slowQueries, 1)
+	s.Equal("return-value", slowQueries[0].Name)
+	s.Equal(map[string]interface{}{"value": RedactedValue}, slowQueries[0].Params)
+	s.Equal(0, slowQueries[0].Retries)
+	s.NotNil(slowQueries[0].Summary)
+}
+
+func (s *DriverTestSuite) TestReadsReportTheServingMember() {
+	var summary neo4j.ResultSummary
+
+	err := s.driver.ExecuteQuery(s.ctx, "RETURN 1", nil, func(result neo4j.ResultWithContext) error {
+		return nil
+	}, WithAccessMode(neo4j.AccessModeRead), WithResultSummary(&summary))
+
+	s.Require().NoError(err)
+	s.NotEmpty(ServedBy(summary))
+}
+
+func (s *DriverTestSuite) TestTransactionMetadataReachesTheServer() {
+	var metadata interface{}
+
+	err := s.driver.ExecuteQuery(s.ctx, "CALL tx.getMetaData() YIELD metadata RETURN metadata", nil, func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(s.ctx)
+		if err != nil {
+			return err
+		}
+		metadata = record.Values[0]
+		return nil
+	}, WithTxMetadata(map[string]interface{}{"app": "issue-451"}), WithTxMetadata(map[string]interface{}{"requestId": "42"}))
+
+	s.Require().NoError(err)
+	s.Equal(map[string]interface{}{"app": "issue-451", "requestId": "42"}, metadata)
+}
+
+func (s *DriverTestSuite) TestCompareAndSetAppliesOnlyOnce() {
+	match := NodeMatch{Label: "Test", Props: map[string]interface{}{"cas": "issue-451"}}
+	err := s.driver.ExecuteQuery(s.ctx, "MERGE (n:Test {cas: 'issue-451'}) SET n.status = 'pending'", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	})
+	s.Require().NoError(err)
+
+	first, err := s.driver.CompareAndSet(s.ctx, match, map[string]interface{}{"status": "pending"}, map[string]interface{}{"status": "done"})
+	s.Require().NoError(err)
+	second, err := s.driver.CompareAndSet(s.ctx, match, map[string]interface{}{"status": "pending"}, map[string]interface{}{"status": "done"})
+	s.Require().NoError(err)
+
+	s.True(first)
+	s.False(second)
+}
+
+func (s *DriverTestSuite) TestDebugBundleIsCapturedWhenQueryFailsAfterRecovery() {
+	bundles := &bytes.Buffer{}
+	settings := connectionSettings
+	settings.DebugBundle = &DebugBundleConfig{Writer: bundles}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	driver.CloseUnderlying(s.ctx)
+
+	err = driver.ExecuteQuery(s.ctx, "RETURN $secret +", map[string]interface{}{"secret": "hunter2"}, func(neo4j.ResultWithContext) error {
+		return nil
+	})
+
+	s.Require().Error(err)
+	bundle := DebugBundle{}
+	s.Require().NoError(json.NewDecoder(bundles).Decode(&bundle))
+	s.Equal("RETURN $secret +", bundle.Query)
+	s.Equal(map[string]interface{}{"secret": RedactedValue}, bundle.Params)
+	s.Equal(1, bundle.Stats.Reconnects)
+	s.NotEmpty(bundle.RecentEvents)
+	s.NotEmpty(bundle.LastErrors)
+}
+
+func (s *DriverTestSuite) TestScriptTransactionIsRolledBackOnFailure() {
+	script := "MATCH (n:ScriptTest) DETACH DELETE n;\nCREATE (:ScriptTest {step: 1});\nCREATE (:ScriptTest {step: });"
+
+	err := s.driver.ExecuteScript(s.ctx, script, WithScriptTransaction())
+
+	scriptErr := &ScriptError{}
+	s.Require().ErrorAs(err, &scriptErr)
+	s.Equal(2, scriptErr.Index)
+	var count int64
+	err = s.driver.ExecuteQuery(s.ctx, "MATCH (n:ScriptTest) RETURN count(n)", nil, func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(s.ctx)
+		if err == nil {
+			count = record.Values[0].(int64)
+		}
+		return err
+	})
+	s.Require().NoError(err)
+	s.Zero(count)
+}
+
+func (s *DriverTestSuite) TestAsyncCommitsAreConfirmedThroughTheCallback() {
+	commits := make(chan error, 2)
+	onCommit := func(err error) {
+		commits <- err
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+
+	s.Require().NoError(executeSimpleQuery(ctx, s.driver, WithAsyncCommit(onCommit)))
+	cancel()
+	s.Require().NoError(<-commits)
+
+	err := s.driver.ExecuteQuery(s.ctx, "UNWIND [1, 0] AS i CREATE (:Test {ratio: 1 / i})", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	}, WithAsyncCommit(onCommit))
+	s.Require().NoError(err)
+	s.Error(<-commits)
+}
+
+func (s *DriverTestSuite) TestWarmUpEstablishesConnectionsBeforeReturning() {
+	settings := connectionSettings
+	settings.WarmUp = &WarmUpConfig{Connections: 4, Retries: 2}
+
+	driver, err := NewDriver(settings)
+
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	s.NoError(executeSimpleQuery(s.ctx, driver))
+}
+
+func (s *DriverTestSuite) TestSafeModeOverrideUnlocksDestructiveStatements() {
+	settings := connectionSettings
+	settings.SafeMode = &SafeModeConfig{OverrideToken: "cleanup"}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	err = driver.ExecuteQuery(s.ctx, "MATCH (n:SafeModeTest) DETACH DELETE n", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	}, WithSafeModeOverride("cleanup"))
+
+	s.NoError(err)
+}
+
+func (s *DriverTestSuite) TestPagesThroughResultsBySkipAndKeyset() {
+	query := "UNWIND range(1, 5) AS i RETURN i % 2 AS parity, i"
+	for _, mode := range []PageMode{PageBySkip, PageByKeyset} {
+		var pages [][]int64
+		page := PageRequest{Size: 2, OrderBy: []string{"parity", "i"}, Mode: mode}
+		for {
+			result, err := s.driver.ExecutePaged(s.ctx, query, nil, page)
+			s.Require().NoError(err)
+			values := make([]int64, len(result.Records))
+			for i, record := range result.Records {
+				value, _ := record.Get("i")
+				values[i] = value.(int64)
+			}
+			pages = append(pages, values)
+			if result.NextCursor == "" {
+				break
+			}
+			page.Cursor = result.NextCursor
+		}
+		s.Equal([][]int64{{2, 4}, {1, 3}, {5}}, pages)
+	}
+}
+
+func executeSimpleQuery(ctx context.Context, driver *Driver, opts ...QueryOption) error {
 	return driver.ExecuteQuery(ctx, "CREATE (test:Test) return true", map[string]interface{}{}, func(result neo4j.ResultWithContext) error {
 		var record *neo4j.Record
 		result.NextRecord(ctx, &record)
@@ -77,7 +318,7 @@ func executeSimpleQuery(ctx context.Context, driver *Driver) error {
 			return errors.New("expected value to be bool")
 		}
 		return nil
-	})
+	}, opts...)
 }
 
 func (s *DriverTestSuite) executeSimpleQuery() error {
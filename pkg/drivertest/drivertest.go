@@ -0,0 +1,89 @@
+// Package drivertest provides the plumbing of integration test suites running against a Neo4j server:
+// waiting for the server, resetting the database and loading Cypher fixtures.
+package drivertest
+
+import (
+	"context"
+	"fmt"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"io/fs"
+	"time"
+)
+
+// DefaultResetBatchSize is the number of nodes ResetDatabase deletes per query
+const DefaultResetBatchSize = 10000
+
+// pollInterval is the delay between two connectivity checks of WaitForNeo4j
+const pollInterval = 250 * time.Millisecond
+
+// Connectivity is implemented by *driver.Driver
+type Connectivity interface {
+	VerifyConnectivity(ctx context.Context) error
+}
+
+// WaitForNeo4j blocks until the server accepts connections, typically in SetupSuite while a container starts.
+// it returns the last connectivity error when the server is still unreachable after timeout.
+func WaitForNeo4j(ctx context.Context, driver Connectivity, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		err := driver.VerifyConnectivity(ctx)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("neo4j is still unreachable after %s: %w", timeout, err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// ResetDatabase deletes all the nodes and relationships, batchSize nodes at a time so that large databases
+// do not exceed the transaction memory. batchSize defaults to DefaultResetBatchSize when zero.
+// indexes and constraints are left untouched.
+func ResetDatabase(ctx context.Context, runner driver.QueryRunner, batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = DefaultResetBatchSize
+	}
+	for {
+		var deleted int64
+		err := runner.ExecuteQuery(ctx, "MATCH (n) WITH n LIMIT $batch DETACH DELETE n RETURN count(*) AS deleted",
+			map[string]interface{}{"batch": batchSize}, func(result neo4j.ResultWithContext) error {
+				record, err := result.Single(ctx)
+				if err != nil {
+					return err
+				}
+				deleted, _ = record.Values[0].(int64)
+				return nil
+			}, driver.WithQueryName("drivertest.reset"))
+		if err != nil {
+			return err
+		}
+		if deleted < int64(batchSize) {
+			return nil
+		}
+	}
+}
+
+// LoadFixture executes the Cypher script at path in fsys statement by statement, see driver.SplitScript.
+// it returns a *driver.ScriptError identifying the statement that failed.
+func LoadFixture(ctx context.Context, runner driver.QueryRunner, fsys fs.FS, path string) error {
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return err
+	}
+	for i, statement := range driver.SplitScript(string(content)) {
+		err := runner.ExecuteQuery(ctx, statement, nil, func(result neo4j.ResultWithContext) error {
+			_, err := result.Consume(ctx)
+			return err
+		}, driver.WithQueryName("drivertest.fixture"))
+		if err != nil {
+			return fmt.Errorf("loading fixture %s: %w", path, &driver.ScriptError{Index: i, Statement: statement, Err: err})
+		}
+	}
+	return nil
+}
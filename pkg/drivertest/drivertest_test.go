@@ -0,0 +1,117 @@
+package drivertest_test
+
+import (
+	"context"
+	"errors"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg/drivertest"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestDriverTest(t *testing.T) {
+	suite.Run(t, new(DriverTestTestSuite))
+}
+
+type DriverTestTestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *DriverTestTestSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+func (s *DriverTestTestSuite) TestWaitsUntilTheServerIsReachable() {
+	server := &fakeServer{unreachableChecks: 2}
+
+	s.NoError(WaitForNeo4j(s.ctx, server, time.Second))
+	s.Equal(3, server.checks)
+}
+
+func (s *DriverTestTestSuite) TestGivesUpAfterTimeout() {
+	server := &fakeServer{unreachableChecks: 1000}
+
+	err := WaitForNeo4j(s.ctx, server, 100*time.Millisecond)
+
+	s.ErrorContains(err, "neo4j is still unreachable after 100ms")
+	s.ErrorIs(err, errUnreachable)
+}
+
+func (s *DriverTestTestSuite) TestResetsInBatches() {
+	server := &fakeServer{nodes: 25}
+
+	s.NoError(ResetDatabase(s.ctx, server, 10))
+
+	s.Zero(server.nodes)
+	s.Len(server.queries, 3)
+}
+
+func (s *DriverTestTestSuite) TestLoadsFixturesStatementByStatement() {
+	server := &fakeServer{failing: "CREAT (broken)"}
+	fixtures := fstest.MapFS{
+		"users.cypher":  {Data: []byte("CREATE (:User {name: 'alice'});\n// bob\nCREATE (:User {name: 'bob'});\n")},
+		"broken.cypher": {Data: []byte("CREATE (:User);\nCREAT (broken);\n")},
+	}
+
+	s.NoError(LoadFixture(s.ctx, server, fixtures, "users.cypher"))
+	s.Equal([]string{"CREATE (:User {name: 'alice'})", "CREATE (:User {name: 'bob'})"}, server.queries)
+
+	err := LoadFixture(s.ctx, server, fixtures, "broken.cypher")
+	scriptErr := &driver.ScriptError{}
+	s.Require().ErrorAs(err, &scriptErr)
+	s.Equal(1, scriptErr.Index)
+	s.ErrorContains(err, "loading fixture broken.cypher")
+}
+
+var errUnreachable = errors.New("connection refused")
+
+// fakeServer emulates connectivity checks, batched deletions and statements execution
+type fakeServer struct {
+	unreachableChecks int
+	checks            int
+	nodes             int64
+	failing           string
+	queries           []string
+}
+
+func (f *fakeServer) VerifyConnectivity(context.Context) error {
+	f.checks++
+	if f.checks <= f.unreachableChecks {
+		return errUnreachable
+	}
+	return nil
+}
+
+func (f *fakeServer) ExecuteQuery(_ context.Context, query string, params map[string]interface{}, onResults driver.ResultsHookFn, _ ...driver.QueryOption) error {
+	f.queries = append(f.queries, query)
+	if query == f.failing {
+		return errors.New("syntax error")
+	}
+	deleted := int64(0)
+	if batch, ok := params["batch"].(int); ok {
+		deleted = f.nodes
+		if deleted > int64(batch) {
+			deleted = int64(batch)
+		}
+		f.nodes -= deleted
+	}
+	return onResults(&countResult{count: deleted})
+}
+
+// countResult returns a single record holding count, the embedded interface is left nil
+type countResult struct {
+	neo4j.ResultWithContext
+	count int64
+}
+
+func (r *countResult) Single(context.Context) (*neo4j.Record, error) {
+	return &neo4j.Record{Keys: []string{"count"}, Values: []any{r.count}}, nil
+}
+
+func (r *countResult) Consume(context.Context) (neo4j.ResultSummary, error) {
+	return nil, nil
+}
@@ -0,0 +1,209 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"sync"
+	"time"
+)
+
+// QueryRunner runs a query and hands its results to a hook. *Driver implements it.
+type QueryRunner interface {
+	ExecuteQuery(ctx context.Context, query string, params map[string]interface{}, onResults ResultsHookFn, opts ...QueryOption) error
+}
+
+// ErrMirrorQueueFull is reported as a divergence when a write could not be queued for mirroring
+var ErrMirrorQueueFull = errors.New("dual write mirror queue is full")
+
+const defaultMirrorQueueSize = 1024
+const maxRecordedDivergences = 100
+
+// DualWriteConfig configures the mirroring of writes to a secondary cluster, e.g. during a migration to Aura
+type DualWriteConfig struct {
+	// Secondary receives the mirrored writes, typically another *Driver
+	Secondary QueryRunner
+	// Designated decides whether a successful query is mirrored.
+	// name is the logical name set with WithQueryName. when nil, only queries executed with WithDualWrite are mirrored.
+	Designated func(name, query string) bool
+	// QueueSize bounds the number of writes waiting to be mirrored, writes are dropped beyond it. defaults to 1024
+	QueueSize int
+	// Timeout bounds every mirrored execution, no timeout when zero
+	Timeout time.Duration
+	// OnDivergence, if set, is called for every write that could not be mirrored
+	OnDivergence func(Divergence)
+	// DeadLetters, if set, receives every write that could not be mirrored, to replay them on the secondary.
+	// EnableDualWrite defaults it to Settings.DeadLetterHandler
+	DeadLetters DeadLetterHandler
+}
+
+// Divergence describes a write applied on the primary but not on the secondary
+type Divergence struct {
+	Name   string
+	Query  string
+	Params map[string]interface{}
+	Err    error
+	At     time.Time
+}
+
+// DualWriteReport summarizes the mirroring activity
+type DualWriteReport struct {
+	Mirrored, Failed, Dropped int
+	// LastLag and MaxLag measure the time between the primary write completion and the secondary one
+	LastLag, MaxLag time.Duration
+	// Divergences holds the most recent divergences, oldest first
+	Divergences []Divergence
+}
+
+// DualWriter mirrors designated writes to a secondary QueryRunner, best effort and asynchronously
+type DualWriter struct {
+	config DualWriteConfig
+	queue  chan mirroredWrite
+	done   chan struct{}
+	mutex  sync.Mutex
+	report DualWriteReport
+	closed bool
+}
+
+type mirroredWrite struct {
+	name     string
+	query    string
+	params   map[string]interface{}
+	queuedAt time.Time
+}
+
+// WithDualWrite designates the query for mirroring when dual write is enabled without a Designated predicate
+func WithDualWrite() QueryOption {
+	return func(options *queryOptions) {
+		options.dualWrite = true
+	}
+}
+
+// NewDualWriter starts a DualWriter and its background mirroring goroutine
+func NewDualWriter(config DualWriteConfig) *DualWriter {
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultMirrorQueueSize
+	}
+	writer := &DualWriter{
+		config: config,
+		queue:  make(chan mirroredWrite, config.QueueSize),
+		done:   make(chan struct{}),
+	}
+	go writer.run()
+	return writer
+}
+
+// EnableDualWrite mirrors the designated writes of this driver to config.Secondary until the returned DualWriter is stopped
+func (d *Driver) EnableDualWrite(config DualWriteConfig) *DualWriter {
+	if config.DeadLetters == nil {
+		config.DeadLetters = d.settings.DeadLetterHandler
+	}
+	writer := NewDualWriter(config)
+	d.accessLock.Lock()
+	defer d.accessLock.Unlock()
+	d.dualWriter = writer
+	return writer
+}
+
+// Mirror queues a write that succeeded on the primary, it never blocks
+func (w *DualWriter) Mirror(name, query string, params map[string]interface{}) {
+	w.mutex.Lock()
+	if w.closed {
+		w.mutex.Unlock()
+		return
+	}
+	select {
+	case w.queue <- mirroredWrite{name: name, query: query, params: params, queuedAt: time.Now()}:
+		w.mutex.Unlock()
+	default:
+		w.report.Dropped++
+		divergence := w.recordDivergence(Divergence{Name: name, Query: query, Params: params, Err: ErrMirrorQueueFull, At: time.Now()})
+		w.mutex.Unlock()
+		w.notifyDivergence(divergence, 0)
+	}
+}
+
+// Report returns a snapshot of the mirroring activity
+func (w *DualWriter) Report() DualWriteReport {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	report := w.report
+	report.Divergences = append([]Divergence(nil), w.report.Divergences...)
+	return report
+}
+
+// Stop stops accepting writes and waits for the queued ones to be mirrored, or for ctx to be done
+func (w *DualWriter) Stop(ctx context.Context) error {
+	w.mutex.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mutex.Unlock()
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *DualWriter) designated(name, query string, options *queryOptions) bool {
+	if w.config.Designated == nil {
+		return options.dualWrite
+	}
+	return options.dualWrite || w.config.Designated(name, query)
+}
+
+func (w *DualWriter) run() {
+	defer close(w.done)
+	for write := range w.queue {
+		err := w.execute(write)
+		w.mutex.Lock()
+		if err != nil {
+			w.report.Failed++
+			divergence := w.recordDivergence(Divergence{Name: write.name, Query: write.query, Params: write.params, Err: err, At: time.Now()})
+			w.mutex.Unlock()
+			w.notifyDivergence(divergence, 1)
+			continue
+		}
+		w.report.Mirrored++
+		w.report.LastLag = time.Since(write.queuedAt)
+		if w.report.LastLag > w.report.MaxLag {
+			w.report.MaxLag = w.report.LastLag
+		}
+		w.mutex.Unlock()
+	}
+}
+
+func (w *DualWriter) execute(write mirroredWrite) error {
+	ctx := context.Background()
+	if w.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.config.Timeout)
+		defer cancel()
+	}
+	return w.config.Secondary.ExecuteQuery(ctx, write.query, write.params, func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(ctx)
+		return err
+	}, WithQueryName(write.name))
+}
+
+// recordDivergence must be called with the mutex held
+func (w *DualWriter) recordDivergence(divergence Divergence) Divergence {
+	w.report.Divergences = append(w.report.Divergences, divergence)
+	if len(w.report.Divergences) > maxRecordedDivergences {
+		w.report.Divergences = w.report.Divergences[1:]
+	}
+	return divergence
+}
+
+// notifyDivergence must be called without the mutex held, so that the callbacks can query the report
+func (w *DualWriter) notifyDivergence(divergence Divergence, attempts int) {
+	if w.config.OnDivergence != nil {
+		w.config.OnDivergence(divergence)
+	}
+	if w.config.DeadLetters != nil {
+		w.config.DeadLetters(context.Background(), DeadLetter{Query: divergence.Query, Params: divergence.Params, Err: divergence.Err, Attempts: attempts, At: divergence.At})
+	}
+}
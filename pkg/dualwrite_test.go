@@ -0,0 +1,82 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestDualWrite(t *testing.T) {
+	suite.Run(t, new(DualWriteTestSuite))
+}
+
+type DualWriteTestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *DualWriteTestSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+func (s *DualWriteTestSuite) TestMirrorsWritesToSecondary() {
+	secondary := &fakeRunner{}
+	writer := NewDualWriter(DualWriteConfig{Secondary: secondary})
+
+	writer.Mirror("create-user", "CREATE (:User)", nil)
+	writer.Mirror("create-team", "CREATE (:Team)", nil)
+	s.Require().NoError(writer.Stop(s.ctx))
+
+	s.Equal([]string{"CREATE (:User)", "CREATE (:Team)"}, secondary.executed())
+	report := writer.Report()
+	s.Equal(2, report.Mirrored)
+	s.Empty(report.Divergences)
+	s.GreaterOrEqual(report.MaxLag, report.LastLag)
+}
+
+func (s *DualWriteTestSuite) TestReportsSecondaryFailuresAsDivergences() {
+	secondary := &fakeRunner{err: errors.New("secondary unavailable")}
+	var notified []Divergence
+	writer := NewDualWriter(DualWriteConfig{Secondary: secondary, OnDivergence: func(divergence Divergence) {
+		notified = append(notified, divergence)
+	}})
+
+	writer.Mirror("create-user", "CREATE (:User)", map[string]interface{}{"id": 1})
+	s.Require().NoError(writer.Stop(s.ctx))
+
+	report := writer.Report()
+	s.Equal(1, report.Failed)
+	s.Require().Len(report.Divergences, 1)
+	s.Equal("create-user", report.Divergences[0].Name)
+	s.EqualError(report.Divergences[0].Err, "secondary unavailable")
+	s.Equal(report.Divergences, notified)
+}
+
+func (s *DualWriteTestSuite) TestHandsFailedMirrorsToTheDeadLetterHandler() {
+	secondary := &fakeRunner{err: errors.New("secondary unavailable")}
+	var letters []DeadLetter
+	writer := NewDualWriter(DualWriteConfig{Secondary: secondary, DeadLetters: func(_ context.Context, letter DeadLetter) {
+		letters = append(letters, letter)
+	}})
+
+	writer.Mirror("create-user", "CREATE (:User {id: $id})", map[string]interface{}{"id": 1})
+	s.Require().NoError(writer.Stop(s.ctx))
+
+	s.Require().Len(letters, 1)
+	s.Equal("CREATE (:User {id: $id})", letters[0].Query)
+	s.Equal(map[string]interface{}{"id": 1}, letters[0].Params)
+	s.Equal(1, letters[0].Attempts)
+	s.EqualError(letters[0].Err, "secondary unavailable")
+}
+
+func (s *DualWriteTestSuite) TestIgnoresWritesAfterStop() {
+	secondary := &fakeRunner{}
+	writer := NewDualWriter(DualWriteConfig{Secondary: secondary})
+	s.Require().NoError(writer.Stop(s.ctx))
+
+	writer.Mirror("create-user", "CREATE (:User)", nil)
+
+	s.Empty(secondary.executed())
+}
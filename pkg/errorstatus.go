@@ -0,0 +1,153 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"net/http"
+	"strings"
+)
+
+// ErrorCategory is a transport-agnostic classification of errors, to be translated into API responses
+type ErrorCategory string
+
+const (
+	CategoryOK              ErrorCategory = "OK"
+	CategoryNotFound        ErrorCategory = "NotFound"
+	CategoryConflict        ErrorCategory = "Conflict"
+	CategoryUnavailable     ErrorCategory = "Unavailable"
+	CategoryInvalidArgument ErrorCategory = "InvalidArgument"
+	CategoryInternal        ErrorCategory = "Internal"
+)
+
+// gRPC status codes, as defined by google.golang.org/grpc/codes
+const (
+	grpcOK              = 0
+	grpcInvalidArgument = 3
+	grpcNotFound        = 5
+	grpcAborted         = 10
+	grpcInternal        = 13
+	grpcUnavailable     = 14
+)
+
+// ErrorStatus is the classification of an error along with the message safe to return to clients
+type ErrorStatus struct {
+	Category ErrorCategory
+	Message  string
+}
+
+// HTTPStatus returns the HTTP status code of the category
+func (s ErrorStatus) HTTPStatus() int {
+	switch s.Category {
+	case CategoryOK:
+		return http.StatusOK
+	case CategoryNotFound:
+		return http.StatusNotFound
+	case CategoryConflict:
+		return http.StatusConflict
+	case CategoryUnavailable:
+		return http.StatusServiceUnavailable
+	case CategoryInvalidArgument:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GRPCCode returns the gRPC status code of the category, convertible with codes.Code(status.GRPCCode())
+func (s ErrorStatus) GRPCCode() uint32 {
+	switch s.Category {
+	case CategoryOK:
+		return grpcOK
+	case CategoryNotFound:
+		return grpcNotFound
+	case CategoryConflict:
+		return grpcAborted
+	case CategoryUnavailable:
+		return grpcUnavailable
+	case CategoryInvalidArgument:
+		return grpcInvalidArgument
+	default:
+		return grpcInternal
+	}
+}
+
+// ErrorClassifierFn classifies the errors it knows about, and reports false for the others
+type ErrorClassifierFn func(err error) (ErrorCategory, bool)
+
+// ErrorMapper maps errors to statuses, trying its custom classifiers before the built-in rules
+type ErrorMapper struct {
+	classifiers []ErrorClassifierFn
+}
+
+// NewErrorMapper creates a mapper trying the given classifiers, in order, before the built-in rules
+func NewErrorMapper(classifiers ...ErrorClassifierFn) *ErrorMapper {
+	return &ErrorMapper{classifiers: classifiers}
+}
+
+var defaultErrorMapper = NewErrorMapper()
+
+// ErrorToStatus maps err with the built-in rules, see ErrorMapper.Status
+func ErrorToStatus(err error) ErrorStatus {
+	return defaultErrorMapper.Status(err)
+}
+
+// Status maps err to its status. the built-in rules are:
+//   - NotFound: ErrNodeNotFound
+//   - Conflict: ErrIllegalTransition, constraint violations
+//   - Unavailable: ErrDriverClosed, ErrQuotaExceeded, connectivity errors, transient and cluster errors, deadlines
+//   - InvalidArgument: statement and request errors, e.g. syntax errors or missing parameters
+//   - Internal: anything else. the message of internal errors is not exposed.
+func (m *ErrorMapper) Status(err error) ErrorStatus {
+	if err == nil {
+		return ErrorStatus{Category: CategoryOK}
+	}
+	for _, classify := range m.classifiers {
+		if category, found := classify(err); found {
+			return newErrorStatus(category, err)
+		}
+	}
+	return newErrorStatus(classifyError(err), err)
+}
+
+func newErrorStatus(category ErrorCategory, err error) ErrorStatus {
+	message := err.Error()
+	if category == CategoryInternal {
+		message = "internal error"
+	}
+	return ErrorStatus{Category: category, Message: message}
+}
+
+func classifyError(err error) ErrorCategory {
+	switch {
+	case errors.Is(err, ErrNodeNotFound):
+		return CategoryNotFound
+	case errors.Is(err, ErrIllegalTransition):
+		return CategoryConflict
+	case errors.Is(err, ErrDriverClosed), errors.Is(err, ErrQuotaExceeded),
+		errors.Is(err, context.DeadlineExceeded), neo4j.IsConnectivityError(err):
+		return CategoryUnavailable
+	}
+	var transportErr *queryAPIConnectivityError
+	if errors.As(err, &transportErr) {
+		return CategoryUnavailable
+	}
+	var neo4jErr *neo4j.Neo4jError
+	if errors.As(err, &neo4jErr) {
+		return classifyNeo4jError(neo4jErr)
+	}
+	return CategoryInternal
+}
+
+func classifyNeo4jError(err *neo4j.Neo4jError) ErrorCategory {
+	switch {
+	case err.Code == "Neo.ClientError.Schema.ConstraintValidationFailed":
+		return CategoryConflict
+	case err.IsRetriableTransient(), err.IsRetriableCluster(), strings.HasPrefix(err.Code, "Neo.ClientError.Cluster."):
+		return CategoryUnavailable
+	case strings.HasPrefix(err.Code, "Neo.ClientError.Statement."), strings.HasPrefix(err.Code, "Neo.ClientError.Request."):
+		return CategoryInvalidArgument
+	default:
+		return CategoryInternal
+	}
+}
@@ -0,0 +1,66 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+)
+
+func TestErrorStatus(t *testing.T) {
+	suite.Run(t, new(ErrorStatusTestSuite))
+}
+
+type ErrorStatusTestSuite struct {
+	suite.Suite
+}
+
+func (s *ErrorStatusTestSuite) TestBuiltInRules() {
+	cases := map[error]ErrorCategory{
+		nil: CategoryOK,
+		fmt.Errorf("loading user: %w", ErrNodeNotFound):                              CategoryNotFound,
+		&IllegalTransitionError{From: "shipped", To: "pending"}:                      CategoryConflict,
+		&neo4j.Neo4jError{Code: "Neo.ClientError.Schema.ConstraintValidationFailed"}: CategoryConflict,
+		ErrDriverClosed: CategoryUnavailable,
+		&QuotaExceededError{Identity: "batch", Limit: QuotaLimitRate}: CategoryUnavailable,
+		context.DeadlineExceeded: CategoryUnavailable,
+		&neo4j.Neo4jError{Code: "Neo.TransientError.Transaction.DeadlockDetected"}: CategoryUnavailable,
+		&neo4j.Neo4jError{Code: "Neo.ClientError.Cluster.NotALeader"}:              CategoryUnavailable,
+		&neo4j.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError"}:           CategoryInvalidArgument,
+		errors.New("boom"): CategoryInternal,
+	}
+
+	for err, category := range cases {
+		s.Equal(category, ErrorToStatus(err).Category, "%v", err)
+	}
+}
+
+func (s *ErrorStatusTestSuite) TestTransportCodes() {
+	status := ErrorToStatus(ErrNodeNotFound)
+
+	s.Equal(http.StatusNotFound, status.HTTPStatus())
+	s.Equal(uint32(5), status.GRPCCode())
+	s.Equal(http.StatusServiceUnavailable, ErrorToStatus(ErrDriverClosed).HTTPStatus())
+	s.Equal(uint32(14), ErrorToStatus(ErrDriverClosed).GRPCCode())
+}
+
+func (s *ErrorStatusTestSuite) TestHidesInternalMessages() {
+	s.Equal(ErrorStatus{Category: CategoryInternal, Message: "internal error"}, ErrorToStatus(errors.New("secret details")))
+}
+
+func (s *ErrorStatusTestSuite) TestCustomClassifiersComeFirst() {
+	errPaymentRequired := errors.New("payment required")
+	mapper := NewErrorMapper(func(err error) (ErrorCategory, bool) {
+		if errors.Is(err, errPaymentRequired) {
+			return CategoryInvalidArgument, true
+		}
+		return "", false
+	})
+
+	s.Equal(CategoryInvalidArgument, mapper.Status(errPaymentRequired).Category)
+	s.Equal(CategoryNotFound, mapper.Status(ErrNodeNotFound).Category)
+}
@@ -0,0 +1,217 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"time"
+)
+
+const defaultEventBatchSize = 100
+
+// Event is an entry of an EventStream
+type Event struct {
+	// Seq orders the events of the stream, starting at 1 without gaps
+	Seq  int64
+	Type string
+	// Payload is stored as a JSON string, its numbers are read back as float64
+	Payload map[string]interface{}
+	At      time.Time
+}
+
+// EventStream is an ordered log of events stored as (:Event {stream, seq}) nodes, for event-sourcing-lite on Neo4j:
+// events are appended in the transaction of the business write they record, and consumed in order by
+// EventConsumer, which checkpoints its progress in the graph as well.
+// appenders of a stream are serialized by a lock on its (:EventStream) node, so that sequence order is commit order.
+type EventStream struct {
+	runner QueryRunner
+	name   string
+}
+
+// NewEventStream returns the stream of the given name, persisted with runner, typically a *Driver
+func NewEventStream(runner QueryRunner, name string) *EventStream {
+	return &EventStream{runner: runner, name: name}
+}
+
+// EnsureSchema creates the index the consumers read the events with, it does nothing if it already exists
+func (s *EventStream) EnsureSchema(ctx context.Context) error {
+	return s.runner.ExecuteQuery(ctx, "CREATE INDEX event_stream_seq IF NOT EXISTS FOR (e:Event) ON (e.stream, e.seq)", nil, consumeResult(ctx),
+		WithQueryName("events.schema"))
+}
+
+// AppendQuery generates the query appending an event, see Append
+func (s *EventStream) AppendQuery(eventType string, payload map[string]interface{}) (string, map[string]interface{}, error) {
+	return s.appendAfter("", nil, eventType, payload)
+}
+
+// AppendWithQuery generates the query running a business write and appending its event in the same transaction,
+// see AppendWith. the business query runs in a CALL subquery, its parameters must not start with "event"
+func (s *EventStream) AppendWithQuery(query string, params map[string]interface{}, eventType string, payload map[string]interface{}) (string, map[string]interface{}, error) {
+	return s.appendAfter(query, params, eventType, payload)
+}
+
+func (s *EventStream) appendAfter(query string, params map[string]interface{}, eventType string, payload map[string]interface{}) (string, map[string]interface{}, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("encoding %s event payload: %w", eventType, err)
+	}
+	appendParams := make(map[string]interface{}, len(params)+3)
+	for key, value := range params {
+		appendParams[key] = value
+	}
+	appendParams["eventStream"] = s.name
+	appendParams["eventType"] = eventType
+	appendParams["eventPayload"] = string(encoded)
+	prefix := ""
+	if query != "" {
+		prefix = "CALL { " + query + " } WITH count(*) AS written "
+	}
+	// setting a property first locks the stream node, so that the sequence is read once no other appender holds it
+	return prefix + "MERGE (s:EventStream {name: $eventStream}) SET s.locked = true" +
+		" WITH s SET s.seq = coalesce(s.seq, 0) + 1 REMOVE s.locked" +
+		" CREATE (e:Event {stream: $eventStream, seq: s.seq, type: $eventType, payload: $eventPayload, at: datetime()})" +
+		" RETURN e.seq AS seq", appendParams, nil
+}
+
+// Append appends an event on its own and returns its sequence number
+func (s *EventStream) Append(ctx context.Context, eventType string, payload map[string]interface{}, opts ...QueryOption) (int64, error) {
+	query, params, err := s.AppendQuery(eventType, payload)
+	if err != nil {
+		return 0, err
+	}
+	return s.appendWith(ctx, query, params, opts)
+}
+
+// AppendWith runs the business write query and appends its event atomically: either both are committed or none is
+func (s *EventStream) AppendWith(ctx context.Context, query string, params map[string]interface{}, eventType string, payload map[string]interface{}, opts ...QueryOption) (int64, error) {
+	query, params, err := s.AppendWithQuery(query, params, eventType, payload)
+	if err != nil {
+		return 0, err
+	}
+	return s.appendWith(ctx, query, params, opts)
+}
+
+func (s *EventStream) appendWith(ctx context.Context, query string, params map[string]interface{}, opts []QueryOption) (seq int64, err error) {
+	opts = append([]QueryOption{WithQueryName("events.append")}, opts...)
+	err = s.runner.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(ctx)
+		if err != nil {
+			return err
+		}
+		seq, _ = record.Values[0].(int64)
+		return nil
+	}, opts...)
+	return seq, err
+}
+
+// EventHandlerFn processes an event, an error stops the consumption before the event is checkpointed
+type EventHandlerFn func(ctx context.Context, event Event) error
+
+// EventConsumer reads the events of a stream in order, and checkpoints the last one it processed in an
+// (:EventCheckpoint {stream, consumer}) node, so that it resumes after it across restarts.
+// events are delivered at least once: a crash between processing an event and checkpointing it replays it
+type EventConsumer struct {
+	stream    *EventStream
+	name      string
+	batchSize int
+}
+
+// Consumer returns the consumer of the given name, every consumer has its own checkpoint
+func (s *EventStream) Consumer(name string) *EventConsumer {
+	return &EventConsumer{stream: s, name: name, batchSize: defaultEventBatchSize}
+}
+
+// WithBatchSize sets the maximum number of events read by every Poll, defaults to 100
+func (c *EventConsumer) WithBatchSize(size int) *EventConsumer {
+	c.batchSize = size
+	return c
+}
+
+// Checkpoint returns the sequence number of the last event processed by the consumer, 0 if none
+func (c *EventConsumer) Checkpoint(ctx context.Context) (seq int64, err error) {
+	query := "OPTIONAL MATCH (c:EventCheckpoint {stream: $stream, consumer: $consumer}) RETURN coalesce(c.seq, 0) AS seq"
+	err = c.stream.runner.ExecuteQuery(ctx, query, c.params(nil), func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(ctx)
+		if err != nil {
+			return err
+		}
+		seq, _ = record.Values[0].(int64)
+		return nil
+	}, WithQueryName("events.checkpoint"), WithAccessMode(neo4j.AccessModeRead))
+	return seq, err
+}
+
+// Poll hands the events following the checkpoint to handler in order, checkpointing each of them once handled.
+// it returns the number of events handled, fewer than the batch size when the consumer caught up
+func (c *EventConsumer) Poll(ctx context.Context, handler EventHandlerFn) (int, error) {
+	events, err := c.next(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, event := range events {
+		if err := handler(ctx, event); err != nil {
+			return i, fmt.Errorf("handling %s event %d: %w", event.Type, event.Seq, err)
+		}
+		if err := c.checkpoint(ctx, event.Seq); err != nil {
+			return i, err
+		}
+	}
+	return len(events), nil
+}
+
+// Run polls the stream until ctx is done or handler fails, waiting for interval whenever the consumer caught up
+func (c *EventConsumer) Run(ctx context.Context, interval time.Duration, handler EventHandlerFn) error {
+	for {
+		handled, err := c.Poll(ctx, handler)
+		if err != nil {
+			return err
+		}
+		if handled == c.batchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (c *EventConsumer) next(ctx context.Context) ([]Event, error) {
+	query := "OPTIONAL MATCH (c:EventCheckpoint {stream: $stream, consumer: $consumer}) WITH coalesce(c.seq, 0) AS after" +
+		" MATCH (e:Event {stream: $stream}) WHERE e.seq > after" +
+		" RETURN e.seq AS seq, e.type AS type, e.payload AS payload, e.at AS at ORDER BY e.seq LIMIT $limit"
+	var events []Event
+	err := c.stream.runner.ExecuteQuery(ctx, query, c.params(map[string]interface{}{"limit": int64(c.batchSize)}), func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for result.NextRecord(ctx, &record) {
+			event := Event{}
+			event.Seq, _ = record.Values[0].(int64)
+			event.Type, _ = record.Values[1].(string)
+			if payload, ok := record.Values[2].(string); ok {
+				if err := json.Unmarshal([]byte(payload), &event.Payload); err != nil {
+					return fmt.Errorf("decoding %s event %d payload: %w", event.Type, event.Seq, err)
+				}
+			}
+			event.At, _ = record.Values[3].(time.Time)
+			events = append(events, event)
+		}
+		return result.Err()
+	}, WithQueryName("events.read"), WithAccessMode(neo4j.AccessModeRead))
+	return events, err
+}
+
+func (c *EventConsumer) checkpoint(ctx context.Context, seq int64) error {
+	query := "MERGE (c:EventCheckpoint {stream: $stream, consumer: $consumer})" +
+		" SET c.seq = CASE WHEN coalesce(c.seq, 0) < $seq THEN $seq ELSE c.seq END"
+	return c.stream.runner.ExecuteQuery(ctx, query, c.params(map[string]interface{}{"seq": seq}), consumeResult(ctx), WithQueryName("events.checkpoint"))
+}
+
+func (c *EventConsumer) params(extra map[string]interface{}) map[string]interface{} {
+	params := map[string]interface{}{"stream": c.stream.name, "consumer": c.name}
+	for key, value := range extra {
+		params[key] = value
+	}
+	return params
+}
@@ -0,0 +1,108 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+)
+
+func TestEventStream(t *testing.T) {
+	suite.Run(t, new(EventStreamTestSuite))
+}
+
+type EventStreamTestSuite struct {
+	suite.Suite
+	ctx   context.Context
+	store *fakeEventStore
+}
+
+func (s *EventStreamTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.store = &fakeEventStore{checkpoints: map[string]int64{}}
+}
+
+func (s *EventStreamTestSuite) TestAppendsEventsInTheBusinessTransaction() {
+	stream := NewEventStream(s.store, "orders")
+
+	query, params, err := stream.AppendWithQuery("MATCH (o:Order {id: $id}) SET o.status = 'paid'", map[string]interface{}{"id": 42}, "OrderPaid", map[string]interface{}{"id": 42})
+
+	s.Require().NoError(err)
+	s.Equal("CALL { MATCH (o:Order {id: $id}) SET o.status = 'paid' } WITH count(*) AS written"+
+		" MERGE (s:EventStream {name: $eventStream}) SET s.locked = true WITH s SET s.seq = coalesce(s.seq, 0) + 1 REMOVE s.locked"+
+		" CREATE (e:Event {stream: $eventStream, seq: s.seq, type: $eventType, payload: $eventPayload, at: datetime()}) RETURN e.seq AS seq", query)
+	s.Equal(map[string]interface{}{"id": 42, "eventStream": "orders", "eventType": "OrderPaid", "eventPayload": `{"id":42}`}, params)
+}
+
+func (s *EventStreamTestSuite) TestConsumesInOrderFromTheCheckpoint() {
+	stream := NewEventStream(s.store, "orders")
+	for _, eventType := range []string{"OrderPlaced", "OrderPaid", "OrderShipped"} {
+		_, err := stream.Append(s.ctx, eventType, map[string]interface{}{"id": 42})
+		s.Require().NoError(err)
+	}
+	consumer := stream.Consumer("billing").WithBatchSize(2)
+	var handled []string
+	handler := func(_ context.Context, event Event) error {
+		handled = append(handled, event.Type)
+		return nil
+	}
+
+	count, err := consumer.Poll(s.ctx, handler)
+	s.Require().NoError(err)
+	s.Equal(2, count)
+	count, err = consumer.Poll(s.ctx, handler)
+	s.Require().NoError(err)
+	s.Equal(1, count)
+
+	s.Equal([]string{"OrderPlaced", "OrderPaid", "OrderShipped"}, handled)
+	checkpoint, err := consumer.Checkpoint(s.ctx)
+	s.Require().NoError(err)
+	s.Equal(int64(3), checkpoint)
+}
+
+func (s *EventStreamTestSuite) TestStopsBeforeCheckpointingFailedEvents() {
+	stream := NewEventStream(s.store, "orders")
+	_, err := stream.Append(s.ctx, "OrderPlaced", nil)
+	s.Require().NoError(err)
+	consumer := stream.Consumer("billing")
+
+	_, err = consumer.Poll(s.ctx, func(context.Context, Event) error {
+		return errors.New("billing down")
+	})
+
+	s.ErrorContains(err, "handling OrderPlaced event 1: billing down")
+	checkpoint, err := consumer.Checkpoint(s.ctx)
+	s.Require().NoError(err)
+	s.Zero(checkpoint)
+}
+
+// fakeEventStore interprets the queries of EventStream and EventConsumer against in-memory events and checkpoints
+type fakeEventStore struct {
+	events      []*neo4j.Record
+	checkpoints map[string]int64
+}
+
+func (f *fakeEventStore) ExecuteQuery(_ context.Context, query string, params map[string]interface{}, onResults ResultsHookFn, _ ...QueryOption) error {
+	var records []*neo4j.Record
+	switch {
+	case strings.Contains(query, "CREATE (e:Event"):
+		seq := int64(len(f.events) + 1)
+		f.events = append(f.events, &neo4j.Record{Values: []any{seq, params["eventType"], params["eventPayload"], nil}})
+		records = []*neo4j.Record{{Keys: []string{"seq"}, Values: []any{seq}}}
+	case strings.HasPrefix(query, "MERGE (c:EventCheckpoint"):
+		f.checkpoints[params["consumer"].(string)] = params["seq"].(int64)
+	case strings.Contains(query, "MATCH (e:Event"):
+		after := f.checkpoints[params["consumer"].(string)]
+		for _, event := range f.events {
+			if event.Values[0].(int64) > after && int64(len(records)) < params["limit"].(int64) {
+				records = append(records, event)
+			}
+		}
+	default:
+		records = []*neo4j.Record{{Keys: []string{"seq"}, Values: []any{f.checkpoints[params["consumer"].(string)]}}}
+	}
+	return onResults(newFakeResult(records))
+}
@@ -0,0 +1,197 @@
+package driver_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"log"
+	"time"
+)
+
+// the examples run against the in-memory fakes of fakes_test.go, so that they execute without a server.
+
+func ExampleNewDriver() {
+	// the underlying drivers are replaced with the in-memory cluster of fakes_test.go
+	defer UseDriverFactory((&fakeCluster{}).newDriver)()
+	ctx := context.Background()
+
+	driver, err := NewDriver(Settings{
+		ConnectionString:     "neo4j://localhost:7687",
+		User:                 "neo4j",
+		Password:             "secret",
+		SlowQueryThreshold:   500 * time.Millisecond,
+		MaxConcurrentQueries: 64,
+		QueryObserver: func(_ context.Context, event QueryEvent) {
+			fmt.Printf("%s: %d attempt(s), error: %v\n", event.DisplayName(), event.Stats.Attempts, event.Err)
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer driver.Close(ctx)
+	err = driver.ExecuteQuery(ctx, "RETURN 1", nil, func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(ctx)
+		return err
+	}, WithQueryName("ping"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// ping: 1 attempt(s), error: <nil>
+}
+
+func ExampleDriver_ExecuteQuery() {
+	// the in-memory cluster of fakes_test.go loses the connections of its first driver
+	defer UseDriverFactory((&fakeCluster{
+		unreachableDrivers: 1,
+		records:            []*neo4j.Record{{Keys: []string{"name"}, Values: []any{"alice"}}, {Keys: []string{"name"}, Values: []any{"bob"}}},
+	}).newDriver)()
+	ctx := context.Background()
+	driver, err := NewDriver(Settings{ConnectionString: "neo4j://localhost:7687", User: "neo4j", Password: "secret"})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer driver.Close(ctx)
+
+	// connection losses are recovered transparently, stats reports how many attempts were needed
+	var stats QueryStats
+	err = driver.ExecuteQuery(ctx, "MATCH (u:User) RETURN u.name AS name", nil, func(result neo4j.ResultWithContext) error {
+		for result.Next(ctx) {
+			name, _ := result.Record().Get("name")
+			fmt.Println(name)
+		}
+		return result.Err()
+	}, WithQueryName("users.names"), WithAccessMode(neo4j.AccessModeRead), WithQueryStats(&stats))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d attempt(s), %d reconnection(s)\n", stats.Attempts, stats.Reconnects)
+	// Output:
+	// alice
+	// bob
+	// 2 attempt(s), 1 reconnection(s)
+}
+
+func ExampleJoinResults() {
+	users := newFakeRecords([]string{"id", "name"}, []any{"1", "alice"}, []any{"2", "bob"})
+	orders := newFakeRecords([]string{"userId", "total"}, []any{"1", 10.5}, []any{"1", 4.0}, []any{"2", 7.25})
+	key := func(column string) JoinKeyFn {
+		return func(record *neo4j.Record) (string, error) {
+			value, _ := record.Get(column)
+			return value.(string), nil
+		}
+	}
+
+	err := JoinResults(context.Background(), users, orders, key("id"), key("userId"), func(user, order *neo4j.Record) error {
+		name, _ := user.Get("name")
+		total, _ := order.Get("total")
+		fmt.Println(name, total)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// alice 10.5
+	// alice 4
+	// bob 7.25
+}
+
+func ExampleToMaps() {
+	alice := neo4j.Node{ElementId: "4:1", Labels: []string{"User"}, Props: map[string]any{"name": "alice"}}
+	result := newFakeRecords([]string{"user", "since"}, []any{alice, neo4j.DateOf(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC))})
+
+	maps, err := ToMaps(context.Background(), result, WithTemporalAsString())
+	if err != nil {
+		log.Fatal(err)
+	}
+	body, _ := json.Marshal(maps)
+	fmt.Println(string(body))
+	// Output:
+	// [{"since":"2020-01-02","user":{"elementId":"4:1","labels":["User"],"props":{"name":"alice"}}}]
+}
+
+func ExampleQueryRegistry_Execute() {
+	runner := &fakeRunner{records: []*neo4j.Record{{Keys: []string{"count"}, Values: []any{int64(2)}}}}
+	registry := NewQueryRegistry().MustRegister("users.count", "MATCH (u:User) RETURN count(u) AS count")
+
+	err := registry.Execute(context.Background(), runner, "users.count", nil, func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(context.Background())
+		if err != nil {
+			return err
+		}
+		fmt.Println(record.Values[0])
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(runner.executed())
+	// Output:
+	// 2
+	// [MATCH (u:User) RETURN count(u) AS count]
+}
+
+func ExampleStatusMachine_TransitionQuery() {
+	machine := NewStatusMachine("Order", "status", map[string][]string{
+		"pending": {"paid", "cancelled"},
+		"paid":    {"shipped"},
+	})
+
+	query, _ := machine.TransitionQuery(map[string]interface{}{"id": 42}, "paid")
+	fmt.Println(machine.Allowed("paid", "pending"))
+	fmt.Println(query)
+	// Output:
+	// false
+	// MATCH (n:`Order`) WHERE n.`id` = $match0 SET n._lock = true REMOVE n._lock WITH n WHERE n.`status` IN $from SET n.`status` = $to RETURN count(n) AS applied
+}
+
+func ExampleIndex_CreateQuery() {
+	index := Index{Label: "User", Properties: []string{"email"}}
+
+	for _, version := range []ServerVersion{{Major: 4, Minor: 4}, {Major: 5, Minor: 0}} {
+		query, _ := index.CreateQuery(version)
+		fmt.Println(query)
+	}
+	// Output:
+	// CREATE INDEX `range_index_user_email` IF NOT EXISTS FOR (n:`User`) ON (n.`email`)
+	// CREATE RANGE INDEX `range_index_user_email` IF NOT EXISTS FOR (n:`User`) ON (n.`email`)
+}
+
+func ExampleRedactParams() {
+	redact := RedactParams("password")
+
+	fmt.Println(redact(map[string]interface{}{"login": "alice", "password": "s3cr3t"}))
+	// Output:
+	// map[login:alice password:<redacted>]
+}
+
+func ExampleErrorToStatus() {
+	err := fmt.Errorf("loading order: %w", ErrNodeNotFound)
+
+	status := ErrorToStatus(err)
+	fmt.Println(status.Category, status.HTTPStatus())
+	fmt.Println(ErrorToStatus(errors.New("disk full")).Message)
+	// Output:
+	// NotFound 404
+	// internal error
+}
+
+func ExampleCollectAs() {
+	type user struct {
+		Name    string   `neo4j:"name"`
+		Friends []string `neo4j:"friends"`
+	}
+	result := newFakeRecords([]string{"name", "friends"}, []any{"alice", []any{"bob", "carol"}})
+
+	users, err := CollectAs[user](context.Background(), result)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%+v\n", users)
+	// Output:
+	// [{Name:alice Friends:[bob carol]}]
+}
@@ -0,0 +1,23 @@
+package driver
+
+import (
+	"context"
+)
+
+// QueryExecutor is the behavior of Driver that applications depend on, so that they can substitute or decorate it,
+// e.g. with MockDriver in unit tests. both *Driver and *MockDriver implement it.
+// code that only runs queries should depend on the narrower QueryRunner instead.
+type QueryExecutor interface {
+	QueryRunner
+	// ExecuteScript runs the statements of a Cypher script in order, see SplitScript
+	ExecuteScript(ctx context.Context, script string, opts ...QueryOption) error
+	// VerifyConnectivity checks that the server can be reached
+	VerifyConnectivity(ctx context.Context) error
+	// Close releases the resources, the following queries fail with ErrDriverClosed
+	Close(ctx context.Context)
+}
+
+var (
+	_ QueryExecutor = (*Driver)(nil)
+	_ QueryExecutor = (*MockDriver)(nil)
+)
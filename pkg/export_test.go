@@ -0,0 +1,62 @@
+package driver
+
+import (
+	"context"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"time"
+)
+
+// CloseUnderlying closes the underlying neo4j driver without closing the wrapper,
+// simulating a connectivity loss the wrapper is expected to recover from
+func (d *Driver) CloseUnderlying(ctx context.Context) {
+	d.recoveryLock.Lock()
+	defer d.recoveryLock.Unlock()
+	d.currentGeneration().driver.Close(ctx)
+}
+
+// ParseConnectionString returns the target and the configuration derived from a connection string
+func ParseConnectionString(connectionString string, routingContext map[string]string) (target string, config neo4j.Config, user string, err error) {
+	target, configurers, credentials, err := parseConnectionString(connectionString, routingContext)
+	for _, configurer := range configurers {
+		configurer(&config)
+	}
+	if credentials != nil {
+		user = credentials.String()
+	}
+	return target, config, user, err
+}
+
+// UseDriverFactory makes NewDriver and the recoveries create their underlying drivers with factory, until restore is called
+func UseDriverFactory(factory func(target string, auth neo4j.AuthToken, configurers ...func(*neo4j.Config)) (neo4j.DriverWithContext, error)) (restore func()) {
+	previous := newDriverWithContext
+	newDriverWithContext = factory
+	return func() {
+		newDriverWithContext = previous
+	}
+}
+
+// HoldRecoveryLock blocks the recoveries of the driver until release is called, simulating a slow reconnect in progress
+func (d *Driver) HoldRecoveryLock() (release func()) {
+	d.recoveryLock.Lock()
+	return d.recoveryLock.Unlock
+}
+
+// Generation returns the number of times the underlying driver was replaced
+func (d *Driver) Generation() uint64 {
+	return d.currentGeneration().number
+}
+
+// UseHostLookup makes the routers resolved on reconnects go through lookup, until restore is called
+func UseHostLookup(lookup func(ctx context.Context, host string) ([]string, error)) (restore func()) {
+	previous := lookupHost
+	lookupHost = lookup
+	return func() {
+		lookupHost = previous
+	}
+}
+
+// UseClock makes the limiter read the time from now, e.g. to expire its idle identities
+func (l *QuotaLimiter) UseClock(now func() time.Time) {
+	l.now = now
+	l.lastSweep = now()
+}
@@ -0,0 +1,360 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/db"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeRecords is an in-memory RecordIterator
+type fakeRecords struct {
+	records []*neo4j.Record
+	err     error
+	pulled  int
+}
+
+func newFakeRecords(keys []string, rows ...[]any) *fakeRecords {
+	records := make([]*neo4j.Record, len(rows))
+	for i, row := range rows {
+		records[i] = &neo4j.Record{Keys: keys, Values: row}
+	}
+	return &fakeRecords{records: records}
+}
+
+func (f *fakeRecords) NextRecord(_ context.Context, record **neo4j.Record) bool {
+	if f.pulled >= len(f.records) {
+		*record = nil
+		return false
+	}
+	*record = f.records[f.pulled]
+	f.pulled++
+	return true
+}
+
+func (f *fakeRecords) Err() error {
+	return f.err
+}
+
+// fakeResult is an in-memory neo4j.ResultWithContext.
+// the embedded interface is left nil, it only provides the unexported methods that are never called.
+type fakeResult struct {
+	neo4j.ResultWithContext
+	*fakeRecords
+	current *neo4j.Record
+	summary neo4j.ResultSummary
+}
+
+func newFakeResult(records []*neo4j.Record) *fakeResult {
+	return &fakeResult{fakeRecords: &fakeRecords{records: records}}
+}
+
+func (f *fakeResult) NextRecord(ctx context.Context, record **neo4j.Record) bool {
+	found := f.fakeRecords.NextRecord(ctx, record)
+	f.current = *record
+	return found
+}
+
+func (f *fakeResult) Next(ctx context.Context) bool {
+	return f.NextRecord(ctx, &f.current)
+}
+
+func (f *fakeResult) Keys() ([]string, error) {
+	if len(f.records) == 0 {
+		return nil, f.err
+	}
+	return f.records[0].Keys, f.err
+}
+
+func (f *fakeResult) Record() *neo4j.Record {
+	return f.current
+}
+
+func (f *fakeResult) Err() error {
+	return f.fakeRecords.Err()
+}
+
+func (f *fakeResult) Collect(ctx context.Context) ([]*neo4j.Record, error) {
+	var records []*neo4j.Record
+	for f.Next(ctx) {
+		records = append(records, f.current)
+	}
+	return records, f.err
+}
+
+func (f *fakeResult) Single(ctx context.Context) (*neo4j.Record, error) {
+	if !f.Next(ctx) {
+		return nil, errors.New("result contains no more records")
+	}
+	return f.current, f.err
+}
+
+func (f *fakeResult) Consume(context.Context) (neo4j.ResultSummary, error) {
+	f.pulled = len(f.records)
+	return f.summary, f.err
+}
+
+func (f *fakeResult) Peek(context.Context) bool {
+	return f.pulled < len(f.records)
+}
+
+func (f *fakeResult) IsOpen() bool {
+	return f.pulled < len(f.records)
+}
+
+// fakeRunner is a QueryRunner recording the queries it receives and handing its records to their hooks
+type fakeRunner struct {
+	mutex   sync.Mutex
+	queries []string
+	records []*neo4j.Record
+	err     error
+}
+
+func (f *fakeRunner) ExecuteQuery(_ context.Context, query string, _ map[string]interface{}, onResults ResultsHookFn, _ ...QueryOption) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.queries = append(f.queries, query)
+	if f.err != nil {
+		return f.err
+	}
+	return onResults(newFakeResult(f.records))
+}
+
+func (f *fakeRunner) executed() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string(nil), f.queries...)
+}
+
+// fakeCluster simulates a cluster whose leader switched: the first staleDrivers drivers it creates route writes
+// to the former leader, which rejects them with NotALeader, or staleErr if set. the following ones route them to the new leader.
+// the first unreachableDrivers drivers it creates fail with connectivity errors instead.
+// it records the access mode and the configuration of the sessions, the transaction metadata of the queries and the explicit transactions it serves,
+// and summarizes its results with summary. resultErr, if set, is raised while streaming the results.
+type fakeCluster struct {
+	mutex              sync.Mutex
+	staleDrivers       int
+	staleErr           error
+	unreachableDrivers int
+	summary            neo4j.ResultSummary
+	resultErr          error
+	created            int
+	closed             int
+	modes              []neo4j.AccessMode
+	sessionConfigs     []neo4j.SessionConfig
+	metadata           []map[string]any
+	transactions       []*fakeClusterTx
+	// streamed, if set, is the number of records of the results, generated as they are pulled
+	streamed int
+	// records, if set, are the records of the results instead of a single {ok: true} one
+	records []*neo4j.Record
+}
+
+func (c *fakeCluster) newDriver(string, neo4j.AuthToken, ...func(*neo4j.Config)) (neo4j.DriverWithContext, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.created++
+	return &fakeClusterDriver{cluster: c, id: c.created, stale: c.created <= c.staleDrivers}, nil
+}
+
+// disconnect makes the drivers created so far, and their sessions, fail with connectivity errors
+func (c *fakeCluster) disconnect() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.unreachableDrivers = c.created
+}
+
+func (c *fakeCluster) drivers() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.created
+}
+
+func (c *fakeCluster) sessions() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.modes)
+}
+
+type fakeClusterDriver struct {
+	neo4j.DriverWithContext
+	cluster *fakeCluster
+	id      int
+	stale   bool
+}
+
+func (d *fakeClusterDriver) unreachable() bool {
+	d.cluster.mutex.Lock()
+	defer d.cluster.mutex.Unlock()
+	return d.id <= d.cluster.unreachableDrivers
+}
+
+func (d *fakeClusterDriver) NewSession(_ context.Context, config neo4j.SessionConfig) neo4j.SessionWithContext {
+	d.cluster.mutex.Lock()
+	defer d.cluster.mutex.Unlock()
+	d.cluster.modes = append(d.cluster.modes, config.AccessMode)
+	d.cluster.sessionConfigs = append(d.cluster.sessionConfigs, config)
+	return &fakeClusterSession{cluster: d.cluster, driver: d}
+}
+
+func (d *fakeClusterDriver) VerifyConnectivity(context.Context) error {
+	if d.unreachable() {
+		return errUnreachable
+	}
+	return nil
+}
+
+func (d *fakeClusterDriver) Close(context.Context) error {
+	d.cluster.mutex.Lock()
+	defer d.cluster.mutex.Unlock()
+	d.cluster.closed++
+	return nil
+}
+
+type fakeClusterSession struct {
+	neo4j.SessionWithContext
+	cluster *fakeCluster
+	driver  *fakeClusterDriver
+}
+
+var errUnreachable = errors.New("ConnectivityError: server unreachable")
+
+// fakeRoutingServers is the routing table advertised by the fake cluster
+var fakeRoutingServers = []any{
+	map[string]any{"role": "WRITE", "addresses": []any{"core-1:7687"}},
+	map[string]any{"role": "READ", "addresses": []any{"core-2:7687", "core-3:7687"}},
+	map[string]any{"role": "ROUTE", "addresses": []any{"core-1:7687", "core-2:7687", "core-3:7687"}},
+}
+
+func (s *fakeClusterSession) Run(_ context.Context, query string, _ map[string]any, configurers ...func(*neo4j.TransactionConfig)) (neo4j.ResultWithContext, error) {
+	config := neo4j.TransactionConfig{}
+	for _, configurer := range configurers {
+		configurer(&config)
+	}
+	s.cluster.mutex.Lock()
+	s.cluster.metadata = append(s.cluster.metadata, config.Metadata)
+	s.cluster.mutex.Unlock()
+	if s.driver.unreachable() {
+		return nil, errUnreachable
+	}
+	if s.driver.stale && s.cluster.staleErr != nil {
+		return nil, s.cluster.staleErr
+	}
+	if s.driver.stale {
+		return nil, &neo4j.Neo4jError{Code: "Neo.ClientError.Cluster.NotALeader", Msg: "no longer the leader"}
+	}
+	if strings.HasPrefix(query, "CALL dbms.routing.getRoutingTable") {
+		return newFakeResult([]*neo4j.Record{{Keys: []string{"ttl", "servers"}, Values: []any{int64(300), fakeRoutingServers}}}), nil
+	}
+	if s.cluster.streamed > 0 {
+		return &streamedResult{size: s.cluster.streamed}, nil
+	}
+	records := s.cluster.records
+	if records == nil {
+		records = []*neo4j.Record{{Keys: []string{"ok"}, Values: []any{true}}}
+	}
+	result := newFakeResult(records)
+	result.summary = s.cluster.summary
+	result.err = s.cluster.resultErr
+	return result, nil
+}
+
+func (s *fakeClusterSession) Close(context.Context) error {
+	return nil
+}
+
+func (s *fakeClusterSession) BeginTransaction(context.Context, ...func(*neo4j.TransactionConfig)) (neo4j.ExplicitTransaction, error) {
+	s.cluster.mutex.Lock()
+	defer s.cluster.mutex.Unlock()
+	tx := &fakeClusterTx{}
+	s.cluster.transactions = append(s.cluster.transactions, tx)
+	return tx, nil
+}
+
+// fakeClusterTx answers every query with a single record holding the query
+type fakeClusterTx struct {
+	neo4j.ExplicitTransaction
+	queries   []string
+	committed bool
+}
+
+func (t *fakeClusterTx) Run(_ context.Context, query string, _ map[string]any) (neo4j.ResultWithContext, error) {
+	t.queries = append(t.queries, query)
+	return newFakeResult([]*neo4j.Record{{Keys: []string{"query"}, Values: []any{query}}}), nil
+}
+
+func (t *fakeClusterTx) Commit(context.Context) error {
+	t.committed = true
+	return nil
+}
+
+func (t *fakeClusterTx) Close(context.Context) error {
+	return nil
+}
+
+// fakeSummary is a neo4j.ResultSummary reporting the server metadata, timings, plans and notifications it holds
+type fakeSummary struct {
+	neo4j.ResultSummary
+	server         fakeServerInfo
+	database       string
+	availableAfter time.Duration
+	consumedAfter  time.Duration
+	plan           neo4j.Plan
+	profile        neo4j.ProfiledPlan
+	notifications  []neo4j.Notification
+}
+
+func (s *fakeSummary) Notifications() []neo4j.Notification {
+	return s.notifications
+}
+
+func (s *fakeSummary) Plan() neo4j.Plan {
+	return s.plan
+}
+
+func (s *fakeSummary) Profile() neo4j.ProfiledPlan {
+	return s.profile
+}
+
+func (s *fakeSummary) Server() neo4j.ServerInfo {
+	return s.server
+}
+
+func (s *fakeSummary) Database() neo4j.DatabaseInfo {
+	return fakeDatabaseInfo(s.database)
+}
+
+func (s *fakeSummary) ResultAvailableAfter() time.Duration {
+	return s.availableAfter
+}
+
+func (s *fakeSummary) ResultConsumedAfter() time.Duration {
+	return s.consumedAfter
+}
+
+type fakeServerInfo struct {
+	address, agent string
+	version        db.ProtocolVersion
+}
+
+func (i fakeServerInfo) Address() string {
+	return i.address
+}
+
+func (i fakeServerInfo) Agent() string {
+	return i.agent
+}
+
+func (i fakeServerInfo) ProtocolVersion() db.ProtocolVersion {
+	return i.version
+}
+
+type fakeDatabaseInfo string
+
+func (i fakeDatabaseInfo) Name() string {
+	return string(i)
+}
@@ -0,0 +1,43 @@
+package driver
+
+import "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+
+const (
+	// FetchSizeDefault pulls the records in batches of the default size of the underlying driver, 1000 records
+	FetchSizeDefault = neo4j.FetchDefault
+	// FetchSizeAll pulls all the records at once, saving the round trips of the batches.
+	// it suits small results, e.g. lookups by key or aggregations, as the whole result is buffered in memory
+	FetchSizeAll = neo4j.FetchAll
+)
+
+// defaultFetchSize is the batch size the underlying driver pulls with FetchSizeDefault. a session asking for
+// FetchSizeDefault would use the one of the driver instead, see WithFetchSize
+const defaultFetchSize = 1000
+
+// WithFetchSize pulls the records of the query in batches of size records, overriding Settings.FetchSize.
+// the underlying driver buffers at most a batch ahead of the hook: streaming a large result with a small fetch size
+// bounds the memory it uses, at the cost of more round trips, while FetchSizeAll suits the small results.
+// FetchSizeDefault pulls the default batches whatever Settings.FetchSize
+func WithFetchSize(size int) QueryOption {
+	return func(options *queryOptions) {
+		options.fetchSize = &size
+	}
+}
+
+// sessionFetchSize is the fetch size of the session of the query, FetchDefault to keep the one of the underlying driver
+func (o *queryOptions) sessionFetchSize() int {
+	if o.fetchSize == nil {
+		return neo4j.FetchDefault
+	}
+	if *o.fetchSize == FetchSizeDefault {
+		return defaultFetchSize
+	}
+	return *o.fetchSize
+}
+
+// withFetchSize configures the fetch size of the underlying driver, see Settings.FetchSize
+func withFetchSize(size int) func(*neo4j.Config) {
+	return func(config *neo4j.Config) {
+		config.FetchSize = size
+	}
+}
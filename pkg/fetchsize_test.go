@@ -0,0 +1,179 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"runtime"
+	"testing"
+)
+
+func TestFetchSize(t *testing.T) {
+	suite.Run(t, new(FetchSizeTestSuite))
+}
+
+type FetchSizeTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	configs []neo4j.Config
+	restore func()
+}
+
+func (s *FetchSizeTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{}
+	s.configs = nil
+	s.restore = UseDriverFactory(func(target string, auth neo4j.AuthToken, configurers ...func(*neo4j.Config)) (neo4j.DriverWithContext, error) {
+		config := neo4j.Config{}
+		for _, configurer := range configurers {
+			configurer(&config)
+		}
+		s.configs = append(s.configs, config)
+		return s.cluster.newDriver(target, auth, configurers...)
+	})
+}
+
+func (s *FetchSizeTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *FetchSizeTestSuite) TestConfiguresTheFetchSizeOfTheUnderlyingDriver() {
+	settings := connectionSettings
+	settings.ConnectionString = "neo4j://localhost?fetch_size=500"
+	settings.FetchSize = 200
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	s.Require().Len(s.configs, 1)
+	s.Equal(200, s.configs[0].FetchSize)
+}
+
+func (s *FetchSizeTestSuite) TestKeepsTheFetchSizeOfTheConnectionStringByDefault() {
+	settings := connectionSettings
+	settings.ConnectionString = "neo4j://localhost?fetch_size=500"
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	s.Require().Len(s.configs, 1)
+	s.Equal(500, s.configs[0].FetchSize)
+}
+
+func (s *FetchSizeTestSuite) TestOverridesTheFetchSizePerQuery() {
+	settings := connectionSettings
+	settings.MaxIdleSessions = 2
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	s.Require().NoError(driver.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook, WithFetchSize(FetchSizeAll)))
+	s.Require().NoError(driver.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook, WithFetchSize(50)))
+	s.Require().NoError(driver.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook))
+	s.Require().NoError(driver.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook, WithFetchSize(50)))
+
+	s.Require().Len(s.cluster.sessionConfigs, 3, "the sessions are only reused for the same fetch size")
+	s.Equal(FetchSizeAll, s.cluster.sessionConfigs[0].FetchSize)
+	s.Equal(50, s.cluster.sessionConfigs[1].FetchSize)
+	s.Equal(FetchSizeDefault, s.cluster.sessionConfigs[2].FetchSize)
+}
+
+func (s *FetchSizeTestSuite) TestOverridesTheFetchSizeOfTheSettingsWithTheDefaultOne() {
+	settings := connectionSettings
+	settings.FetchSize = FetchSizeAll
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	s.Require().NoError(driver.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook, WithFetchSize(FetchSizeDefault)))
+
+	s.Equal(FetchSizeAll, s.configs[0].FetchSize)
+	s.Require().Len(s.cluster.sessionConfigs, 1)
+	s.Equal(1000, s.cluster.sessionConfigs[0].FetchSize, "the default batches are pulled instead of the ones of the settings")
+}
+
+func (s *FetchSizeTestSuite) TestStreamsLargeResultsInBoundedMemory() {
+	const records = 1_000_000
+	s.cluster.streamed = records
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	baseline := heapInUse()
+	var streamed int
+	var peak uint64
+
+	err = driver.ExecuteQuery(s.ctx, "UNWIND range(1, $count) AS i RETURN i", map[string]interface{}{"count": records}, func(result neo4j.ResultWithContext) error {
+		for result.Next(s.ctx) {
+			streamed++
+			if streamed%100_000 == 0 {
+				if heap := heapInUse(); heap > peak {
+					peak = heap
+				}
+			}
+		}
+		return result.Err()
+	}, WithFetchSize(1000), WithConsumeGuard())
+
+	s.Require().NoError(err)
+	s.Equal(records, streamed)
+	// buffering the records would take over 100MB
+	s.Less(int64(peak)-int64(baseline), int64(16<<20), "the records must not be retained while they are streamed")
+}
+
+func heapInUse() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// streamedResult is a neo4j.ResultWithContext generating its records as they are pulled, without retaining them
+type streamedResult struct {
+	neo4j.ResultWithContext
+	size    int
+	pulled  int
+	current *neo4j.Record
+}
+
+func (r *streamedResult) Keys() ([]string, error) {
+	return []string{"i"}, nil
+}
+
+func (r *streamedResult) NextRecord(_ context.Context, record **neo4j.Record) bool {
+	if r.pulled >= r.size {
+		r.current = nil
+		*record = nil
+		return false
+	}
+	r.pulled++
+	r.current = &neo4j.Record{Keys: []string{"i"}, Values: []any{int64(r.pulled)}}
+	*record = r.current
+	return true
+}
+
+func (r *streamedResult) Next(ctx context.Context) bool {
+	return r.NextRecord(ctx, &r.current)
+}
+
+func (r *streamedResult) Peek(context.Context) bool {
+	return r.pulled < r.size
+}
+
+func (r *streamedResult) Record() *neo4j.Record {
+	return r.current
+}
+
+func (r *streamedResult) Err() error {
+	return nil
+}
+
+func (r *streamedResult) Consume(context.Context) (neo4j.ResultSummary, error) {
+	r.pulled = r.size
+	return nil, nil
+}
+
+func (r *streamedResult) IsOpen() bool {
+	return r.pulled < r.size
+}
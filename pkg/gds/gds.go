@@ -0,0 +1,289 @@
+// Package gds runs Graph Data Science algorithms through the resilient driver:
+//
+//	client := gds.New(d)
+//	_, err := client.Project(ctx, gds.Projection{Name: "people", Nodes: "Person", Relationships: "KNOWS"})
+//	scores, err := client.PageRank(ctx, "people", nil)
+//	summary, err := client.Execute(ctx, gds.Louvain, gds.ModeWrite, "people", map[string]interface{}{"writeProperty": "community"})
+//	err = client.Drop(ctx, "people")
+//
+// long-running algorithms are tracked with Jobs, matching the jobId set in their configuration.
+// every algorithm runs in the stream, write, mutate or stats mode, see Execute and Stream.
+// GDS is a server plugin, calls fail with an error matching ErrNotInstalled when the server does not provide it.
+package gds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"regexp"
+)
+
+// ErrNotInstalled is matched by the errors of the calls to GDS procedures the server does not provide
+var ErrNotInstalled = errors.New("GDS is not installed")
+
+// NotInstalledError is returned for the calls to GDS procedures the server does not provide
+type NotInstalledError struct {
+	Procedure string
+	Err       error
+}
+
+func (e *NotInstalledError) Error() string {
+	return fmt.Sprintf("%s: %s is not available: %v", ErrNotInstalled.Error(), e.Procedure, e.Err)
+}
+
+func (e *NotInstalledError) Is(target error) bool {
+	return target == ErrNotInstalled
+}
+
+func (e *NotInstalledError) Unwrap() error {
+	return e.Err
+}
+
+// Algorithm is the procedure name of an algorithm, without its mode
+type Algorithm string
+
+const (
+	PageRank       Algorithm = "gds.pageRank"
+	Louvain        Algorithm = "gds.louvain"
+	NodeSimilarity Algorithm = "gds.nodeSimilarity"
+)
+
+// Mode is the execution mode of an algorithm
+type Mode string
+
+const (
+	// ModeStream returns the results, see Stream
+	ModeStream Mode = "stream"
+	// ModeWrite stores the results in the database, under the property set by the writeProperty configuration
+	ModeWrite Mode = "write"
+	// ModeMutate stores the results in the projected graph, under the property set by the mutateProperty configuration
+	ModeMutate Mode = "mutate"
+	// ModeStats only computes statistics about the results
+	ModeStats Mode = "stats"
+)
+
+// procedurePattern restricts the algorithm names concatenated to the queries
+var procedurePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(\.[A-Za-z][A-Za-z0-9]*)*$`)
+
+// Client runs GDS procedures through a runner, typically a *driver.Driver
+type Client struct {
+	runner driver.QueryRunner
+}
+
+// New creates a client running its queries through runner
+func New(runner driver.QueryRunner) *Client {
+	return &Client{runner: runner}
+}
+
+// Projection describes a graph projected in memory with gds.graph.project
+type Projection struct {
+	Name string
+	// Nodes is the node projection, e.g. a label, a list of labels or a map of projections. "*" projects all the nodes
+	Nodes interface{}
+	// Relationships is the relationship projection, e.g. a type, a list of types or a map of projections. "*" projects all the relationships
+	Relationships interface{}
+	Config        map[string]interface{}
+}
+
+// ProjectedGraph summarizes a projection
+type ProjectedGraph struct {
+	Name              string `neo4j:"graphName"`
+	NodeCount         int64  `neo4j:"nodeCount"`
+	RelationshipCount int64  `neo4j:"relationshipCount"`
+	ProjectMillis     int64  `neo4j:"projectMillis"`
+}
+
+// Project projects a graph in memory, the algorithms run against it by name
+func (c *Client) Project(ctx context.Context, projection Projection) (ProjectedGraph, error) {
+	var graph ProjectedGraph
+	params := map[string]interface{}{
+		"name":          projection.Name,
+		"nodes":         projection.Nodes,
+		"relationships": projection.Relationships,
+		"config":        emptyIfNil(projection.Config),
+	}
+	err := c.runner.ExecuteQuery(ctx, "CALL gds.graph.project($name, $nodes, $relationships, $config) "+
+		"YIELD graphName, nodeCount, relationshipCount, projectMillis "+
+		"RETURN graphName, nodeCount, relationshipCount, projectMillis", params, func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(ctx)
+		if err != nil {
+			return err
+		}
+		return driver.DecodeRecord(record, &graph)
+	}, driver.WithQueryName("gds.graph.project"))
+	return graph, notInstalled("gds.graph.project", err)
+}
+
+// Exists reports whether a graph with this name is projected
+func (c *Client) Exists(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	err := c.runner.ExecuteQuery(ctx, "CALL gds.graph.exists($name) YIELD exists RETURN exists", map[string]interface{}{"name": name}, func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(ctx)
+		if err != nil {
+			return err
+		}
+		exists, _ = record.Values[0].(bool)
+		return nil
+	}, driver.WithQueryName("gds.graph.exists"), driver.WithAccessMode(neo4j.AccessModeRead))
+	return exists, notInstalled("gds.graph.exists", err)
+}
+
+// Drop releases a projected graph, if it exists
+func (c *Client) Drop(ctx context.Context, name string) error {
+	err := c.runner.ExecuteQuery(ctx, "CALL gds.graph.drop($name, false) YIELD graphName RETURN graphName", map[string]interface{}{"name": name}, func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(ctx)
+		return err
+	}, driver.WithQueryName("gds.graph.drop"))
+	return notInstalled("gds.graph.drop", err)
+}
+
+// Summary is the outcome of an algorithm run in the write, mutate or stats mode
+type Summary struct {
+	NodePropertiesWritten int64 `neo4j:"nodePropertiesWritten"`
+	RelationshipsWritten  int64 `neo4j:"relationshipsWritten"`
+	PreProcessingMillis   int64 `neo4j:"preProcessingMillis"`
+	ComputeMillis         int64 `neo4j:"computeMillis"`
+	WriteMillis           int64 `neo4j:"writeMillis"`
+	MutateMillis          int64 `neo4j:"mutateMillis"`
+	PostProcessingMillis  int64 `neo4j:"postProcessingMillis"`
+	// Values holds all the columns returned by the algorithm, e.g. the communityCount of Louvain
+	Values map[string]interface{} `neo4j:"-"`
+}
+
+// Execute runs the algorithm against the projected graph in the write, mutate or stats mode, see Stream for the stream mode
+func (c *Client) Execute(ctx context.Context, algorithm Algorithm, mode Mode, graph string, config map[string]interface{}) (Summary, error) {
+	if mode == ModeStream {
+		return Summary{}, errors.New("stream mode results are read with Stream")
+	}
+	procedure, err := procedureName(algorithm, mode)
+	if err != nil {
+		return Summary{}, err
+	}
+	var summary Summary
+	err = c.runner.ExecuteQuery(ctx, "CALL "+procedure+"($graph, $config)", algorithmParams(graph, config), func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(ctx)
+		if err != nil {
+			return err
+		}
+		summary.Values = make(map[string]interface{}, len(record.Keys))
+		for i, key := range record.Keys {
+			summary.Values[key] = record.Values[i]
+		}
+		return driver.DecodeRecord(record, &summary)
+	}, driver.WithQueryName(procedure))
+	return summary, notInstalled(procedure, err)
+}
+
+// Stream runs the algorithm against the projected graph in the stream mode and decodes its rows into T, see driver.CollectAs
+func Stream[T any](ctx context.Context, client *Client, algorithm Algorithm, graph string, config map[string]interface{}) ([]T, error) {
+	procedure, err := procedureName(algorithm, ModeStream)
+	if err != nil {
+		return nil, err
+	}
+	var rows []T
+	err = client.runner.ExecuteQuery(ctx, "CALL "+procedure+"($graph, $config)", algorithmParams(graph, config), func(result neo4j.ResultWithContext) error {
+		var err error
+		rows, err = driver.CollectAs[T](ctx, result)
+		return err
+	}, driver.WithQueryName(procedure), driver.WithAccessMode(neo4j.AccessModeRead))
+	return rows, notInstalled(procedure, err)
+}
+
+// Score is a row streamed by PageRank
+type Score struct {
+	NodeID int64   `neo4j:"nodeId"`
+	Score  float64 `neo4j:"score"`
+}
+
+// Community is a row streamed by Louvain
+type Community struct {
+	NodeID      int64 `neo4j:"nodeId"`
+	CommunityID int64 `neo4j:"communityId"`
+}
+
+// Similarity is a row streamed by NodeSimilarity
+type Similarity struct {
+	Node1      int64   `neo4j:"node1"`
+	Node2      int64   `neo4j:"node2"`
+	Similarity float64 `neo4j:"similarity"`
+}
+
+// PageRank streams the PageRank score of every node of the projected graph
+func (c *Client) PageRank(ctx context.Context, graph string, config map[string]interface{}) ([]Score, error) {
+	return Stream[Score](ctx, c, PageRank, graph, config)
+}
+
+// Louvain streams the community of every node of the projected graph
+func (c *Client) Louvain(ctx context.Context, graph string, config map[string]interface{}) ([]Community, error) {
+	return Stream[Community](ctx, c, Louvain, graph, config)
+}
+
+// NodeSimilarity streams the similarity of the pairs of nodes of the projected graph sharing neighbors
+func (c *Client) NodeSimilarity(ctx context.Context, graph string, config map[string]interface{}) ([]Similarity, error) {
+	return Stream[Similarity](ctx, c, NodeSimilarity, graph, config)
+}
+
+// Job is the progress of a running GDS task
+type Job struct {
+	JobID       string `neo4j:"jobId"`
+	TaskName    string `neo4j:"taskName"`
+	Progress    string `neo4j:"progress"`
+	Status      string `neo4j:"status"`
+	ElapsedTime string `neo4j:"elapsedTime"`
+}
+
+// Jobs lists the running GDS tasks of the current user
+func (c *Client) Jobs(ctx context.Context) ([]Job, error) {
+	return c.jobs(ctx, "CALL gds.listProgress() YIELD jobId, taskName, progress, status, elapsedTime "+
+		"RETURN jobId, taskName, progress, status, toString(elapsedTime) AS elapsedTime", nil)
+}
+
+// Job reports the progress of the running task with this id, found is false once it has completed
+func (c *Client) Job(ctx context.Context, jobID string) (job Job, found bool, err error) {
+	jobs, err := c.jobs(ctx, "CALL gds.listProgress($jobId) YIELD jobId, taskName, progress, status, elapsedTime "+
+		"RETURN jobId, taskName, progress, status, toString(elapsedTime) AS elapsedTime LIMIT 1", map[string]interface{}{"jobId": jobID})
+	if err != nil || len(jobs) == 0 {
+		return Job{}, false, err
+	}
+	return jobs[0], true, nil
+}
+
+func (c *Client) jobs(ctx context.Context, query string, params map[string]interface{}) ([]Job, error) {
+	var jobs []Job
+	err := c.runner.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) error {
+		var err error
+		jobs, err = driver.CollectAs[Job](ctx, result)
+		return err
+	}, driver.WithQueryName("gds.listProgress"), driver.WithAccessMode(neo4j.AccessModeRead))
+	return jobs, notInstalled("gds.listProgress", err)
+}
+
+func procedureName(algorithm Algorithm, mode Mode) (string, error) {
+	procedure := string(algorithm) + "." + string(mode)
+	if !procedurePattern.MatchString(procedure) {
+		return "", fmt.Errorf("invalid GDS procedure name %q", procedure)
+	}
+	return procedure, nil
+}
+
+func algorithmParams(graph string, config map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"graph": graph, "config": emptyIfNil(config)}
+}
+
+func emptyIfNil(config map[string]interface{}) map[string]interface{} {
+	if config == nil {
+		return map[string]interface{}{}
+	}
+	return config
+}
+
+// notInstalled turns the errors of the server about unknown procedures into a *NotInstalledError
+func notInstalled(procedure string, err error) error {
+	var neo4jErr *neo4j.Neo4jError
+	if errors.As(err, &neo4jErr) && neo4jErr.Code == "Neo.ClientError.Procedure.ProcedureNotFound" {
+		return &NotInstalledError{Procedure: procedure, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,129 @@
+package gds_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg/gds"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestGds(t *testing.T) {
+	suite.Run(t, new(GdsTestSuite))
+}
+
+type GdsTestSuite struct {
+	suite.Suite
+	ctx    context.Context
+	mock   *MockDriver
+	client *gds.Client
+}
+
+func (s *GdsTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mock = NewMockDriver()
+	s.client = gds.New(s.mock)
+}
+
+func (s *GdsTestSuite) TestProjectsAndDropsGraphs() {
+	s.mock.ExpectQuery(`^CALL gds\.graph\.project\(`).WithParams(map[string]interface{}{
+		"name":          "people",
+		"nodes":         "Person",
+		"relationships": []string{"KNOWS"},
+		"config":        map[string]interface{}{},
+	}).WillReturn([]string{"graphName", "nodeCount", "relationshipCount", "projectMillis"}, []any{"people", int64(3), int64(2), int64(12)})
+	s.mock.ExpectQuery(`^CALL gds\.graph\.exists`).WillReturn([]string{"exists"}, []any{true})
+	s.mock.ExpectQuery(`^CALL gds\.graph\.drop\(\$name, false\)`).WillReturn([]string{"graphName"}, []any{"people"})
+
+	graph, err := s.client.Project(s.ctx, gds.Projection{Name: "people", Nodes: "Person", Relationships: []string{"KNOWS"}})
+	s.Require().NoError(err)
+	exists, err := s.client.Exists(s.ctx, "people")
+	s.Require().NoError(err)
+
+	s.NoError(s.client.Drop(s.ctx, "people"))
+	s.Equal(gds.ProjectedGraph{Name: "people", NodeCount: 3, RelationshipCount: 2, ProjectMillis: 12}, graph)
+	s.True(exists)
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *GdsTestSuite) TestStreamsAlgorithmResults() {
+	s.mock.ExpectQuery(`^CALL gds\.pageRank\.stream\(\$graph, \$config\)$`).WithParams(map[string]interface{}{
+		"graph":  "people",
+		"config": map[string]interface{}{"maxIterations": 20},
+	}).WillReturn([]string{"nodeId", "score"}, []any{int64(1), 0.5}, []any{int64(2), 1.5})
+	s.mock.ExpectQuery(`^CALL gds\.louvain\.stream`).WillReturn([]string{"nodeId", "communityId"}, []any{int64(1), int64(7)})
+	s.mock.ExpectQuery(`^CALL gds\.nodeSimilarity\.stream`).WillReturn([]string{"node1", "node2", "similarity"}, []any{int64(1), int64(2), 0.25})
+
+	scores, err := s.client.PageRank(s.ctx, "people", map[string]interface{}{"maxIterations": 20})
+	s.Require().NoError(err)
+	communities, err := s.client.Louvain(s.ctx, "people", nil)
+	s.Require().NoError(err)
+	similarities, err := s.client.NodeSimilarity(s.ctx, "people", nil)
+	s.Require().NoError(err)
+
+	s.Equal([]gds.Score{{NodeID: 1, Score: 0.5}, {NodeID: 2, Score: 1.5}}, scores)
+	s.Equal([]gds.Community{{NodeID: 1, CommunityID: 7}}, communities)
+	s.Equal([]gds.Similarity{{Node1: 1, Node2: 2, Similarity: 0.25}}, similarities)
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *GdsTestSuite) TestSummarizesWriteAndMutateRuns() {
+	s.mock.ExpectQuery(`^CALL gds\.louvain\.write\(`).WillReturn(
+		[]string{"nodePropertiesWritten", "communityCount", "computeMillis", "writeMillis"},
+		[]any{int64(3), int64(2), int64(40), int64(5)},
+	)
+	s.mock.ExpectQuery(`^CALL gds\.pageRank\.mutate\(`).WillReturn([]string{"nodePropertiesWritten", "mutateMillis"}, []any{int64(3), int64(1)})
+
+	written, err := s.client.Execute(s.ctx, gds.Louvain, gds.ModeWrite, "people", map[string]interface{}{"writeProperty": "community"})
+	s.Require().NoError(err)
+	mutated, err := s.client.Execute(s.ctx, gds.PageRank, gds.ModeMutate, "people", map[string]interface{}{"mutateProperty": "rank"})
+	s.Require().NoError(err)
+
+	s.Equal(int64(3), written.NodePropertiesWritten)
+	s.Equal(int64(40), written.ComputeMillis)
+	s.Equal(int64(5), written.WriteMillis)
+	s.Equal(int64(2), written.Values["communityCount"])
+	s.Equal(int64(1), mutated.MutateMillis)
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *GdsTestSuite) TestRejectsInvalidProcedures() {
+	_, err := s.client.Execute(s.ctx, gds.Algorithm("gds.pageRank(); MATCH (n) DETACH DELETE n //"), gds.ModeWrite, "people", nil)
+	s.ErrorContains(err, "invalid GDS procedure name")
+
+	_, err = s.client.Execute(s.ctx, gds.PageRank, gds.ModeStream, "people", nil)
+	s.EqualError(err, "stream mode results are read with Stream")
+}
+
+func (s *GdsTestSuite) TestTracksRunningJobs() {
+	s.mock.ExpectQuery(`^CALL gds\.listProgress\(\) `).WillReturn(
+		[]string{"jobId", "taskName", "progress", "status", "elapsedTime"},
+		[]any{"job-1", "Louvain", "42%", "RUNNING", "PT3S"},
+	)
+	s.mock.ExpectQuery(`^CALL gds\.listProgress\(\$jobId\)`).WithParams(map[string]interface{}{"jobId": "job-2"}).
+		WillReturn([]string{"jobId", "taskName", "progress", "status", "elapsedTime"})
+
+	jobs, err := s.client.Jobs(s.ctx)
+	s.Require().NoError(err)
+	_, found, err := s.client.Job(s.ctx, "job-2")
+	s.Require().NoError(err)
+
+	s.Equal([]gds.Job{{JobID: "job-1", TaskName: "Louvain", Progress: "42%", Status: "RUNNING", ElapsedTime: "PT3S"}}, jobs)
+	s.False(found)
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *GdsTestSuite) TestFailsWithATypedErrorWhenGdsIsMissing() {
+	s.mock.ExpectQuery(`^CALL gds\.pageRank\.stream`).WillReturnError(&neo4j.Neo4jError{
+		Code: "Neo.ClientError.Procedure.ProcedureNotFound",
+		Msg:  "There is no procedure with the name `gds.pageRank.stream` registered for this database instance",
+	})
+
+	_, err := s.client.PageRank(s.ctx, "people", nil)
+
+	s.ErrorIs(err, gds.ErrNotInstalled)
+	var notInstalled *gds.NotInstalledError
+	s.Require().ErrorAs(err, &notInstalled)
+	s.Equal("gds.pageRank.stream", notInstalled.Procedure)
+}
@@ -0,0 +1,39 @@
+package driver
+
+import (
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"sync/atomic"
+)
+
+// driverGeneration is an underlying driver along with its generation, numbered from 0 and incremented every time
+// a recovery or a cutover replaces the driver. it is published through an atomic pointer, so that queries read the current
+// driver without locking while a recovery swaps it, and recoveries tell whether the driver that failed a query was already replaced
+type driverGeneration struct {
+	driver neo4j.DriverWithContext
+	number uint64
+	// config creates the drivers replacing this one on reconnects
+	config underlyingConfig
+	// inFlight counts the queries using driver, so that a cutover closes it once they are done
+	inFlight atomic.Int64
+}
+
+// currentGeneration returns the underlying driver queries must use
+func (d *Driver) currentGeneration() *driverGeneration {
+	return d.current.Load()
+}
+
+// acquireGeneration returns the current generation, counting the caller in its in-flight queries until release is called
+func (d *Driver) acquireGeneration() (generation *driverGeneration, release func()) {
+	generation = d.currentGeneration()
+	generation.inFlight.Add(1)
+	return generation, func() {
+		generation.inFlight.Add(-1)
+	}
+}
+
+// swapDriver publishes driver, created with config, as the next generation and returns the previous one, recoveryLock must be held
+func (d *Driver) swapDriver(driver neo4j.DriverWithContext, config underlyingConfig) *driverGeneration {
+	previous := d.current.Load()
+	d.current.Store(&driverGeneration{driver: driver, number: previous.number + 1, config: config})
+	return previous
+}
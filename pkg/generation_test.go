@@ -0,0 +1,85 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"sync"
+	"testing"
+	"time"
+)
+
+// run with -race: the swaps of the underlying driver must not race with the queries reading it
+func TestGeneration(t *testing.T) {
+	suite.Run(t, new(GenerationTestSuite))
+}
+
+type GenerationTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	restore func()
+}
+
+func (s *GenerationTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+}
+
+func (s *GenerationTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *GenerationTestSuite) TestReplacesTheDriverOncePerOutage() {
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	s.cluster.disconnect()
+
+	s.runConcurrently(16, func() {
+		s.NoError(executeSimpleQuery(s.ctx, driver))
+	})
+
+	s.Equal(uint64(1), driver.Generation(), "the queries finding a newer generation must not reconnect again")
+	s.Equal(2, s.cluster.drivers())
+}
+
+func (s *GenerationTestSuite) TestSwapsTheDriverUnderConcurrentQueries() {
+	settings := connectionSettings
+	settings.MaxAttempts = 100
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			s.cluster.disconnect()
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	s.runConcurrently(8, func() {
+		for i := 0; i < 20; i++ {
+			s.NoError(executeSimpleQuery(s.ctx, driver))
+		}
+	})
+	<-done
+
+	s.NoError(executeSimpleQuery(s.ctx, driver))
+	s.Equal(uint64(s.cluster.drivers()-1), driver.Generation())
+	s.NoError(driver.VerifyConnectivity(s.ctx))
+}
+
+func (s *GenerationTestSuite) runConcurrently(goroutines int, work func()) {
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			work()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,175 @@
+package driver
+
+import (
+	"context"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Graph is an in-memory graph made of the nodes and relationships returned by queries, deduplicated by element id,
+// e.g. for visualization layers or in-memory graph algorithms.
+// relationships are kept even when their start or end node was not returned
+type Graph struct {
+	// Nodes and Relationships are keyed by element id
+	Nodes         map[string]neo4j.Node
+	Relationships map[string]neo4j.Relationship
+	// Outgoing and Incoming map the element id of a node to the element ids of its relationships, in the order they were added
+	Outgoing, Incoming map[string][]string
+
+	nodeIDs, relationshipIDs []string
+	labels                   map[string][]string
+	types                    map[string][]string
+}
+
+// NewGraph creates an empty graph
+func NewGraph() *Graph {
+	return &Graph{
+		Nodes:         map[string]neo4j.Node{},
+		Relationships: map[string]neo4j.Relationship{},
+		Outgoing:      map[string][]string{},
+		Incoming:      map[string][]string{},
+		labels:        map[string][]string{},
+		types:         map[string][]string{},
+	}
+}
+
+// ExecuteGraph runs the query and builds a Graph from the nodes, relationships and paths it returns,
+// wherever they are in the records, including within lists and maps
+func (d *Driver) ExecuteGraph(ctx context.Context, query string, params map[string]interface{}, opts ...QueryOption) (*Graph, error) {
+	var graph *Graph
+	err := d.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) error {
+		var err error
+		graph, err = CollectGraph(ctx, result)
+		return err
+	}, opts...)
+	return graph, err
+}
+
+// CollectGraph reads all the remaining records into a Graph, see ExecuteGraph
+func CollectGraph(ctx context.Context, result RecordIterator) (*Graph, error) {
+	graph := NewGraph()
+	var record *neo4j.Record
+	for result.NextRecord(ctx, &record) {
+		graph.AddRecord(record)
+	}
+	return graph, result.Err()
+}
+
+// AddRecord adds the nodes, relationships and paths of the record values to the graph
+func (g *Graph) AddRecord(record *neo4j.Record) {
+	for _, value := range record.Values {
+		g.Add(value)
+	}
+}
+
+// Add adds a node, a relationship or the elements of a path to the graph, looking into lists and maps.
+// the other values are ignored
+func (g *Graph) Add(value interface{}) {
+	switch value := value.(type) {
+	case neo4j.Node:
+		g.addNode(value)
+	case neo4j.Relationship:
+		g.addRelationship(value)
+	case neo4j.Path:
+		for _, node := range value.Nodes {
+			g.addNode(node)
+		}
+		for _, relationship := range value.Relationships {
+			g.addRelationship(relationship)
+		}
+	case []interface{}:
+		for _, element := range value {
+			g.Add(element)
+		}
+	case map[string]interface{}:
+		for _, key := range sortedKeys(value) {
+			g.Add(value[key])
+		}
+	}
+}
+
+func (g *Graph) addNode(node neo4j.Node) {
+	if _, found := g.Nodes[node.ElementId]; found {
+		return
+	}
+	g.Nodes[node.ElementId] = node
+	g.nodeIDs = append(g.nodeIDs, node.ElementId)
+	for _, label := range node.Labels {
+		g.labels[label] = append(g.labels[label], node.ElementId)
+	}
+}
+
+func (g *Graph) addRelationship(relationship neo4j.Relationship) {
+	if _, found := g.Relationships[relationship.ElementId]; found {
+		return
+	}
+	g.Relationships[relationship.ElementId] = relationship
+	g.relationshipIDs = append(g.relationshipIDs, relationship.ElementId)
+	g.types[relationship.Type] = append(g.types[relationship.Type], relationship.ElementId)
+	g.Outgoing[relationship.StartElementId] = append(g.Outgoing[relationship.StartElementId], relationship.ElementId)
+	g.Incoming[relationship.EndElementId] = append(g.Incoming[relationship.EndElementId], relationship.ElementId)
+}
+
+// NodeList returns the nodes in the order they were added
+func (g *Graph) NodeList() []neo4j.Node {
+	return g.nodes(g.nodeIDs)
+}
+
+// RelationshipList returns the relationships in the order they were added
+func (g *Graph) RelationshipList() []neo4j.Relationship {
+	return g.relationships(g.relationshipIDs)
+}
+
+// NodesByLabel returns the nodes having label, in the order they were added
+func (g *Graph) NodesByLabel(label string) []neo4j.Node {
+	return g.nodes(g.labels[label])
+}
+
+// RelationshipsByType returns the relationships of type relationshipType, in the order they were added
+func (g *Graph) RelationshipsByType(relationshipType string) []neo4j.Relationship {
+	return g.relationships(g.types[relationshipType])
+}
+
+// OutgoingRelationships returns the relationships starting at the node
+func (g *Graph) OutgoingRelationships(nodeID string) []neo4j.Relationship {
+	return g.relationships(g.Outgoing[nodeID])
+}
+
+// IncomingRelationships returns the relationships ending at the node
+func (g *Graph) IncomingRelationships(nodeID string) []neo4j.Relationship {
+	return g.relationships(g.Incoming[nodeID])
+}
+
+// Neighbors returns the nodes of the graph linked to the node by a relationship in either direction, once each
+func (g *Graph) Neighbors(nodeID string) []neo4j.Node {
+	var neighbors []neo4j.Node
+	seen := map[string]bool{}
+	add := func(id string) {
+		if node, found := g.Nodes[id]; found && !seen[id] {
+			seen[id] = true
+			neighbors = append(neighbors, node)
+		}
+	}
+	for _, id := range g.Outgoing[nodeID] {
+		add(g.Relationships[id].EndElementId)
+	}
+	for _, id := range g.Incoming[nodeID] {
+		add(g.Relationships[id].StartElementId)
+	}
+	return neighbors
+}
+
+func (g *Graph) nodes(ids []string) []neo4j.Node {
+	nodes := make([]neo4j.Node, len(ids))
+	for i, id := range ids {
+		nodes[i] = g.Nodes[id]
+	}
+	return nodes
+}
+
+func (g *Graph) relationships(ids []string) []neo4j.Relationship {
+	relationships := make([]neo4j.Relationship, len(ids))
+	for i, id := range ids {
+		relationships[i] = g.Relationships[id]
+	}
+	return relationships
+}
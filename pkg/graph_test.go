@@ -0,0 +1,75 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestGraph(t *testing.T) {
+	suite.Run(t, new(GraphTestSuite))
+}
+
+type GraphTestSuite struct {
+	suite.Suite
+	ctx                context.Context
+	alice, bob, carol  neo4j.Node
+	knows, worksWith   neo4j.Relationship
+	managedBy, orphans neo4j.Relationship
+}
+
+func (s *GraphTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.alice = neo4j.Node{ElementId: "n1", Labels: []string{"Person", "Admin"}, Props: map[string]any{"name": "alice"}}
+	s.bob = neo4j.Node{ElementId: "n2", Labels: []string{"Person"}, Props: map[string]any{"name": "bob"}}
+	s.carol = neo4j.Node{ElementId: "n3", Labels: []string{"Person"}, Props: map[string]any{"name": "carol"}}
+	s.knows = neo4j.Relationship{ElementId: "r1", Type: "KNOWS", StartElementId: "n1", EndElementId: "n2"}
+	s.worksWith = neo4j.Relationship{ElementId: "r2", Type: "WORKS_WITH", StartElementId: "n2", EndElementId: "n3"}
+	s.managedBy = neo4j.Relationship{ElementId: "r3", Type: "MANAGED_BY", StartElementId: "n3", EndElementId: "n1"}
+	s.orphans = neo4j.Relationship{ElementId: "r4", Type: "KNOWS", StartElementId: "n1", EndElementId: "n9"}
+}
+
+func (s *GraphTestSuite) TestBuildsTheGraphFromNodesRelationshipsAndPaths() {
+	records := newFakeRecords([]string{"path", "extra"},
+		[]any{neo4j.Path{Nodes: []neo4j.Node{s.alice, s.bob, s.carol}, Relationships: []neo4j.Relationship{s.knows, s.worksWith}}, nil},
+		[]any{s.bob, map[string]any{"manager": s.managedBy, "peers": []any{s.alice, s.carol}}},
+	)
+
+	graph, err := CollectGraph(s.ctx, records)
+
+	s.Require().NoError(err)
+	s.Equal([]neo4j.Node{s.alice, s.bob, s.carol}, graph.NodeList())
+	s.Equal([]neo4j.Relationship{s.knows, s.worksWith, s.managedBy}, graph.RelationshipList())
+	s.Equal(map[string][]string{"n1": {"r1"}, "n2": {"r2"}, "n3": {"r3"}}, graph.Outgoing)
+	s.Equal(map[string][]string{"n2": {"r1"}, "n3": {"r2"}, "n1": {"r3"}}, graph.Incoming)
+}
+
+func (s *GraphTestSuite) TestLooksUpNodesAndRelationships() {
+	graph := NewGraph()
+	for _, value := range []any{s.alice, s.bob, s.carol, s.knows, s.worksWith, s.managedBy, s.orphans} {
+		graph.Add(value)
+	}
+
+	s.Equal([]neo4j.Node{s.alice}, graph.NodesByLabel("Admin"))
+	s.Equal([]neo4j.Node{s.alice, s.bob, s.carol}, graph.NodesByLabel("Person"))
+	s.Empty(graph.NodesByLabel("Robot"))
+	s.Equal([]neo4j.Relationship{s.knows, s.orphans}, graph.RelationshipsByType("KNOWS"))
+	s.Equal([]neo4j.Relationship{s.knows, s.orphans}, graph.OutgoingRelationships("n1"))
+	s.Equal([]neo4j.Relationship{s.managedBy}, graph.IncomingRelationships("n1"))
+	s.Equal([]neo4j.Node{s.bob, s.carol}, graph.Neighbors("n1"))
+}
+
+func (s *GraphTestSuite) TestExecutesGraphQueries() {
+	defer UseDriverFactory((&fakeCluster{}).newDriver)()
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	graph, err := driver.ExecuteGraph(s.ctx, "RETURN true AS ok", nil)
+
+	s.Require().NoError(err)
+	s.Empty(graph.Nodes)
+	s.Empty(graph.Relationships)
+}
@@ -0,0 +1,205 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"strings"
+)
+
+const (
+	defaultMaxPathHops    = 15
+	defaultNeighborsLimit = 1000
+)
+
+// PathDirection restricts the direction of the relationships traversed by the graph algorithms
+type PathDirection int
+
+const (
+	BothDirections PathDirection = iota
+	Outgoing
+	Incoming
+)
+
+// TraversalFilter restricts the traversals of the graph algorithms, its zero value traverses everything
+type TraversalFilter struct {
+	// RelationshipTypes are the traversed relationship types, all of them when empty
+	RelationshipTypes []string
+	Direction         PathDirection
+	// ExcludeLabels are the labels of the nodes a traversal must not go through
+	ExcludeLabels []string
+	// MaxHops bounds the length of the shortest paths, defaults to 15. the k of KHopNeighborhood bounds the other traversals
+	MaxHops int
+	// Limit bounds the number of neighbors returned, defaults to 1000
+	Limit int
+}
+
+// Neighbor is a node reached by KHopNeighborhood, along with its distance from the start node
+type Neighbor struct {
+	Node neo4j.Node
+	Hops int64
+}
+
+// ShortestPathQuery generates the query run by ShortestPath
+func ShortestPathQuery(from, to NodeMatch, filter TraversalFilter) (string, map[string]interface{}) {
+	params := map[string]interface{}{}
+	maxHops := filter.MaxHops
+	if maxHops <= 0 {
+		maxHops = defaultMaxPathHops
+	}
+	query := strings.Builder{}
+	query.WriteString(matchNode("a", "from", from, params) + " " + matchNode("b", "to", to, params))
+	// shortestPath fails when both ends are the same node
+	query.WriteString(" WITH a, b WHERE a <> b")
+	query.WriteString(fmt.Sprintf(" MATCH path = shortestPath((a)%s(b))", filter.relationshipPattern(fmt.Sprintf("*..%d", maxHops))))
+	if exclusion := filter.exclusion("nodes(path)"); exclusion != "" {
+		query.WriteString(" WHERE " + exclusion)
+	}
+	query.WriteString(" RETURN path")
+	return query.String(), params
+}
+
+// ShortestPath finds a shortest path between the nodes identified by from and to, it returns nil when there is none.
+// the exclusions of filter are evaluated while searching, not on the path found
+func (d *Driver) ShortestPath(ctx context.Context, from, to NodeMatch, filter TraversalFilter, opts ...QueryOption) (*neo4j.Path, error) {
+	query, params := ShortestPathQuery(from, to, filter)
+	var path *neo4j.Path
+	err := d.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		if result.NextRecord(ctx, &record) {
+			if found, ok := record.Values[0].(neo4j.Path); ok {
+				path = &found
+			}
+		}
+		return result.Err()
+	}, readOptions("graph.shortest-path", opts)...)
+	return path, err
+}
+
+// KHopQuery generates the query run by KHopNeighborhood
+func KHopQuery(start NodeMatch, k int, filter TraversalFilter) (string, map[string]interface{}) {
+	params := map[string]interface{}{"limit": filter.limit()}
+	query := strings.Builder{}
+	query.WriteString(matchNode("s", "start", start, params))
+	query.WriteString(fmt.Sprintf(" MATCH path = (s)%s(m) WHERE m <> s", filter.relationshipPattern(fmt.Sprintf("*1..%d", k))))
+	if exclusion := filter.exclusion("nodes(path)"); exclusion != "" {
+		query.WriteString(" AND " + exclusion)
+	}
+	query.WriteString(" RETURN m AS node, min(length(path)) AS hops ORDER BY hops LIMIT $limit")
+	return query.String(), params
+}
+
+// KHopNeighborhood returns the nodes at most k hops away from the node identified by start, closest first.
+// the number of paths grows exponentially with k, keep it small on dense graphs
+func (d *Driver) KHopNeighborhood(ctx context.Context, start NodeMatch, k int, filter TraversalFilter, opts ...QueryOption) ([]Neighbor, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive, got %d", k)
+	}
+	query, params := KHopQuery(start, k, filter)
+	var neighbors []Neighbor
+	err := d.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for result.NextRecord(ctx, &record) {
+			node, _ := record.Values[0].(neo4j.Node)
+			hops, _ := record.Values[1].(int64)
+			neighbors = append(neighbors, Neighbor{Node: node, Hops: hops})
+		}
+		return result.Err()
+	}, readOptions("graph.k-hop", opts)...)
+	return neighbors, err
+}
+
+// CommonNeighborsQuery generates the query run by CommonNeighbors
+func CommonNeighborsQuery(a, b NodeMatch, filter TraversalFilter) (string, map[string]interface{}) {
+	params := map[string]interface{}{"limit": filter.limit()}
+	query := strings.Builder{}
+	query.WriteString(matchNode("a", "a", a, params) + " " + matchNode("b", "b", b, params))
+	// with a direction, both nodes point to, or are pointed by, their common neighbors
+	reverse := filter
+	switch filter.Direction {
+	case Outgoing:
+		reverse.Direction = Incoming
+	case Incoming:
+		reverse.Direction = Outgoing
+	}
+	query.WriteString(fmt.Sprintf(" MATCH (a)%s(m)%s(b) WHERE a <> b AND m <> a AND m <> b", filter.relationshipPattern(""), reverse.relationshipPattern("")))
+	if exclusion := filter.exclusion("[m]"); exclusion != "" {
+		query.WriteString(" AND " + exclusion)
+	}
+	query.WriteString(" RETURN DISTINCT m AS node LIMIT $limit")
+	return query.String(), params
+}
+
+// CommonNeighbors returns the nodes directly connected to both nodes identified by a and b
+func (d *Driver) CommonNeighbors(ctx context.Context, a, b NodeMatch, filter TraversalFilter, opts ...QueryOption) ([]neo4j.Node, error) {
+	query, params := CommonNeighborsQuery(a, b, filter)
+	var nodes []neo4j.Node
+	err := d.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for result.NextRecord(ctx, &record) {
+			if node, ok := record.Values[0].(neo4j.Node); ok {
+				nodes = append(nodes, node)
+			}
+		}
+		return result.Err()
+	}, readOptions("graph.common-neighbors", opts)...)
+	return nodes, err
+}
+
+// matchNode returns the MATCH clause of the nodes identified by match, bound to variable
+func matchNode(variable, paramPrefix string, match NodeMatch, params map[string]interface{}) string {
+	clause := "MATCH (" + variable
+	if match.Label != "" {
+		clause += ":" + QuoteIdentifier(match.Label)
+	}
+	clause += ")"
+	if predicates := propertyPredicates(variable, paramPrefix, match.Props, params); len(predicates) > 0 {
+		clause += " WHERE " + strings.Join(predicates, " AND ")
+	}
+	return clause
+}
+
+// relationshipPattern returns the pattern of the traversed relationships, e.g. -[:`KNOWS`*..15]->
+func (f TraversalFilter) relationshipPattern(length string) string {
+	types := make([]string, len(f.RelationshipTypes))
+	for i, relType := range f.RelationshipTypes {
+		types[i] = QuoteIdentifier(relType)
+	}
+	relationship := "["
+	if len(types) > 0 {
+		relationship += ":" + strings.Join(types, "|")
+	}
+	relationship += length + "]"
+	switch f.Direction {
+	case Outgoing:
+		return "-" + relationship + "->"
+	case Incoming:
+		return "<-" + relationship + "-"
+	default:
+		return "-" + relationship + "-"
+	}
+}
+
+// exclusion returns the predicate rejecting the nodes of the excluded labels in list, empty when no label is excluded
+func (f TraversalFilter) exclusion(list string) string {
+	if len(f.ExcludeLabels) == 0 {
+		return ""
+	}
+	labels := make([]string, len(f.ExcludeLabels))
+	for i, label := range f.ExcludeLabels {
+		labels[i] = "n:" + QuoteIdentifier(label)
+	}
+	return fmt.Sprintf("none(n IN %s WHERE %s)", list, strings.Join(labels, " OR "))
+}
+
+func (f TraversalFilter) limit() int64 {
+	if f.Limit <= 0 {
+		return defaultNeighborsLimit
+	}
+	return int64(f.Limit)
+}
+
+// readOptions names the query and runs it in read mode, unless opts say otherwise
+func readOptions(name string, opts []QueryOption) []QueryOption {
+	return append([]QueryOption{WithQueryName(name), WithAccessMode(neo4j.AccessModeRead)}, opts...)
+}
@@ -0,0 +1,54 @@
+package driver_test
+
+import (
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestGraphAlgorithms(t *testing.T) {
+	suite.Run(t, new(GraphAlgorithmsTestSuite))
+}
+
+type GraphAlgorithmsTestSuite struct {
+	suite.Suite
+}
+
+func (s *GraphAlgorithmsTestSuite) TestFindsShortestPathsAvoidingExcludedLabels() {
+	query, params := ShortestPathQuery(
+		NodeMatch{Label: "User", Props: map[string]interface{}{"id": 1}},
+		NodeMatch{Label: "User", Props: map[string]interface{}{"id": 2}},
+		TraversalFilter{RelationshipTypes: []string{"KNOWS", "WORKS_WITH"}, ExcludeLabels: []string{"Banned"}, MaxHops: 6},
+	)
+
+	s.Equal("MATCH (a:`User`) WHERE a.`id` = $from0 MATCH (b:`User`) WHERE b.`id` = $to0 WITH a, b WHERE a <> b"+
+		" MATCH path = shortestPath((a)-[:`KNOWS`|`WORKS_WITH`*..6]-(b)) WHERE none(n IN nodes(path) WHERE n:`Banned`) RETURN path", query)
+	s.Equal(map[string]interface{}{"from0": 1, "to0": 2}, params)
+}
+
+func (s *GraphAlgorithmsTestSuite) TestBoundsShortestPathsByDefault() {
+	query, _ := ShortestPathQuery(NodeMatch{Label: "User"}, NodeMatch{Label: "Team"}, TraversalFilter{Direction: Outgoing})
+
+	s.Equal("MATCH (a:`User`) MATCH (b:`Team`) WITH a, b WHERE a <> b MATCH path = shortestPath((a)-[*..15]->(b)) RETURN path", query)
+}
+
+func (s *GraphAlgorithmsTestSuite) TestExpandsKHopNeighborhoods() {
+	query, params := KHopQuery(NodeMatch{Label: "User", Props: map[string]interface{}{"id": 1}}, 2, TraversalFilter{Direction: Incoming, Limit: 50})
+
+	s.Equal("MATCH (s:`User`) WHERE s.`id` = $start0 MATCH path = (s)<-[*1..2]-(m) WHERE m <> s"+
+		" RETURN m AS node, min(length(path)) AS hops ORDER BY hops LIMIT $limit", query)
+	s.Equal(map[string]interface{}{"start0": 1, "limit": int64(50)}, params)
+}
+
+func (s *GraphAlgorithmsTestSuite) TestMatchesCommonNeighborsInTheSameDirection() {
+	query, params := CommonNeighborsQuery(
+		NodeMatch{Label: "User", Props: map[string]interface{}{"id": 1}},
+		NodeMatch{Label: "User", Props: map[string]interface{}{"id": 2}},
+		TraversalFilter{RelationshipTypes: []string{"FOLLOWS"}, Direction: Outgoing, ExcludeLabels: []string{"Bot"}},
+	)
+
+	s.Equal("MATCH (a:`User`) WHERE a.`id` = $a0 MATCH (b:`User`) WHERE b.`id` = $b0"+
+		" MATCH (a)-[:`FOLLOWS`]->(m)<-[:`FOLLOWS`]-(b) WHERE a <> b AND m <> a AND m <> b AND none(n IN [m] WHERE n:`Bot`)"+
+		" RETURN DISTINCT m AS node LIMIT $limit", query)
+	s.Equal(map[string]interface{}{"a0": 1, "b0": 2, "limit": int64(1000)}, params)
+}
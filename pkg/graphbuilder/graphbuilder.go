@@ -0,0 +1,126 @@
+// Package graphbuilder declaratively builds small graphs, typically test fixtures,
+// and persists them through the resilient driver in a single write:
+//
+//	b := graphbuilder.New()
+//	alice := b.Node("User", graphbuilder.Props{"name": "alice"})
+//	b.Node("User", graphbuilder.Props{"name": "bob"}).RelTo(alice, "KNOWS")
+//	err := b.Persist(ctx, d)
+package graphbuilder
+
+import (
+	"context"
+	"fmt"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"strings"
+)
+
+// Props are the properties of a node or relationship
+type Props = map[string]interface{}
+
+// Builder accumulates nodes and relationships until they are persisted
+type Builder struct {
+	nodes         []*Node
+	relationships []*relationship
+	namespace     driver.Namespace
+}
+
+// Node is a node to create, used to connect it to other nodes
+type Node struct {
+	builder *Builder
+	index   int
+	labels  []string
+	props   Props
+}
+
+type relationship struct {
+	from, to *Node
+	kind     string
+	props    Props
+}
+
+// New creates an empty builder
+func New() *Builder {
+	return &Builder{}
+}
+
+// InNamespace prefixes the labels and relationship types of the graph, typically with the namespace of the driver
+func (b *Builder) InNamespace(namespace driver.Namespace) *Builder {
+	b.namespace = namespace
+	return b
+}
+
+// Node adds a node with the given label and properties
+func (b *Builder) Node(label string, props Props) *Node {
+	node := &Node{builder: b, index: len(b.nodes), labels: []string{label}, props: props}
+	b.nodes = append(b.nodes, node)
+	return node
+}
+
+// WithLabels adds labels to the node
+func (n *Node) WithLabels(labels ...string) *Node {
+	n.labels = append(n.labels, labels...)
+	return n
+}
+
+// RelTo adds a relationship of the given type from n to other, and returns n so that calls can be chained
+func (n *Node) RelTo(other *Node, kind string) *Node {
+	return n.RelToWith(other, kind, nil)
+}
+
+// RelToWith is like RelTo, with relationship properties
+func (n *Node) RelToWith(other *Node, kind string, props Props) *Node {
+	if other.builder != n.builder {
+		panic("graphbuilder: cannot connect nodes of different builders")
+	}
+	n.builder.relationships = append(n.builder.relationships, &relationship{from: n, to: other, kind: kind, props: props})
+	return n
+}
+
+// Query generates the single CREATE statement of the graph and its parameters, one per non-empty property map
+func (b *Builder) Query() (string, map[string]interface{}) {
+	params := map[string]interface{}{}
+	patterns := make([]string, 0, len(b.nodes)+len(b.relationships))
+	for _, node := range b.nodes {
+		labels := make([]string, len(node.labels))
+		for i, label := range node.labels {
+			labels[i] = ":" + driver.QuoteIdentifier(b.namespace.Label(label))
+		}
+		variable := node.variable()
+		patterns = append(patterns, fmt.Sprintf("(%s%s%s)", variable, strings.Join(labels, ""), propsParam(params, variable, node.props)))
+	}
+	for i, rel := range b.relationships {
+		param := fmt.Sprintf("r%d", i)
+		patterns = append(patterns, fmt.Sprintf("(%s)-[:%s%s]->(%s)",
+			rel.from.variable(), driver.QuoteIdentifier(b.namespace.Label(rel.kind)), propsParam(params, param, rel.props), rel.to.variable()))
+	}
+	if len(patterns) == 0 {
+		return "", params
+	}
+	return "CREATE " + strings.Join(patterns, ", "), params
+}
+
+// Persist creates the whole graph in one write. it does nothing when the builder is empty
+func (b *Builder) Persist(ctx context.Context, runner driver.QueryRunner, opts ...driver.QueryOption) error {
+	query, params := b.Query()
+	if query == "" {
+		return nil
+	}
+	opts = append([]driver.QueryOption{driver.WithQueryName("graphbuilder")}, opts...)
+	return runner.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(ctx)
+		return err
+	}, opts...)
+}
+
+func (n *Node) variable() string {
+	return fmt.Sprintf("n%d", n.index)
+}
+
+func propsParam(params map[string]interface{}, name string, props Props) string {
+	if len(props) == 0 {
+		return ""
+	}
+	params[name] = props
+	return " $" + name
+}
@@ -0,0 +1,76 @@
+package graphbuilder_test
+
+import (
+	"context"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg/graphbuilder"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestGraphBuilder(t *testing.T) {
+	suite.Run(t, new(GraphBuilderTestSuite))
+}
+
+type GraphBuilderTestSuite struct {
+	suite.Suite
+}
+
+func (s *GraphBuilderTestSuite) TestGeneratesSingleCreateStatement() {
+	b := New()
+	alice := b.Node("User", Props{"name": "alice"}).WithLabels("Admin")
+	team := b.Node("Team", nil)
+	b.Node("User", Props{"name": "bob"}).RelTo(alice, "KNOWS").RelToWith(team, "MEMBER_OF", Props{"since": 2020})
+
+	query, params := b.Query()
+
+	s.Equal("CREATE (n0:`User`:`Admin` $n0), (n1:`Team`), (n2:`User` $n2), (n2)-[:`KNOWS`]->(n0), (n2)-[:`MEMBER_OF` $r1]->(n1)", query)
+	s.Equal(map[string]interface{}{"n0": Props{"name": "alice"}, "n2": Props{"name": "bob"}, "r1": Props{"since": 2020}}, params)
+}
+
+func (s *GraphBuilderTestSuite) TestPersistsInOneWrite() {
+	runner := &recordingRunner{}
+	b := New()
+	b.Node("User", nil).RelTo(b.Node("User", nil), "KNOWS")
+
+	s.NoError(b.Persist(context.Background(), runner))
+	s.NoError(New().Persist(context.Background(), runner))
+
+	s.Equal([]string{"CREATE (n0:`User`), (n1:`User`), (n0)-[:`KNOWS`]->(n1)"}, runner.queries)
+}
+
+func (s *GraphBuilderTestSuite) TestRefusesToConnectBuilders() {
+	other := New().Node("User", nil)
+
+	s.Panics(func() { New().Node("User", nil).RelTo(other, "KNOWS") })
+}
+
+// recordingRunner records the queries it receives, without results
+type recordingRunner struct {
+	queries []string
+}
+
+func (r *recordingRunner) ExecuteQuery(_ context.Context, query string, _ map[string]interface{}, onResults driver.ResultsHookFn, _ ...driver.QueryOption) error {
+	r.queries = append(r.queries, query)
+	return onResults(emptyResult{})
+}
+
+// emptyResult only implements Consume, the embedded interface is left nil
+type emptyResult struct {
+	neo4j.ResultWithContext
+}
+
+func (emptyResult) Consume(context.Context) (neo4j.ResultSummary, error) {
+	return nil, nil
+}
+
+func (s *GraphBuilderTestSuite) TestPrefixesLabelsWithTheNamespace() {
+	b := New().InNamespace(driver.Namespace{Prefix: "App"})
+	alice := b.Node("User", nil)
+	b.Node("User", nil).RelTo(alice, "KNOWS")
+
+	query, _ := b.Query()
+
+	s.Equal("CREATE (n0:`App_User`), (n1:`App_User`), (n1)-[:`App_KNOWS`]->(n0)", query)
+}
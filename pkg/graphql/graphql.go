@@ -0,0 +1,184 @@
+// Package graphql executes the Cypher translations of GraphQL operations through the resilient driver and shapes their
+// results into the {"data": ..., "errors": [...]} responses GraphQL clients expect.
+//
+// the translation is left to a TranslatorFn, e.g. wrapping the Neo4j GraphQL library running in a sidecar,
+// or to the resolvers themselves, with ExecuteTranslation:
+//
+//	bridge := graphql.NewBridge(d, translate)
+//	http.Handle("/graphql", bridge)
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"net/http"
+)
+
+// Request is a GraphQL operation, as posted by the clients
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Translation is the Cypher statement a root field of a GraphQL operation translates to
+type Translation struct {
+	// Field is the name, or alias, of the root field the results are returned under, e.g. "movies"
+	Field  string
+	Cypher string
+	Params map[string]interface{}
+	// List makes the field a list of all the records, it is the first record, or null, otherwise
+	List bool
+	// Write runs the statement in write mode, e.g. for the fields of mutations. it runs in read mode otherwise
+	Write bool
+}
+
+// TranslatorFn translates a GraphQL operation into the statements of its root fields, executed in order
+type TranslatorFn func(ctx context.Context, request Request) ([]Translation, error)
+
+// Error is a GraphQL error. Extensions["code"] holds the driver.ErrorCategory of the error
+type Error struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Response is a GraphQL response. the fields whose statement failed are null, and reported in Errors
+type Response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []Error                `json:"errors,omitempty"`
+}
+
+// Bridge translates GraphQL operations and executes their statements through a driver
+type Bridge struct {
+	runner    driver.QueryRunner
+	translate TranslatorFn
+}
+
+// NewBridge creates a bridge executing the translations of translate with runner, typically a *driver.Driver
+func NewBridge(runner driver.QueryRunner, translate TranslatorFn) *Bridge {
+	return &Bridge{runner: runner, translate: translate}
+}
+
+// Execute translates the request and executes the statements of its root fields in order.
+// a failed field does not prevent the next ones from executing, like GraphQL's partial results
+func (b *Bridge) Execute(ctx context.Context, request Request, opts ...driver.QueryOption) Response {
+	translations, err := b.translate(ctx, request)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error(), Extensions: map[string]interface{}{"code": "GRAPHQL_VALIDATION_FAILED"}}}}
+	}
+	response := Response{Data: make(map[string]interface{}, len(translations))}
+	for _, translation := range translations {
+		value, err := ExecuteTranslation(ctx, b.runner, translation, opts...)
+		response.Data[translation.Field] = value
+		if err != nil {
+			response.Errors = append(response.Errors, fieldError(translation.Field, err))
+		}
+	}
+	return response
+}
+
+// ServeHTTP executes the operations posted as JSON and answers with their GraphQL responses
+func (b *Bridge) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if request.Method != http.MethodPost {
+		writer.Header().Set("Allow", http.MethodPost)
+		writeResponse(writer, http.StatusMethodNotAllowed, Response{Errors: []Error{{Message: "GraphQL operations must be posted"}}})
+		return
+	}
+	var operation Request
+	if err := json.NewDecoder(request.Body).Decode(&operation); err != nil {
+		writeResponse(writer, http.StatusBadRequest, Response{Errors: []Error{{Message: fmt.Sprintf("invalid GraphQL request: %v", err)}}})
+		return
+	}
+	writeResponse(writer, http.StatusOK, b.Execute(request.Context(), operation))
+}
+
+// ExecuteTranslation executes the statement of a root field with runner and shapes its records into the value of the field:
+// the records of single-column statements are their value, the others are objects keyed by column,
+// nodes and relationships are reduced to their properties and temporal values are formatted as strings
+func ExecuteTranslation(ctx context.Context, runner driver.QueryRunner, translation Translation, opts ...driver.QueryOption) (interface{}, error) {
+	accessMode := neo4j.AccessModeRead
+	if translation.Write {
+		accessMode = neo4j.AccessModeWrite
+	}
+	opts = append([]driver.QueryOption{driver.WithQueryName("graphql." + translation.Field), driver.WithAccessMode(accessMode)}, opts...)
+	values := []interface{}{}
+	err := runner.ExecuteQuery(ctx, translation.Cypher, translation.Params, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for result.NextRecord(ctx, &record) {
+			values = append(values, shapeRecord(record))
+			if !translation.List {
+				break
+			}
+		}
+		if err := result.Err(); err != nil {
+			return err
+		}
+		_, err := result.Consume(ctx)
+		return err
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if translation.List {
+		return values, nil
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return values[0], nil
+}
+
+func shapeRecord(record *neo4j.Record) interface{} {
+	if len(record.Values) == 1 {
+		return shape(record.Values[0])
+	}
+	object := make(map[string]interface{}, len(record.Keys))
+	for i, key := range record.Keys {
+		object[key] = shape(record.Values[i])
+	}
+	return object
+}
+
+// shape converts a value into its GraphQL representation, GraphQL objects having no room for element ids or labels
+func shape(value interface{}) interface{} {
+	switch value := value.(type) {
+	case neo4j.Node:
+		return shape(value.Props)
+	case neo4j.Relationship:
+		return shape(value.Props)
+	case []interface{}:
+		shaped := make([]interface{}, len(value))
+		for i, element := range value {
+			shaped[i] = shape(element)
+		}
+		return shaped
+	case map[string]interface{}:
+		shaped := make(map[string]interface{}, len(value))
+		for key, element := range value {
+			shaped[key] = shape(element)
+		}
+		return shaped
+	default:
+		return driver.ToPlainValue(value, driver.WithTemporalAsString())
+	}
+}
+
+// fieldError reports the failure of a field with the message of driver.ErrorToStatus, which hides the internal errors
+func fieldError(field string, err error) Error {
+	status := driver.ErrorToStatus(err)
+	return Error{
+		Message:    status.Message,
+		Path:       []interface{}{field},
+		Extensions: map[string]interface{}{"code": string(status.Category)},
+	}
+}
+
+func writeResponse(writer http.ResponseWriter, status int, response Response) {
+	writer.WriteHeader(status)
+	_ = json.NewEncoder(writer).Encode(response)
+}
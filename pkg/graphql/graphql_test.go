@@ -0,0 +1,108 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg/graphql"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGraphQL(t *testing.T) {
+	suite.Run(t, new(GraphQLTestSuite))
+}
+
+type GraphQLTestSuite struct {
+	suite.Suite
+	ctx  context.Context
+	mock *MockDriver
+}
+
+func (s *GraphQLTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mock = NewMockDriver()
+}
+
+func (s *GraphQLTestSuite) TestShapesTheRecordsIntoTheFieldsOfTheResponse() {
+	released := time.Date(1999, 3, 31, 0, 0, 0, 0, time.UTC)
+	s.mock.ExpectQuery("^MATCH \\(this:Movie {title").WithParams(map[string]interface{}{"title": "The Matrix"}).WillReturn([]string{"this"},
+		[]any{map[string]any{"title": "The Matrix", "released": released, "actors": []any{neo4j.Node{ElementId: "4:a:1", Labels: []string{"Person"}, Props: map[string]any{"name": "Keanu Reeves"}}}}},
+	)
+	s.mock.ExpectQuery("^MATCH \\(this:Person\\)").WillReturn([]string{"name", "born"}, []any{"Keanu Reeves", int64(1964)}, []any{"Carrie-Anne Moss", int64(1967)})
+	bridge := graphql.NewBridge(s.mock, func(_ context.Context, request graphql.Request) ([]graphql.Translation, error) {
+		return []graphql.Translation{
+			{Field: "movie", Cypher: "MATCH (this:Movie {title: $title}) RETURN this { .title, .released, actors: [(this)<-[:ACTED_IN]-(a) | a] } AS this", Params: request.Variables},
+			{Field: "people", Cypher: "MATCH (this:Person) RETURN this.name AS name, this.born AS born", List: true},
+		}, nil
+	})
+
+	response := bridge.Execute(s.ctx, graphql.Request{Query: "{ movie(title: $title) { title } people { name } }", Variables: map[string]interface{}{"title": "The Matrix"}})
+
+	s.Empty(response.Errors)
+	s.Equal(map[string]interface{}{
+		"movie": map[string]interface{}{
+			"title":    "The Matrix",
+			"released": "1999-03-31T00:00:00Z",
+			"actors":   []interface{}{map[string]interface{}{"name": "Keanu Reeves"}},
+		},
+		"people": []interface{}{
+			map[string]interface{}{"name": "Keanu Reeves", "born": int64(1964)},
+			map[string]interface{}{"name": "Carrie-Anne Moss", "born": int64(1967)},
+		},
+	}, response.Data)
+}
+
+func (s *GraphQLTestSuite) TestReportsTheFailedFieldsAlongWithThePartialData() {
+	s.mock.ExpectQuery("Movie").WillReturn([]string{"this"})
+	s.mock.ExpectQuery("Person").WillReturnError(&neo4j.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError", Msg: "Invalid input"})
+	bridge := graphql.NewBridge(s.mock, func(context.Context, graphql.Request) ([]graphql.Translation, error) {
+		return []graphql.Translation{{Field: "movie", Cypher: "MATCH (this:Movie) RETURN this"}, {Field: "people", Cypher: "MATCH (this:Person RETURN this", List: true}}, nil
+	})
+
+	response := bridge.Execute(s.ctx, graphql.Request{})
+
+	s.Equal(map[string]interface{}{"movie": nil, "people": nil}, response.Data)
+	s.Require().Len(response.Errors, 1)
+	s.Equal([]interface{}{"people"}, response.Errors[0].Path)
+	s.Equal("InvalidArgument", response.Errors[0].Extensions["code"])
+}
+
+func (s *GraphQLTestSuite) TestReportsTheTranslationFailures() {
+	bridge := graphql.NewBridge(s.mock, func(context.Context, graphql.Request) ([]graphql.Translation, error) {
+		return nil, errors.New(`Cannot query field "titel" on type "Movie"`)
+	})
+
+	response := bridge.Execute(s.ctx, graphql.Request{Query: "{ movies { titel } }"})
+
+	s.Nil(response.Data)
+	s.Equal([]graphql.Error{{Message: `Cannot query field "titel" on type "Movie"`, Extensions: map[string]interface{}{"code": "GRAPHQL_VALIDATION_FAILED"}}}, response.Errors)
+}
+
+func (s *GraphQLTestSuite) TestServesThePostedOperations() {
+	s.mock.ExpectQuery("Movie").WithParams(map[string]interface{}{"limit": float64(1)}).WillReturn([]string{"this"}, []any{map[string]any{"title": "The Matrix"}})
+	bridge := graphql.NewBridge(s.mock, func(_ context.Context, request graphql.Request) ([]graphql.Translation, error) {
+		return []graphql.Translation{{Field: "movies", Cypher: "MATCH (this:Movie) RETURN this { .title } AS this LIMIT $limit", Params: request.Variables, List: true}}, nil
+	})
+	recorder := httptest.NewRecorder()
+
+	bridge.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ movies { title } }", "variables": {"limit": 1}}`)))
+
+	s.Equal(http.StatusOK, recorder.Code)
+	s.Equal("application/json", recorder.Header().Get("Content-Type"))
+	s.JSONEq(`{"data": {"movies": [{"title": "The Matrix"}]}}`, recorder.Body.String())
+}
+
+func (s *GraphQLTestSuite) TestRejectsTheOperationsThatAreNotPosted() {
+	recorder := httptest.NewRecorder()
+
+	graphql.NewBridge(s.mock, nil).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/graphql", nil))
+
+	s.Equal(http.StatusMethodNotAllowed, recorder.Code)
+	s.Equal(http.MethodPost, recorder.Header().Get("Allow"))
+}
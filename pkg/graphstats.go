@@ -0,0 +1,151 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"sort"
+)
+
+// GraphStats describes the size and shape of the graph, for dashboards and capacity reviews
+type GraphStats struct {
+	Nodes, Relationships int64
+	// Labels counts the nodes of every label, a node with several labels is counted in each of them
+	Labels map[string]int64
+	// RelationshipTypes counts the relationships of every type
+	RelationshipTypes map[string]int64
+	// Degrees holds the degree distribution of every label, only when sampled with WithDegreeSampling
+	Degrees map[string]DegreeDistribution
+}
+
+// DegreeDistribution summarizes the number of relationships, in both directions, of a sample of nodes
+type DegreeDistribution struct {
+	// Sampled is the number of nodes in the sample, the first ones of the label in store order
+	Sampled       int
+	Min, Max      int64
+	Mean          float64
+	P50, P90, P99 int64
+}
+
+// StatsOption customizes Stats
+type StatsOption func(*statsOptions)
+
+type statsOptions struct {
+	degreeSampleSize int
+}
+
+// WithDegreeSampling samples the degree of at most sampleSize nodes of every label. unlike the counts,
+// which are read from the count store, sampling reads the nodes and their relationships
+func WithDegreeSampling(sampleSize int) StatsOption {
+	return func(options *statsOptions) {
+		options.degreeSampleSize = sampleSize
+	}
+}
+
+// Stats counts the nodes per label and the relationships per type, see CollectGraphStats
+func (d *Driver) Stats(ctx context.Context, opts ...StatsOption) (GraphStats, error) {
+	return CollectGraphStats(ctx, d, opts...)
+}
+
+// CollectGraphStats counts the nodes per label and the relationships per type with runner.
+// every count matches a single label or type, so that the server answers it from its count store without scanning the graph
+func CollectGraphStats(ctx context.Context, runner QueryRunner, opts ...StatsOption) (GraphStats, error) {
+	options := &statsOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	stats := GraphStats{Labels: map[string]int64{}, RelationshipTypes: map[string]int64{}}
+	var err error
+	if stats.Nodes, err = countWith(ctx, runner, "MATCH (n) RETURN count(n)", "stats.nodes"); err != nil {
+		return stats, err
+	}
+	if stats.Relationships, err = countWith(ctx, runner, "MATCH ()-[r]->() RETURN count(r)", "stats.relationships"); err != nil {
+		return stats, err
+	}
+	labels, err := namesWith(ctx, runner, "CALL db.labels() YIELD label RETURN label", "stats.labels")
+	if err != nil {
+		return stats, err
+	}
+	for _, label := range labels {
+		if stats.Labels[label], err = countWith(ctx, runner, fmt.Sprintf("MATCH (n:%s) RETURN count(n)", QuoteIdentifier(label)), "stats.label"); err != nil {
+			return stats, err
+		}
+	}
+	types, err := namesWith(ctx, runner, "CALL db.relationshipTypes() YIELD relationshipType RETURN relationshipType", "stats.types")
+	if err != nil {
+		return stats, err
+	}
+	for _, relType := range types {
+		if stats.RelationshipTypes[relType], err = countWith(ctx, runner, fmt.Sprintf("MATCH ()-[r:%s]->() RETURN count(r)", QuoteIdentifier(relType)), "stats.type"); err != nil {
+			return stats, err
+		}
+	}
+	if options.degreeSampleSize <= 0 {
+		return stats, nil
+	}
+	stats.Degrees = map[string]DegreeDistribution{}
+	for _, label := range labels {
+		if stats.Degrees[label], err = sampleDegrees(ctx, runner, label, options.degreeSampleSize); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+func sampleDegrees(ctx context.Context, runner QueryRunner, label string, sampleSize int) (DegreeDistribution, error) {
+	query := fmt.Sprintf("MATCH (n:%s) WITH n LIMIT $sample RETURN COUNT { (n)--() } AS degree", QuoteIdentifier(label))
+	var degrees []int64
+	err := runner.ExecuteQuery(ctx, query, map[string]interface{}{"sample": int64(sampleSize)}, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for result.NextRecord(ctx, &record) {
+			degree, _ := record.Values[0].(int64)
+			degrees = append(degrees, degree)
+		}
+		return result.Err()
+	}, WithQueryName("stats.degrees"), WithAccessMode(neo4j.AccessModeRead))
+	if err != nil || len(degrees) == 0 {
+		return DegreeDistribution{}, err
+	}
+	sort.Slice(degrees, func(i, j int) bool { return degrees[i] < degrees[j] })
+	sum := int64(0)
+	for _, degree := range degrees {
+		sum += degree
+	}
+	percentile := func(p float64) int64 {
+		return degrees[int(p*float64(len(degrees)-1))]
+	}
+	return DegreeDistribution{
+		Sampled: len(degrees),
+		Min:     degrees[0],
+		Max:     degrees[len(degrees)-1],
+		Mean:    float64(sum) / float64(len(degrees)),
+		P50:     percentile(0.5),
+		P90:     percentile(0.9),
+		P99:     percentile(0.99),
+	}, nil
+}
+
+func countWith(ctx context.Context, runner QueryRunner, query, name string) (count int64, err error) {
+	err = runner.ExecuteQuery(ctx, query, nil, func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(ctx)
+		if err != nil {
+			return err
+		}
+		count, _ = record.Values[0].(int64)
+		return nil
+	}, WithQueryName(name), WithAccessMode(neo4j.AccessModeRead))
+	return count, err
+}
+
+func namesWith(ctx context.Context, runner QueryRunner, query, name string) (names []string, err error) {
+	err = runner.ExecuteQuery(ctx, query, nil, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for result.NextRecord(ctx, &record) {
+			if value, ok := record.Values[0].(string); ok {
+				names = append(names, value)
+			}
+		}
+		return result.Err()
+	}, WithQueryName(name), WithAccessMode(neo4j.AccessModeRead))
+	return names, err
+}
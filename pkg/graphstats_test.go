@@ -0,0 +1,70 @@
+package driver_test
+
+import (
+	"context"
+	"fmt"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestGraphStats(t *testing.T) {
+	suite.Run(t, new(GraphStatsTestSuite))
+}
+
+type GraphStatsTestSuite struct {
+	suite.Suite
+	runner scriptedRunner
+}
+
+func (s *GraphStatsTestSuite) SetupTest() {
+	s.runner = scriptedRunner{
+		"MATCH (n) RETURN count(n)":                                                  {{int64(3)}},
+		"MATCH ()-[r]->() RETURN count(r)":                                           {{int64(2)}},
+		"CALL db.labels() YIELD label RETURN label":                                  {{"User"}, {"Team"}},
+		"MATCH (n:`User`) RETURN count(n)":                                           {{int64(2)}},
+		"MATCH (n:`Team`) RETURN count(n)":                                           {{int64(1)}},
+		"CALL db.relationshipTypes() YIELD relationshipType RETURN relationshipType": {{"MEMBER_OF"}},
+		"MATCH ()-[r:`MEMBER_OF`]->() RETURN count(r)":                               {{int64(2)}},
+		"MATCH (n:`User`) WITH n LIMIT $sample RETURN COUNT { (n)--() } AS degree":   {{int64(1)}, {int64(1)}},
+		"MATCH (n:`Team`) WITH n LIMIT $sample RETURN COUNT { (n)--() } AS degree":   {{int64(2)}},
+	}
+}
+
+func (s *GraphStatsTestSuite) TestCountsPerLabelAndType() {
+	stats, err := CollectGraphStats(context.Background(), s.runner)
+
+	s.Require().NoError(err)
+	s.Equal(GraphStats{
+		Nodes:             3,
+		Relationships:     2,
+		Labels:            map[string]int64{"User": 2, "Team": 1},
+		RelationshipTypes: map[string]int64{"MEMBER_OF": 2},
+	}, stats)
+}
+
+func (s *GraphStatsTestSuite) TestSamplesDegrees() {
+	stats, err := CollectGraphStats(context.Background(), s.runner, WithDegreeSampling(100))
+
+	s.Require().NoError(err)
+	s.Equal(map[string]DegreeDistribution{
+		"User": {Sampled: 2, Min: 1, Max: 1, Mean: 1, P50: 1, P90: 1, P99: 1},
+		"Team": {Sampled: 1, Min: 2, Max: 2, Mean: 2, P50: 2, P90: 2, P99: 2},
+	}, stats.Degrees)
+}
+
+// scriptedRunner answers every query with the single-column rows scripted for it, and fails for the other ones
+type scriptedRunner map[string][][]any
+
+func (r scriptedRunner) ExecuteQuery(_ context.Context, query string, _ map[string]interface{}, onResults ResultsHookFn, _ ...QueryOption) error {
+	rows, found := r[query]
+	if !found {
+		return fmt.Errorf("unexpected query %q", query)
+	}
+	records := make([]*neo4j.Record, len(rows))
+	for i, row := range rows {
+		records[i] = &neo4j.Record{Keys: []string{"value"}, Values: row}
+	}
+	return onResults(newFakeResult(records))
+}
@@ -0,0 +1,96 @@
+// Package health exposes the connectivity of a driver to the readiness and liveness probes of Kubernetes:
+//
+//	http.Handle("/readyz", health.Ready(d, health.ReadyConfig{Timeout: 2 * time.Second, CacheFor: 5 * time.Second}))
+//	http.Handle("/livez", health.Live(d))
+//
+// both handlers answer 200 with a short plain-text status when healthy, 503 otherwise.
+package health
+
+import (
+	"context"
+	"fmt"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultReadyTimeout bounds the connectivity checks of the readiness handlers configured without Timeout
+const DefaultReadyTimeout = 5 * time.Second
+
+// Verifier checks the connectivity to the server, e.g. a *driver.Driver
+type Verifier interface {
+	VerifyConnectivity(ctx context.Context) error
+}
+
+// StateReporter reports the lifecycle state of a driver, e.g. a *driver.Driver
+type StateReporter interface {
+	State() driver.State
+}
+
+// ReadyConfig configures the readiness handler
+type ReadyConfig struct {
+	// Timeout bounds every connectivity check, defaults to DefaultReadyTimeout
+	Timeout time.Duration
+	// CacheFor reuses the outcome of a check for the probes received in the meantime, so that frequent probes
+	// from many replicas do not open as many connections. every probe checks the connectivity when zero
+	CacheFor time.Duration
+}
+
+// Ready returns a handler answering 200 when verifier reaches the server, 503 with the error otherwise
+func Ready(verifier Verifier, config ReadyConfig) http.Handler {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultReadyTimeout
+	}
+	return &readyHandler{verifier: verifier, config: config}
+}
+
+// Live returns a handler answering 200 until the driver is closed, 503 afterwards.
+// a degraded driver is live, as it reconnects by itself: restarting the service would not help
+func Live(reporter StateReporter) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		state := reporter.State()
+		status := http.StatusOK
+		if state == driver.StateClosed {
+			status = http.StatusServiceUnavailable
+		}
+		respond(writer, status, state.String())
+	})
+}
+
+type readyHandler struct {
+	verifier  Verifier
+	config    ReadyConfig
+	mutex     sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+func (h *readyHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if err := h.check(request.Context()); err != nil {
+		respond(writer, http.StatusServiceUnavailable, fmt.Sprintf("unavailable: %v", err))
+		return
+	}
+	respond(writer, http.StatusOK, "ok")
+}
+
+// check verifies the connectivity unless the previous outcome is still cached. concurrent probes wait for the ongoing check
+func (h *readyHandler) check(ctx context.Context) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.config.CacheFor > 0 && !h.checkedAt.IsZero() && time.Since(h.checkedAt) < h.config.CacheFor {
+		return h.err
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.config.Timeout)
+	defer cancel()
+	h.err = h.verifier.VerifyConnectivity(ctx)
+	h.checkedAt = time.Now()
+	return h.err
+}
+
+func respond(writer http.ResponseWriter, status int, body string) {
+	writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(status)
+	fmt.Fprintln(writer, body)
+}
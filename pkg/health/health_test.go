@@ -0,0 +1,107 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg/health"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealth(t *testing.T) {
+	suite.Run(t, new(HealthTestSuite))
+}
+
+type HealthTestSuite struct {
+	suite.Suite
+	verifier *fakeVerifier
+}
+
+func (s *HealthTestSuite) SetupTest() {
+	s.verifier = &fakeVerifier{}
+}
+
+func (s *HealthTestSuite) TestIsReadyWhenTheServerIsReachable() {
+	response := probe(health.Ready(s.verifier, health.ReadyConfig{}))
+
+	s.Equal(http.StatusOK, response.Code)
+	s.Equal("ok\n", response.Body.String())
+	s.Equal(1, s.verifier.checks)
+}
+
+func (s *HealthTestSuite) TestIsNotReadyWhenTheServerIsUnreachable() {
+	s.verifier.err = errors.New("ConnectivityError: connection refused")
+
+	response := probe(health.Ready(s.verifier, health.ReadyConfig{}))
+
+	s.Equal(http.StatusServiceUnavailable, response.Code)
+	s.Equal("unavailable: ConnectivityError: connection refused\n", response.Body.String())
+}
+
+func (s *HealthTestSuite) TestBoundsTheConnectivityChecks() {
+	s.verifier.delay = time.Second
+
+	response := probe(health.Ready(s.verifier, health.ReadyConfig{Timeout: time.Millisecond}))
+
+	s.Equal(http.StatusServiceUnavailable, response.Code)
+	s.ErrorIs(s.verifier.lastErr, context.DeadlineExceeded)
+}
+
+func (s *HealthTestSuite) TestCachesTheOutcomeOfTheChecks() {
+	ready := health.Ready(s.verifier, health.ReadyConfig{CacheFor: time.Hour})
+
+	probe(ready)
+	s.verifier.err = errors.New("ConnectivityError: connection refused")
+	response := probe(ready)
+
+	s.Equal(http.StatusOK, response.Code)
+	s.Equal(1, s.verifier.checks)
+}
+
+func (s *HealthTestSuite) TestIsLiveUntilTheDriverIsClosed() {
+	for state, status := range map[State]int{
+		StateNew:       http.StatusOK,
+		StateConnected: http.StatusOK,
+		StateDegraded:  http.StatusOK,
+		StateClosed:    http.StatusServiceUnavailable,
+	} {
+		response := probe(health.Live(fakeReporter(state)))
+
+		s.Equal(status, response.Code, state.String())
+		s.Equal(state.String()+"\n", response.Body.String())
+	}
+}
+
+func probe(handler http.Handler) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	return recorder
+}
+
+type fakeVerifier struct {
+	err     error
+	delay   time.Duration
+	checks  int
+	lastErr error
+}
+
+func (v *fakeVerifier) VerifyConnectivity(ctx context.Context) error {
+	v.checks++
+	v.lastErr = v.err
+	select {
+	case <-time.After(v.delay):
+	case <-ctx.Done():
+		v.lastErr = ctx.Err()
+	}
+	return v.lastErr
+}
+
+type fakeReporter State
+
+func (r fakeReporter) State() State {
+	return State(r)
+}
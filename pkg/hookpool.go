@@ -0,0 +1,81 @@
+package driver
+
+import (
+	"context"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"runtime"
+	"sync"
+)
+
+// RecordHookFn handles a single record, see OnWorkerPool
+type RecordHookFn func(record *neo4j.Record) error
+
+// WorkerPoolConfig sizes the pool of OnWorkerPool
+type WorkerPoolConfig struct {
+	// Workers is the number of goroutines running the hook, defaults to runtime.GOMAXPROCS(0)
+	Workers int
+	// Buffer is the capacity of the channel between the goroutine reading the result and the workers, defaults to twice Workers.
+	// reading pauses while it is full, so that slow hooks apply backpressure instead of buffering the whole result in memory
+	Buffer int
+}
+
+// OnWorkerPool returns a hook reading the result on the calling goroutine and handing its records to onRecord
+// on a pool of workers, so that CPU-heavy decoding of large results does not slow down the consumption of the Bolt stream.
+// records are handled concurrently and in no particular order, onRecord must be safe for concurrent use.
+// the first error returned by onRecord stops the consumption of the result and is returned once the workers are done
+func OnWorkerPool(ctx context.Context, config WorkerPoolConfig, onRecord RecordHookFn) ResultsHookFn {
+	workers := config.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	buffer := config.Buffer
+	if buffer <= 0 {
+		buffer = 2 * workers
+	}
+	return func(result neo4j.ResultWithContext) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		var failure error
+		var once sync.Once
+		fail := func(err error) {
+			once.Do(func() {
+				failure = err
+				cancel()
+			})
+		}
+
+		records := make(chan *neo4j.Record, buffer)
+		var wg sync.WaitGroup
+		wg.Add(workers)
+		for i := 0; i < workers; i++ {
+			go func() {
+				defer wg.Done()
+				for record := range records {
+					if ctx.Err() != nil {
+						continue
+					}
+					if err := onRecord(record); err != nil {
+						fail(err)
+					}
+				}
+			}()
+		}
+
+		var record *neo4j.Record
+		for ctx.Err() == nil && result.NextRecord(ctx, &record) {
+			select {
+			case records <- record:
+			case <-ctx.Done():
+			}
+		}
+		close(records)
+		wg.Wait()
+		if failure != nil {
+			return failure
+		}
+		if err := result.Err(); err != nil {
+			return err
+		}
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,80 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHookPool(t *testing.T) {
+	suite.Run(t, new(HookPoolTestSuite))
+}
+
+type HookPoolTestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *HookPoolTestSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+func (s *HookPoolTestSuite) TestHandsEveryRecordToTheWorkers() {
+	var sum atomic.Int64
+	hook := OnWorkerPool(s.ctx, WorkerPoolConfig{Workers: 4, Buffer: 1}, func(record *neo4j.Record) error {
+		sum.Add(record.Values[0].(int64))
+		return nil
+	})
+
+	s.Require().NoError(hook(newFakeResult(numberedRecords(1000))))
+
+	s.Equal(int64(500500), sum.Load())
+}
+
+func (s *HookPoolTestSuite) TestRunsTheHooksConcurrently() {
+	var started sync.WaitGroup
+	started.Add(4)
+	hook := OnWorkerPool(s.ctx, WorkerPoolConfig{Workers: 4}, func(*neo4j.Record) error {
+		started.Done()
+		started.Wait()
+		return nil
+	})
+
+	s.NoError(hook(newFakeResult(numberedRecords(4))), "the 4 hooks must run at once to release each other")
+}
+
+func (s *HookPoolTestSuite) TestStopsConsumingOnTheFirstError() {
+	result := newFakeResult(numberedRecords(1000))
+	hook := OnWorkerPool(s.ctx, WorkerPoolConfig{Workers: 2, Buffer: 1}, func(record *neo4j.Record) error {
+		if record.Values[0].(int64) == 10 {
+			return errors.New("cannot decode record 10")
+		}
+		return nil
+	})
+
+	s.EqualError(hook(result), "cannot decode record 10")
+	s.True(result.IsOpen(), "the rest of the result must be left unread")
+}
+
+func (s *HookPoolTestSuite) TestReportsTheErrorsOfTheResult() {
+	result := newFakeResult(numberedRecords(3))
+	result.err = errors.New("connection reset")
+	hook := OnWorkerPool(s.ctx, WorkerPoolConfig{}, func(*neo4j.Record) error {
+		return nil
+	})
+
+	s.EqualError(hook(result), "connection reset")
+}
+
+func numberedRecords(count int) []*neo4j.Record {
+	records := make([]*neo4j.Record, count)
+	for i := range records {
+		records[i] = &neo4j.Record{Keys: []string{"n"}, Values: []any{int64(i + 1)}}
+	}
+	return records
+}
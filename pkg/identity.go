@@ -0,0 +1,52 @@
+package driver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"os"
+	"strings"
+)
+
+// identityProduct names this package in the user agent of the connections
+const identityProduct = "neo4j-go-driver-issue-451"
+
+// PodNameEnv is the environment variable holding the pod name stamped on the connections, e.g. set through the Kubernetes downward API
+const PodNameEnv = "POD_NAME"
+
+// processInstanceID identifies this process when Settings.InstanceID is empty
+var processInstanceID = newInstanceID()
+
+func newInstanceID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(id)
+}
+
+// connectionIdentity is the user agent suffix attributing the connections to this service instance in the server query logs,
+// e.g. "neo4j-go-driver-issue-451 instance/4f2a9c0e1b3d5f67 pod/orders-7d9f8-xk2lp"
+func connectionIdentity(settings Settings) string {
+	instance := settings.InstanceID
+	if instance == "" {
+		instance = processInstanceID
+	}
+	identity := identityProduct + " instance/" + instance
+	if pod := os.Getenv(PodNameEnv); pod != "" {
+		identity += " pod/" + pod
+	}
+	return identity
+}
+
+// stampIdentity appends identity to the user agent, which defaults to the one of the underlying driver
+func stampIdentity(identity string) func(*neo4j.Config) {
+	return func(config *neo4j.Config) {
+		config.UserAgent = strings.TrimSpace(config.UserAgent + " " + identity)
+	}
+}
+
+// Identity returns the identity stamped on the user agent of the connections, empty when Settings.DisableIdentityStamp is set
+func (d *Driver) Identity() string {
+	return d.identity
+}
@@ -0,0 +1,75 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestIdentity(t *testing.T) {
+	suite.Run(t, new(IdentityTestSuite))
+}
+
+type IdentityTestSuite struct {
+	suite.Suite
+	ctx       context.Context
+	userAgent string
+	restore   func()
+}
+
+func (s *IdentityTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	cluster := &fakeCluster{}
+	s.restore = UseDriverFactory(func(target string, auth neo4j.AuthToken, configurers ...func(*neo4j.Config)) (neo4j.DriverWithContext, error) {
+		config := &neo4j.Config{UserAgent: neo4j.UserAgent}
+		for _, configurer := range configurers {
+			configurer(config)
+		}
+		s.userAgent = config.UserAgent
+		return cluster.newDriver(target, auth, configurers...)
+	})
+}
+
+func (s *IdentityTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *IdentityTestSuite) TestStampsTheUserAgentWithTheInstanceAndPod() {
+	s.T().Setenv(PodNameEnv, "orders-7d9f8-xk2lp")
+	settings := connectionSettings
+	settings.ConnectionString = "neo4j://localhost?user_agent=orders/1.2"
+	settings.InstanceID = "eu-1"
+
+	driver, err := NewDriver(settings)
+
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	s.Equal("neo4j-go-driver-issue-451 instance/eu-1 pod/orders-7d9f8-xk2lp", driver.Identity())
+	s.Equal("orders/1.2 neo4j-go-driver-issue-451 instance/eu-1 pod/orders-7d9f8-xk2lp", s.userAgent)
+}
+
+func (s *IdentityTestSuite) TestGeneratesOneInstanceIdentifierPerProcess() {
+	first, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer first.Close(s.ctx)
+	second, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer second.Close(s.ctx)
+
+	s.Regexp(`^neo4j-go-driver-issue-451 instance/[0-9a-f]{16}$`, first.Identity())
+	s.Equal(first.Identity(), second.Identity())
+}
+
+func (s *IdentityTestSuite) TestStampCanBeDisabled() {
+	settings := connectionSettings
+	settings.DisableIdentityStamp = true
+
+	driver, err := NewDriver(settings)
+
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	s.Empty(driver.Identity())
+	s.Equal(neo4j.UserAgent, s.userAgent)
+}
@@ -0,0 +1,126 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrSuspectedInjection is returned by the injection guard for queries that look built by interpolating values
+var ErrSuspectedInjection = errors.New("query looks built by string interpolation")
+
+const defaultMaxLiteralLength = 64
+
+// InjectionRisk is the reason a query is suspected of Cypher injection
+type InjectionRisk string
+
+const (
+	// RiskLongLiteral flags string literals longer than InjectionGuardConfig.MaxLiteralLength, which are rarely constants
+	RiskLongLiteral InjectionRisk = "long string literal"
+	// RiskUserInputLiteral flags string literals compared to or assigned to a property, holding more than an identifier-like
+	// constant (e.g. 'active' or 'en-US'): whitespace, @ or quotes are typical of interpolated user input
+	RiskUserInputLiteral InjectionRisk = "user input literal"
+	// RiskTautology flags comparisons of equal values such as OR '1'='1', the classic injection payload
+	RiskTautology InjectionRisk = "tautology"
+	// RiskUnterminatedLiteral flags string literals without closing quote, typical of input breaking out of a literal
+	RiskUnterminatedLiteral InjectionRisk = "unterminated string literal"
+)
+
+var (
+	// propertyOperator matches the end of the text preceding a literal compared to or assigned to a property
+	propertyOperator = regexp.MustCompile(`(?i)(\w\s*(=|<>|=~|<|>|<=|>=|:)|\b(CONTAINS|STARTS\s+WITH|ENDS\s+WITH|IN\s*\[.*))\s*$`)
+	constantLiteral  = regexp.MustCompile(`^[A-Za-z0-9_.:-]*$`)
+	tautology        = regexp.MustCompile(`(?i)\bOR\s+('(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"|\d+)\s*=\s*('(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"|\d+)`)
+)
+
+// InjectionFinding is a suspicious part of a query, see LintQuery
+type InjectionFinding struct {
+	Risk      InjectionRisk
+	Statement string
+	// Literal is the suspicious literal, quotes included
+	Literal string
+}
+
+func (f InjectionFinding) String() string {
+	literal := f.Literal
+	if len(literal) > 32 {
+		literal = literal[:32] + "..."
+	}
+	return fmt.Sprintf("%s %s", f.Risk, literal)
+}
+
+// InjectionGuardConfig rejects the queries that look built by interpolating values instead of passing parameters,
+// to catch Cypher injection before it reaches production. queries relying on literals on purpose are executed
+// WithTrustedLiterals. see LintQuery for the heuristics
+type InjectionGuardConfig struct {
+	// MaxLiteralLength is the length of the longest string literal allowed, quotes excluded. defaults to 64
+	MaxLiteralLength int
+}
+
+// WithTrustedLiterals exempts the query from the injection guard, for queries embedding literals on purpose
+func WithTrustedLiterals() QueryOption {
+	return func(options *queryOptions) {
+		options.trustedLiterals = true
+	}
+}
+
+// LintQuery returns the parts of query suspected of Cypher injection, e.g. to fail CI on the queries of an application
+// in a unit test. it flags long string literals, literals holding user-input-like values compared to properties,
+// tautologies and unterminated literals. queries passing all values as $parameters yield no findings
+func LintQuery(query string, config InjectionGuardConfig) []InjectionFinding {
+	maxLength := config.MaxLiteralLength
+	if maxLength <= 0 {
+		maxLength = defaultMaxLiteralLength
+	}
+	var findings []InjectionFinding
+	for _, statement := range SplitScript(query) {
+		for _, match := range tautology.FindAllStringSubmatch(statement, -1) {
+			if normalizeLiteral(match[1]) == normalizeLiteral(match[2]) {
+				findings = append(findings, InjectionFinding{Risk: RiskTautology, Statement: statement, Literal: match[0]})
+			}
+		}
+		for i := 0; i < len(statement); i++ {
+			quote := statement[i]
+			if quote != '\'' && quote != '"' && quote != '`' {
+				continue
+			}
+			end := closingQuote(statement, i)
+			literal := statement[i:end]
+			start := i
+			i = end - 1
+			if quote == '`' {
+				continue
+			}
+			content := literal[1:]
+			if len(literal) < 2 || literal[len(literal)-1] != quote {
+				findings = append(findings, InjectionFinding{Risk: RiskUnterminatedLiteral, Statement: statement, Literal: literal})
+				continue
+			}
+			content = content[:len(content)-1]
+			switch {
+			case len(content) > maxLength:
+				findings = append(findings, InjectionFinding{Risk: RiskLongLiteral, Statement: statement, Literal: literal})
+			case !constantLiteral.MatchString(content) && propertyOperator.MatchString(statement[:start]):
+				findings = append(findings, InjectionFinding{Risk: RiskUserInputLiteral, Statement: statement, Literal: literal})
+			}
+		}
+	}
+	return findings
+}
+
+func normalizeLiteral(literal string) string {
+	return strings.Trim(literal, `'"`)
+}
+
+// checkInjection fails with ErrSuspectedInjection when the guard is enabled and query has findings
+func (d *Driver) checkInjection(query string, options *queryOptions) error {
+	config := d.settings.InjectionGuard
+	if config == nil || options.trustedLiterals {
+		return nil
+	}
+	if findings := LintQuery(query, *config); len(findings) > 0 {
+		return fmt.Errorf("%w: %s", ErrSuspectedInjection, findings[0])
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+)
+
+func TestInjectionGuard(t *testing.T) {
+	suite.Run(t, new(InjectionGuardTestSuite))
+}
+
+type InjectionGuardTestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *InjectionGuardTestSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+func (s *InjectionGuardTestSuite) TestFlagsInterpolatedValues() {
+	for query, risk := range map[string]InjectionRisk{
+		"MATCH (u:User) WHERE u.email = 'alice@example.com' RETURN u":       RiskUserInputLiteral,
+		"CREATE (:User {name: \"John Smith\"})":                             RiskUserInputLiteral,
+		"MATCH (u:User) WHERE u.name = '' OR '1'='1' RETURN u":              RiskTautology,
+		"MATCH (u:User) WHERE u.name = 'x' OR 1=1 RETURN u":                 RiskTautology,
+		"MATCH (u:User) WHERE u.name = 'robert RETURN u":                    RiskUnterminatedLiteral,
+		"RETURN '" + strings.Repeat("a", 65) + "' AS padding":               RiskLongLiteral,
+		"MATCH (n:Note) WHERE n.text CONTAINS 'drop table' RETURN n":        RiskUserInputLiteral,
+		"MATCH (u:User) WHERE u.name IN ['alice', 'bob smith'] RETURN u.id": RiskUserInputLiteral,
+	} {
+		findings := LintQuery(query, InjectionGuardConfig{})
+		s.Require().NotEmpty(findings, query)
+		s.Equal(risk, findings[0].Risk, query)
+	}
+}
+
+func (s *InjectionGuardTestSuite) TestAcceptsParametersAndConstants() {
+	for _, query := range []string{
+		"MATCH (u:User) WHERE u.email = $email RETURN u",
+		"MATCH (u:User {status: 'active', locale: 'en-US'}) RETURN u",
+		"MATCH (u:`User Account`) RETURN u.name AS `full name`",
+		"RETURN 'Hello, world!' AS greeting",
+		"MATCH (u:User) WHERE u.role = \"ADMIN\" OR u.role = 'owner' RETURN u // 'not a literal",
+	} {
+		s.Empty(LintQuery(query, InjectionGuardConfig{}), query)
+	}
+}
+
+func (s *InjectionGuardTestSuite) TestRejectsSuspiciousQueriesUnlessTrusted() {
+	cluster := &fakeCluster{}
+	defer UseDriverFactory(cluster.newDriver)()
+	settings := connectionSettings
+	settings.InjectionGuard = &InjectionGuardConfig{}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	query := "MATCH (u:User) WHERE u.email = 'alice@example.com' RETURN u"
+
+	err = driver.ExecuteQuery(s.ctx, query, nil, noopHook)
+	s.ErrorIs(err, ErrSuspectedInjection)
+	s.ErrorIs(driver.ExecuteScript(s.ctx, query), ErrSuspectedInjection)
+	s.Equal(0, cluster.sessions())
+
+	s.NoError(driver.ExecuteQuery(s.ctx, query, nil, noopHook, WithTrustedLiterals()))
+}
@@ -0,0 +1,7 @@
+//go:build !neo4j_uninstrumented
+
+package driver
+
+// instrumented reports whether the query observer, the slow query detection and the debug bundles are compiled in.
+// building with the neo4j_uninstrumented tag compiles them out, see instrumentation_off.go
+const instrumented = true
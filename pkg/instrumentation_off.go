@@ -0,0 +1,9 @@
+//go:build neo4j_uninstrumented
+
+package driver
+
+// instrumented is false when building with the neo4j_uninstrumented tag, for hot embedded use cases where every
+// allocation per query counts. the resilience (reconnects, routing refreshes, retries) is kept, but
+// Settings.QueryObserver, Settings.SlowQueryThreshold and Settings.DebugBundle are ignored.
+// compare the overhead with: go test -run '^$' -bench ExecuteQuery [-tags neo4j_uninstrumented]
+const instrumented = false
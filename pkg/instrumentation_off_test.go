@@ -0,0 +1,46 @@
+//go:build neo4j_uninstrumented
+
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+// run with: go test -tags neo4j_uninstrumented -run TestUninstrumented
+func TestUninstrumented(t *testing.T) {
+	suite.Run(t, new(UninstrumentedTestSuite))
+}
+
+type UninstrumentedTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	restore func()
+}
+
+func (s *UninstrumentedTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.restore = UseDriverFactory((&fakeCluster{}).newDriver)
+}
+
+func (s *UninstrumentedTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *UninstrumentedTestSuite) TestCompilesTheObserverOut() {
+	settings := connectionSettings
+	settings.QueryObserver = func(context.Context, QueryEvent) {
+		s.Fail("the observer must be compiled out")
+	}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	s.NoError(executeSimpleQuery(s.ctx, driver))
+	s.NoError(driver.ExecuteScript(s.ctx, "CREATE (:A);\nCREATE (:B);"))
+	s.NoError(driver.ExportConsistent(s.ctx, []QuerySpec{{Name: "a", Query: "MATCH (a:A) RETURN a"}}, func(QuerySpec, RecordIterator) error {
+		return nil
+	}))
+}
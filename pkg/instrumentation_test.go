@@ -0,0 +1,56 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+// BenchmarkExecuteQuery measures the per-query overhead of the driver against an in-memory server,
+// run it with and without -tags neo4j_uninstrumented to compare the instrumented and the fast path.
+// the pooled sessions variant measures the overhead saved by Settings.MaxIdleSessions
+func BenchmarkExecuteQuery(b *testing.B) {
+	b.Run("bare", func(b *testing.B) {
+		benchmarkExecuteQuery(b, connectionSettings)
+	})
+	b.Run("instrumented", func(b *testing.B) {
+		settings := connectionSettings
+		settings.QueryObserver = func(context.Context, QueryEvent) {}
+		settings.SlowQueryThreshold = time.Nanosecond
+		settings.OnSlowQuery = func(context.Context, SlowQuery) {}
+		settings.Logger = log.New(io.Discard, "", 0)
+		settings.DebugBundle = &DebugBundleConfig{Dir: b.TempDir()}
+		benchmarkExecuteQuery(b, settings)
+	})
+	b.Run("pooled sessions", func(b *testing.B) {
+		settings := connectionSettings
+		settings.MaxIdleSessions = 1
+		benchmarkExecuteQuery(b, settings)
+	})
+}
+
+func benchmarkExecuteQuery(b *testing.B, settings Settings) {
+	ctx := context.Background()
+	defer UseDriverFactory((&fakeCluster{}).newDriver)()
+	driver, err := NewDriver(settings)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer driver.Close(ctx)
+	onResults := func(result neo4j.ResultWithContext) error {
+		_, err := result.Collect(ctx)
+		return err
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := driver.ExecuteQuery(ctx, "RETURN true AS ok", nil, onResults, WithQueryName("ok")); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
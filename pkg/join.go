@@ -0,0 +1,129 @@
+package driver
+
+import (
+	"context"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// RecordIterator is the subset of neo4j.ResultWithContext needed to stream records one by one.
+// neo4j.ResultWithContext satisfies it.
+type RecordIterator interface {
+	NextRecord(ctx context.Context, record **neo4j.Record) bool
+	Err() error
+}
+
+// JoinKeyFn extracts the join key of a record.
+// keys are compared as strings, so both sides must return their records in ascending key order (ORDER BY key)
+type JoinKeyFn func(record *neo4j.Record) (string, error)
+
+// JoinHookFn receives every pair of records sharing the same key
+type JoinHookFn func(left, right *neo4j.Record) error
+
+// JoinSide describes one of the two ordered queries of a streaming join
+type JoinSide struct {
+	Query  string
+	Params map[string]interface{}
+	Key    JoinKeyFn
+}
+
+// ExecuteJoin runs both queries and merges their streams by key, calling onMatch for every matching pair.
+// it never materializes any side fully: only the records of the current right-side key are held in memory.
+// both queries go through the checks of ExecuteQuery and are reported like two of its calls, with the options of opts.
+// they are executed under a single access lock, quota and concurrency slot, so the join is admitted like a single query.
+func (d *Driver) ExecuteJoin(ctx context.Context, left, right JoinSide, onMatch JoinHookFn, opts ...QueryOption) error {
+	if err := d.lifecycle.enter(); err != nil {
+		return err
+	}
+	defer d.lifecycle.exit()
+	d.usage.inFlight.Add(1)
+	defer d.usage.inFlight.Add(-1)
+	if d.quotas != nil {
+		release, err := d.quotas.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	leftOptions, rightOptions := newQueryOptions(opts), newQueryOptions(opts)
+	if err := d.screen(ctx, left.Query, leftOptions); err != nil {
+		return err
+	}
+	if err := d.screen(ctx, right.Query, rightOptions); err != nil {
+		return err
+	}
+	release, err := d.acquireConcurrency(ctx, leftOptions)
+	if err != nil {
+		return err
+	}
+	defer release()
+	d.accessLock.RLock()
+	defer d.accessLock.RUnlock()
+	return d.executeAdmitted(ctx, left.Query, CoerceParams(left.Params), func(leftResult neo4j.ResultWithContext) error {
+		return d.executeAdmitted(ctx, right.Query, CoerceParams(right.Params), func(rightResult neo4j.ResultWithContext) error {
+			return JoinResults(ctx, leftResult, rightResult, left.Key, right.Key, onMatch)
+		}, rightOptions)
+	}, leftOptions)
+}
+
+// JoinResults performs a sort-merge inner join of two record streams ordered by their keys.
+// records with the same key on the right side are buffered so that they can be paired with every left record
+// sharing that key; the buffer is released as soon as the left side moves past that key.
+func JoinResults(ctx context.Context, left, right RecordIterator, leftKey, rightKey JoinKeyFn, onMatch JoinHookFn) error {
+	var leftRecord *neo4j.Record
+	rightCursor := &joinCursor{iterator: right, key: rightKey}
+	if err := rightCursor.advance(ctx); err != nil {
+		return err
+	}
+
+	var group []*neo4j.Record
+	var groupKey string
+	grouped := false
+	for left.NextRecord(ctx, &leftRecord) {
+		key, err := leftKey(leftRecord)
+		if err != nil {
+			return err
+		}
+		if !grouped || key != groupKey {
+			group, groupKey, grouped = nil, key, true
+			for rightCursor.current != nil && rightCursor.currentKey < key {
+				if err := rightCursor.advance(ctx); err != nil {
+					return err
+				}
+			}
+			for rightCursor.current != nil && rightCursor.currentKey == key {
+				group = append(group, rightCursor.current)
+				if err := rightCursor.advance(ctx); err != nil {
+					return err
+				}
+			}
+		}
+		for _, match := range group {
+			if err := onMatch(leftRecord, match); err != nil {
+				return err
+			}
+		}
+	}
+	return left.Err()
+}
+
+// joinCursor keeps track of the current record of a join side and its key
+type joinCursor struct {
+	iterator   RecordIterator
+	key        JoinKeyFn
+	current    *neo4j.Record
+	currentKey string
+}
+
+func (c *joinCursor) advance(ctx context.Context) error {
+	var record *neo4j.Record
+	if !c.iterator.NextRecord(ctx, &record) {
+		c.current = nil
+		return c.iterator.Err()
+	}
+	key, err := c.key(record)
+	if err != nil {
+		return err
+	}
+	c.current, c.currentKey = record, key
+	return nil
+}
@@ -0,0 +1,89 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestJoinResults(t *testing.T) {
+	suite.Run(t, new(JoinTestSuite))
+}
+
+type JoinTestSuite struct {
+	suite.Suite
+}
+
+func keyAt(index int) JoinKeyFn {
+	return func(record *neo4j.Record) (string, error) {
+		return record.Values[index].(string), nil
+	}
+}
+
+func (s *JoinTestSuite) join(left, right *fakeRecords) ([][2]any, error) {
+	var pairs [][2]any
+	err := JoinResults(context.Background(), left, right, keyAt(0), keyAt(0), func(l, r *neo4j.Record) error {
+		pairs = append(pairs, [2]any{l.Values[1], r.Values[1]})
+		return nil
+	})
+	return pairs, err
+}
+
+func (s *JoinTestSuite) TestMergesOrderedStreamsByKey() {
+	left := newFakeRecords([]string{"k", "v"}, []any{"a", 1}, []any{"b", 2}, []any{"b", 3}, []any{"d", 4})
+	right := newFakeRecords([]string{"k", "v"}, []any{"b", "x"}, []any{"b", "y"}, []any{"c", "z"}, []any{"d", "w"})
+
+	pairs, err := s.join(left, right)
+
+	s.Require().NoError(err)
+	s.Equal([][2]any{{2, "x"}, {2, "y"}, {3, "x"}, {3, "y"}, {4, "w"}}, pairs)
+}
+
+func (s *JoinTestSuite) TestEmptySide() {
+	left := newFakeRecords([]string{"k", "v"}, []any{"a", 1})
+	right := newFakeRecords([]string{"k", "v"})
+
+	pairs, err := s.join(left, right)
+
+	s.Require().NoError(err)
+	s.Empty(pairs)
+}
+
+func (s *JoinTestSuite) TestSurfacesStreamErrors() {
+	left := newFakeRecords([]string{"k", "v"})
+	left.err = errors.New("boom")
+	right := newFakeRecords([]string{"k", "v"}, []any{"a", 1})
+
+	_, err := s.join(left, right)
+
+	s.EqualError(err, "boom")
+}
+
+func (s *JoinTestSuite) TestJoinsGoThroughTheChecksOfTheQueries() {
+	ctx := context.Background()
+	cluster := &fakeCluster{}
+	defer UseDriverFactory(cluster.newDriver)()
+	settings := connectionSettings
+	settings.SafeMode = &SafeModeConfig{}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(ctx)
+	okKey := func(*neo4j.Record) (string, error) { return "ok", nil }
+	matches := 0
+	onMatch := func(_, _ *neo4j.Record) error {
+		matches++
+		return nil
+	}
+
+	err = driver.ExecuteJoin(ctx, JoinSide{Query: "RETURN true AS ok", Key: okKey}, JoinSide{Query: "MATCH (n) DETACH DELETE n", Key: okKey}, onMatch)
+	s.ErrorIs(err, ErrUnsafeStatement)
+	s.Empty(cluster.sessionConfigs)
+
+	err = driver.ExecuteJoin(ctx, JoinSide{Query: "RETURN true AS ok", Key: okKey}, JoinSide{Query: "RETURN true AS ok", Key: okKey}, onMatch, WithAccessMode(neo4j.AccessModeRead))
+	s.Require().NoError(err)
+	s.Equal(1, matches)
+	s.Equal([]neo4j.AccessMode{neo4j.AccessModeRead, neo4j.AccessModeRead}, cluster.modes)
+}
@@ -0,0 +1,25 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"io"
+)
+
+// RecordToJSON encodes a record as a JSON object, converting its values with ToPlainValue.
+// temporal values are always encoded as strings, see WithTemporalAsString.
+func RecordToJSON(record *neo4j.Record, opts ...MapOption) ([]byte, error) {
+	return json.Marshal(RecordToMap(record, jsonMapOptions(opts)...))
+}
+
+// WriteJSON streams all the remaining records to w as a JSON array of objects, one record at a time,
+// so that large results can be returned from HTTP handlers without being held in memory.
+// temporal values are always encoded as strings, see WithTemporalAsString.
+func WriteJSON(ctx context.Context, w io.Writer, result RecordIterator, opts ...MapOption) error {
+	return encodeRecords(ctx, result, nil, NewJSONEncoder(w, opts...))
+}
+
+func jsonMapOptions(opts []MapOption) []MapOption {
+	return append([]MapOption{WithTemporalAsString()}, opts...)
+}
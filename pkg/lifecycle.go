@@ -0,0 +1,150 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// State is a step of the driver lifecycle: New → Connected ⇄ Degraded → Closed
+type State int
+
+const (
+	// StateNew is the state of a driver that did not talk to the server yet
+	StateNew State = iota
+	// StateConnected is the state of a driver whose last interaction with the server succeeded
+	StateConnected
+	// StateDegraded is the state of a driver that lost connectivity and did not recover yet
+	StateDegraded
+	// StateClosed is the final state of a driver closed with Close or Shutdown, it is never reconnected
+	StateClosed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateNew:
+		return "new"
+	case StateConnected:
+		return "connected"
+	case StateDegraded:
+		return "degraded"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// State returns the current lifecycle state of the driver
+func (d *Driver) State() State {
+	return d.lifecycle.current()
+}
+
+// ErrDriverClosed is returned by queries executed once the driver has been closed or is shutting down
+var ErrDriverClosed = errors.New("driver is closed")
+
+// lifecycle tracks the state and the in-flight queries of a driver
+type lifecycle struct {
+	mutex    sync.Mutex
+	state    State
+	inFlight int
+	drained  chan struct{}
+	// commits are the async commits in flight, see WithAsyncCommit
+	commits sync.WaitGroup
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{drained: make(chan struct{})}
+}
+
+// enter registers a new in-flight query, unless the driver is closed
+func (l *lifecycle) enter() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.state == StateClosed {
+		return ErrDriverClosed
+	}
+	l.inFlight++
+	return nil
+}
+
+// enterCommit registers an async commit as an in-flight query, unless the driver is closed
+func (l *lifecycle) enterCommit() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.state == StateClosed {
+		return ErrDriverClosed
+	}
+	l.inFlight++
+	l.commits.Add(1)
+	return nil
+}
+
+func (l *lifecycle) exitCommit() {
+	l.commits.Done()
+	l.exit()
+}
+
+// awaitCommits waits for the async commits in flight until ctx is done. once closed, no commit can start anymore
+func (l *lifecycle) awaitCommits(ctx context.Context) error {
+	committed := make(chan struct{})
+	go func() {
+		l.commits.Wait()
+		close(committed)
+	}()
+	select {
+	case <-committed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *lifecycle) exit() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.inFlight--
+	if l.state == StateClosed && l.inFlight == 0 {
+		close(l.drained)
+	}
+}
+
+// close stops accepting new queries, the returned channel is closed once in-flight queries are done
+func (l *lifecycle) close() <-chan struct{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.state != StateClosed {
+		l.state = StateClosed
+		if l.inFlight == 0 {
+			close(l.drained)
+		}
+	}
+	return l.drained
+}
+
+func (l *lifecycle) isClosed() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.state == StateClosed
+}
+
+// transition moves the driver to the given state, unless it is closed: closing is final
+func (l *lifecycle) transition(state State) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.state != StateClosed {
+		l.state = state
+	}
+}
+
+func (l *lifecycle) inFlightCount() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.inFlight
+}
+
+func (l *lifecycle) current() State {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.state
+}
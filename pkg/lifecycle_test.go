@@ -0,0 +1,72 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestLifecycle(t *testing.T) {
+	suite.Run(t, new(LifecycleTestSuite))
+}
+
+type LifecycleTestSuite struct {
+	suite.Suite
+	ctx    context.Context
+	driver *Driver
+}
+
+func (s *LifecycleTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	s.driver = driver
+}
+
+func (s *LifecycleTestSuite) TestQueriesFailFastAfterClose() {
+	s.driver.Close(s.ctx)
+
+	err := s.driver.ExecuteQuery(s.ctx, "RETURN 1", nil, func(neo4j.ResultWithContext) error {
+		s.Fail("hook must not be called")
+		return nil
+	})
+
+	s.ErrorIs(err, ErrDriverClosed)
+}
+
+func (s *LifecycleTestSuite) TestQueriesFailFastAfterShutdown() {
+	s.Require().NoError(s.driver.Shutdown(s.ctx))
+
+	err := s.driver.ExecuteQuery(s.ctx, "RETURN 1", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	})
+
+	s.ErrorIs(err, ErrDriverClosed)
+}
+
+func (s *LifecycleTestSuite) TestShutdownIsIdempotent() {
+	s.Require().NoError(s.driver.Shutdown(s.ctx))
+	s.Require().NoError(s.driver.Shutdown(s.ctx))
+	s.driver.Close(s.ctx)
+}
+
+func (s *LifecycleTestSuite) TestStateMovesFromNewToClosed() {
+	s.Equal(StateNew, s.driver.State())
+
+	s.driver.Close(s.ctx)
+
+	s.Equal(StateClosed, s.driver.State())
+	s.Equal("closed", s.driver.State().String())
+}
+
+func (s *LifecycleTestSuite) TestWarmUpFailsWhenTheServerIsUnreachable() {
+	settings := Settings{ConnectionString: "bolt://localhost:1", WarmUp: &WarmUpConfig{Connections: 2, Retries: 1, Timeout: 5 * time.Second}}
+
+	driver, err := NewDriver(settings)
+
+	s.Nil(driver)
+	s.ErrorContains(err, "warming up connections")
+}
@@ -0,0 +1,15 @@
+package driver
+
+import "log"
+
+// Logger receives the log entries of the driver, *log.Logger implements it
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+func (d *Driver) logger() Logger {
+	if d.settings.Logger == nil {
+		return log.Default()
+	}
+	return d.settings.Logger
+}
@@ -0,0 +1,170 @@
+package driver
+
+import (
+	"context"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"sort"
+	"time"
+)
+
+// Layouts used to format temporal values when WithTemporalAsString is set
+const (
+	DateLayout          = "2006-01-02"
+	LocalTimeLayout     = "15:04:05.999999999"
+	LocalDateTimeLayout = "2006-01-02T15:04:05.999999999"
+	OffsetTimeLayout    = "15:04:05.999999999Z07:00"
+	DateTimeLayout      = time.RFC3339Nano
+)
+
+// MapOption customizes the conversion of values done by ToMaps, RecordToMap and ToPlainValue
+type MapOption func(*mapOptions)
+
+type mapOptions struct {
+	temporalAsString bool
+	// withoutIdentities omits the element ids, which differ between databases holding the same data, and sorts labels
+	withoutIdentities bool
+}
+
+// WithTemporalAsString formats temporal values as strings (RFC3339 for date times, see the *Layout constants for the others)
+// and durations as ISO-8601 strings
+func WithTemporalAsString() MapOption {
+	return func(options *mapOptions) {
+		options.temporalAsString = true
+	}
+}
+
+// ToMaps reads all the remaining records and converts each of them with RecordToMap
+func ToMaps(ctx context.Context, result RecordIterator, opts ...MapOption) ([]map[string]interface{}, error) {
+	maps := []map[string]interface{}{}
+	var record *neo4j.Record
+	for result.NextRecord(ctx, &record) {
+		maps = append(maps, RecordToMap(record, opts...))
+	}
+	return maps, result.Err()
+}
+
+// RecordToMap converts a record into a map of its keys to their values converted with ToPlainValue
+func RecordToMap(record *neo4j.Record, opts ...MapOption) map[string]interface{} {
+	return newMapOptions(opts).convert(record).(map[string]interface{})
+}
+
+// ToPlainValue converts a value returned by the driver into a tree made of maps, slices and scalars only.
+// the conversion rules are stable:
+//   - nil, bool, int64, float64, string and []byte are kept as is
+//   - lists become []interface{} and maps map[string]interface{}, their elements being converted recursively
+//   - records become map[string]interface{}, keyed by their keys
+//   - nodes become {"elementId": string, "labels": []string, "props": map}
+//   - relationships become {"elementId", "type", "startElementId", "endElementId": string, "props": map}
+//   - paths become {"nodes": []node, "relationships": []relationship}
+//   - points become {"srid": uint32, "x", "y": float64}, plus "z" for 3D points
+//   - temporal values become time.Time and durations stay neo4j.Duration, unless WithTemporalAsString is set
+func ToPlainValue(value interface{}, opts ...MapOption) interface{} {
+	return newMapOptions(opts).convert(value)
+}
+
+func newMapOptions(opts []MapOption) *mapOptions {
+	options := &mapOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+func (o *mapOptions) convert(value interface{}) interface{} {
+	switch value := value.(type) {
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, element := range value {
+			converted[i] = o.convert(element)
+		}
+		return converted
+	case map[string]interface{}:
+		return o.convertProps(value)
+	case *neo4j.Record:
+		converted := make(map[string]interface{}, len(value.Keys))
+		for i, key := range value.Keys {
+			converted[key] = o.convert(value.Values[i])
+		}
+		return converted
+	case neo4j.Node:
+		return o.convertNode(value)
+	case neo4j.Relationship:
+		return o.convertRelationship(value)
+	case neo4j.Path:
+		nodes := make([]interface{}, len(value.Nodes))
+		for i, node := range value.Nodes {
+			nodes[i] = o.convertNode(node)
+		}
+		relationships := make([]interface{}, len(value.Relationships))
+		for i, relationship := range value.Relationships {
+			relationships[i] = o.convertRelationship(relationship)
+		}
+		return map[string]interface{}{"nodes": nodes, "relationships": relationships}
+	case neo4j.Point2D:
+		return map[string]interface{}{"srid": value.SpatialRefId, "x": value.X, "y": value.Y}
+	case neo4j.Point3D:
+		return map[string]interface{}{"srid": value.SpatialRefId, "x": value.X, "y": value.Y, "z": value.Z}
+	case time.Time:
+		return o.convertTemporal(value, DateTimeLayout)
+	case neo4j.Date:
+		return o.convertTemporal(value.Time(), DateLayout)
+	case neo4j.LocalTime:
+		return o.convertTemporal(value.Time(), LocalTimeLayout)
+	case neo4j.LocalDateTime:
+		return o.convertTemporal(value.Time(), LocalDateTimeLayout)
+	case neo4j.Time:
+		return o.convertTemporal(value.Time(), OffsetTimeLayout)
+	case neo4j.Duration:
+		if o.temporalAsString {
+			return value.String()
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+func (o *mapOptions) convertProps(props map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(props))
+	for key, value := range props {
+		converted[key] = o.convert(value)
+	}
+	return converted
+}
+
+func (o *mapOptions) convertNode(node neo4j.Node) map[string]interface{} {
+	labels := node.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+	if o.withoutIdentities {
+		labels = append([]string(nil), labels...)
+		sort.Strings(labels)
+		return map[string]interface{}{"labels": labels, "props": o.convertProps(node.Props)}
+	}
+	return map[string]interface{}{
+		"elementId": node.ElementId,
+		"labels":    labels,
+		"props":     o.convertProps(node.Props),
+	}
+}
+
+func (o *mapOptions) convertRelationship(relationship neo4j.Relationship) map[string]interface{} {
+	if o.withoutIdentities {
+		return map[string]interface{}{"type": relationship.Type, "props": o.convertProps(relationship.Props)}
+	}
+	return map[string]interface{}{
+		"elementId":      relationship.ElementId,
+		"type":           relationship.Type,
+		"startElementId": relationship.StartElementId,
+		"endElementId":   relationship.EndElementId,
+		"props":          o.convertProps(relationship.Props),
+	}
+}
+
+func (o *mapOptions) convertTemporal(value time.Time, layout string) interface{} {
+	if o.temporalAsString {
+		return value.Format(layout)
+	}
+	return value
+}
@@ -0,0 +1,99 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaps(t *testing.T) {
+	suite.Run(t, new(MapsTestSuite))
+}
+
+type MapsTestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *MapsTestSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+var alice = neo4j.Node{ElementId: "4:1", Labels: []string{"User"}, Props: map[string]any{"name": "alice"}}
+var bob = neo4j.Node{ElementId: "4:2", Labels: []string{"User"}, Props: map[string]any{"name": "bob"}}
+var knows = neo4j.Relationship{ElementId: "5:1", Type: "KNOWS", StartElementId: "4:1", EndElementId: "4:2", Props: map[string]any{"since": int64(2020)}}
+
+func (s *MapsTestSuite) TestConvertsEntities() {
+	result := newFakeRecords([]string{"path", "count"}, []any{neo4j.Path{Nodes: []neo4j.Node{alice, bob}, Relationships: []neo4j.Relationship{knows}}, int64(1)})
+
+	maps, err := ToMaps(s.ctx, result)
+
+	s.Require().NoError(err)
+	s.Equal([]map[string]any{{
+		"count": int64(1),
+		"path": map[string]any{
+			"nodes": []any{
+				map[string]any{"elementId": "4:1", "labels": []string{"User"}, "props": map[string]any{"name": "alice"}},
+				map[string]any{"elementId": "4:2", "labels": []string{"User"}, "props": map[string]any{"name": "bob"}},
+			},
+			"relationships": []any{
+				map[string]any{"elementId": "5:1", "type": "KNOWS", "startElementId": "4:1", "endElementId": "4:2", "props": map[string]any{"since": int64(2020)}},
+			},
+		},
+	}}, maps)
+}
+
+func (s *MapsTestSuite) TestConvertsTemporalValuesToStrings() {
+	instant := time.Date(2023, 3, 14, 15, 9, 26, 0, time.UTC)
+	values := []any{instant, neo4j.DateOf(instant), neo4j.DurationOf(1, 2, 3, 0), neo4j.Point2D{X: 1, Y: 2, SpatialRefId: 7203}}
+
+	converted := ToPlainValue(values, WithTemporalAsString())
+
+	s.Equal([]any{"2023-03-14T15:09:26Z", "2023-03-14", "P1M2DT3S", map[string]any{"srid": uint32(7203), "x": 1.0, "y": 2.0}}, converted)
+}
+
+func (s *MapsTestSuite) TestKeepsTemporalValuesAsTimeByDefault() {
+	instant := time.Date(2023, 3, 14, 0, 0, 0, 0, time.UTC)
+
+	s.Equal(instant, ToPlainValue(neo4j.DateOf(instant)))
+}
+
+func (s *MapsTestSuite) TestEmptyResultConvertsToEmptySlice() {
+	maps, err := ToMaps(s.ctx, newFakeRecords([]string{"n"}))
+
+	s.Require().NoError(err)
+	s.NotNil(maps)
+	s.Empty(maps)
+}
+
+func (s *MapsTestSuite) TestEncodesRecordsAsJSON() {
+	record := &neo4j.Record{Keys: []string{"user", "born"}, Values: []any{alice, neo4j.DateOf(time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC))}}
+
+	body, err := RecordToJSON(record)
+
+	s.Require().NoError(err)
+	s.JSONEq(`{"user": {"elementId": "4:1", "labels": ["User"], "props": {"name": "alice"}}, "born": "1990-05-01"}`, string(body))
+}
+
+func (s *MapsTestSuite) TestStreamsResultsAsJSONArray() {
+	var empty, full strings.Builder
+
+	s.Require().NoError(WriteJSON(s.ctx, &empty, newFakeRecords([]string{"name"})))
+	s.Require().NoError(WriteJSON(s.ctx, &full, newFakeRecords([]string{"rel"}, []any{knows}, []any{nil})))
+
+	s.JSONEq(`[]`, empty.String())
+	s.JSONEq(`[{"rel": {"elementId": "5:1", "type": "KNOWS", "startElementId": "4:1", "endElementId": "4:2", "props": {"since": 2020}}}, {"rel": null}]`, full.String())
+}
+
+func (s *MapsTestSuite) TestStopsStreamingOnResultErrors() {
+	result := newFakeRecords([]string{"name"}, []any{"alice"})
+	result.err = errors.New("connection reset")
+
+	s.EqualError(WriteJSON(s.ctx, io.Discard, result), "connection reset")
+}
@@ -0,0 +1,26 @@
+package driver
+
+import "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+
+// WithTxMetadata attaches metadata to the transaction running the query, e.g. application and request identifiers.
+// it shows up in Neo4j's query log and in the output of SHOW TRANSACTIONS (dbms.listTransactions() before 5.0).
+// metadata given by several options is merged, the last value of a key wins.
+func WithTxMetadata(metadata map[string]interface{}) QueryOption {
+	return func(options *queryOptions) {
+		if options.txMetadata == nil {
+			options.txMetadata = make(map[string]interface{}, len(metadata))
+		}
+		for key, value := range metadata {
+			options.txMetadata[key] = value
+		}
+	}
+}
+
+// txConfigurers maps the options to the neo4j transaction configuration
+func (o *queryOptions) txConfigurers() []func(*neo4j.TransactionConfig) {
+	var configurers []func(*neo4j.TransactionConfig)
+	if len(o.txMetadata) > 0 {
+		configurers = append(configurers, neo4j.WithTxMetadata(o.txMetadata))
+	}
+	return configurers
+}
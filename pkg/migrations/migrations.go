@@ -0,0 +1,299 @@
+// Package migrations applies versioned Cypher migrations through the resilient driver.
+//
+// migrations are read from files named <version>_<name>.up.cypher and <version>_<name>.down.cypher,
+// typically embedded with embed.FS. the current version is stored in a (:SchemaVersion) node,
+// and a (:SchemaLock) node prevents concurrent migrators from running at the same time.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ErrDirty is returned when a previous migration failed halfway, the schema must be fixed manually before migrating again
+var ErrDirty = errors.New("schema is dirty")
+
+// ErrLocked is returned when another migrator holds the lock
+var ErrLocked = errors.New("migrations are locked by another migrator")
+
+// ErrNoDownMigration is returned when rolling back a migration that has no down file
+var ErrNoDownMigration = errors.New("migration has no down statements")
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.cypher$`)
+
+// Migration is a versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	// Up and Down are executed statement by statement, in order
+	Up, Down []string
+}
+
+// MigrationStatus tells whether a migration has been applied
+type MigrationStatus struct {
+	Migration
+	Applied bool
+}
+
+// Load reads the migrations of dir, sorted by version
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(matches[1])
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, found := byVersion[version]
+		if !found {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		} else if migration.Name != matches[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, migration.Name, matches[2])
+		}
+		if matches[3] == "up" {
+			migration.Up = SplitStatements(string(content))
+		} else {
+			migration.Down = SplitStatements(string(content))
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// SplitStatements splits a migration file into its statements, see driver.SplitScript
+func SplitStatements(content string) []string {
+	return driver.SplitScript(content)
+}
+
+// Option customizes a Migrator
+type Option func(*Migrator)
+
+// WithName sets the name identifying the version and lock nodes, so that several schemas can live in one database
+func WithName(name string) Option {
+	return func(m *Migrator) {
+		m.name = name
+	}
+}
+
+// WithOwner sets the identifier written into the lock node, defaults to a timestamp-based identifier
+func WithOwner(owner string) Option {
+	return func(m *Migrator) {
+		m.owner = owner
+	}
+}
+
+// WithLockTTL sets how long a lock is honored, so that a crashed migrator does not block others forever. defaults to 10 minutes
+func WithLockTTL(ttl time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTTL = ttl
+	}
+}
+
+// Migrator applies and rolls back migrations
+type Migrator struct {
+	runner     driver.QueryRunner
+	migrations []Migration
+	name       string
+	owner      string
+	lockTTL    time.Duration
+}
+
+// New creates a migrator for the given migrations, executed through runner (typically a *driver.Driver)
+func New(runner driver.QueryRunner, migrations []Migration, opts ...Option) (*Migrator, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Version == sorted[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", sorted[i].Version)
+		}
+	}
+	migrator := &Migrator{
+		runner:     runner,
+		migrations: sorted,
+		name:       "default",
+		owner:      fmt.Sprintf("migrator-%d", time.Now().UnixNano()),
+		lockTTL:    10 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(migrator)
+	}
+	return migrator, nil
+}
+
+// Version returns the current schema version, 0 when no migration was applied, and whether the schema is dirty
+func (m *Migrator) Version(ctx context.Context) (version int, dirty bool, err error) {
+	err = m.runner.ExecuteQuery(ctx, "MATCH (v:SchemaVersion {name: $name}) RETURN v.version AS version, v.dirty AS dirty",
+		map[string]interface{}{"name": m.name}, func(result neo4j.ResultWithContext) error {
+			var record *neo4j.Record
+			if result.NextRecord(ctx, &record) {
+				current, _ := record.Values[0].(int64)
+				version = int(current)
+				dirty, _ = record.Values[1].(bool)
+			}
+			return result.Err()
+		}, driver.WithQueryName("migrations-version"))
+	return version, dirty, err
+}
+
+// Status lists every migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	version, _, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = MigrationStatus{Migration: migration, Applied: migration.Version <= version}
+	}
+	return statuses, nil
+}
+
+// Up applies all pending migrations in order and returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (applied int, err error) {
+	err = m.locked(ctx, func(version int) error {
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if err := m.apply(ctx, migration.Version, migration.Up); err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			version = migration.Version
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the last steps applied migrations
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.locked(ctx, func(version int) error {
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version {
+				continue
+			}
+			if len(migration.Down) == 0 {
+				return fmt.Errorf("%w: %d_%s", ErrNoDownMigration, migration.Version, migration.Name)
+			}
+			previous := 0
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := m.apply(ctx, previous, migration.Down); err != nil {
+				return fmt.Errorf("rollback of migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			version = previous
+			steps--
+		}
+		return nil
+	})
+}
+
+// locked runs work with the lock held, giving it the current clean version
+func (m *Migrator) locked(ctx context.Context, work func(version int) error) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.unlock(context.Background())
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w at version %d", ErrDirty, version)
+	}
+	return work(version)
+}
+
+// apply marks the schema dirty at the target version, runs the statements and marks it clean
+func (m *Migrator) apply(ctx context.Context, target int, statements []string) error {
+	if err := m.setVersion(ctx, target, true); err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		err := m.runner.ExecuteQuery(ctx, statement, nil, consume(ctx), driver.WithQueryName("migration"))
+		if err != nil {
+			return err
+		}
+	}
+	return m.setVersion(ctx, target, false)
+}
+
+func (m *Migrator) setVersion(ctx context.Context, version int, dirty bool) error {
+	return m.runner.ExecuteQuery(ctx,
+		"MERGE (v:SchemaVersion {name: $name}) SET v.version = $version, v.dirty = $dirty, v.updatedAt = datetime()",
+		map[string]interface{}{"name": m.name, "version": version, "dirty": dirty}, consume(ctx), driver.WithQueryName("migrations-set-version"))
+}
+
+// lock takes the lock of the migrator name. the uniqueness constraint makes the concurrent migrators of a fresh database
+// MERGE the same lock node, instead of each creating and acquiring its own
+func (m *Migrator) lock(ctx context.Context) error {
+	err := m.runner.ExecuteQuery(ctx, "CREATE CONSTRAINT schema_lock_name IF NOT EXISTS FOR (l:SchemaLock) REQUIRE l.name IS UNIQUE",
+		nil, consume(ctx), driver.WithQueryName("migrations-lock-constraint"))
+	if err != nil {
+		return err
+	}
+	acquired := false
+	err = m.runner.ExecuteQuery(ctx, `MERGE (l:SchemaLock {name: $name})
+SET l.touchedAt = datetime()
+WITH l WHERE l.owner IS NULL OR l.owner = $owner OR l.expiresAt < datetime()
+SET l.owner = $owner, l.expiresAt = datetime() + duration({seconds: $ttl})
+RETURN count(l) AS acquired`,
+		map[string]interface{}{"name": m.name, "owner": m.owner, "ttl": int64(m.lockTTL.Seconds())},
+		func(result neo4j.ResultWithContext) error {
+			record, err := result.Single(ctx)
+			if err != nil {
+				return err
+			}
+			count, _ := record.Values[0].(int64)
+			acquired = count > 0
+			return nil
+		}, driver.WithQueryName("migrations-lock"))
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return ErrLocked
+	}
+	return nil
+}
+
+func (m *Migrator) unlock(ctx context.Context) {
+	_ = m.runner.ExecuteQuery(ctx, "MATCH (l:SchemaLock {name: $name, owner: $owner}) REMOVE l.owner, l.expiresAt",
+		map[string]interface{}{"name": m.name, "owner": m.owner}, consume(ctx), driver.WithQueryName("migrations-unlock"))
+}
+
+func consume(ctx context.Context) driver.ResultsHookFn {
+	return func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(ctx)
+		return err
+	}
+}
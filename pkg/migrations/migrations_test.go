@@ -0,0 +1,164 @@
+package migrations_test
+
+import (
+	"context"
+	"errors"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg/migrations"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMigrations(t *testing.T) {
+	suite.Run(t, new(MigrationsTestSuite))
+}
+
+type MigrationsTestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *MigrationsTestSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+var migrationFiles = fstest.MapFS{
+	"migrations/0001_users.up.cypher":   {Data: []byte("CREATE CONSTRAINT user_id IF NOT EXISTS FOR (u:User) REQUIRE u.id IS UNIQUE;\nCREATE INDEX user_name IF NOT EXISTS FOR (u:User) ON (u.name);\n")},
+	"migrations/0001_users.down.cypher": {Data: []byte("DROP INDEX user_name IF EXISTS;\nDROP CONSTRAINT user_id IF EXISTS;\n")},
+	"migrations/0002_teams.up.cypher":   {Data: []byte("CREATE INDEX team_name IF NOT EXISTS\nFOR (t:Team) ON (t.name)")},
+	"migrations/notes.txt":              {Data: []byte("ignored")},
+}
+
+func (s *MigrationsTestSuite) TestLoadsVersionedFiles() {
+	migrations, err := Load(migrationFiles, "migrations")
+
+	s.Require().NoError(err)
+	s.Equal([]Migration{
+		{
+			Version: 1,
+			Name:    "users",
+			Up:      []string{"CREATE CONSTRAINT user_id IF NOT EXISTS FOR (u:User) REQUIRE u.id IS UNIQUE", "CREATE INDEX user_name IF NOT EXISTS FOR (u:User) ON (u.name)"},
+			Down:    []string{"DROP INDEX user_name IF EXISTS", "DROP CONSTRAINT user_id IF EXISTS"},
+		},
+		{
+			Version: 2,
+			Name:    "teams",
+			Up:      []string{"CREATE INDEX team_name IF NOT EXISTS\nFOR (t:Team) ON (t.name)"},
+		},
+	}, migrations)
+}
+
+func (s *MigrationsTestSuite) TestAppliesPendingMigrationsUnderLock() {
+	migrations, err := Load(migrationFiles, "migrations")
+	s.Require().NoError(err)
+	database := &fakeDatabase{version: 1}
+	migrator, err := New(database, migrations, WithOwner("test"))
+	s.Require().NoError(err)
+
+	applied, err := migrator.Up(s.ctx)
+
+	s.Require().NoError(err)
+	s.Equal(1, applied)
+	s.Equal(int64(2), database.version)
+	s.False(database.dirty)
+	s.False(database.locked)
+	s.Contains(database.statements, "CREATE INDEX team_name IF NOT EXISTS\nFOR (t:Team) ON (t.name)")
+	s.NotContains(database.statements, "CREATE INDEX user_name IF NOT EXISTS FOR (u:User) ON (u.name)")
+}
+
+func (s *MigrationsTestSuite) TestRefusesToRunWhenLocked() {
+	database := &fakeDatabase{locked: true}
+	migrator, err := New(database, nil)
+	s.Require().NoError(err)
+
+	_, err = migrator.Up(s.ctx)
+
+	s.ErrorIs(err, ErrLocked)
+}
+
+func (s *MigrationsTestSuite) TestConstrainsTheLockNodesBeforeLocking() {
+	database := &fakeDatabase{}
+	migrator, err := New(database, nil)
+	s.Require().NoError(err)
+
+	_, err = migrator.Up(s.ctx)
+
+	s.Require().NoError(err)
+	s.True(database.constrained)
+	s.Empty(database.statements)
+}
+
+func (s *MigrationsTestSuite) TestRefusesToRunWhenDirty() {
+	database := &fakeDatabase{version: 1, dirty: true}
+	migrator, err := New(database, nil)
+	s.Require().NoError(err)
+
+	_, err = migrator.Up(s.ctx)
+
+	s.ErrorIs(err, ErrDirty)
+}
+
+// fakeDatabase emulates the version and lock nodes, and records the migration statements.
+// it refuses to take the lock before the uniqueness constraint of the lock nodes exists
+type fakeDatabase struct {
+	version     int64
+	dirty       bool
+	locked      bool
+	constrained bool
+	statements  []string
+}
+
+func (f *fakeDatabase) ExecuteQuery(_ context.Context, query string, params map[string]interface{}, onResults driver.ResultsHookFn, _ ...driver.QueryOption) error {
+	var records []*neo4j.Record
+	switch {
+	case strings.HasPrefix(query, "CREATE CONSTRAINT schema_lock_name IF NOT EXISTS FOR (l:SchemaLock) REQUIRE l.name IS UNIQUE"):
+		f.constrained = true
+	case strings.HasPrefix(query, "MERGE (l:SchemaLock") && !f.constrained:
+		return errors.New("concurrent migrators may each create a lock node")
+	case strings.HasPrefix(query, "MERGE (l:SchemaLock"):
+		acquired := int64(0)
+		if !f.locked {
+			f.locked, acquired = true, 1
+		}
+		records = []*neo4j.Record{{Keys: []string{"acquired"}, Values: []any{acquired}}}
+	case strings.HasPrefix(query, "MATCH (l:SchemaLock"):
+		f.locked = false
+	case strings.HasPrefix(query, "MATCH (v:SchemaVersion"):
+		records = []*neo4j.Record{{Keys: []string{"version", "dirty"}, Values: []any{f.version, f.dirty}}}
+	case strings.HasPrefix(query, "MERGE (v:SchemaVersion"):
+		f.version, f.dirty = int64(params["version"].(int)), params["dirty"].(bool)
+	default:
+		f.statements = append(f.statements, query)
+	}
+	return onResults(&fakeResult{records: records})
+}
+
+type fakeResult struct {
+	neo4j.ResultWithContext
+	records []*neo4j.Record
+}
+
+func (f *fakeResult) NextRecord(_ context.Context, record **neo4j.Record) bool {
+	if len(f.records) == 0 {
+		return false
+	}
+	*record, f.records = f.records[0], f.records[1:]
+	return true
+}
+
+func (f *fakeResult) Single(ctx context.Context) (*neo4j.Record, error) {
+	var record *neo4j.Record
+	f.NextRecord(ctx, &record)
+	return record, nil
+}
+
+func (f *fakeResult) Err() error {
+	return nil
+}
+
+func (f *fakeResult) Consume(context.Context) (neo4j.ResultSummary, error) {
+	return nil, nil
+}
@@ -0,0 +1,188 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrUnexpectedQuery is returned by MockDriver for queries matching none of its pending expectations
+var ErrUnexpectedQuery = errors.New("unexpected query")
+
+// MockDriver is an in-memory QueryExecutor answering queries with programmed expectations,
+// to unit test code written against QueryExecutor or QueryRunner without a server.
+// like Driver, it honors WithQueryStats, recovers from simulated connectivity errors and fails with ErrDriverClosed once closed.
+type MockDriver struct {
+	mutex        sync.Mutex
+	expectations []*MockExpectation
+	closed       bool
+}
+
+// MockExpectation describes an expected query and how MockDriver answers it
+type MockExpectation struct {
+	pattern      *regexp.Regexp
+	params       map[string]interface{}
+	checkParams  bool
+	database     string
+	checkDB      bool
+	records      []*neo4j.Record
+	err          error
+	connectivity int
+	recoveryErr  error
+	times        int
+	calls        int
+}
+
+// NewMockDriver creates a mock without expectations
+func NewMockDriver() *MockDriver {
+	return &MockDriver{}
+}
+
+// ExpectQuery adds an expectation for one execution of a query matching the regular expression pattern.
+// queries are matched against the pending expectations in the order they were added.
+func (m *MockDriver) ExpectQuery(pattern string) *MockExpectation {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	expectation := &MockExpectation{pattern: regexp.MustCompile(pattern), times: 1}
+	m.expectations = append(m.expectations, expectation)
+	return expectation
+}
+
+// WithParams restricts the expectation to queries executed with exactly these parameters
+func (e *MockExpectation) WithParams(params map[string]interface{}) *MockExpectation {
+	e.params, e.checkParams = params, true
+	return e
+}
+
+// OnDatabase restricts the expectation to queries executed against the named database, see WithDatabase.
+// an empty name matches the queries executed against the default database
+func (e *MockExpectation) OnDatabase(name string) *MockExpectation {
+	e.database, e.checkDB = name, true
+	return e
+}
+
+// WillReturn sets the records handed to the hook, one row of values per record
+func (e *MockExpectation) WillReturn(keys []string, rows ...[]interface{}) *MockExpectation {
+	e.records = make([]*neo4j.Record, len(rows))
+	for i, row := range rows {
+		e.records[i] = &neo4j.Record{Keys: keys, Values: row}
+	}
+	return e
+}
+
+// WillReturnError makes the query fail with err, without calling the hook
+func (e *MockExpectation) WillReturnError(err error) *MockExpectation {
+	e.err = err
+	return e
+}
+
+// WillFailConnectivity simulates times connection losses before the query runs, each one recovered with a reconnection.
+// if recoveryErr is not nil, the first reconnection fails with it instead, like when the server stays unreachable.
+func (e *MockExpectation) WillFailConnectivity(times int, recoveryErr error) *MockExpectation {
+	e.connectivity, e.recoveryErr = times, recoveryErr
+	return e
+}
+
+// Times sets how many executions the expectation answers, 1 by default
+func (e *MockExpectation) Times(times int) *MockExpectation {
+	e.times = times
+	return e
+}
+
+func (e *MockExpectation) matches(query string, params map[string]interface{}, database string) bool {
+	if e.calls >= e.times || !e.pattern.MatchString(query) || (e.checkDB && e.database != database) {
+		return false
+	}
+	return !e.checkParams || reflect.DeepEqual(e.params, params)
+}
+
+// ExecuteQuery answers the query with the first pending expectation it matches
+func (m *MockDriver) ExecuteQuery(_ context.Context, query string, params map[string]interface{}, onResults ResultsHookFn, opts ...QueryOption) (err error) {
+	options := newQueryOptions(opts)
+	defer options.report()
+	expectation, err := m.match(query, CoerceParams(params), options.database)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < expectation.connectivity; i++ {
+		options.stats.Attempts++
+		if expectation.recoveryErr != nil {
+			options.recoveryFailed = true
+			return expectation.recoveryErr
+		}
+		options.stats.Reconnects++
+	}
+	options.stats.Attempts++
+	if expectation.err != nil {
+		return expectation.err
+	}
+	return executeHook(onResults, &replayResult{records: expectation.records})
+}
+
+// ExecuteScript answers the statements of the script in order, see SplitScript.
+// like Driver, it stops at the first failing statement and returns a *ScriptError identifying it.
+func (m *MockDriver) ExecuteScript(ctx context.Context, script string, opts ...QueryOption) error {
+	for i, statement := range SplitScript(script) {
+		err := m.ExecuteQuery(ctx, statement, nil, func(result neo4j.ResultWithContext) error {
+			_, err := result.Consume(ctx)
+			return err
+		}, opts...)
+		if err != nil {
+			return &ScriptError{Index: i, Statement: statement, Err: err}
+		}
+	}
+	return nil
+}
+
+// VerifyConnectivity fails with ErrDriverClosed once the mock is closed
+func (m *MockDriver) VerifyConnectivity(context.Context) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.closed {
+		return ErrDriverClosed
+	}
+	return nil
+}
+
+func (m *MockDriver) match(query string, params map[string]interface{}, database string) (*MockExpectation, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.closed {
+		return nil, ErrDriverClosed
+	}
+	for _, expectation := range m.expectations {
+		if expectation.matches(query, params, database) {
+			expectation.calls++
+			return expectation, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnexpectedQuery, query)
+}
+
+// ExpectationsWereMet returns an error listing the expectations that were not executed as many times as expected
+func (m *MockDriver) ExpectationsWereMet() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var unmet []string
+	for _, expectation := range m.expectations {
+		if expectation.calls < expectation.times {
+			unmet = append(unmet, fmt.Sprintf("%q executed %d/%d times", expectation.pattern, expectation.calls, expectation.times))
+		}
+	}
+	if len(unmet) > 0 {
+		return fmt.Errorf("unmet expectations: %s", strings.Join(unmet, ", "))
+	}
+	return nil
+}
+
+// Close makes the following queries fail with ErrDriverClosed
+func (m *MockDriver) Close(context.Context) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.closed = true
+}
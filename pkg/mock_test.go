@@ -0,0 +1,121 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestMockDriver(t *testing.T) {
+	suite.Run(t, new(MockDriverTestSuite))
+}
+
+type MockDriverTestSuite struct {
+	suite.Suite
+	ctx  context.Context
+	mock *MockDriver
+}
+
+func (s *MockDriverTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mock = NewMockDriver()
+}
+
+func (s *MockDriverTestSuite) TestReturnsCannedRecords() {
+	s.mock.ExpectQuery(`^MATCH \(u:User\)`).WithParams(map[string]interface{}{"name": "alice"}).
+		WillReturn([]string{"name", "age"}, []any{"alice", int64(42)})
+
+	var maps []map[string]any
+	err := s.mock.ExecuteQuery(s.ctx, "MATCH (u:User) WHERE u.name = $name RETURN u.name AS name, u.age AS age", map[string]interface{}{"name": "alice"}, func(result neo4j.ResultWithContext) (err error) {
+		maps, err = ToMaps(s.ctx, result)
+		return err
+	})
+
+	s.Require().NoError(err)
+	s.Equal([]map[string]any{{"name": "alice", "age": int64(42)}}, maps)
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *MockDriverTestSuite) TestFailsOnUnexpectedQueries() {
+	s.mock.ExpectQuery("MATCH").WithParams(map[string]interface{}{"name": "alice"})
+
+	err := s.mock.ExecuteQuery(s.ctx, "MATCH (n) RETURN n", map[string]interface{}{"name": "bob"}, nil)
+
+	s.ErrorIs(err, ErrUnexpectedQuery)
+	s.EqualError(s.mock.ExpectationsWereMet(), `unmet expectations: "MATCH" executed 0/1 times`)
+}
+
+func (s *MockDriverTestSuite) TestReturnsErrors() {
+	failure := &neo4j.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError"}
+	s.mock.ExpectQuery("CREAT").WillReturnError(failure)
+
+	err := NewQueryRegistry().MustRegister("typo", "CREAT (n)").Execute(s.ctx, s.mock, "typo", nil, nil)
+
+	s.ErrorIs(err, failure)
+}
+
+func (s *MockDriverTestSuite) TestSimulatesRecoveredConnectivityFailures() {
+	s.mock.ExpectQuery("RETURN 1").WillFailConnectivity(2, nil).Times(2)
+	stats := QueryStats{}
+
+	s.NoError(s.mock.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook, WithQueryStats(&stats)))
+	s.Equal(QueryStats{Attempts: 3, Reconnects: 2}, stats)
+	s.NoError(s.mock.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook))
+	s.ErrorIs(s.mock.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook), ErrUnexpectedQuery)
+}
+
+func (s *MockDriverTestSuite) TestSimulatesFailedRecoveries() {
+	unreachable := errors.New("server unreachable")
+	s.mock.ExpectQuery("RETURN 1").WillFailConnectivity(1, unreachable)
+
+	s.ErrorIs(s.mock.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook), unreachable)
+}
+
+func (s *MockDriverTestSuite) TestFailsFastOnceClosed() {
+	s.mock.ExpectQuery("RETURN 1")
+	s.mock.Close(s.ctx)
+
+	s.ErrorIs(s.mock.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook), ErrDriverClosed)
+}
+
+func noopHook(neo4j.ResultWithContext) error {
+	return nil
+}
+
+func (s *MockDriverTestSuite) TestSubstitutesTheDriverAsQueryExecutor() {
+	var executor QueryExecutor = s.mock
+	s.mock.ExpectQuery(`^CREATE`)
+	s.mock.ExpectQuery(`^MATCH`).WillReturnError(errors.New("boom"))
+
+	err := executor.ExecuteScript(s.ctx, "CREATE (:User);\nMATCH (u:User) SET u.seen = true;")
+	executor.Close(s.ctx)
+
+	scriptErr := &ScriptError{}
+	s.Require().ErrorAs(err, &scriptErr)
+	s.Equal(1, scriptErr.Index)
+	s.ErrorIs(executor.VerifyConnectivity(s.ctx), ErrDriverClosed)
+}
+
+func (s *MockDriverTestSuite) TestMatchesTheDatabaseOfTheQueries() {
+	s.mock.ExpectQuery(`^MATCH \(u:User\)`).OnDatabase("tenant-a").WillReturn([]string{"count"}, []any{int64(1)})
+	s.mock.ExpectQuery(`^MATCH \(u:User\)`).OnDatabase("").WillReturn([]string{"count"}, []any{int64(2)})
+
+	counts := map[string]int64{}
+	count := func(database string) ResultsHookFn {
+		return func(result neo4j.ResultWithContext) error {
+			record, err := result.Single(s.ctx)
+			if err == nil {
+				counts[database] = record.Values[0].(int64)
+			}
+			return err
+		}
+	}
+	s.Require().NoError(s.mock.ExecuteQuery(s.ctx, "MATCH (u:User) RETURN count(u)", nil, count("default")))
+	s.Require().NoError(OnDatabase(s.mock, "tenant-a").ExecuteQuery(s.ctx, "MATCH (u:User) RETURN count(u)", nil, count("tenant-a")))
+
+	s.Equal(map[string]int64{"default": 2, "tenant-a": 1}, counts)
+	s.NoError(s.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,48 @@
+package driver
+
+import "strings"
+
+// namespaceSeparator separates the namespace prefix from the label or relationship type, e.g. App_User
+const namespaceSeparator = "_"
+
+// Namespace prefixes labels and relationship types, so that several applications can share one database without
+// their graphs colliding. property names are left as is, since they are already scoped by the labels of their nodes.
+// the zero Namespace leaves names untouched. the querybuilder and graphbuilder packages apply it with InNamespace
+type Namespace struct {
+	// Prefix is prepended to every label and relationship type, e.g. "App" turns User into App_User
+	Prefix string
+}
+
+// Label returns the namespaced label or relationship type
+func (n Namespace) Label(name string) string {
+	if n.Prefix == "" {
+		return name
+	}
+	return n.Prefix + namespaceSeparator + name
+}
+
+// Labels returns the namespaced labels or relationship types
+func (n Namespace) Labels(names []string) []string {
+	namespaced := make([]string, len(names))
+	for i, name := range names {
+		namespaced[i] = n.Label(name)
+	}
+	return namespaced
+}
+
+// Strip returns the label or relationship type without the namespace prefix, and whether it belonged to the namespace
+func (n Namespace) Strip(name string) (string, bool) {
+	if n.Prefix == "" {
+		return name, true
+	}
+	prefix := n.Prefix + namespaceSeparator
+	if !strings.HasPrefix(name, prefix) {
+		return name, false
+	}
+	return strings.TrimPrefix(name, prefix), true
+}
+
+// Namespace returns Settings.Namespace, to be applied to the queries built for this driver
+func (d *Driver) Namespace() Namespace {
+	return d.settings.Namespace
+}
@@ -0,0 +1,45 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	suite.Run(t, new(NamespaceTestSuite))
+}
+
+type NamespaceTestSuite struct {
+	suite.Suite
+}
+
+func (s *NamespaceTestSuite) TestPrefixesLabels() {
+	namespace := Namespace{Prefix: "App"}
+
+	s.Equal("App_User", namespace.Label("User"))
+	s.Equal([]string{"App_User", "App_KNOWS"}, namespace.Labels([]string{"User", "KNOWS"}))
+	s.Equal("User", Namespace{}.Label("User"))
+}
+
+func (s *NamespaceTestSuite) TestStripsPrefixes() {
+	namespace := Namespace{Prefix: "App"}
+
+	label, found := namespace.Strip("App_User")
+	s.True(found)
+	s.Equal("User", label)
+	_, found = namespace.Strip("Billing_User")
+	s.False(found)
+}
+
+func (s *NamespaceTestSuite) TestIsConfiguredPerDriver() {
+	settings := connectionSettings
+	settings.Namespace = Namespace{Prefix: "Billing"}
+
+	driver, err := NewDriver(settings)
+
+	s.Require().NoError(err)
+	defer driver.Close(context.Background())
+	s.Equal("Billing_Invoice", driver.Namespace().Label("Invoice"))
+}
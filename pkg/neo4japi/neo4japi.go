@@ -0,0 +1,328 @@
+// Package neo4japi is a stable facade over the values returned by the resilient driver, so that applications
+// do not import the neo4j driver types directly, and migrating to a new major version of the neo4j driver only
+// changes this package:
+//
+//	err := neo4japi.Execute(ctx, d, "MATCH (u:User) RETURN u", nil, func(result neo4japi.Result) error {
+//		for result.Next(ctx) {
+//			user, _ := result.Record().Get("u")
+//			fmt.Println(user.(neo4japi.Node).Props()["name"])
+//		}
+//		return result.Err()
+//	})
+//
+// values are converted once, when the record is read:
+//   - nil, bool, int64, float64, string and []byte are kept as is
+//   - lists become []interface{} and maps map[string]interface{}, their elements being converted recursively
+//   - nodes, relationships and paths become Node, Relationship and Path
+//   - points become Point, temporal values time.Time and durations Duration
+package neo4japi
+
+import (
+	"context"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"time"
+)
+
+// Record is a row of a result
+type Record interface {
+	Keys() []string
+	Values() []interface{}
+	// Get returns the value of key, and whether the record has it
+	Get(key string) (interface{}, bool)
+}
+
+// Node is a node of the graph
+type Node interface {
+	ElementId() string
+	Labels() []string
+	Props() map[string]interface{}
+}
+
+// Relationship is a relationship of the graph
+type Relationship interface {
+	ElementId() string
+	StartElementId() string
+	EndElementId() string
+	Type() string
+	Props() map[string]interface{}
+}
+
+// Path alternates nodes and the relationships between them, it has one more node than relationships
+type Path interface {
+	Nodes() []Node
+	Relationships() []Relationship
+}
+
+// Point is a 2D or 3D spatial point, Z is zero for 2D points
+type Point struct {
+	SRID    uint32
+	X, Y, Z float64
+}
+
+// Duration is a temporal amount, its months and days have no fixed length
+type Duration struct {
+	Months, Days, Seconds int64
+	Nanos                 int
+}
+
+// Counters are the updates made by a query
+type Counters interface {
+	ContainsUpdates() bool
+	NodesCreated() int
+	NodesDeleted() int
+	RelationshipsCreated() int
+	RelationshipsDeleted() int
+	PropertiesSet() int
+	LabelsAdded() int
+	LabelsRemoved() int
+}
+
+// Summary describes a consumed result
+type Summary interface {
+	Query() string
+	Parameters() map[string]interface{}
+	Database() string
+	Counters() Counters
+	ResultAvailableAfter() time.Duration
+	ResultConsumedAfter() time.Duration
+}
+
+// Result iterates over the records of a query
+type Result interface {
+	// Next moves to the next record, it returns false once the records are exhausted or the query failed, see Err
+	Next(ctx context.Context) bool
+	Record() Record
+	Err() error
+	// Collect reads all the remaining records
+	Collect(ctx context.Context) ([]Record, error)
+	// Consume discards the remaining records and returns the summary
+	Consume(ctx context.Context) (Summary, error)
+}
+
+// Execute runs the query through runner, typically a *driver.Driver, and hands its result to onResults
+func Execute(ctx context.Context, runner driver.QueryRunner, query string, params map[string]interface{}, onResults func(result Result) error, opts ...driver.QueryOption) error {
+	return runner.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) error {
+		return onResults(&resultAdapter{result: result})
+	}, opts...)
+}
+
+// Collect runs the query through runner and returns all its records
+func Collect(ctx context.Context, runner driver.QueryRunner, query string, params map[string]interface{}, opts ...driver.QueryOption) ([]Record, error) {
+	var records []Record
+	err := Execute(ctx, runner, query, params, func(result Result) (err error) {
+		records, err = result.Collect(ctx)
+		return err
+	}, opts...)
+	return records, err
+}
+
+// NewRecord converts a record of the neo4j driver, for code bridging both APIs
+func NewRecord(record *neo4j.Record) Record {
+	values := make([]interface{}, len(record.Values))
+	for i, value := range record.Values {
+		values[i] = convert(value)
+	}
+	return &recordAdapter{keys: record.Keys, values: values}
+}
+
+func convert(value interface{}) interface{} {
+	switch value := value.(type) {
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, element := range value {
+			converted[i] = convert(element)
+		}
+		return converted
+	case map[string]interface{}:
+		return convertProps(value)
+	case neo4j.Node:
+		return newNode(value)
+	case neo4j.Relationship:
+		return newRelationship(value)
+	case neo4j.Path:
+		path := &pathAdapter{nodes: make([]Node, len(value.Nodes)), relationships: make([]Relationship, len(value.Relationships))}
+		for i, node := range value.Nodes {
+			path.nodes[i] = newNode(node)
+		}
+		for i, relationship := range value.Relationships {
+			path.relationships[i] = newRelationship(relationship)
+		}
+		return path
+	case neo4j.Point2D:
+		return Point{SRID: value.SpatialRefId, X: value.X, Y: value.Y}
+	case neo4j.Point3D:
+		return Point{SRID: value.SpatialRefId, X: value.X, Y: value.Y, Z: value.Z}
+	case neo4j.Date:
+		return value.Time()
+	case neo4j.LocalTime:
+		return value.Time()
+	case neo4j.LocalDateTime:
+		return value.Time()
+	case neo4j.Time:
+		return value.Time()
+	case neo4j.Duration:
+		return Duration{Months: value.Months, Days: value.Days, Seconds: value.Seconds, Nanos: value.Nanos}
+	default:
+		return value
+	}
+}
+
+func convertProps(props map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(props))
+	for key, value := range props {
+		converted[key] = convert(value)
+	}
+	return converted
+}
+
+type resultAdapter struct {
+	result neo4j.ResultWithContext
+}
+
+func (r *resultAdapter) Next(ctx context.Context) bool {
+	return r.result.Next(ctx)
+}
+
+func (r *resultAdapter) Record() Record {
+	record := r.result.Record()
+	if record == nil {
+		return nil
+	}
+	return NewRecord(record)
+}
+
+func (r *resultAdapter) Err() error {
+	return r.result.Err()
+}
+
+func (r *resultAdapter) Collect(ctx context.Context) ([]Record, error) {
+	records := []Record{}
+	for r.result.Next(ctx) {
+		records = append(records, NewRecord(r.result.Record()))
+	}
+	return records, r.result.Err()
+}
+
+func (r *resultAdapter) Consume(ctx context.Context) (Summary, error) {
+	summary, err := r.result.Consume(ctx)
+	if err != nil || summary == nil {
+		return nil, err
+	}
+	return &summaryAdapter{summary: summary}, nil
+}
+
+type recordAdapter struct {
+	keys   []string
+	values []interface{}
+}
+
+func (r *recordAdapter) Keys() []string {
+	return r.keys
+}
+
+func (r *recordAdapter) Values() []interface{} {
+	return r.values
+}
+
+func (r *recordAdapter) Get(key string) (interface{}, bool) {
+	for i, k := range r.keys {
+		if k == key {
+			return r.values[i], true
+		}
+	}
+	return nil, false
+}
+
+type nodeAdapter struct {
+	elementId string
+	labels    []string
+	props     map[string]interface{}
+}
+
+func newNode(node neo4j.Node) Node {
+	return &nodeAdapter{elementId: node.ElementId, labels: node.Labels, props: convertProps(node.Props)}
+}
+
+func (n *nodeAdapter) ElementId() string {
+	return n.elementId
+}
+
+func (n *nodeAdapter) Labels() []string {
+	return n.labels
+}
+
+func (n *nodeAdapter) Props() map[string]interface{} {
+	return n.props
+}
+
+type relationshipAdapter struct {
+	elementId, startElementId, endElementId, relType string
+	props                                            map[string]interface{}
+}
+
+func newRelationship(relationship neo4j.Relationship) Relationship {
+	return &relationshipAdapter{elementId: relationship.ElementId, startElementId: relationship.StartElementId, endElementId: relationship.EndElementId,
+		relType: relationship.Type, props: convertProps(relationship.Props)}
+}
+
+func (r *relationshipAdapter) ElementId() string {
+	return r.elementId
+}
+
+func (r *relationshipAdapter) StartElementId() string {
+	return r.startElementId
+}
+
+func (r *relationshipAdapter) EndElementId() string {
+	return r.endElementId
+}
+
+func (r *relationshipAdapter) Type() string {
+	return r.relType
+}
+
+func (r *relationshipAdapter) Props() map[string]interface{} {
+	return r.props
+}
+
+type pathAdapter struct {
+	nodes         []Node
+	relationships []Relationship
+}
+
+func (p *pathAdapter) Nodes() []Node {
+	return p.nodes
+}
+
+func (p *pathAdapter) Relationships() []Relationship {
+	return p.relationships
+}
+
+type summaryAdapter struct {
+	summary neo4j.ResultSummary
+}
+
+func (s *summaryAdapter) Query() string {
+	return s.summary.Query().Text()
+}
+
+func (s *summaryAdapter) Parameters() map[string]interface{} {
+	return s.summary.Query().Parameters()
+}
+
+func (s *summaryAdapter) Database() string {
+	return s.summary.Database().Name()
+}
+
+func (s *summaryAdapter) Counters() Counters {
+	return s.summary.Counters()
+}
+
+func (s *summaryAdapter) ResultAvailableAfter() time.Duration {
+	return s.summary.ResultAvailableAfter()
+}
+
+func (s *summaryAdapter) ResultConsumedAfter() time.Duration {
+	return s.summary.ResultConsumedAfter()
+}
@@ -0,0 +1,106 @@
+package neo4japi_test
+
+import (
+	"context"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg/neo4japi"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestNeo4jAPI(t *testing.T) {
+	suite.Run(t, new(Neo4jAPITestSuite))
+}
+
+type Neo4jAPITestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *Neo4jAPITestSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+func (s *Neo4jAPITestSuite) TestConvertsGraphValues() {
+	born := time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC)
+	alice := neo4j.Node{ElementId: "4:a:1", Labels: []string{"User"}, Props: map[string]any{"name": "alice", "born": neo4j.DateOf(born)}}
+	bob := neo4j.Node{ElementId: "4:a:2", Labels: []string{"User"}, Props: map[string]any{"name": "bob"}}
+	knows := neo4j.Relationship{ElementId: "5:a:1", StartElementId: "4:a:1", EndElementId: "4:a:2", Type: "KNOWS", Props: map[string]any{"since": int64(2020)}}
+	runner := &fakeRunner{records: []*neo4j.Record{{
+		Keys: []string{"path", "home", "session"},
+		Values: []any{
+			neo4j.Path{Nodes: []neo4j.Node{alice, bob}, Relationships: []neo4j.Relationship{knows}},
+			[]any{neo4j.Point2D{X: 1, Y: 2, SpatialRefId: 7203}},
+			neo4j.DurationOf(1, 2, 3, 4),
+		},
+	}}}
+
+	records, err := Collect(s.ctx, runner, "MATCH path = (:User)-[:KNOWS]->(:User) RETURN path", nil)
+
+	s.Require().NoError(err)
+	s.Require().Len(records, 1)
+	value, found := records[0].Get("path")
+	s.Require().True(found)
+	path := value.(Path)
+	s.Equal("alice", path.Nodes()[0].Props()["name"])
+	s.Equal(born, path.Nodes()[0].Props()["born"])
+	s.Equal([]string{"User"}, path.Nodes()[1].Labels())
+	s.Equal("KNOWS", path.Relationships()[0].Type())
+	s.Equal("4:a:2", path.Relationships()[0].EndElementId())
+	s.Equal([]any{Point{SRID: 7203, X: 1, Y: 2}}, records[0].Values()[1])
+	s.Equal(Duration{Months: 1, Days: 2, Seconds: 3, Nanos: 4}, records[0].Values()[2])
+	_, found = records[0].Get("missing")
+	s.False(found)
+}
+
+func (s *Neo4jAPITestSuite) TestIteratesOverRecords() {
+	runner := &fakeRunner{records: []*neo4j.Record{
+		{Keys: []string{"i"}, Values: []any{int64(1)}},
+		{Keys: []string{"i"}, Values: []any{int64(2)}},
+	}}
+	var values []any
+
+	err := Execute(s.ctx, runner, "UNWIND [1, 2] AS i RETURN i", nil, func(result Result) error {
+		for result.Next(s.ctx) {
+			values = append(values, result.Record().Values()[0])
+		}
+		return result.Err()
+	})
+
+	s.Require().NoError(err)
+	s.Equal([]any{int64(1), int64(2)}, values)
+}
+
+type fakeRunner struct {
+	records []*neo4j.Record
+}
+
+func (f *fakeRunner) ExecuteQuery(_ context.Context, _ string, _ map[string]interface{}, onResults driver.ResultsHookFn, _ ...driver.QueryOption) error {
+	return onResults(&fakeResult{records: f.records})
+}
+
+// fakeResult is an in-memory neo4j.ResultWithContext, the embedded interface is left nil
+type fakeResult struct {
+	neo4j.ResultWithContext
+	records []*neo4j.Record
+	current *neo4j.Record
+}
+
+func (f *fakeResult) Next(context.Context) bool {
+	if len(f.records) == 0 {
+		f.current = nil
+		return false
+	}
+	f.current, f.records = f.records[0], f.records[1:]
+	return true
+}
+
+func (f *fakeResult) Record() *neo4j.Record {
+	return f.current
+}
+
+func (f *fakeResult) Err() error {
+	return nil
+}
@@ -0,0 +1,6 @@
+package neotest
+
+// ContainerEnv returns the environment of the container started for config
+func ContainerEnv(config Config) (map[string]string, error) {
+	return containerEnv(withDefaults(config))
+}
@@ -0,0 +1,125 @@
+// Package neotest starts disposable Neo4j servers in Docker with testcontainers,
+// so that integration test suites do not depend on a manually started server:
+//
+//	server, err := neotest.Start(ctx, neotest.Config{Image: "neo4j:5", Plugins: []string{"apoc"}})
+//	...
+//	defer server.Terminate(ctx)
+//	d, err := driver.NewDriver(server.Settings)
+package neotest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"strings"
+	"time"
+)
+
+// Defaults of Config
+const (
+	DefaultImage          = "neo4j:5"
+	DefaultPassword       = "letmein!"
+	DefaultStartupTimeout = 2 * time.Minute
+)
+
+const boltPort = "7687/tcp"
+
+// Config describes the server to start, its zero value starts the latest Neo4j 5 without plugins
+type Config struct {
+	// Image is the Docker image, e.g. neo4j:4.4-enterprise. defaults to DefaultImage
+	Image string
+	// Password of the neo4j user, defaults to DefaultPassword
+	Password string
+	// Plugins are installed at startup by the image, e.g. apoc
+	Plugins []string
+	// Env holds additional environment variables, e.g. NEO4J_ACCEPT_LICENSE_AGREEMENT for enterprise images
+	Env map[string]string
+	// StartupTimeout bounds the time waited for the server to accept connections, defaults to DefaultStartupTimeout
+	StartupTimeout time.Duration
+}
+
+// Server is a running Neo4j container
+type Server struct {
+	container testcontainers.Container
+	// Settings connect to the server with a direct bolt:// connection
+	Settings driver.Settings
+}
+
+// Start runs a Neo4j container and waits until it accepts connections
+func Start(ctx context.Context, config Config) (*Server, error) {
+	config = withDefaults(config)
+	env, err := containerEnv(config)
+	if err != nil {
+		return nil, err
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        config.Image,
+			ExposedPorts: []string{boltPort},
+			Env:          env,
+			WaitingFor:   wait.ForLog("Started.").WithStartupTimeout(config.StartupTimeout),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	uri, err := container.PortEndpoint(ctx, boltPort, "bolt")
+	if err != nil {
+		_ = container.Terminate(ctx)
+		return nil, err
+	}
+	return &Server{
+		container: container,
+		Settings:  driver.Settings{ConnectionString: uri, User: "neo4j", Password: config.Password},
+	}, nil
+}
+
+// Terminate stops and removes the container
+func (s *Server) Terminate(ctx context.Context) error {
+	return s.container.Terminate(ctx)
+}
+
+func withDefaults(config Config) Config {
+	if config.Image == "" {
+		config.Image = DefaultImage
+	}
+	if config.Password == "" {
+		config.Password = DefaultPassword
+	}
+	if config.StartupTimeout <= 0 {
+		config.StartupTimeout = DefaultStartupTimeout
+	}
+	return config
+}
+
+// containerEnv configures authentication and plugins, whose variable was renamed in Neo4j 5
+func containerEnv(config Config) (map[string]string, error) {
+	env := map[string]string{"NEO4J_AUTH": "neo4j/" + config.Password}
+	if len(config.Plugins) > 0 {
+		plugins, err := json.Marshal(config.Plugins)
+		if err != nil {
+			return nil, err
+		}
+		variable := "NEO4J_PLUGINS"
+		if isNeo4j4(config.Image) {
+			variable = "NEO4JLABS_PLUGINS"
+		}
+		env[variable] = string(plugins)
+	}
+	for key, value := range config.Env {
+		if _, reserved := env[key]; reserved {
+			return nil, fmt.Errorf("environment variable %s is set from Config", key)
+		}
+		env[key] = value
+	}
+	return env, nil
+}
+
+func isNeo4j4(image string) bool {
+	tag := image[strings.LastIndex(image, ":")+1:]
+	return strings.HasPrefix(tag, "4.")
+}
@@ -0,0 +1,39 @@
+package neotest_test
+
+import (
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg/neotest"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestNeoTest(t *testing.T) {
+	suite.Run(t, new(NeoTestTestSuite))
+}
+
+type NeoTestTestSuite struct {
+	suite.Suite
+}
+
+func (s *NeoTestTestSuite) TestConfiguresAuthentication() {
+	env, err := ContainerEnv(Config{})
+
+	s.Require().NoError(err)
+	s.Equal(map[string]string{"NEO4J_AUTH": "neo4j/" + DefaultPassword}, env)
+}
+
+func (s *NeoTestTestSuite) TestInstallsPluginsPerVersion() {
+	env, err := ContainerEnv(Config{Image: "neo4j:5.5", Plugins: []string{"apoc", "graph-data-science"}})
+	s.Require().NoError(err)
+	s.Equal(`["apoc","graph-data-science"]`, env["NEO4J_PLUGINS"])
+
+	env, err = ContainerEnv(Config{Image: "neo4j:4.4-enterprise", Plugins: []string{"apoc"}, Env: map[string]string{"NEO4J_ACCEPT_LICENSE_AGREEMENT": "yes"}})
+	s.Require().NoError(err)
+	s.Equal(`["apoc"]`, env["NEO4JLABS_PLUGINS"])
+	s.Equal("yes", env["NEO4J_ACCEPT_LICENSE_AGREEMENT"])
+}
+
+func (s *NeoTestTestSuite) TestRefusesToOverrideAuthentication() {
+	_, err := ContainerEnv(Config{Env: map[string]string{"NEO4J_AUTH": "none"}})
+
+	s.ErrorContains(err, "NEO4J_AUTH is set from Config")
+}
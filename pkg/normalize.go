@@ -0,0 +1,62 @@
+package driver
+
+import (
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"sort"
+	"strings"
+)
+
+// normalizeRecords returns a canonical, order-insensitive representation of records.
+// entity identifiers are left out as they differ from one cluster to another.
+func normalizeRecords(records []*neo4j.Record) []string {
+	normalized := make([]string, len(records))
+	for i, record := range records {
+		normalized[i] = normalizeRecord(record)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func normalizeRecord(record *neo4j.Record) string {
+	values := make(map[string]interface{}, len(record.Keys))
+	for i, key := range record.Keys {
+		values[key] = normalizeValue(record.Values[i])
+	}
+	return fmt.Sprintf("%v", values)
+}
+
+func normalizeValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case neo4j.Node:
+		labels := append([]string(nil), value.Labels...)
+		sort.Strings(labels)
+		return fmt.Sprintf("(:%s %v)", strings.Join(labels, ":"), normalizeValue(value.Props))
+	case neo4j.Relationship:
+		return fmt.Sprintf("[:%s %v]", value.Type, normalizeValue(value.Props))
+	case neo4j.Path:
+		nodes := make([]interface{}, len(value.Nodes))
+		for i, node := range value.Nodes {
+			nodes[i] = normalizeValue(node)
+		}
+		relationships := make([]interface{}, len(value.Relationships))
+		for i, relationship := range value.Relationships {
+			relationships[i] = normalizeValue(relationship)
+		}
+		return fmt.Sprintf("path%v%v", nodes, relationships)
+	case []interface{}:
+		normalized := make([]interface{}, len(value))
+		for i, element := range value {
+			normalized[i] = normalizeValue(element)
+		}
+		return normalized
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(value))
+		for key, element := range value {
+			normalized[key] = normalizeValue(element)
+		}
+		return normalized
+	default:
+		return value
+	}
+}
@@ -0,0 +1,98 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"strings"
+)
+
+// NotificationSeverity orders the severities of the notifications returned by the server
+type NotificationSeverity int
+
+const (
+	NotificationInformation NotificationSeverity = iota
+	NotificationWarning
+	// NotificationsOff filters out all the notifications
+	NotificationsOff
+)
+
+// QueryNotification is a notification the server returned for a query, e.g. about deprecated syntax,
+// a missing index for a hint or a cartesian product
+type QueryNotification struct {
+	QueryName   string
+	Query       string
+	Code        string
+	Title       string
+	Description string
+	Severity    NotificationSeverity
+	// Line and Column locate the notification in the query, starting at 1. they are 0 when the notification has no position
+	Line, Column int
+}
+
+// NotificationHandlerFn receives the notifications of the queries, see Settings.NotificationHandler
+type NotificationHandlerFn func(ctx context.Context, notification QueryNotification)
+
+// WithNotificationSeverity hands Settings.NotificationHandler the notifications of the query at least as severe as minimum,
+// overriding Settings.MinNotificationSeverity. NotificationsOff discards them all
+func WithNotificationSeverity(minimum NotificationSeverity) QueryOption {
+	return func(options *queryOptions) {
+		options.notificationSeverity = &minimum
+	}
+}
+
+func (s NotificationSeverity) String() string {
+	switch s {
+	case NotificationInformation:
+		return "INFORMATION"
+	case NotificationWarning:
+		return "WARNING"
+	case NotificationsOff:
+		return "OFF"
+	}
+	return fmt.Sprintf("NotificationSeverity(%d)", int(s))
+}
+
+func notificationSeverityOf(severity string) NotificationSeverity {
+	if strings.EqualFold(severity, "WARNING") {
+		return NotificationWarning
+	}
+	return NotificationInformation
+}
+
+// notify hands the notifications of the summary of the query to Settings.NotificationHandler.
+// like the summary sinks, it must be called once the hook is done with the result, as it consumes what's left of it
+func (d *Driver) notify(ctx context.Context, query string, result neo4j.ResultWithContext, options *queryOptions) {
+	if d.settings.NotificationHandler == nil {
+		return
+	}
+	minimum := d.settings.MinNotificationSeverity
+	if options.notificationSeverity != nil {
+		minimum = *options.notificationSeverity
+	}
+	if minimum >= NotificationsOff {
+		return
+	}
+	summary := options.resultSummary(ctx, result)
+	if summary == nil {
+		return
+	}
+	for _, notification := range summary.Notifications() {
+		severity := notificationSeverityOf(notification.Severity())
+		if severity < minimum {
+			continue
+		}
+		queryNotification := QueryNotification{
+			QueryName:   options.name,
+			Query:       query,
+			Code:        notification.Code(),
+			Title:       notification.Title(),
+			Description: notification.Description(),
+			Severity:    severity,
+		}
+		if position := notification.Position(); position != nil {
+			queryNotification.Line, queryNotification.Column = position.Line(), position.Column()
+		}
+		d.settings.NotificationHandler(ctx, queryNotification)
+	}
+}
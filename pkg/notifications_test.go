@@ -0,0 +1,107 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestNotifications(t *testing.T) {
+	suite.Run(t, new(NotificationsTestSuite))
+}
+
+type NotificationsTestSuite struct {
+	suite.Suite
+	ctx           context.Context
+	restore       func()
+	settings      Settings
+	notifications []QueryNotification
+}
+
+func (s *NotificationsTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.notifications = nil
+	s.restore = UseDriverFactory((&fakeCluster{summary: &fakeSummary{notifications: []neo4j.Notification{
+		fakeNotification{code: "Neo.ClientNotification.Statement.CartesianProduct", severity: "INFORMATION", position: &fakePosition{line: 1, column: 1}},
+		fakeNotification{code: "Neo.ClientNotification.Statement.FeatureDeprecationWarning", severity: "WARNING"},
+	}}}).newDriver)
+	s.settings = connectionSettings
+	s.settings.NotificationHandler = func(_ context.Context, notification QueryNotification) {
+		s.notifications = append(s.notifications, notification)
+	}
+}
+
+func (s *NotificationsTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *NotificationsTestSuite) TestHandsTheNotificationsToTheHandler() {
+	driver, err := NewDriver(s.settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	s.Require().NoError(driver.ExecuteQuery(s.ctx, "MATCH (a), (b) RETURN a, b", nil, noopHook, WithQueryName("pairs")))
+
+	s.Equal([]QueryNotification{
+		{QueryName: "pairs", Query: "MATCH (a), (b) RETURN a, b", Code: "Neo.ClientNotification.Statement.CartesianProduct",
+			Severity: NotificationInformation, Line: 1, Column: 1},
+		{QueryName: "pairs", Query: "MATCH (a), (b) RETURN a, b", Code: "Neo.ClientNotification.Statement.FeatureDeprecationWarning",
+			Severity: NotificationWarning},
+	}, s.notifications)
+}
+
+func (s *NotificationsTestSuite) TestFiltersTheNotificationsBySeverity() {
+	s.settings.MinNotificationSeverity = NotificationWarning
+	driver, err := NewDriver(s.settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	s.Require().NoError(driver.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook))
+	s.Require().NoError(driver.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook, WithNotificationSeverity(NotificationsOff)))
+
+	s.Require().Len(s.notifications, 1)
+	s.Equal(NotificationWarning, s.notifications[0].Severity)
+}
+
+type fakeNotification struct {
+	code, severity string
+	position       neo4j.InputPosition
+}
+
+func (n fakeNotification) Code() string {
+	return n.code
+}
+
+func (n fakeNotification) Title() string {
+	return ""
+}
+
+func (n fakeNotification) Description() string {
+	return ""
+}
+
+func (n fakeNotification) Position() neo4j.InputPosition {
+	return n.position
+}
+
+func (n fakeNotification) Severity() string {
+	return n.severity
+}
+
+type fakePosition struct {
+	line, column int
+}
+
+func (p *fakePosition) Offset() int {
+	return 0
+}
+
+func (p *fakePosition) Line() int {
+	return p.line
+}
+
+func (p *fakePosition) Column() int {
+	return p.column
+}
@@ -0,0 +1,48 @@
+package driver
+
+import (
+	"context"
+	"time"
+)
+
+// QueryEvent describes a finished query execution
+type QueryEvent struct {
+	// Name is the logical query name set with WithQueryName, empty when none was given
+	Name string
+	// Labels are the labels set with WithQueryLabels
+	Labels map[string]string
+	// Query is the raw Cypher text
+	Query    string
+	Duration time.Duration
+	Stats    QueryStats
+	Err      error
+}
+
+// QueryObserverFn is notified after every query execution, it is the integration point for metrics, traces and logs
+type QueryObserverFn func(ctx context.Context, event QueryEvent)
+
+// DisplayName returns the logical query name, falling back to the raw Cypher text
+func (e QueryEvent) DisplayName() string {
+	return displayName(e.Name, e.Query)
+}
+
+func displayName(name, query string) string {
+	if name != "" {
+		return name
+	}
+	return query
+}
+
+func (d *Driver) notifyObserver(ctx context.Context, query string, options *queryOptions, duration time.Duration, err error) {
+	if d.settings.QueryObserver == nil {
+		return
+	}
+	d.settings.QueryObserver(ctx, QueryEvent{
+		Name:     options.name,
+		Labels:   options.labels,
+		Query:    query,
+		Duration: duration,
+		Stats:    options.stats,
+		Err:      err,
+	})
+}
@@ -0,0 +1,101 @@
+package driver
+
+import (
+	"context"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"time"
+)
+
+// QueryOption customizes a single ExecuteQuery call
+type QueryOption func(*queryOptions)
+
+// queryOptions holds the per-call configuration and the bookkeeping of a single query execution
+type queryOptions struct {
+	name       string
+	labels     map[string]string
+	stats      QueryStats
+	statsSink  *QueryStats
+	dualWrite  bool
+	readShadow bool
+	start      time.Time
+	weight     int64
+	txMetadata map[string]interface{}
+	tags       map[string]interface{}
+	cacheTTL   time.Duration
+
+	scriptTransaction bool
+	onCommit          CommitCallbackFn
+	// committing is set once the commit of the query is handed over to the background, see completeAsync,
+	// which then calls afterCommit with its outcome
+	committing  bool
+	afterCommit func(ctx context.Context, err error)
+
+	safeMode         bool
+	safeModeOverride string
+	trustedLiterals  bool
+
+	recoveryFailed bool
+
+	accessMode     neo4j.AccessMode
+	database       string
+	impersonated   string
+	summary        neo4j.ResultSummary
+	summaryFetched bool
+	summarySink    *neo4j.ResultSummary
+
+	diagnosticsSink *QueryDiagnostics
+
+	notificationSeverity *NotificationSeverity
+	consumeGuard         bool
+
+	maxAttempts int
+	noRetry     bool
+	// lastAttempt is set for the last attempt the retry policy allows, whose recovery is left to the next queries
+	lastAttempt bool
+
+	sessionConfigurers []func(*neo4j.SessionConfig)
+	fetchSize          *int
+
+	rows int
+}
+
+func newQueryOptions(opts []QueryOption) *queryOptions {
+	options := &queryOptions{start: time.Now(), accessMode: neo4j.AccessModeWrite, weight: 1}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// report publishes the bookkeeping of the execution to the sinks requested by the caller
+func (o *queryOptions) report() {
+	if o.statsSink != nil {
+		*o.statsSink = o.stats
+	}
+	if o.summarySink != nil {
+		*o.summarySink = o.summary
+	}
+	if o.diagnosticsSink != nil {
+		*o.diagnosticsSink = newQueryDiagnostics(o.summary, time.Since(o.start))
+	}
+}
+
+// WithQueryName attaches a logical name to the query (e.g. "load-user"),
+// so metrics, traces and logs can group executions by a meaningful identifier instead of the raw Cypher text
+func WithQueryName(name string) QueryOption {
+	return func(options *queryOptions) {
+		options.name = name
+	}
+}
+
+// WithQueryLabels attaches arbitrary labels to the query, reported along with its name
+func WithQueryLabels(labels map[string]string) QueryOption {
+	return func(options *queryOptions) {
+		if options.labels == nil {
+			options.labels = make(map[string]string, len(labels))
+		}
+		for key, value := range labels {
+			options.labels[key] = value
+		}
+	}
+}
@@ -0,0 +1,223 @@
+// Package outbox implements the transactional outbox pattern on top of the resilient driver:
+// events are appended as nodes within the write transactions of the application, so that they are committed
+// if and only if the graph changes are, and a Relay dispatches them to the downstream messaging afterwards:
+//
+//	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+//		if _, err := tx.Run(ctx, "CREATE (:Order {id: $id})", params); err != nil {
+//			return nil, err
+//		}
+//		return outbox.Append(ctx, tx, "orders.created", order)
+//	})
+//
+//	relay := outbox.NewRelay(d, publish, outbox.RelayConfig{})
+//	err = relay.Run(ctx)
+//
+// delivery is at least once: an event dispatched right before its relay stops is dispatched again by the next one,
+// the dispatch callback should deduplicate on Event.ID. a single relay must run at a time.
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"log"
+	"time"
+)
+
+const (
+	// Label is the label of the event nodes
+	Label = "OutboxEvent"
+
+	defaultBatchSize    = 100
+	defaultPollInterval = time.Second
+)
+
+// Transaction is the transaction events are appended in, e.g. a neo4j.ManagedTransaction or a neo4j.ExplicitTransaction
+type Transaction interface {
+	Run(ctx context.Context, cypher string, params map[string]interface{}) (neo4j.ResultWithContext, error)
+}
+
+// Event is an entry of the outbox
+type Event struct {
+	ID    string
+	Topic string
+	// Payload is the JSON encoding of the payload given to Append, see Decode
+	Payload   json.RawMessage
+	CreatedAt time.Time
+	// Attempts is the number of failed dispatches so far, LastError the error of the last one
+	Attempts  int
+	LastError string
+}
+
+// Decode unmarshals the payload of the event into target
+func (e Event) Decode(target interface{}) error {
+	return json.Unmarshal(e.Payload, target)
+}
+
+// Append adds an event to the outbox within tx and returns its identifier. payload is stored as JSON.
+// the event is only visible to the relay once tx commits, and discarded if it rolls back
+func Append(ctx context.Context, tx Transaction, topic string, payload interface{}) (string, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("could not encode the payload of the %s event: %w", topic, err)
+	}
+	id, err := newEventID()
+	if err != nil {
+		return "", err
+	}
+	result, err := tx.Run(ctx, "CREATE (:"+Label+" {id: $id, topic: $topic, payload: $payload, createdAt: datetime(), attempts: 0})", map[string]interface{}{
+		"id":      id,
+		"topic":   topic,
+		"payload": string(encoded),
+	})
+	if err != nil {
+		return "", err
+	}
+	if _, err := result.Consume(ctx); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// DispatchFn publishes an event downstream. the event is marked as processed when it returns no error,
+// otherwise it is dispatched again by the next poll
+type DispatchFn func(ctx context.Context, event Event) error
+
+// RelayConfig configures a Relay
+type RelayConfig struct {
+	// BatchSize bounds the events read per poll, defaults to 100
+	BatchSize int
+	// PollInterval is the delay between polls once the outbox is drained, defaults to a second
+	PollInterval time.Duration
+	// Retention, if set, makes the relay delete the events processed for longer than Retention. they are kept forever otherwise
+	Retention time.Duration
+	// Logger receives the errors of the polls of Run, the standard logger when nil
+	Logger driver.Logger
+}
+
+// Relay dispatches the events of the outbox in the order they were appended
+type Relay struct {
+	runner   driver.QueryRunner
+	dispatch DispatchFn
+	config   RelayConfig
+}
+
+// NewRelay creates a relay reading the outbox with runner, typically a *driver.Driver
+func NewRelay(runner driver.QueryRunner, dispatch DispatchFn, config RelayConfig) *Relay {
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultPollInterval
+	}
+	if config.Logger == nil {
+		config.Logger = log.Default()
+	}
+	return &Relay{runner: runner, dispatch: dispatch, config: config}
+}
+
+// Run polls the outbox until ctx is done. the errors are logged and the failed dispatches are retried at the next poll,
+// preserving the order of the events
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		dispatched, err := r.Poll(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			r.config.Logger.Printf("[neo4j] outbox relay: %v", err)
+		}
+		if err == nil && dispatched == r.config.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.config.PollInterval):
+		}
+	}
+}
+
+// Poll dispatches the pending events of one batch, in order, and returns how many were dispatched.
+// it stops at the first failed dispatch, recording the failure on the event, and returns its error
+func (r *Relay) Poll(ctx context.Context) (int, error) {
+	events, err := r.pending(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, event := range events {
+		if err := r.dispatch(ctx, event); err != nil {
+			return i, r.recordFailure(ctx, event, err)
+		}
+		if err := r.markProcessed(ctx, event); err != nil {
+			return i, err
+		}
+	}
+	if r.config.Retention > 0 {
+		return len(events), r.purge(ctx)
+	}
+	return len(events), nil
+}
+
+func (r *Relay) pending(ctx context.Context) ([]Event, error) {
+	var events []Event
+	query := "MATCH (e:" + Label + ") WHERE e.processedAt IS NULL " +
+		"RETURN e.id, e.topic, e.payload, e.createdAt, e.attempts, e.lastError ORDER BY e.createdAt, e.id LIMIT $limit"
+	err := r.runner.ExecuteQuery(ctx, query, map[string]interface{}{"limit": r.config.BatchSize}, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for result.NextRecord(ctx, &record) {
+			event := Event{}
+			event.ID, _ = record.Values[0].(string)
+			event.Topic, _ = record.Values[1].(string)
+			if payload, ok := record.Values[2].(string); ok {
+				event.Payload = json.RawMessage(payload)
+			}
+			event.CreatedAt, _ = record.Values[3].(time.Time)
+			if attempts, ok := record.Values[4].(int64); ok {
+				event.Attempts = int(attempts)
+			}
+			event.LastError, _ = record.Values[5].(string)
+			events = append(events, event)
+		}
+		return result.Err()
+	}, driver.WithQueryName("outbox.pending"), driver.WithAccessMode(neo4j.AccessModeRead))
+	return events, err
+}
+
+func (r *Relay) markProcessed(ctx context.Context, event Event) error {
+	return r.runner.ExecuteQuery(ctx, "MATCH (e:"+Label+" {id: $id}) SET e.processedAt = datetime()", map[string]interface{}{"id": event.ID},
+		consume(ctx), driver.WithQueryName("outbox.processed"))
+}
+
+func (r *Relay) recordFailure(ctx context.Context, event Event, dispatchErr error) error {
+	err := r.runner.ExecuteQuery(ctx, "MATCH (e:"+Label+" {id: $id}) SET e.attempts = e.attempts + 1, e.lastError = $error",
+		map[string]interface{}{"id": event.ID, "error": dispatchErr.Error()}, consume(ctx), driver.WithQueryName("outbox.failed"))
+	if err != nil {
+		return fmt.Errorf("could not dispatch event %s (%v), nor record the failure: %w", event.ID, dispatchErr, err)
+	}
+	return fmt.Errorf("could not dispatch event %s: %w", event.ID, dispatchErr)
+}
+
+func (r *Relay) purge(ctx context.Context) error {
+	return r.runner.ExecuteQuery(ctx, "MATCH (e:"+Label+") WHERE e.processedAt < datetime() - duration({seconds: $retention}) DELETE e",
+		map[string]interface{}{"retention": int64(r.config.Retention / time.Second)}, consume(ctx), driver.WithQueryName("outbox.purge"))
+}
+
+func consume(ctx context.Context) driver.ResultsHookFn {
+	return func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(ctx)
+		return err
+	}
+}
+
+func newEventID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,137 @@
+package outbox_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg/outbox"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestOutbox(t *testing.T) {
+	suite.Run(t, new(OutboxTestSuite))
+}
+
+type OutboxTestSuite struct {
+	suite.Suite
+	ctx  context.Context
+	mock *MockDriver
+	keys []string
+}
+
+func (s *OutboxTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mock = NewMockDriver()
+	s.keys = []string{"e.id", "e.topic", "e.payload", "e.createdAt", "e.attempts", "e.lastError"}
+}
+
+func (s *OutboxTestSuite) TestAppendsTheEventWithinTheTransaction() {
+	tx := &fakeTx{}
+
+	id, err := outbox.Append(s.ctx, tx, "orders.created", map[string]interface{}{"id": 42})
+
+	s.Require().NoError(err)
+	s.Len(id, 32)
+	s.Contains(tx.query, "CREATE (:OutboxEvent {")
+	s.Equal(map[string]interface{}{"id": id, "topic": "orders.created", "payload": `{"id":42}`}, tx.params)
+	s.True(tx.consumed)
+}
+
+func (s *OutboxTestSuite) TestRejectsThePayloadsThatCannotBeEncoded() {
+	tx := &fakeTx{}
+
+	_, err := outbox.Append(s.ctx, tx, "orders.created", make(chan int))
+
+	s.ErrorContains(err, "could not encode the payload of the orders.created event")
+	s.Empty(tx.query)
+}
+
+func (s *OutboxTestSuite) TestDispatchesThePendingEventsInOrder() {
+	s.mock.ExpectQuery(`^MATCH \(e:OutboxEvent\) WHERE e.processedAt IS NULL`).WithParams(map[string]interface{}{"limit": 100}).
+		WillReturn(s.keys, []any{"a", "orders.created", `{"id":1}`, time.Time{}, int64(0), nil}, []any{"b", "orders.paid", `{"id":1}`, time.Time{}, int64(2), "timeout"})
+	s.mock.ExpectQuery("SET e.processedAt").WithParams(map[string]interface{}{"id": "a"}).WillReturn(nil)
+	s.mock.ExpectQuery("SET e.processedAt").WithParams(map[string]interface{}{"id": "b"}).WillReturn(nil)
+	var dispatched []outbox.Event
+	relay := outbox.NewRelay(s.mock, func(_ context.Context, event outbox.Event) error {
+		dispatched = append(dispatched, event)
+		return nil
+	}, outbox.RelayConfig{})
+
+	count, err := relay.Poll(s.ctx)
+
+	s.Require().NoError(err)
+	s.Equal(2, count)
+	s.Require().Len(dispatched, 2)
+	s.Equal("orders.created", dispatched[0].Topic)
+	s.Equal(2, dispatched[1].Attempts)
+	s.Equal("timeout", dispatched[1].LastError)
+	var payload struct{ ID int }
+	s.Require().NoError(dispatched[0].Decode(&payload))
+	s.Equal(1, payload.ID)
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *OutboxTestSuite) TestStopsAtTheFirstFailedDispatch() {
+	s.mock.ExpectQuery("WHERE e.processedAt IS NULL").
+		WillReturn(s.keys, []any{"a", "orders.created", `{}`, time.Time{}, int64(0), nil}, []any{"b", "orders.paid", `{}`, time.Time{}, int64(0), nil})
+	s.mock.ExpectQuery("SET e.attempts = e.attempts \\+ 1").WithParams(map[string]interface{}{"id": "a", "error": "broker down"}).WillReturn(nil)
+	relay := outbox.NewRelay(s.mock, func(context.Context, outbox.Event) error {
+		return errors.New("broker down")
+	}, outbox.RelayConfig{})
+
+	count, err := relay.Poll(s.ctx)
+
+	s.EqualError(err, "could not dispatch event a: broker down")
+	s.Zero(count)
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *OutboxTestSuite) TestPurgesTheProcessedEventsPastTheRetention() {
+	s.mock.ExpectQuery("WHERE e.processedAt IS NULL").WillReturn(s.keys)
+	s.mock.ExpectQuery("DELETE e$").WithParams(map[string]interface{}{"retention": int64(86400)}).WillReturn(nil)
+	relay := outbox.NewRelay(s.mock, nil, outbox.RelayConfig{Retention: 24 * time.Hour})
+
+	_, err := relay.Poll(s.ctx)
+
+	s.NoError(err)
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *OutboxTestSuite) TestLogsTheFailedPollsUntilTheContextIsDone() {
+	s.mock.ExpectQuery("WHERE e.processedAt IS NULL").WillReturnError(errors.New("ConnectivityError"))
+	var logs bytes.Buffer
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Millisecond)
+	defer cancel()
+	relay := outbox.NewRelay(s.mock, nil, outbox.RelayConfig{PollInterval: time.Hour, Logger: log.New(&logs, "", 0)})
+
+	err := relay.Run(ctx)
+
+	s.ErrorIs(err, context.DeadlineExceeded)
+	s.Equal("[neo4j] outbox relay: ConnectivityError\n", logs.String())
+}
+
+type fakeTx struct {
+	query    string
+	params   map[string]interface{}
+	consumed bool
+}
+
+func (t *fakeTx) Run(_ context.Context, query string, params map[string]interface{}) (neo4j.ResultWithContext, error) {
+	t.query, t.params = query, params
+	return &fakeResult{tx: t}, nil
+}
+
+type fakeResult struct {
+	neo4j.ResultWithContext
+	tx *fakeTx
+}
+
+func (r *fakeResult) Consume(context.Context) (neo4j.ResultSummary, error) {
+	r.tx.consumed = true
+	return nil, nil
+}
@@ -0,0 +1,202 @@
+package driver
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"strings"
+)
+
+// ErrInvalidCursor is returned for a PageRequest.Cursor that was not returned by ExecutePaged with the same mode
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// PageMode selects how ExecutePaged skips the rows of the previous pages
+type PageMode int
+
+const (
+	// PageBySkip skips the rows of the previous pages with SKIP. it works with any query,
+	// but the server still computes the skipped rows, and rows shift when the data changes between pages
+	PageBySkip PageMode = iota
+	// PageByKeyset resumes after the OrderBy values of the last row of the previous page.
+	// it costs the same for every page when an index backs the ordering, and is stable while the data changes,
+	// but the OrderBy columns must identify rows uniquely and hold strings, numbers or booleans
+	PageByKeyset
+)
+
+// PageRequest describes the page ExecutePaged fetches
+type PageRequest struct {
+	// Size is the maximum number of rows in the page
+	Size int
+	// Cursor is the PageResult.NextCursor of the previous page, empty for the first one
+	Cursor string
+	// OrderBy lists the returned columns sorting the rows in ascending order. it is optional with PageBySkip
+	// when the query orders its rows itself
+	OrderBy []string
+	Mode    PageMode
+}
+
+// PageResult is a page of records
+type PageResult struct {
+	Records []*neo4j.Record
+	// NextCursor fetches the next page, it is empty on the last page
+	NextCursor string
+}
+
+// pageCursor is the decoded PageResult.NextCursor
+type pageCursor struct {
+	Skip  int           `json:"skip,omitempty"`
+	After []interface{} `json:"after,omitempty"`
+}
+
+// PagedQuery generates the query run by ExecutePaged, which fetches one row more than the page size
+// to find out whether there is a next page.
+// with PageBySkip, the query must end with its RETURN clause. with PageByKeyset, it is wrapped in a CALL subquery
+// returning all its columns, whose order is then up to the server: read the records by key.
+func PagedQuery(query string, params map[string]interface{}, page PageRequest) (string, map[string]interface{}, error) {
+	if page.Size <= 0 {
+		return "", nil, fmt.Errorf("page size must be positive, got %d", page.Size)
+	}
+	cursor, err := decodePageCursor(page.Cursor)
+	if err != nil {
+		return "", nil, err
+	}
+	paged := make(map[string]interface{}, len(params)+2)
+	for key, value := range params {
+		paged[key] = value
+	}
+	paged["pageLimit"] = int64(page.Size + 1)
+	orderBy := make([]string, len(page.OrderBy))
+	for i, column := range page.OrderBy {
+		orderBy[i] = QuoteIdentifier(column)
+	}
+
+	switch page.Mode {
+	case PageBySkip:
+		if cursor.After != nil {
+			return "", nil, fmt.Errorf("%w: keyset cursor used to page by skip", ErrInvalidCursor)
+		}
+		paged["pageSkip"] = int64(cursor.Skip)
+		builder := strings.Builder{}
+		builder.WriteString(query)
+		if len(orderBy) > 0 {
+			builder.WriteString(" ORDER BY " + strings.Join(orderBy, ", "))
+		}
+		builder.WriteString(" SKIP $pageSkip LIMIT $pageLimit")
+		return builder.String(), paged, nil
+	case PageByKeyset:
+		if len(orderBy) == 0 {
+			return "", nil, errors.New("paging by keyset requires columns to order by")
+		}
+		if cursor.Skip != 0 || (cursor.After != nil && len(cursor.After) != len(orderBy)) {
+			return "", nil, fmt.Errorf("%w: cursor does not match the %d columns to order by", ErrInvalidCursor, len(orderBy))
+		}
+		builder := strings.Builder{}
+		builder.WriteString("CALL { " + query + " } WITH *")
+		if cursor.After != nil {
+			builder.WriteString(" WHERE " + keysetPredicate(orderBy, cursor.After, paged))
+		}
+		builder.WriteString(" RETURN * ORDER BY " + strings.Join(orderBy, ", ") + " LIMIT $pageLimit")
+		return builder.String(), paged, nil
+	default:
+		return "", nil, fmt.Errorf("unknown page mode %d", page.Mode)
+	}
+}
+
+// ExecutePaged fetches one page of the rows returned by query, standardizing paging across services.
+// PageResult does not count the rows of all the pages, as that requires running the whole query. when a total is needed,
+// either run a separate count query sharing the MATCH and WHERE clauses of the paged one and cache it across pages,
+// or only tell whether more pages follow from NextCursor, which is free. counting all the nodes of a label with
+// MATCH (n:Label) RETURN count(n) is answered from the count store, without scanning the nodes.
+func (d *Driver) ExecutePaged(ctx context.Context, query string, params map[string]interface{}, page PageRequest, opts ...QueryOption) (PageResult, error) {
+	paged, pagedParams, err := PagedQuery(query, params, page)
+	if err != nil {
+		return PageResult{}, err
+	}
+	var records []*neo4j.Record
+	err = d.ExecuteQuery(ctx, paged, pagedParams, func(result neo4j.ResultWithContext) error {
+		records, err = result.Collect(ctx)
+		return err
+	}, opts...)
+	if err != nil {
+		return PageResult{}, err
+	}
+	return newPageResult(records, page)
+}
+
+func newPageResult(records []*neo4j.Record, page PageRequest) (PageResult, error) {
+	if len(records) <= page.Size {
+		return PageResult{Records: records}, nil
+	}
+	records = records[:page.Size]
+	next := pageCursor{}
+	if page.Mode == PageByKeyset {
+		last := records[len(records)-1]
+		for _, column := range page.OrderBy {
+			value, found := last.Get(column)
+			if !found {
+				return PageResult{}, fmt.Errorf("column %q to order by is not returned", column)
+			}
+			next.After = append(next.After, value)
+		}
+	} else {
+		current, _ := decodePageCursor(page.Cursor)
+		next.Skip = current.Skip + page.Size
+	}
+	cursor, err := json.Marshal(next)
+	if err != nil {
+		return PageResult{}, fmt.Errorf("encoding page cursor: %w", err)
+	}
+	return PageResult{Records: records, NextCursor: base64.RawURLEncoding.EncodeToString(cursor)}, nil
+}
+
+// keysetPredicate matches the rows sorted after the values: (a > $a) OR (a = $a AND b > $b) ...
+func keysetPredicate(columns []string, after []interface{}, params map[string]interface{}) string {
+	alternatives := make([]string, len(columns))
+	for i := range columns {
+		conditions := make([]string, 0, i+1)
+		for j := 0; j <= i; j++ {
+			param := fmt.Sprintf("pageAfter%d", j)
+			params[param] = after[j]
+			operator := "="
+			if j == i {
+				operator = ">"
+			}
+			conditions = append(conditions, fmt.Sprintf("%s %s $%s", columns[j], operator, param))
+		}
+		alternatives[i] = "(" + strings.Join(conditions, " AND ") + ")"
+	}
+	return strings.Join(alternatives, " OR ")
+}
+
+func decodePageCursor(cursor string) (pageCursor, error) {
+	decoded := pageCursor{}
+	if cursor == "" {
+		return decoded, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return decoded, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return decoded, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	for i, value := range decoded.After {
+		number, ok := value.(json.Number)
+		if !ok {
+			continue
+		}
+		// integers stay integers, as the rows are compared with the values read from the server
+		if integer, err := number.Int64(); err == nil {
+			decoded.After[i] = integer
+		} else if float, err := number.Float64(); err == nil {
+			decoded.After[i] = float
+		}
+	}
+	return decoded, nil
+}
@@ -0,0 +1,51 @@
+package driver_test
+
+import (
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestPaging(t *testing.T) {
+	suite.Run(t, new(PagingTestSuite))
+}
+
+type PagingTestSuite struct {
+	suite.Suite
+}
+
+func (s *PagingTestSuite) TestAppendsOrderSkipAndLimit() {
+	params := map[string]interface{}{"active": true}
+
+	query, paged, err := PagedQuery("MATCH (u:User {active: $active}) RETURN u.name AS name", params, PageRequest{Size: 10, OrderBy: []string{"name"}})
+
+	s.Require().NoError(err)
+	s.Equal("MATCH (u:User {active: $active}) RETURN u.name AS name ORDER BY `name` SKIP $pageSkip LIMIT $pageLimit", query)
+	s.Equal(map[string]interface{}{"active": true, "pageSkip": int64(0), "pageLimit": int64(11)}, paged)
+	s.Len(params, 1)
+}
+
+func (s *PagingTestSuite) TestStartsKeysetPagesWithoutPredicate() {
+	query, _, err := PagedQuery("MATCH (u:User) RETURN u.name AS name, u.id AS id", nil, PageRequest{Size: 2, OrderBy: []string{"name", "id"}, Mode: PageByKeyset})
+
+	s.Require().NoError(err)
+	s.Equal("CALL { MATCH (u:User) RETURN u.name AS name, u.id AS id } WITH * RETURN * ORDER BY `name`, `id` LIMIT $pageLimit", query)
+}
+
+func (s *PagingTestSuite) TestRequiresColumnsToPageByKeyset() {
+	_, _, err := PagedQuery("MATCH (u:User) RETURN u", nil, PageRequest{Size: 2, Mode: PageByKeyset})
+
+	s.ErrorContains(err, "requires columns to order by")
+}
+
+func (s *PagingTestSuite) TestRejectsForeignCursors() {
+	_, _, err := PagedQuery("MATCH (u:User) RETURN u", nil, PageRequest{Size: 2, Cursor: "not a cursor"})
+
+	s.ErrorIs(err, ErrInvalidCursor)
+}
+
+func (s *PagingTestSuite) TestRejectsEmptyPages() {
+	_, _, err := PagedQuery("MATCH (u:User) RETURN u", nil, PageRequest{})
+
+	s.ErrorContains(err, "page size must be positive")
+}
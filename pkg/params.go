@@ -0,0 +1,168 @@
+package driver
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// neo4jPackage prefixes the package path of the driver types, e.g. neo4j.Date or neo4j.Point2D, passed as is
+const neo4jPackage = "github.com/neo4j/neo4j-go-driver/"
+
+// BindParams converts a struct, or a pointer to one, into query parameters, like sqlx does for SQL.
+// exported fields are named after their FieldTag tag, e.g. `neo4j:"name"`, or else keep their name.
+// fields tagged "-" are skipped, and the ",omitempty" tag option skips zero values.
+// embedded structs are flattened, nested structs become maps and time.Duration values neo4j.Duration,
+// so that structs decoded with DecodeRecord can be bound back as is.
+func BindParams(value interface{}) (map[string]interface{}, error) {
+	source := reflect.ValueOf(value)
+	for source.Kind() == reflect.Pointer && !source.IsNil() {
+		source = source.Elem()
+	}
+	if source.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("params must be bound from a struct or a pointer to one, got %T", value)
+	}
+	return bindStruct(source), nil
+}
+
+// MustBindParams is like BindParams but panics if value is not a struct
+func MustBindParams(value interface{}) map[string]interface{} {
+	params, err := BindParams(value)
+	if err != nil {
+		panic(err)
+	}
+	return params
+}
+
+func bindStruct(source reflect.Value) map[string]interface{} {
+	params := map[string]interface{}{}
+	for _, field := range reflect.VisibleFields(source.Type()) {
+		tag, tagged := field.Tag.Lookup(FieldTag)
+		name, flags, _ := strings.Cut(tag, ",")
+		if !field.IsExported() || name == "-" || (field.Anonymous && !tagged && indirect(field.Type).Kind() == reflect.Struct) {
+			continue
+		}
+		value, err := source.FieldByIndexErr(field.Index)
+		if err != nil {
+			// promoted through a nil embedded struct pointer
+			continue
+		}
+		if flags == "omitempty" && value.IsZero() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		params[name] = bindValue(value)
+	}
+	return params
+}
+
+func bindValue(value reflect.Value) interface{} {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	switch {
+	case value.Type() == durationType:
+		return DurationOf(time.Duration(value.Int()))
+	case value.Type() == timeType || strings.HasPrefix(value.Type().PkgPath(), neo4jPackage):
+		return value.Interface()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		return bindStruct(value)
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return value.Interface()
+		}
+		list := make([]interface{}, value.Len())
+		for i := range list {
+			list[i] = bindValue(value.Index(i))
+		}
+		return list
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return value.Interface()
+		}
+		entries := make(map[string]interface{}, value.Len())
+		iterator := value.MapRange()
+		for iterator.Next() {
+			entries[iterator.Key().String()] = bindValue(iterator.Value())
+		}
+		return entries
+	}
+	return value.Interface()
+}
+
+// isBindable reports whether value is a struct of the application, or a pointer to one, converted with BindParams
+func isBindable(value interface{}) bool {
+	valueType := reflect.TypeOf(value)
+	if valueType == nil {
+		return false
+	}
+	valueType = indirect(valueType)
+	return valueType.Kind() == reflect.Struct && valueType != timeType && !strings.HasPrefix(valueType.PkgPath(), neo4jPackage)
+}
+
+// CoerceParams returns params with the Go values the server cannot represent natively converted to their Neo4j
+// counterpart, recursively in lists and maps. ExecuteQuery applies it to every query:
+//   - time.Duration becomes a neo4j.Duration, instead of an integer of nanoseconds
+//   - structs and pointers to structs become maps, see BindParams
+//
+// params is returned as is when no value needs to be converted
+func CoerceParams(params map[string]interface{}) map[string]interface{} {
+	if !needsCoercion(params) {
+		return params
+	}
+	return coerceValue(params).(map[string]interface{})
+}
+
+func coerceValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case time.Duration:
+		return DurationOf(value)
+	case []interface{}:
+		coerced := make([]interface{}, len(value))
+		for i, element := range value {
+			coerced[i] = coerceValue(element)
+		}
+		return coerced
+	case map[string]interface{}:
+		coerced := make(map[string]interface{}, len(value))
+		for key, element := range value {
+			coerced[key] = coerceValue(element)
+		}
+		return coerced
+	default:
+		if isBindable(value) {
+			return bindValue(reflect.ValueOf(value))
+		}
+		return value
+	}
+}
+
+func needsCoercion(value interface{}) bool {
+	switch value := value.(type) {
+	case time.Duration:
+		return true
+	case []interface{}:
+		for _, element := range value {
+			if needsCoercion(element) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, element := range value {
+			if needsCoercion(element) {
+				return true
+			}
+		}
+	default:
+		return isBindable(value)
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package driver_test
+
+import (
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestParams(t *testing.T) {
+	suite.Run(t, new(ParamsTestSuite))
+}
+
+type ParamsTestSuite struct {
+	suite.Suite
+}
+
+type boundAddress struct {
+	City    string `neo4j:"city"`
+	Zipcode string `neo4j:"zipcode,omitempty"`
+}
+
+type boundUser struct {
+	decodedAudit
+	Name      string         `neo4j:"name"`
+	Nickname  string         `neo4j:"nickname,omitempty"`
+	Password  string         `neo4j:"-"`
+	Address   *boundAddress  `neo4j:"address"`
+	Previous  []boundAddress `neo4j:"previous"`
+	Born      neo4j.Date     `neo4j:"born"`
+	Session   time.Duration  `neo4j:"session"`
+	Location  neo4j.Point2D  `neo4j:"location"`
+	Manager   *boundUser     `neo4j:"manager"`
+	Scores    map[string]int `neo4j:"scores"`
+	Untagged  bool
+	unexposed string
+}
+
+func (s *ParamsTestSuite) TestBindsTaggedFields() {
+	created := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	born := neo4j.DateOf(time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC))
+	user := boundUser{
+		decodedAudit: decodedAudit{CreatedAt: created},
+		Name:         "alice",
+		Password:     "hunter2",
+		Address:      &boundAddress{City: "Malmö"},
+		Previous:     []boundAddress{{City: "Paris", Zipcode: "75001"}},
+		Born:         born,
+		Session:      time.Hour,
+		Location:     neo4j.Point2D{X: 1, Y: 2, SpatialRefId: 7203},
+		Scores:       map[string]int{"q1": 3},
+		Untagged:     true,
+		unexposed:    "ignored",
+	}
+
+	params, err := BindParams(&user)
+
+	s.Require().NoError(err)
+	s.Equal(map[string]interface{}{
+		"created_at": created,
+		"name":       "alice",
+		"address":    map[string]interface{}{"city": "Malmö"},
+		"previous":   []interface{}{map[string]interface{}{"city": "Paris", "zipcode": "75001"}},
+		"born":       born,
+		"session":    neo4j.DurationOf(0, 0, 3600, 0),
+		"location":   neo4j.Point2D{X: 1, Y: 2, SpatialRefId: 7203},
+		"manager":    nil,
+		"scores":     map[string]interface{}{"q1": 3},
+		"Untagged":   true,
+	}, params)
+}
+
+func (s *ParamsTestSuite) TestRejectsNonStructs() {
+	_, err := BindParams(map[string]interface{}{"name": "alice"})
+
+	s.ErrorContains(err, "got map[string]interface {}")
+	s.Panics(func() { MustBindParams("alice") })
+}
+
+func (s *ParamsTestSuite) TestCoercesNestedStructs() {
+	params := map[string]interface{}{"props": boundAddress{City: "Malmö"}, "limit": int64(10)}
+
+	s.Equal(map[string]interface{}{"props": map[string]interface{}{"city": "Malmö"}, "limit": int64(10)}, CoerceParams(params))
+}
+
+func (s *ParamsTestSuite) TestRoundTripsDecodedStructs() {
+	original := decodedUser{Name: "alice", Age: 42, Emails: []string{"alice@example.com"}}
+	decoded := decodedUser{}
+
+	s.Require().NoError(DecodeValue(CoerceParams(MustBindParams(original)), &decoded))
+
+	s.Equal(original, decoded)
+}
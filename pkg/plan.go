@@ -0,0 +1,158 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"sort"
+	"strings"
+)
+
+// PlanNode is an operator of the execution plan of a query, see Explain and Profile
+type PlanNode struct {
+	Operator    string
+	Identifiers []string
+	// EstimatedRows is the number of rows the planner expects the operator to produce
+	EstimatedRows float64
+	// Profiled is set for the plans returned by Profile, which measure Rows, DbHits, the page cache activity and Time
+	Profiled        bool
+	Rows, DbHits    int64
+	PageCacheHits   int64
+	PageCacheMisses int64
+	// Time is the time spent in the operator, in nanoseconds, when reported by the server
+	Time int64
+	// Arguments holds all the details reported by the server, e.g. the details of the operator or the planner
+	Arguments map[string]interface{}
+	Children  []PlanNode
+}
+
+// Explain asks the server to plan the query without executing it, see ExplainWith
+func (d *Driver) Explain(ctx context.Context, query string, params map[string]interface{}, opts ...QueryOption) (PlanNode, error) {
+	return ExplainWith(ctx, d, query, params, opts...)
+}
+
+// Profile executes the query and returns its plan, measured operator by operator, see ProfileWith.
+// the query is fully executed: its writes are committed
+func (d *Driver) Profile(ctx context.Context, query string, params map[string]interface{}, opts ...QueryOption) (PlanNode, error) {
+	return ProfileWith(ctx, d, query, params, opts...)
+}
+
+// ExplainWith plans the query with runner, prefixing it with EXPLAIN. the query runs in read mode
+func ExplainWith(ctx context.Context, runner QueryRunner, query string, params map[string]interface{}, opts ...QueryOption) (PlanNode, error) {
+	var plan PlanNode
+	opts = append([]QueryOption{WithAccessMode(neo4j.AccessModeRead)}, opts...)
+	err := runner.ExecuteQuery(ctx, "EXPLAIN "+query, params, func(result neo4j.ResultWithContext) error {
+		summary, err := result.Consume(ctx)
+		if err != nil {
+			return err
+		}
+		if summary == nil || summary.Plan() == nil {
+			return fmt.Errorf("the server returned no plan for %q", query)
+		}
+		plan = PlanOf(summary.Plan())
+		return nil
+	}, opts...)
+	return plan, err
+}
+
+// ProfileWith executes the query with runner, prefixing it with PROFILE, and returns its measured plan
+func ProfileWith(ctx context.Context, runner QueryRunner, query string, params map[string]interface{}, opts ...QueryOption) (PlanNode, error) {
+	var plan PlanNode
+	err := runner.ExecuteQuery(ctx, "PROFILE "+query, params, func(result neo4j.ResultWithContext) error {
+		summary, err := result.Consume(ctx)
+		if err != nil {
+			return err
+		}
+		if summary == nil || summary.Profile() == nil {
+			return fmt.Errorf("the server returned no profile for %q", query)
+		}
+		plan = ProfiledPlanOf(summary.Profile())
+		return nil
+	}, opts...)
+	return plan, err
+}
+
+// PlanOf converts the plan of a result summary
+func PlanOf(plan neo4j.Plan) PlanNode {
+	node := PlanNode{
+		Operator:      plan.Operator(),
+		Identifiers:   plan.Identifiers(),
+		EstimatedRows: estimatedRows(plan.Arguments()),
+		Arguments:     plan.Arguments(),
+	}
+	for _, child := range plan.Children() {
+		node.Children = append(node.Children, PlanOf(child))
+	}
+	return node
+}
+
+// ProfiledPlanOf converts the profiled plan of a result summary
+func ProfiledPlanOf(plan neo4j.ProfiledPlan) PlanNode {
+	node := PlanNode{
+		Operator:        plan.Operator(),
+		Identifiers:     plan.Identifiers(),
+		EstimatedRows:   estimatedRows(plan.Arguments()),
+		Profiled:        true,
+		Rows:            plan.Records(),
+		DbHits:          plan.DbHits(),
+		PageCacheHits:   plan.PageCacheHits(),
+		PageCacheMisses: plan.PageCacheMisses(),
+		Time:            plan.Time(),
+		Arguments:       plan.Arguments(),
+	}
+	for _, child := range plan.Children() {
+		node.Children = append(node.Children, ProfiledPlanOf(child))
+	}
+	return node
+}
+
+func estimatedRows(arguments map[string]interface{}) float64 {
+	switch rows := arguments["EstimatedRows"].(type) {
+	case float64:
+		return rows
+	case int64:
+		return float64(rows)
+	}
+	return 0
+}
+
+// TotalDbHits sums the database hits of the operator and of all the operators below it
+func (p PlanNode) TotalDbHits() int64 {
+	total := p.DbHits
+	for _, child := range p.Children {
+		total += child.TotalDbHits()
+	}
+	return total
+}
+
+// String pretty-prints the plan as a tree, one operator per line, e.g.
+//
+//	ProduceResults (estimated rows: 1, rows: 1, db hits: 0) [p]
+//	└── NodeIndexSeek (estimated rows: 1, rows: 1, db hits: 2) [p]
+func (p PlanNode) String() string {
+	var builder strings.Builder
+	p.format(&builder, "", "")
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func (p PlanNode) format(builder *strings.Builder, prefix, childPrefix string) {
+	builder.WriteString(prefix + p.Operator)
+	builder.WriteString(fmt.Sprintf(" (estimated rows: %g", p.EstimatedRows))
+	if p.Profiled {
+		builder.WriteString(fmt.Sprintf(", rows: %d, db hits: %d", p.Rows, p.DbHits))
+	}
+	builder.WriteString(")")
+	if len(p.Identifiers) > 0 {
+		identifiers := append([]string(nil), p.Identifiers...)
+		sort.Strings(identifiers)
+		builder.WriteString(" [" + strings.Join(identifiers, ", ") + "]")
+	}
+	builder.WriteString("\n")
+	for i, child := range p.Children {
+		if i == len(p.Children)-1 {
+			child.format(builder, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			child.format(builder, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
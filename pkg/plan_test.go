@@ -0,0 +1,154 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	suite.Run(t, new(PlanTestSuite))
+}
+
+type PlanTestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *PlanTestSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+func (s *PlanTestSuite) TestExplainsQueries() {
+	cluster := &fakeCluster{summary: &fakeSummary{plan: fakePlan{
+		operator: "ProduceResults@neo4j", identifiers: []string{"p"}, arguments: map[string]any{"EstimatedRows": 1.5},
+		children: []fakePlan{{operator: "NodeByLabelScan@neo4j", identifiers: []string{"p"}, arguments: map[string]any{"EstimatedRows": int64(3)}}},
+	}}}
+	defer UseDriverFactory(cluster.newDriver)()
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	plan, err := driver.Explain(s.ctx, "MATCH (p:Person) RETURN p", nil)
+
+	s.Require().NoError(err)
+	s.Equal("ProduceResults@neo4j", plan.Operator)
+	s.Equal(1.5, plan.EstimatedRows)
+	s.False(plan.Profiled)
+	s.Require().Len(plan.Children, 1)
+	s.Equal(3.0, plan.Children[0].EstimatedRows)
+	s.Equal([]neo4j.AccessMode{neo4j.AccessModeRead}, cluster.modes)
+}
+
+func (s *PlanTestSuite) TestProfilesQueries() {
+	cluster := &fakeCluster{summary: &fakeSummary{profile: fakeProfiledPlan{
+		fakePlan: fakePlan{operator: "ProduceResults", identifiers: []string{"p"}}, records: 2, dbHits: 1,
+		children: []fakeProfiledPlan{{
+			fakePlan: fakePlan{operator: "NodeIndexSeek", identifiers: []string{"p"}}, records: 2, dbHits: 4, pageCacheHits: 7,
+		}},
+	}}}
+	defer UseDriverFactory(cluster.newDriver)()
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	plan, err := driver.Profile(s.ctx, "MATCH (p:Person {name: $name}) RETURN p", map[string]interface{}{"name": "alice"})
+
+	s.Require().NoError(err)
+	s.True(plan.Profiled)
+	s.Equal(int64(2), plan.Rows)
+	s.Equal(int64(5), plan.TotalDbHits())
+	s.Equal(int64(7), plan.Children[0].PageCacheHits)
+}
+
+func (s *PlanTestSuite) TestFailsWithoutPlan() {
+	defer UseDriverFactory((&fakeCluster{}).newDriver)()
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	_, err = driver.Explain(s.ctx, "RETURN 1", nil)
+
+	s.EqualError(err, `the server returned no plan for "RETURN 1"`)
+}
+
+func (s *PlanTestSuite) TestPrettyPrintsPlans() {
+	plan := PlanNode{Operator: "ProduceResults", EstimatedRows: 2, Profiled: true, Rows: 2, Identifiers: []string{"q", "p"}, Children: []PlanNode{
+		{Operator: "CartesianProduct", EstimatedRows: 2, Profiled: true, Rows: 2, Children: []PlanNode{
+			{Operator: "NodeByLabelScan", EstimatedRows: 1, Profiled: true, Rows: 1, DbHits: 2, Identifiers: []string{"p"}},
+			{Operator: "NodeByLabelScan", EstimatedRows: 2, Profiled: true, Rows: 2, DbHits: 3, Identifiers: []string{"q"}},
+		}},
+	}}
+
+	s.Equal("ProduceResults (estimated rows: 2, rows: 2, db hits: 0) [p, q]\n"+
+		"└── CartesianProduct (estimated rows: 2, rows: 2, db hits: 0)\n"+
+		"    ├── NodeByLabelScan (estimated rows: 1, rows: 1, db hits: 2) [p]\n"+
+		"    └── NodeByLabelScan (estimated rows: 2, rows: 2, db hits: 3) [q]", plan.String())
+}
+
+type fakePlan struct {
+	operator    string
+	identifiers []string
+	arguments   map[string]any
+	children    []fakePlan
+}
+
+func (p fakePlan) Operator() string {
+	return p.operator
+}
+
+func (p fakePlan) Arguments() map[string]any {
+	return p.arguments
+}
+
+func (p fakePlan) Identifiers() []string {
+	return p.identifiers
+}
+
+func (p fakePlan) Children() []neo4j.Plan {
+	children := make([]neo4j.Plan, len(p.children))
+	for i, child := range p.children {
+		children[i] = child
+	}
+	return children
+}
+
+type fakeProfiledPlan struct {
+	fakePlan
+	records, dbHits, pageCacheHits int64
+	children                       []fakeProfiledPlan
+}
+
+func (p fakeProfiledPlan) DbHits() int64 {
+	return p.dbHits
+}
+
+func (p fakeProfiledPlan) Records() int64 {
+	return p.records
+}
+
+func (p fakeProfiledPlan) PageCacheHits() int64 {
+	return p.pageCacheHits
+}
+
+func (p fakeProfiledPlan) PageCacheMisses() int64 {
+	return 0
+}
+
+func (p fakeProfiledPlan) PageCacheHitRatio() float64 {
+	return 1
+}
+
+func (p fakeProfiledPlan) Time() int64 {
+	return 0
+}
+
+func (p fakeProfiledPlan) Children() []neo4j.ProfiledPlan {
+	children := make([]neo4j.ProfiledPlan, len(p.children))
+	for i, child := range p.children {
+		children[i] = child
+	}
+	return children
+}
@@ -0,0 +1,648 @@
+package driver
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/db"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Transport selects how the driver talks to the server
+type Transport string
+
+const (
+	// TransportBolt is the Bolt protocol of the neo4j driver, it is the default
+	TransportBolt Transport = "bolt"
+	// TransportHTTP sends the queries to the HTTP Query API of Neo4j 5, for the environments where network policies
+	// block the Bolt ports. every query is an HTTP request whose records are buffered in memory. there is no client-side
+	// routing, and the transaction metadata and timeouts are not sent. see Settings.QueryAPIURL
+	TransportHTTP Transport = "http"
+)
+
+// ErrUnsupportedTransport is returned for a Settings.Transport other than TransportBolt and TransportHTTP
+var ErrUnsupportedTransport = errors.New("unsupported transport")
+
+const (
+	queryAPIContentType = "application/vnd.neo4j.query"
+	// defaultQueryAPIDatabase is the database of the sessions without one, the Query API has no notion of home database
+	defaultQueryAPIDatabase = "neo4j"
+	// clusterAffinityHeader routes the requests of an explicit transaction to the cluster member that opened it
+	clusterAffinityHeader = "neo4j-cluster-affinity"
+	// sessionOnClosedDriver is the error of the neo4j driver the recoveries recognize, see attemptQuery
+	sessionOnClosedDriver = "Trying to create session on closed driver"
+)
+
+func validateTransport(transport Transport) error {
+	if transport == "" || transport == TransportBolt || transport == TransportHTTP {
+		return nil
+	}
+	return fmt.Errorf("%w: %q, use %q or %q", ErrUnsupportedTransport, transport, TransportBolt, TransportHTTP)
+}
+
+// queryAPIURL returns the base URL of the Query API, derived from the connection string when rawURL is empty:
+// http on port 7474, or https on port 7473 for the encrypted schemes
+func queryAPIURL(rawURL string, info ConnectionInfo) (*url.URL, error) {
+	if rawURL != "" {
+		base, err := url.Parse(rawURL)
+		if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+			return nil, fmt.Errorf("%w: invalid Query API URL %q, e.g. https://localhost:7473", ErrInvalidConnectionString, rawURL)
+		}
+		return base, nil
+	}
+	if info.Encrypted() {
+		return &url.URL{Scheme: "https", Host: net.JoinHostPort(info.Host, "7473")}, nil
+	}
+	return &url.URL{Scheme: "http", Host: net.JoinHostPort(info.Host, "7474")}, nil
+}
+
+// queryAPIBackend creates the underlying drivers of TransportHTTP. the neo4j auth tokens are opaque,
+// hence the credentials are given here. the configurers set the user agent, the connection timeout and the TLS configuration
+func queryAPIBackend(base *url.URL, user, password string, skipVerify bool) DriverBackend {
+	return func(_ string, _ neo4j.AuthToken, configurers ...func(*neo4j.Config)) (neo4j.DriverWithContext, error) {
+		config := neo4j.Config{}
+		for _, configurer := range configurers {
+			configurer(&config)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if config.SocketConnectTimeout > 0 {
+			transport.DialContext = (&net.Dialer{Timeout: config.SocketConnectTimeout}).DialContext
+		}
+		if config.TlsConfig != nil {
+			transport.TLSClientConfig = config.TlsConfig.Clone()
+		}
+		if skipVerify {
+			if transport.TLSClientConfig == nil {
+				transport.TLSClientConfig = &tls.Config{}
+			}
+			transport.TLSClientConfig.InsecureSkipVerify = true
+		}
+		return &queryAPIDriver{
+			client:    &http.Client{Transport: transport},
+			base:      *base,
+			user:      user,
+			password:  password,
+			userAgent: config.UserAgent,
+		}, nil
+	}
+}
+
+// queryAPIConnectivityError is returned when the Query API cannot be reached. its message starts like the connectivity errors
+// of the neo4j driver, so that the queries failing with it recover the same way
+type queryAPIConnectivityError struct {
+	err error
+}
+
+func (e *queryAPIConnectivityError) Error() string {
+	return fmt.Sprintf("ConnectivityError: %v", e.err)
+}
+
+func (e *queryAPIConnectivityError) Unwrap() error {
+	return e.err
+}
+
+// queryAPIDriver implements neo4j.DriverWithContext on top of the HTTP Query API
+type queryAPIDriver struct {
+	client    *http.Client
+	base      url.URL
+	user      string
+	password  string
+	userAgent string
+	closed    atomic.Bool
+}
+
+func (d *queryAPIDriver) DefaultExecuteQueryBookmarkManager() neo4j.BookmarkManager {
+	return nil
+}
+
+func (d *queryAPIDriver) Target() url.URL {
+	return d.base
+}
+
+func (d *queryAPIDriver) NewSession(_ context.Context, config neo4j.SessionConfig) neo4j.SessionWithContext {
+	database := config.DatabaseName
+	if database == "" {
+		database = defaultQueryAPIDatabase
+	}
+	return &queryAPISession{driver: d, config: config, database: database, bookmarks: config.Bookmarks}
+}
+
+// VerifyConnectivity reads the discovery document of the server, it does not check the credentials
+func (d *queryAPIDriver) VerifyConnectivity(ctx context.Context) error {
+	_, err := d.GetServerInfo(ctx)
+	return err
+}
+
+func (d *queryAPIDriver) Close(context.Context) error {
+	d.closed.Store(true)
+	d.client.CloseIdleConnections()
+	return nil
+}
+
+func (d *queryAPIDriver) IsEncrypted() bool {
+	return d.base.Scheme == "https"
+}
+
+func (d *queryAPIDriver) GetServerInfo(ctx context.Context) (neo4j.ServerInfo, error) {
+	var discovery struct {
+		Version string `json:"neo4j_version"`
+	}
+	if err := d.send(ctx, http.MethodGet, "/", nil, "", &discovery); err != nil {
+		return nil, err
+	}
+	return queryAPIServerInfo{address: d.base.Host, agent: "Neo4j/" + discovery.Version}, nil
+}
+
+// send sends a request to the Query API and decodes the response into target.
+// the errors reported by the server are returned as *neo4j.Neo4jError
+func (d *queryAPIDriver) send(ctx context.Context, method, path string, body interface{}, affinity string, target interface{}) error {
+	_, err := d.sendWithAffinity(ctx, method, path, body, affinity, target)
+	return err
+}
+
+// sendWithAffinity is send, also returning the cluster affinity of the response
+func (d *queryAPIDriver) sendWithAffinity(ctx context.Context, method, path string, body interface{}, affinity string, target interface{}) (string, error) {
+	if d.closed.Load() {
+		return "", errors.New(sessionOnClosedDriver)
+	}
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return "", err
+		}
+	}
+	endpoint := d.base
+	endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + path
+	request, err := http.NewRequestWithContext(ctx, method, endpoint.String(), &payload)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Accept", queryAPIContentType+", application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", queryAPIContentType)
+	}
+	if d.user != "" {
+		request.SetBasicAuth(d.user, d.password)
+	}
+	if d.userAgent != "" {
+		request.Header.Set("User-Agent", d.userAgent)
+	}
+	if affinity != "" {
+		request.Header.Set(clusterAffinityHeader, affinity)
+	}
+	response, err := d.client.Do(request)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", &queryAPIConnectivityError{err: err}
+	}
+	defer response.Body.Close()
+	var failure struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if response.StatusCode >= 400 {
+		_ = json.NewDecoder(response.Body).Decode(&failure)
+		if len(failure.Errors) > 0 {
+			return "", &neo4j.Neo4jError{Code: failure.Errors[0].Code, Msg: failure.Errors[0].Message}
+		}
+		if response.StatusCode >= 500 {
+			return "", &queryAPIConnectivityError{err: fmt.Errorf("the Query API answered %s", response.Status)}
+		}
+		return "", fmt.Errorf("the Query API answered %s", response.Status)
+	}
+	if target != nil {
+		if err := json.NewDecoder(response.Body).Decode(target); err != nil {
+			return "", fmt.Errorf("could not decode the response of the Query API: %w", err)
+		}
+	}
+	return response.Header.Get(clusterAffinityHeader), nil
+}
+
+// queryAPIRequest is the body of the query and transaction requests
+type queryAPIRequest struct {
+	Statement        string                 `json:"statement,omitempty"`
+	Parameters       map[string]interface{} `json:"parameters,omitempty"`
+	IncludeCounters  bool                   `json:"includeCounters,omitempty"`
+	Bookmarks        []string               `json:"bookmarks,omitempty"`
+	AccessMode       string                 `json:"accessMode,omitempty"`
+	ImpersonatedUser string                 `json:"impersonatedUser,omitempty"`
+}
+
+// queryAPIResponse is the body of the responses to the query and transaction requests
+type queryAPIResponse struct {
+	Data struct {
+		Fields []string       `json:"fields"`
+		Values [][]typedValue `json:"values"`
+	} `json:"data"`
+	Counters      queryAPICounters       `json:"counters"`
+	Notifications []queryAPINotification `json:"notifications"`
+	Bookmarks     []string               `json:"bookmarks"`
+	Transaction   struct {
+		ID string `json:"id"`
+	} `json:"transaction"`
+}
+
+// result converts the response into a buffered result
+func (r *queryAPIResponse) result(summary *queryAPISummary) (neo4j.ResultWithContext, error) {
+	records := make([]*neo4j.Record, len(r.Data.Values))
+	for i, row := range r.Data.Values {
+		values := make([]interface{}, len(row))
+		for j, value := range row {
+			decoded, err := value.decode()
+			if err != nil {
+				return nil, err
+			}
+			values[j] = decoded
+		}
+		records[i] = &neo4j.Record{Keys: r.Data.Fields, Values: values}
+	}
+	keys := r.Data.Fields
+	if keys == nil {
+		keys = []string{}
+	}
+	summary.counters = r.Counters
+	summary.notifications = r.Notifications
+	return &replayResult{keys: keys, records: records, summary: summary}, nil
+}
+
+// queryAPISession implements neo4j.SessionWithContext with one request per query.
+// the embedded interface is left nil: it only provides the unexported methods, which are never called.
+type queryAPISession struct {
+	neo4j.SessionWithContext
+	driver    *queryAPIDriver
+	config    neo4j.SessionConfig
+	database  string
+	bookmarks neo4j.Bookmarks
+}
+
+func (s *queryAPISession) LastBookmarks() neo4j.Bookmarks {
+	return s.bookmarks
+}
+
+func (s *queryAPISession) Run(ctx context.Context, cypher string, params map[string]any, _ ...func(*neo4j.TransactionConfig)) (neo4j.ResultWithContext, error) {
+	request, err := s.request(cypher, params)
+	if err != nil {
+		return nil, err
+	}
+	return s.execute(ctx, s.path(""), request, "")
+}
+
+func (s *queryAPISession) BeginTransaction(ctx context.Context, _ ...func(*neo4j.TransactionConfig)) (neo4j.ExplicitTransaction, error) {
+	request, err := s.request("", nil)
+	if err != nil {
+		return nil, err
+	}
+	var response queryAPIResponse
+	affinity, err := s.driver.sendWithAffinity(ctx, http.MethodPost, s.path("/tx"), request, "", &response)
+	if err != nil {
+		return nil, err
+	}
+	return &queryAPITransaction{session: s, id: response.Transaction.ID, affinity: affinity}, nil
+}
+
+func (s *queryAPISession) ExecuteRead(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
+	return s.executeTransaction(ctx, work, configurers...)
+}
+
+func (s *queryAPISession) ExecuteWrite(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
+	return s.executeTransaction(ctx, work, configurers...)
+}
+
+func (s *queryAPISession) Close(context.Context) error {
+	return nil
+}
+
+// executeTransaction runs work in a transaction, committed when it succeeds. unlike the neo4j driver, it does not retry:
+// the queries are retried by the wrapper
+func (s *queryAPISession) executeTransaction(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
+	tx, err := s.BeginTransaction(ctx, configurers...)
+	if err != nil {
+		return nil, err
+	}
+	result, err := work(tx.(*queryAPITransaction))
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+	return result, tx.Commit(ctx)
+}
+
+func (s *queryAPISession) path(suffix string) string {
+	return "/db/" + url.PathEscape(s.database) + "/query/v2" + suffix
+}
+
+func (s *queryAPISession) request(cypher string, params map[string]any) (queryAPIRequest, error) {
+	request := queryAPIRequest{
+		Statement:        cypher,
+		IncludeCounters:  cypher != "",
+		Bookmarks:        s.bookmarks,
+		AccessMode:       "WRITE",
+		ImpersonatedUser: s.config.ImpersonatedUser,
+	}
+	if s.config.AccessMode == neo4j.AccessModeRead {
+		request.AccessMode = "READ"
+	}
+	if len(params) > 0 {
+		request.Parameters = make(map[string]interface{}, len(params))
+		for key, value := range params {
+			encoded, err := encodeTyped(value)
+			if err != nil {
+				return request, fmt.Errorf("parameter %q: %w", key, err)
+			}
+			request.Parameters[key] = encoded
+		}
+	}
+	return request, nil
+}
+
+func (s *queryAPISession) execute(ctx context.Context, path string, request queryAPIRequest, affinity string) (neo4j.ResultWithContext, error) {
+	start := time.Now()
+	var response queryAPIResponse
+	if err := s.driver.send(ctx, http.MethodPost, path, request, affinity, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Bookmarks) > 0 {
+		s.bookmarks = response.Bookmarks
+	}
+	return response.result(&queryAPISummary{
+		server:   queryAPIServerInfo{address: s.driver.base.Host},
+		query:    queryAPIQuery{text: request.Statement, params: request.Parameters},
+		database: s.database,
+		duration: time.Since(start),
+	})
+}
+
+// queryAPITransaction implements neo4j.ExplicitTransaction and neo4j.ManagedTransaction.
+// the embedded interface is left nil: it only provides the unexported methods, which are never called.
+type queryAPITransaction struct {
+	neo4j.ExplicitTransaction
+	session  *queryAPISession
+	id       string
+	affinity string
+	done     bool
+}
+
+func (t *queryAPITransaction) Run(ctx context.Context, cypher string, params map[string]any) (neo4j.ResultWithContext, error) {
+	request, err := t.session.request(cypher, params)
+	if err != nil {
+		return nil, err
+	}
+	request.Bookmarks, request.AccessMode, request.ImpersonatedUser = nil, "", ""
+	return t.session.execute(ctx, t.session.path("/tx/"+url.PathEscape(t.id)), request, t.affinity)
+}
+
+func (t *queryAPITransaction) Commit(ctx context.Context) error {
+	t.done = true
+	var response queryAPIResponse
+	if err := t.session.driver.send(ctx, http.MethodPost, t.session.path("/tx/"+url.PathEscape(t.id)+"/commit"), queryAPIRequest{}, t.affinity, &response); err != nil {
+		return err
+	}
+	if len(response.Bookmarks) > 0 {
+		t.session.bookmarks = response.Bookmarks
+	}
+	return nil
+}
+
+func (t *queryAPITransaction) Rollback(ctx context.Context) error {
+	t.done = true
+	return t.session.driver.send(ctx, http.MethodDelete, t.session.path("/tx/"+url.PathEscape(t.id)), nil, t.affinity, nil)
+}
+
+func (t *queryAPITransaction) Close(ctx context.Context) error {
+	if t.done {
+		return nil
+	}
+	return t.Rollback(ctx)
+}
+
+type queryAPIServerInfo struct {
+	address, agent string
+}
+
+func (i queryAPIServerInfo) Address() string {
+	return i.address
+}
+
+func (i queryAPIServerInfo) Agent() string {
+	return i.agent
+}
+
+func (i queryAPIServerInfo) ProtocolVersion() db.ProtocolVersion {
+	return db.ProtocolVersion{}
+}
+
+type queryAPIQuery struct {
+	text   string
+	params map[string]interface{}
+}
+
+func (q queryAPIQuery) Text() string {
+	return q.text
+}
+
+func (q queryAPIQuery) Parameters() map[string]any {
+	return q.params
+}
+
+type queryAPIDatabase string
+
+func (d queryAPIDatabase) Name() string {
+	return string(d)
+}
+
+// queryAPISummary implements neo4j.ResultSummary. the Query API reports no plan, and the statement type is unknown
+type queryAPISummary struct {
+	server        queryAPIServerInfo
+	query         queryAPIQuery
+	database      string
+	duration      time.Duration
+	counters      queryAPICounters
+	notifications []queryAPINotification
+}
+
+func (s *queryAPISummary) Server() neo4j.ServerInfo {
+	return s.server
+}
+
+func (s *queryAPISummary) Query() neo4j.Query {
+	return s.query
+}
+
+func (s *queryAPISummary) StatementType() neo4j.StatementType {
+	return neo4j.StatementTypeUnknown
+}
+
+func (s *queryAPISummary) Counters() neo4j.Counters {
+	return s.counters
+}
+
+func (s *queryAPISummary) Plan() neo4j.Plan {
+	return nil
+}
+
+func (s *queryAPISummary) Profile() neo4j.ProfiledPlan {
+	return nil
+}
+
+func (s *queryAPISummary) Notifications() []neo4j.Notification {
+	notifications := make([]neo4j.Notification, len(s.notifications))
+	for i, notification := range s.notifications {
+		notifications[i] = notification
+	}
+	return notifications
+}
+
+func (s *queryAPISummary) ResultAvailableAfter() time.Duration {
+	return s.duration
+}
+
+func (s *queryAPISummary) ResultConsumedAfter() time.Duration {
+	return 0
+}
+
+func (s *queryAPISummary) Database() neo4j.DatabaseInfo {
+	return queryAPIDatabase(s.database)
+}
+
+// queryAPICounters implements neo4j.Counters over the counters of a response, keyed like the methods
+type queryAPICounters map[string]interface{}
+
+func (c queryAPICounters) ContainsUpdates() bool {
+	return c.flag("containsUpdates")
+}
+
+func (c queryAPICounters) NodesCreated() int {
+	return c.count("nodesCreated")
+}
+
+func (c queryAPICounters) NodesDeleted() int {
+	return c.count("nodesDeleted")
+}
+
+func (c queryAPICounters) RelationshipsCreated() int {
+	return c.count("relationshipsCreated")
+}
+
+func (c queryAPICounters) RelationshipsDeleted() int {
+	return c.count("relationshipsDeleted")
+}
+
+func (c queryAPICounters) PropertiesSet() int {
+	return c.count("propertiesSet")
+}
+
+func (c queryAPICounters) LabelsAdded() int {
+	return c.count("labelsAdded")
+}
+
+func (c queryAPICounters) LabelsRemoved() int {
+	return c.count("labelsRemoved")
+}
+
+func (c queryAPICounters) IndexesAdded() int {
+	return c.count("indexesAdded")
+}
+
+func (c queryAPICounters) IndexesRemoved() int {
+	return c.count("indexesRemoved")
+}
+
+func (c queryAPICounters) ConstraintsAdded() int {
+	return c.count("constraintsAdded")
+}
+
+func (c queryAPICounters) ConstraintsRemoved() int {
+	return c.count("constraintsRemoved")
+}
+
+func (c queryAPICounters) SystemUpdates() int {
+	return c.count("systemUpdates")
+}
+
+func (c queryAPICounters) ContainsSystemUpdates() bool {
+	return c.flag("containsSystemUpdates")
+}
+
+func (c queryAPICounters) count(key string) int {
+	count, _ := c[key].(float64)
+	return int(count)
+}
+
+func (c queryAPICounters) flag(key string) bool {
+	flag, _ := c[key].(bool)
+	return flag
+}
+
+type queryAPINotification struct {
+	NotificationCode        string            `json:"code"`
+	NotificationTitle       string            `json:"title"`
+	NotificationDescription string            `json:"description"`
+	NotificationSeverity    string            `json:"severity"`
+	NotificationPosition    *queryAPIPosition `json:"position"`
+}
+
+func (n queryAPINotification) Code() string {
+	return n.NotificationCode
+}
+
+func (n queryAPINotification) Title() string {
+	return n.NotificationTitle
+}
+
+func (n queryAPINotification) Description() string {
+	return n.NotificationDescription
+}
+
+func (n queryAPINotification) Position() neo4j.InputPosition {
+	if n.NotificationPosition == nil {
+		return nil
+	}
+	return n.NotificationPosition
+}
+
+func (n queryAPINotification) Severity() string {
+	return n.NotificationSeverity
+}
+
+type queryAPIPosition struct {
+	PositionOffset int `json:"offset"`
+	PositionLine   int `json:"line"`
+	PositionColumn int `json:"column"`
+}
+
+func (p *queryAPIPosition) Offset() int {
+	return p.PositionOffset
+}
+
+func (p *queryAPIPosition) Line() int {
+	return p.PositionLine
+}
+
+func (p *queryAPIPosition) Column() int {
+	return p.PositionColumn
+}
+
+// typedValue is a value of the typed JSON format of the Query API, e.g. {"$type": "Integer", "_value": "42"}
+type typedValue struct {
+	Type  string          `json:"$type"`
+	Value json.RawMessage `json:"_value"`
+}
+
+// typedOutput is a typedValue being encoded
+type typedOutput struct {
+	Type  string      `json:"$type"`
+	Value interface{} `json:"_value"`
+}
@@ -0,0 +1,186 @@
+package driver_test
+
+import (
+	"context"
+	"encoding/json"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueryAPI(t *testing.T) {
+	suite.Run(t, new(QueryAPITestSuite))
+}
+
+type QueryAPITestSuite struct {
+	suite.Suite
+	ctx      context.Context
+	server   *httptest.Server
+	mutex    sync.Mutex
+	requests []queryAPIRequest
+	answer   func(writer http.ResponseWriter, request *http.Request)
+	settings Settings
+}
+
+type queryAPIRequest struct {
+	Method, Path, User, Affinity string
+	Body                         map[string]interface{}
+}
+
+func (s *QueryAPITestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.requests = nil
+	s.server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		user, _, _ := request.BasicAuth()
+		recorded := queryAPIRequest{Method: request.Method, Path: request.URL.Path, User: user, Affinity: request.Header.Get("neo4j-cluster-affinity")}
+		if body, _ := io.ReadAll(request.Body); len(body) > 0 {
+			s.Require().NoError(json.Unmarshal(body, &recorded.Body))
+			s.Equal("application/vnd.neo4j.query", request.Header.Get("Content-Type"))
+		}
+		s.mutex.Lock()
+		s.requests = append(s.requests, recorded)
+		s.mutex.Unlock()
+		s.answer(writer, request)
+	}))
+	s.settings = Settings{
+		ConnectionString: "neo4j://localhost",
+		User:             "neo4j",
+		Password:         "secret",
+		Transport:        TransportHTTP,
+		QueryAPIURL:      s.server.URL,
+		MaxAttempts:      1,
+	}
+}
+
+func (s *QueryAPITestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *QueryAPITestSuite) TestExecutesTheQueriesOverHTTP() {
+	s.answer = respondWith(`{
+		"data": {"fields": ["n", "born", "seen", "every", "at"], "values": [[
+			{"$type": "Node", "_value": {"_element_id": "4:db:1", "_labels": ["Person"], "_properties": {"name": {"$type": "String", "_value": "Keanu Reeves"}}}},
+			{"$type": "Integer", "_value": "1964"},
+			{"$type": "OffsetDateTime", "_value": "2024-05-01T10:00:00Z"},
+			{"$type": "Duration", "_value": "P1M2DT3.5S"},
+			{"$type": "Point", "_value": "SRID=4326;POINT (2.35 48.85)"}
+		]]},
+		"counters": {"containsUpdates": true, "propertiesSet": 1},
+		"bookmarks": ["FB:1"]
+	}`)
+	driver, err := NewDriver(s.settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	var record *neo4j.Record
+	var summary neo4j.ResultSummary
+
+	err = driver.ExecuteQuery(s.ctx, "MATCH (n:Person {born: $born}) SET n.seen = $seen RETURN n", map[string]interface{}{"born": 1964, "seen": "now"}, func(result neo4j.ResultWithContext) error {
+		record, err = result.Single(s.ctx)
+		return err
+	}, WithDatabase("movies"), WithResultSummary(&summary))
+
+	s.Require().NoError(err)
+	s.Require().Len(s.requests, 1)
+	s.Equal(http.MethodPost, s.requests[0].Method)
+	s.Equal("/db/movies/query/v2", s.requests[0].Path)
+	s.Equal("neo4j", s.requests[0].User)
+	s.Equal("MATCH (n:Person {born: $born}) SET n.seen = $seen RETURN n", s.requests[0].Body["statement"])
+	s.Equal(map[string]interface{}{
+		"born": map[string]interface{}{"$type": "Integer", "_value": "1964"},
+		"seen": map[string]interface{}{"$type": "String", "_value": "now"},
+	}, s.requests[0].Body["parameters"])
+	s.Require().NotNil(record)
+	s.Equal(neo4j.Node{ElementId: "4:db:1", Labels: []string{"Person"}, Props: map[string]interface{}{"name": "Keanu Reeves"}}, record.Values[0])
+	s.Equal(int64(1964), record.Values[1])
+	s.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), record.Values[2])
+	s.Equal(neo4j.Duration{Months: 1, Days: 2, Seconds: 3, Nanos: 500_000_000}, record.Values[3])
+	s.Equal(neo4j.Point2D{SpatialRefId: 4326, X: 2.35, Y: 48.85}, record.Values[4])
+	s.Require().NotNil(summary)
+	s.Equal(1, summary.Counters().PropertiesSet())
+	s.Equal("movies", summary.Database().Name())
+}
+
+func (s *QueryAPITestSuite) TestReportsTheErrorsOfTheServer() {
+	s.answer = func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(writer, `{"errors": [{"code": "Neo.ClientError.Statement.SyntaxError", "message": "Invalid input"}]}`)
+	}
+	driver, err := NewDriver(s.settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	err = executeSimpleQuery(s.ctx, driver)
+
+	neo4jErr := &neo4j.Neo4jError{}
+	s.Require().ErrorAs(err, &neo4jErr)
+	s.Equal("Neo.ClientError.Statement.SyntaxError", neo4jErr.Code)
+	s.Equal(CategoryInvalidArgument, ErrorToStatus(err).Category)
+}
+
+func (s *QueryAPITestSuite) TestRunsTheExplicitTransactionsOnTheMemberThatOpenedThem() {
+	s.answer = func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("neo4j-cluster-affinity", "member-2")
+		switch request.URL.Path {
+		case "/db/neo4j/query/v2/tx":
+			_, _ = io.WriteString(writer, `{"transaction": {"id": "tx-1"}}`)
+		case "/db/neo4j/query/v2/tx/tx-1/commit":
+			_, _ = io.WriteString(writer, `{"bookmarks": ["FB:2"]}`)
+		default:
+			_, _ = io.WriteString(writer, `{"data": {"fields": [], "values": []}}`)
+		}
+	}
+	driver, err := NewDriver(s.settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	err = driver.ExecuteScript(s.ctx, "CREATE (:Person); CREATE (:Movie);", WithScriptTransaction())
+
+	s.Require().NoError(err)
+	s.Require().Len(s.requests, 4)
+	s.Equal([]string{"/db/neo4j/query/v2/tx", "/db/neo4j/query/v2/tx/tx-1", "/db/neo4j/query/v2/tx/tx-1", "/db/neo4j/query/v2/tx/tx-1/commit"},
+		[]string{s.requests[0].Path, s.requests[1].Path, s.requests[2].Path, s.requests[3].Path})
+	s.Equal("", s.requests[0].Affinity)
+	s.Equal("member-2", s.requests[3].Affinity)
+	s.Equal("CREATE (:Movie)", s.requests[2].Body["statement"])
+}
+
+func (s *QueryAPITestSuite) TestReportsTheUnreachableServersAsConnectivityErrors() {
+	s.server.Close()
+	driver, err := NewDriver(s.settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	err = executeSimpleQuery(s.ctx, driver)
+
+	s.ErrorContains(err, "ConnectivityError")
+	s.Equal(CategoryUnavailable, ErrorToStatus(err).Category)
+}
+
+func (s *QueryAPITestSuite) TestVerifiesTheConnectivityWithTheDiscoveryDocument() {
+	s.answer = respondWith(`{"neo4j_version": "5.20.0", "neo4j_edition": "enterprise"}`)
+	driver, err := NewDriver(s.settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	s.NoError(driver.VerifyConnectivity(s.ctx))
+	s.Equal("/", s.requests[0].Path)
+}
+
+func (s *QueryAPITestSuite) TestRejectsUnknownTransports() {
+	s.settings.Transport = "grpc"
+
+	s.ErrorIs(s.settings.Validate(), ErrUnsupportedTransport)
+}
+
+func respondWith(body string) func(http.ResponseWriter, *http.Request) {
+	return func(writer http.ResponseWriter, _ *http.Request) {
+		writer.Header().Set("Content-Type", "application/vnd.neo4j.query")
+		_, _ = io.WriteString(writer, body)
+	}
+}
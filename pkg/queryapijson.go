@@ -0,0 +1,342 @@
+package driver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// pointPattern matches the WKT points of the typed JSON format, e.g. SRID=4326;POINT (12.5 41.9) or SRID=9157;POINT Z (1 2 3)
+	pointPattern = regexp.MustCompile(`^SRID=(\d+);POINT( Z)? \(([^ ]+) ([^ ]+)(?: ([^ ]+))?\)$`)
+	// durationPattern matches the ISO-8601 durations of the typed JSON format, e.g. P1Y2M3DT4H5M6.007S
+	durationPattern = regexp.MustCompile(`^(-)?P(?:(-?\d+)Y)?(?:(-?\d+)M)?(?:(-?\d+)W)?(?:(-?\d+)D)?(?:T(?:(-?\d+)H)?(?:(-?\d+)M)?(?:(-?\d+)(?:\.(\d{1,9}))?S)?)?$`)
+)
+
+// encodeTyped converts a parameter into the typed JSON format of the Query API,
+// accepting the same values as the neo4j driver, e.g. after CoerceParams
+func encodeTyped(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case nil:
+		return typedOutput{Type: "Null"}, nil
+	case bool:
+		return typedOutput{Type: "Boolean", Value: value}, nil
+	case string:
+		return typedOutput{Type: "String", Value: value}, nil
+	case []byte:
+		return typedOutput{Type: "Base64", Value: base64.StdEncoding.EncodeToString(value)}, nil
+	case time.Time:
+		return typedOutput{Type: "OffsetDateTime", Value: value.Format(DateTimeLayout)}, nil
+	case neo4j.Date:
+		return typedOutput{Type: "Date", Value: value.Time().Format(DateLayout)}, nil
+	case neo4j.LocalTime:
+		return typedOutput{Type: "LocalTime", Value: value.Time().Format(LocalTimeLayout)}, nil
+	case neo4j.LocalDateTime:
+		return typedOutput{Type: "LocalDateTime", Value: value.Time().Format(LocalDateTimeLayout)}, nil
+	case neo4j.Time:
+		return typedOutput{Type: "Time", Value: value.Time().Format(OffsetTimeLayout)}, nil
+	case neo4j.Duration:
+		return typedOutput{Type: "Duration", Value: value.String()}, nil
+	case neo4j.Point2D:
+		return typedOutput{Type: "Point", Value: fmt.Sprintf("SRID=%d;POINT (%v %v)", value.SpatialRefId, value.X, value.Y)}, nil
+	case neo4j.Point3D:
+		return typedOutput{Type: "Point", Value: fmt.Sprintf("SRID=%d;POINT Z (%v %v %v)", value.SpatialRefId, value.X, value.Y, value.Z)}, nil
+	}
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return typedOutput{Type: "Integer", Value: strconv.FormatInt(reflected.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return typedOutput{Type: "Integer", Value: strconv.FormatUint(reflected.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return typedOutput{Type: "Float", Value: strconv.FormatFloat(reflected.Float(), 'g', -1, 64)}, nil
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, reflected.Len())
+		for i := range list {
+			element, err := encodeTyped(reflected.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			list[i] = element
+		}
+		return typedOutput{Type: "List", Value: list}, nil
+	case reflect.Map:
+		if reflected.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", reflected.Type().Key())
+		}
+		entries := make(map[string]interface{}, reflected.Len())
+		iterator := reflected.MapRange()
+		for iterator.Next() {
+			entry, err := encodeTyped(iterator.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			entries[iterator.Key().String()] = entry
+		}
+		return typedOutput{Type: "Map", Value: entries}, nil
+	case reflect.Pointer:
+		if reflected.IsNil() {
+			return typedOutput{Type: "Null"}, nil
+		}
+		return encodeTyped(reflected.Elem().Interface())
+	}
+	return nil, fmt.Errorf("unsupported parameter type %T", value)
+}
+
+// decode converts the value into the types the neo4j driver returns
+func (v typedValue) decode() (interface{}, error) {
+	switch v.Type {
+	case "Null":
+		return nil, nil
+	case "Boolean":
+		var value bool
+		err := json.Unmarshal(v.Value, &value)
+		return value, err
+	case "Integer":
+		raw, err := v.text()
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseInt(raw, 10, 64)
+	case "Float":
+		raw, err := v.text()
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseFloat(raw, 64)
+	case "String":
+		return v.text()
+	case "Base64":
+		raw, err := v.text()
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(raw)
+	case "Date":
+		value, err := v.temporal(DateLayout)
+		return neo4j.Date(value), err
+	case "LocalTime":
+		value, err := v.temporal(LocalTimeLayout)
+		return neo4j.LocalTime(value), err
+	case "LocalDateTime":
+		value, err := v.temporal(LocalDateTimeLayout)
+		return neo4j.LocalDateTime(value), err
+	case "Time":
+		value, err := v.temporal(OffsetTimeLayout)
+		return neo4j.Time(value), err
+	case "OffsetDateTime", "DateTime", "ZonedDateTime":
+		return v.dateTime()
+	case "Duration":
+		raw, err := v.text()
+		if err != nil {
+			return nil, err
+		}
+		return parseISODuration(raw)
+	case "Point":
+		raw, err := v.text()
+		if err != nil {
+			return nil, err
+		}
+		return parseWKTPoint(raw)
+	case "List":
+		var elements []typedValue
+		if err := json.Unmarshal(v.Value, &elements); err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, len(elements))
+		for i, element := range elements {
+			decoded, err := element.decode()
+			if err != nil {
+				return nil, err
+			}
+			list[i] = decoded
+		}
+		return list, nil
+	case "Map":
+		var entries map[string]typedValue
+		if err := json.Unmarshal(v.Value, &entries); err != nil {
+			return nil, err
+		}
+		return decodeTypedMap(entries)
+	case "Node":
+		return v.node()
+	case "Relationship":
+		return v.relationship()
+	case "Path":
+		return v.path()
+	}
+	return nil, fmt.Errorf("unsupported value type %q in the response of the Query API", v.Type)
+}
+
+func (v typedValue) text() (string, error) {
+	var value string
+	err := json.Unmarshal(v.Value, &value)
+	return value, err
+}
+
+func (v typedValue) temporal(layout string) (time.Time, error) {
+	raw, err := v.text()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(layout, raw)
+}
+
+// dateTime parses the date times, whose zone id, if any, follows the offset between brackets, e.g. 2015-11-21T21:40:32.142+01:00[Europe/Paris]
+func (v typedValue) dateTime() (time.Time, error) {
+	raw, err := v.text()
+	if err != nil {
+		return time.Time{}, err
+	}
+	zone := ""
+	if start := strings.IndexByte(raw, '['); start >= 0 && strings.HasSuffix(raw, "]") {
+		raw, zone = raw[:start], raw[start+1:len(raw)-1]
+	}
+	value, err := time.Parse(DateTimeLayout, raw)
+	if err != nil || zone == "" {
+		return value, err
+	}
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		return value, nil
+	}
+	return value.In(location), nil
+}
+
+func (v typedValue) node() (neo4j.Node, error) {
+	var node struct {
+		ElementID  string                `json:"_element_id"`
+		Labels     []string              `json:"_labels"`
+		Properties map[string]typedValue `json:"_properties"`
+	}
+	if err := json.Unmarshal(v.Value, &node); err != nil {
+		return neo4j.Node{}, err
+	}
+	props, err := decodeTypedMap(node.Properties)
+	return neo4j.Node{ElementId: node.ElementID, Labels: node.Labels, Props: props}, err
+}
+
+func (v typedValue) relationship() (neo4j.Relationship, error) {
+	var relationship struct {
+		ElementID      string                `json:"_element_id"`
+		StartElementID string                `json:"_start_node_element_id"`
+		EndElementID   string                `json:"_end_node_element_id"`
+		Type           string                `json:"_type"`
+		Properties     map[string]typedValue `json:"_properties"`
+	}
+	if err := json.Unmarshal(v.Value, &relationship); err != nil {
+		return neo4j.Relationship{}, err
+	}
+	props, err := decodeTypedMap(relationship.Properties)
+	return neo4j.Relationship{
+		ElementId:      relationship.ElementID,
+		StartElementId: relationship.StartElementID,
+		EndElementId:   relationship.EndElementID,
+		Type:           relationship.Type,
+		Props:          props,
+	}, err
+}
+
+// path decodes a path, whose elements alternate between nodes and relationships
+func (v typedValue) path() (neo4j.Path, error) {
+	var elements []typedValue
+	if err := json.Unmarshal(v.Value, &elements); err != nil {
+		return neo4j.Path{}, err
+	}
+	path := neo4j.Path{}
+	for _, element := range elements {
+		decoded, err := element.decode()
+		if err != nil {
+			return path, err
+		}
+		switch decoded := decoded.(type) {
+		case neo4j.Node:
+			path.Nodes = append(path.Nodes, decoded)
+		case neo4j.Relationship:
+			path.Relationships = append(path.Relationships, decoded)
+		default:
+			return path, fmt.Errorf("unexpected %s in path", element.Type)
+		}
+	}
+	return path, nil
+}
+
+func decodeTypedMap(entries map[string]typedValue) (map[string]interface{}, error) {
+	decoded := make(map[string]interface{}, len(entries))
+	for key, entry := range entries {
+		value, err := entry.decode()
+		if err != nil {
+			return nil, err
+		}
+		decoded[key] = value
+	}
+	return decoded, nil
+}
+
+func parseWKTPoint(raw string) (interface{}, error) {
+	match := pointPattern.FindStringSubmatch(raw)
+	if match == nil {
+		return nil, fmt.Errorf("invalid point %q", raw)
+	}
+	srid, _ := strconv.ParseUint(match[1], 10, 32)
+	coordinates := make([]float64, 0, 3)
+	for _, coordinate := range match[3:] {
+		if coordinate == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(coordinate, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid point %q: %w", raw, err)
+		}
+		coordinates = append(coordinates, value)
+	}
+	if match[2] != "" && len(coordinates) == 3 {
+		return neo4j.Point3D{SpatialRefId: uint32(srid), X: coordinates[0], Y: coordinates[1], Z: coordinates[2]}, nil
+	}
+	if match[2] != "" || len(coordinates) != 2 {
+		return nil, fmt.Errorf("invalid point %q", raw)
+	}
+	return neo4j.Point2D{SpatialRefId: uint32(srid), X: coordinates[0], Y: coordinates[1]}, nil
+}
+
+func parseISODuration(raw string) (neo4j.Duration, error) {
+	match := durationPattern.FindStringSubmatch(raw)
+	if match == nil || raw == "P" || strings.HasSuffix(raw, "T") {
+		return neo4j.Duration{}, fmt.Errorf("invalid duration %q", raw)
+	}
+	part := func(i int) int64 {
+		value, _ := strconv.ParseInt(match[i], 10, 64)
+		return value
+	}
+	duration := neo4j.Duration{
+		Months:  part(2)*12 + part(3),
+		Days:    part(4)*7 + part(5),
+		Seconds: part(6)*3600 + part(7)*60 + part(8),
+	}
+	if match[9] != "" {
+		nanos, _ := strconv.ParseInt((match[9] + "00000000")[:9], 10, 64)
+		if strings.HasPrefix(match[8], "-") {
+			nanos = -nanos
+		}
+		duration.Seconds += nanos / int64(time.Second)
+		duration.Nanos = int(nanos % int64(time.Second))
+		if duration.Nanos < 0 {
+			duration.Seconds--
+			duration.Nanos += int(time.Second)
+		}
+	}
+	if match[1] != "" {
+		duration.Months, duration.Days, duration.Seconds = -duration.Months, -duration.Days, -duration.Seconds
+		if duration.Nanos > 0 {
+			duration.Seconds--
+			duration.Nanos = int(time.Second) - duration.Nanos
+		}
+	}
+	return duration, nil
+}
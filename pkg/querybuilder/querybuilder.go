@@ -0,0 +1,447 @@
+// Package querybuilder fluently builds parameterized Cypher queries, so that dynamic filters compose
+// without concatenating values into the query text:
+//
+//	q := querybuilder.New().
+//		Match(querybuilder.Node("u", "User")).
+//		Where(querybuilder.Prop("u", "age").Gte(18)).
+//		Return(querybuilder.Prop("u", "name").As("name")).
+//		OrderBy("name").Limit(10)
+//	err := q.Execute(ctx, d, onResults)
+//
+// values always become parameters. labels, relationship types, property names and aliases are quoted,
+// only the variables and the raw expressions given to Return, With and OrderBy are embedded as is.
+package querybuilder
+
+import (
+	"context"
+	"fmt"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"sort"
+	"strings"
+)
+
+// Props are the properties of a node or relationship pattern
+type Props = map[string]interface{}
+
+// Query accumulates the clauses of a query until it is built
+type Query struct {
+	clauses []string
+	params  map[string]interface{}
+	// whereOpen is set while the last clause is a WHERE, that the following conditions are added to
+	whereOpen bool
+	namespace driver.Namespace
+}
+
+// New creates an empty query
+func New() *Query {
+	return &Query{params: map[string]interface{}{}}
+}
+
+// InNamespace prefixes the labels and relationship types of the patterns added afterwards, typically with the
+// namespace of the driver: New().InNamespace(d.Namespace())
+func (q *Query) InNamespace(namespace driver.Namespace) *Query {
+	q.namespace = namespace
+	return q
+}
+
+// Match adds a MATCH clause of the comma-separated patterns
+func (q *Query) Match(patterns ...Pattern) *Query {
+	return q.add("MATCH " + q.patterns(patterns))
+}
+
+// OptionalMatch adds an OPTIONAL MATCH clause of the comma-separated patterns
+func (q *Query) OptionalMatch(patterns ...Pattern) *Query {
+	return q.add("OPTIONAL MATCH " + q.patterns(patterns))
+}
+
+// Create adds a CREATE clause of the comma-separated patterns
+func (q *Query) Create(patterns ...Pattern) *Query {
+	return q.add("CREATE " + q.patterns(patterns))
+}
+
+// Merge adds a MERGE clause of the pattern
+func (q *Query) Merge(pattern Pattern) *Query {
+	return q.add("MERGE " + pattern.render(q))
+}
+
+// Where filters the preceding MATCH or WITH with all the conditions. consecutive calls are combined with AND,
+// and a call without conditions adds nothing, so that optional filters can be passed as they are collected.
+func (q *Query) Where(conditions ...Condition) *Query {
+	var predicates []string
+	for _, condition := range conditions {
+		if condition.render != nil {
+			predicates = append(predicates, condition.render(q))
+		}
+	}
+	if len(predicates) == 0 {
+		return q
+	}
+	rendered := strings.Join(predicates, " AND ")
+	if q.whereOpen {
+		q.clauses[len(q.clauses)-1] += " AND " + rendered
+		return q
+	}
+	q.add("WHERE " + rendered)
+	q.whereOpen = true
+	return q
+}
+
+// Set adds a SET clause assigning value to property
+func (q *Query) Set(property Property, value interface{}) *Query {
+	return q.add(fmt.Sprintf("SET %s = %s", property, q.param(value)))
+}
+
+// SetProps adds a SET clause merging props into the properties of variable
+func (q *Query) SetProps(variable string, props Props) *Query {
+	return q.add(fmt.Sprintf("SET %s += %s", variable, q.param(props)))
+}
+
+// Delete adds a DETACH DELETE clause of the variables
+func (q *Query) Delete(variables ...string) *Query {
+	return q.add("DETACH DELETE " + strings.Join(variables, ", "))
+}
+
+// With adds a WITH clause projecting the expressions
+func (q *Query) With(expressions ...fmt.Stringer) *Query {
+	return q.add("WITH " + join(expressions))
+}
+
+// Return adds a RETURN clause of the expressions, e.g. Var("u") or Prop("u", "name").As("name")
+func (q *Query) Return(expressions ...fmt.Stringer) *Query {
+	return q.add("RETURN " + join(expressions))
+}
+
+// OrderBy adds an ORDER BY clause, e.g. OrderBy("name", Desc("age"))
+func (q *Query) OrderBy(expressions ...string) *Query {
+	return q.add("ORDER BY " + strings.Join(expressions, ", "))
+}
+
+// Skip adds a SKIP clause, with count as a parameter
+func (q *Query) Skip(count int64) *Query {
+	return q.add("SKIP " + q.param(count))
+}
+
+// Limit adds a LIMIT clause, with count as a parameter
+func (q *Query) Limit(count int64) *Query {
+	return q.add("LIMIT " + q.param(count))
+}
+
+// Build returns the query and its parameters
+func (q *Query) Build() (string, map[string]interface{}) {
+	return strings.Join(q.clauses, " "), q.params
+}
+
+// Execute runs the built query with runner, typically a *driver.Driver
+func (q *Query) Execute(ctx context.Context, runner driver.QueryRunner, onResults driver.ResultsHookFn, opts ...driver.QueryOption) error {
+	query, params := q.Build()
+	return runner.ExecuteQuery(ctx, query, params, onResults, opts...)
+}
+
+func (q *Query) add(clause string) *Query {
+	q.clauses = append(q.clauses, clause)
+	q.whereOpen = false
+	return q
+}
+
+// param registers value as the next parameter, and returns its reference
+func (q *Query) param(value interface{}) string {
+	name := fmt.Sprintf("p%d", len(q.params))
+	q.params[name] = value
+	return "$" + name
+}
+
+func (q *Query) patterns(patterns []Pattern) string {
+	rendered := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		rendered[i] = pattern.render(q)
+	}
+	return strings.Join(rendered, ", ")
+}
+
+// Desc sorts OrderBy by expression in descending order
+func Desc(expression string) string {
+	return expression + " DESC"
+}
+
+// Expr is a raw Cypher expression, embedded as is. it must never contain user input
+type Expr string
+
+func (e Expr) String() string {
+	return string(e)
+}
+
+// As aliases the expression, e.g. in Return
+func (e Expr) As(alias string) Expr {
+	return Expr(string(e) + " AS " + driver.QuoteIdentifier(alias))
+}
+
+// Var refers to a variable bound by a pattern
+func Var(variable string) Expr {
+	return Expr(variable)
+}
+
+func join(expressions []fmt.Stringer) string {
+	rendered := make([]string, len(expressions))
+	for i, expression := range expressions {
+		rendered[i] = expression.String()
+	}
+	return strings.Join(rendered, ", ")
+}
+
+// Pattern is a node or path pattern of MATCH, CREATE and MERGE
+type Pattern interface {
+	render(q *Query) string
+}
+
+// NodePattern matches or creates a node
+type NodePattern struct {
+	variable string
+	labels   []string
+	props    Props
+}
+
+// Node creates a node pattern bound to variable, which may be empty
+func Node(variable string, labels ...string) *NodePattern {
+	return &NodePattern{variable: variable, labels: labels}
+}
+
+// WithProps constrains the properties of the node, each of them being a parameter
+func (n *NodePattern) WithProps(props Props) *NodePattern {
+	n.props = props
+	return n
+}
+
+// RelTo starts a path from n to other through rel, i.e. (n)-[rel]->(other)
+func (n *NodePattern) RelTo(rel *RelPattern, other *NodePattern) *PathPattern {
+	return (&PathPattern{start: n}).RelTo(rel, other)
+}
+
+// RelFrom starts a path from other to n through rel, i.e. (n)<-[rel]-(other)
+func (n *NodePattern) RelFrom(rel *RelPattern, other *NodePattern) *PathPattern {
+	return (&PathPattern{start: n}).RelFrom(rel, other)
+}
+
+// RelWith starts a path between n and other through rel in any direction, i.e. (n)-[rel]-(other)
+func (n *NodePattern) RelWith(rel *RelPattern, other *NodePattern) *PathPattern {
+	return (&PathPattern{start: n}).RelWith(rel, other)
+}
+
+func (n *NodePattern) render(q *Query) string {
+	return "(" + n.variable + labels(":", q.namespace.Labels(n.labels)) + props(q, n.props) + ")"
+}
+
+// RelPattern matches or creates a relationship
+type RelPattern struct {
+	variable string
+	types    []string
+	props    Props
+}
+
+// Rel creates a relationship pattern bound to variable, which may be empty, of any of the types
+func Rel(variable string, types ...string) *RelPattern {
+	return &RelPattern{variable: variable, types: types}
+}
+
+// WithProps constrains the properties of the relationship, each of them being a parameter
+func (r *RelPattern) WithProps(props Props) *RelPattern {
+	r.props = props
+	return r
+}
+
+func (r *RelPattern) render(q *Query) string {
+	types := ""
+	if len(r.types) > 0 {
+		types = ":" + labels("|", q.namespace.Labels(r.types))[1:]
+	}
+	return "[" + r.variable + types + props(q, r.props) + "]"
+}
+
+// PathPattern is a chain of nodes connected by relationships
+type PathPattern struct {
+	start *NodePattern
+	steps []string
+	rels  []*RelPattern
+	nodes []*NodePattern
+}
+
+// RelTo extends the path to other through rel, i.e. ...-[rel]->(other)
+func (p *PathPattern) RelTo(rel *RelPattern, other *NodePattern) *PathPattern {
+	return p.step("-%s->", rel, other)
+}
+
+// RelFrom extends the path from other through rel, i.e. ...<-[rel]-(other)
+func (p *PathPattern) RelFrom(rel *RelPattern, other *NodePattern) *PathPattern {
+	return p.step("<-%s-", rel, other)
+}
+
+// RelWith extends the path to other through rel in any direction, i.e. ...-[rel]-(other)
+func (p *PathPattern) RelWith(rel *RelPattern, other *NodePattern) *PathPattern {
+	return p.step("-%s-", rel, other)
+}
+
+func (p *PathPattern) step(arrow string, rel *RelPattern, other *NodePattern) *PathPattern {
+	p.steps = append(p.steps, arrow)
+	p.rels = append(p.rels, rel)
+	p.nodes = append(p.nodes, other)
+	return p
+}
+
+func (p *PathPattern) render(q *Query) string {
+	path := strings.Builder{}
+	path.WriteString(p.start.render(q))
+	for i, arrow := range p.steps {
+		path.WriteString(fmt.Sprintf(arrow, p.rels[i].render(q)))
+		path.WriteString(p.nodes[i].render(q))
+	}
+	return path.String()
+}
+
+func labels(separator string, names []string) string {
+	quoted := strings.Builder{}
+	for _, name := range names {
+		quoted.WriteString(separator + driver.QuoteIdentifier(name))
+	}
+	return quoted.String()
+}
+
+// props renders the property map of a pattern, one parameter per property in a stable order
+func props(q *Query, props Props) string {
+	if len(props) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	entries := make([]string, len(keys))
+	for i, key := range keys {
+		entries[i] = driver.QuoteIdentifier(key) + ": " + q.param(props[key])
+	}
+	return " {" + strings.Join(entries, ", ") + "}"
+}
+
+// Property is a property of a variable, e.g. u.name
+type Property struct {
+	variable, name string
+}
+
+// Prop refers to the property name of variable
+func Prop(variable, name string) Property {
+	return Property{variable: variable, name: name}
+}
+
+func (p Property) String() string {
+	return p.variable + "." + driver.QuoteIdentifier(p.name)
+}
+
+// As aliases the property, e.g. in Return
+func (p Property) As(alias string) Expr {
+	return Expr(p.String()).As(alias)
+}
+
+// Eq is satisfied when the property equals value
+func (p Property) Eq(value interface{}) Condition {
+	return p.compare("=", value)
+}
+
+// Neq is satisfied when the property differs from value
+func (p Property) Neq(value interface{}) Condition {
+	return p.compare("<>", value)
+}
+
+// Gt is satisfied when the property is greater than value
+func (p Property) Gt(value interface{}) Condition {
+	return p.compare(">", value)
+}
+
+// Gte is satisfied when the property is greater than or equal to value
+func (p Property) Gte(value interface{}) Condition {
+	return p.compare(">=", value)
+}
+
+// Lt is satisfied when the property is less than value
+func (p Property) Lt(value interface{}) Condition {
+	return p.compare("<", value)
+}
+
+// Lte is satisfied when the property is less than or equal to value
+func (p Property) Lte(value interface{}) Condition {
+	return p.compare("<=", value)
+}
+
+// In is satisfied when the property is one of values, a list
+func (p Property) In(values interface{}) Condition {
+	return p.compare("IN", values)
+}
+
+// StartsWith is satisfied when the property starts with prefix
+func (p Property) StartsWith(prefix string) Condition {
+	return p.compare("STARTS WITH", prefix)
+}
+
+// Contains is satisfied when the property contains text
+func (p Property) Contains(text string) Condition {
+	return p.compare("CONTAINS", text)
+}
+
+// IsNull is satisfied when the property is not set
+func (p Property) IsNull() Condition {
+	return Condition{render: func(*Query) string { return p.String() + " IS NULL" }}
+}
+
+// IsNotNull is satisfied when the property is set
+func (p Property) IsNotNull() Condition {
+	return Condition{render: func(*Query) string { return p.String() + " IS NOT NULL" }}
+}
+
+func (p Property) compare(operator string, value interface{}) Condition {
+	return Condition{render: func(q *Query) string {
+		return p.String() + " " + operator + " " + q.param(value)
+	}}
+}
+
+// Condition is a predicate of Where, composed with And, Or and Not
+type Condition struct {
+	render func(q *Query) string
+}
+
+// And is satisfied when all the conditions are. conditions without predicate, such as And(), are ignored
+func And(conditions ...Condition) Condition {
+	return combine(" AND ", conditions)
+}
+
+// Or is satisfied when any of the conditions is. conditions without predicate, such as Or(), are ignored
+func Or(conditions ...Condition) Condition {
+	return combine(" OR ", conditions)
+}
+
+// Not is satisfied when condition is not
+func Not(condition Condition) Condition {
+	if condition.render == nil {
+		return condition
+	}
+	return Condition{render: func(q *Query) string { return "NOT (" + condition.render(q) + ")" }}
+}
+
+func combine(operator string, conditions []Condition) Condition {
+	var present []Condition
+	for _, condition := range conditions {
+		if condition.render != nil {
+			present = append(present, condition)
+		}
+	}
+	switch len(present) {
+	case 0:
+		return Condition{}
+	case 1:
+		return present[0]
+	}
+	return Condition{render: func(q *Query) string {
+		rendered := make([]string, len(present))
+		for i, condition := range present {
+			rendered[i] = condition.render(q)
+		}
+		return "(" + strings.Join(rendered, operator) + ")"
+	}}
+}
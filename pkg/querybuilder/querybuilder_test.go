@@ -0,0 +1,94 @@
+package querybuilder_test
+
+import (
+	"context"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg/querybuilder"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	suite.Run(t, new(QueryBuilderTestSuite))
+}
+
+type QueryBuilderTestSuite struct {
+	suite.Suite
+}
+
+func (s *QueryBuilderTestSuite) TestBuildsParameterizedQueries() {
+	query, params := New().
+		Match(Node("u", "User").WithProps(Props{"active": true}).RelTo(Rel("", "MEMBER_OF", "OWNS"), Node("t", "Team"))).
+		Where(Prop("u", "age").Gte(18), Or(Prop("t", "name").StartsWith("core"), Prop("t", "name").IsNull())).
+		Where(Not(Prop("u", "role").In([]string{"bot"}))).
+		Return(Prop("u", "name").As("name"), Var("t")).
+		OrderBy("name", Desc("t.size")).
+		Skip(20).
+		Limit(10).
+		Build()
+
+	s.Equal("MATCH (u:`User` {`active`: $p0})-[:`MEMBER_OF`|`OWNS`]->(t:`Team`) "+
+		"WHERE u.`age` >= $p1 AND (t.`name` STARTS WITH $p2 OR t.`name` IS NULL) AND NOT (u.`role` IN $p3) "+
+		"RETURN u.`name` AS `name`, t ORDER BY name, t.size DESC SKIP $p4 LIMIT $p5", query)
+	s.Equal(map[string]interface{}{"p0": true, "p1": 18, "p2": "core", "p3": []string{"bot"}, "p4": int64(20), "p5": int64(10)}, params)
+}
+
+func (s *QueryBuilderTestSuite) TestSkipsEmptyFilters() {
+	var filters []Condition
+
+	query, _ := New().Match(Node("u", "User")).Where(filters...).Where(And(), Or()).Return(Var("u")).Build()
+
+	s.Equal("MATCH (u:`User`) RETURN u", query)
+}
+
+func (s *QueryBuilderTestSuite) TestQuotesIdentifiersAndParameterizesValues() {
+	query, params := New().
+		Merge(Node("n", "User`) DETACH DELETE n //").WithProps(Props{"name": "'; DROP"})).
+		Set(Prop("n", "seen"), true).
+		Build()
+
+	s.Equal("MERGE (n:`User``) DETACH DELETE n //` {`name`: $p0}) SET n.`seen` = $p1", query)
+	s.Equal(map[string]interface{}{"p0": "'; DROP", "p1": true}, params)
+}
+
+func (s *QueryBuilderTestSuite) TestBuildsWrites() {
+	query, params := New().
+		Match(Node("a", "User").WithProps(Props{"id": 1}), Node("b", "User").WithProps(Props{"id": 2})).
+		Create(Node("a").RelTo(Rel("r", "KNOWS").WithProps(Props{"since": 2020}), Node("b"))).
+		SetProps("b", Props{"invited": true}).
+		With(Var("a")).
+		OptionalMatch(Node("a").RelFrom(Rel("", "BLOCKED"), Node("x")).RelWith(Rel(""), Node("y"))).
+		Delete("x").
+		Build()
+
+	s.Equal("MATCH (a:`User` {`id`: $p0}), (b:`User` {`id`: $p1}) CREATE (a)-[r:`KNOWS` {`since`: $p2}]->(b) SET b += $p3 "+
+		"WITH a OPTIONAL MATCH (a)<-[:`BLOCKED`]-(x)-[]-(y) DETACH DELETE x", query)
+	s.Len(params, 4)
+}
+
+func (s *QueryBuilderTestSuite) TestExecutesThroughTheRunner() {
+	runner := &recordingRunner{}
+
+	err := New().Match(Node("u", "User")).Return(Var("u")).Execute(context.Background(), runner, nil)
+
+	s.NoError(err)
+	s.Equal("MATCH (u:`User`) RETURN u", runner.query)
+}
+
+type recordingRunner struct {
+	query string
+}
+
+func (r *recordingRunner) ExecuteQuery(_ context.Context, query string, _ map[string]interface{}, _ driver.ResultsHookFn, _ ...driver.QueryOption) error {
+	r.query = query
+	return nil
+}
+
+func (s *QueryBuilderTestSuite) TestPrefixesLabelsWithTheNamespace() {
+	query, _ := New().InNamespace(driver.Namespace{Prefix: "App"}).
+		Match(Node("u", "User").RelTo(Rel("", "KNOWS"), Node("f", "User"))).
+		Return(Var("f")).
+		Build()
+
+	s.Equal("MATCH (u:`App_User`)-[:`App_KNOWS`]->(f:`App_User`) RETURN f", query)
+}
@@ -0,0 +1,166 @@
+package driver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"strings"
+	"time"
+)
+
+// WithCache serves the query from Settings.QueryCache when possible, and caches its records for ttl otherwise.
+// it is meant for read queries on hot reference data, it only applies along with WithAccessMode(neo4j.AccessModeRead)
+// so that a write is never skipped. cached executions replay the records to the hook
+// without reaching the server, so their result summary is not available.
+// the results are cached per database and impersonated user, see WithDatabase and WithImpersonatedUser
+func WithCache(ttl time.Duration) QueryOption {
+	return func(options *queryOptions) {
+		options.cacheTTL = ttl
+	}
+}
+
+// CacheKey returns the cache key of a query: its whitespace-normalized text and a hash of its parameters
+func CacheKey(query string, params map[string]interface{}) string {
+	normalized := strings.Join(strings.Fields(query), " ")
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%#v", normalizeValue(params))))
+	return normalized + "#" + hex.EncodeToString(hash[:])
+}
+
+// InvalidateQuery evicts the cached results of the query executed with these parameters against the default database
+func (d *Driver) InvalidateQuery(query string, params map[string]interface{}) {
+	if d.settings.QueryCache != nil {
+		d.settings.QueryCache.Delete(CacheKey(query, params))
+	}
+}
+
+// InvalidateCache evicts all cached query results
+func (d *Driver) InvalidateCache() {
+	if d.settings.QueryCache != nil {
+		d.settings.QueryCache.Clear()
+	}
+}
+
+// cachedResults serves the query from the cache if possible. otherwise it returns a hook caching the records
+// onResults reads, to be used for the actual execution
+func (d *Driver) cachedResults(ctx context.Context, query string, params map[string]interface{}, onResults ResultsHookFn, options *queryOptions) (hit bool, hook ResultsHookFn, err error) {
+	store := d.settings.QueryCache
+	if store == nil || options.cacheTTL <= 0 || options.sessionConfig().AccessMode != neo4j.AccessModeRead {
+		return false, onResults, nil
+	}
+	key := options.cacheKey(query, params)
+	if records, found := store.Get(key); found {
+		return true, nil, executeHook(onResults, &replayResult{records: records})
+	}
+	return false, func(result neo4j.ResultWithContext) error {
+		tee := &teeResult{ResultWithContext: result}
+		if err := onResults(tee); err != nil {
+			return err
+		}
+		if err := tee.drain(ctx); err != nil {
+			return err
+		}
+		store.Set(key, tee.records, options.cacheTTL)
+		return nil
+	}, nil
+}
+
+// cacheKey keeps the cached results of the databases and of the impersonated users apart,
+// so that the results of a user are never served to another one that the server would deny them to.
+// they are the ones of the session the query runs in, after WithSessionConfig
+func (o *queryOptions) cacheKey(query string, params map[string]interface{}) string {
+	config := o.sessionConfig()
+	key := CacheKey(query, params)
+	if config.DatabaseName != "" {
+		key = config.DatabaseName + "/" + key
+	}
+	if config.ImpersonatedUser != "" {
+		key = key + "@" + config.ImpersonatedUser
+	}
+	return key
+}
+
+// replayResult serves cached or buffered records through the neo4j.ResultWithContext API.
+// the embedded interface is left nil: it only provides the unexported methods, which are never called.
+// keys and summary are optional, the keys default to the ones of the first record
+type replayResult struct {
+	neo4j.ResultWithContext
+	keys    []string
+	records []*neo4j.Record
+	current *neo4j.Record
+	next    int
+	summary neo4j.ResultSummary
+}
+
+func (r *replayResult) Keys() ([]string, error) {
+	if r.keys != nil {
+		return r.keys, nil
+	}
+	if len(r.records) == 0 {
+		return []string{}, nil
+	}
+	return r.records[0].Keys, nil
+}
+
+func (r *replayResult) NextRecord(_ context.Context, record **neo4j.Record) bool {
+	if r.next >= len(r.records) {
+		r.current = nil
+		*record = nil
+		return false
+	}
+	r.current = r.records[r.next]
+	r.next++
+	*record = r.current
+	return true
+}
+
+func (r *replayResult) Next(ctx context.Context) bool {
+	var record *neo4j.Record
+	return r.NextRecord(ctx, &record)
+}
+
+func (r *replayResult) PeekRecord(_ context.Context, record **neo4j.Record) bool {
+	if r.next >= len(r.records) {
+		return false
+	}
+	*record = r.records[r.next]
+	return true
+}
+
+func (r *replayResult) Peek(ctx context.Context) bool {
+	var record *neo4j.Record
+	return r.PeekRecord(ctx, &record)
+}
+
+func (r *replayResult) Err() error {
+	return nil
+}
+
+func (r *replayResult) Record() *neo4j.Record {
+	return r.current
+}
+
+func (r *replayResult) Collect(context.Context) ([]*neo4j.Record, error) {
+	records := r.records[r.next:]
+	r.next = len(r.records)
+	r.current = nil
+	return records, nil
+}
+
+func (r *replayResult) Single(ctx context.Context) (*neo4j.Record, error) {
+	remaining, _ := r.Collect(ctx)
+	if len(remaining) != 1 {
+		return nil, fmt.Errorf("expected a single record, found %d", len(remaining))
+	}
+	return remaining[0], nil
+}
+
+func (r *replayResult) Consume(ctx context.Context) (neo4j.ResultSummary, error) {
+	_, _ = r.Collect(ctx)
+	return r.summary, nil
+}
+
+func (r *replayResult) IsOpen() bool {
+	return r.next < len(r.records)
+}
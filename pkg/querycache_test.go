@@ -0,0 +1,115 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestQueryCache(t *testing.T) {
+	suite.Run(t, new(QueryCacheTestSuite))
+}
+
+type QueryCacheTestSuite struct {
+	suite.Suite
+	ctx    context.Context
+	cache  *MemoryCache
+	driver *Driver
+}
+
+func (s *QueryCacheTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cache = NewBoundedMemoryCache(2)
+	settings := connectionSettings
+	settings.QueryCache = s.cache
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	s.driver = driver
+}
+
+func (s *QueryCacheTestSuite) TearDownTest() {
+	s.driver.Close(s.ctx)
+}
+
+func (s *QueryCacheTestSuite) TestCacheKeyNormalizesWhitespaceAndHashesParams() {
+	s.Equal(CacheKey("MATCH (n)\n\tRETURN n", map[string]interface{}{"a": 1, "b": 2}), CacheKey("  MATCH (n) RETURN n ", map[string]interface{}{"b": 2, "a": 1}))
+	s.NotEqual(CacheKey("MATCH (n) RETURN n", map[string]interface{}{"a": 1}), CacheKey("MATCH (n) RETURN n", map[string]interface{}{"a": 2}))
+}
+
+func (s *QueryCacheTestSuite) TestServesCachedRecordsWithoutReachingTheServer() {
+	params := map[string]interface{}{"name": "alice"}
+	s.cache.Set(CacheKey("MATCH (user:User {name: $name}) RETURN user", params), cachedRecords, time.Minute)
+	var users []*neo4j.Record
+
+	err := s.driver.ExecuteQuery(s.ctx, "MATCH (user:User {name: $name}) RETURN user", params, func(result neo4j.ResultWithContext) error {
+		var err error
+		users, err = result.Collect(s.ctx)
+		return err
+	}, WithCache(time.Minute), WithAccessMode(neo4j.AccessModeRead))
+
+	s.Require().NoError(err)
+	s.Equal(cachedRecords, users)
+}
+
+func (s *QueryCacheTestSuite) TestKeepsTheResultsOfTheImpersonatedUsersApart() {
+	cluster := &fakeCluster{}
+	defer UseDriverFactory(cluster.newDriver)()
+	settings := connectionSettings
+	settings.QueryCache = NewMemoryCache()
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	for _, user := range []string{"alice", "bob", "alice", ""} {
+		s.Require().NoError(executeSimpleQuery(s.ctx, driver, WithCache(time.Minute), WithAccessMode(neo4j.AccessModeRead), WithImpersonatedUser(user)))
+	}
+
+	s.Require().Len(cluster.sessionConfigs, 3)
+	s.Equal("alice", cluster.sessionConfigs[0].ImpersonatedUser)
+	s.Equal("bob", cluster.sessionConfigs[1].ImpersonatedUser)
+	s.Equal("", cluster.sessionConfigs[2].ImpersonatedUser)
+}
+
+func (s *QueryCacheTestSuite) TestNeverSkipsWrites() {
+	cluster := &fakeCluster{}
+	defer UseDriverFactory(cluster.newDriver)()
+	settings := connectionSettings
+	settings.QueryCache = NewMemoryCache()
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	for i := 0; i < 2; i++ {
+		s.Require().NoError(executeSimpleQuery(s.ctx, driver, WithCache(time.Minute)))
+	}
+
+	s.Equal(2, cluster.sessions(), "the writes reach the server whatever WithCache")
+}
+
+func (s *QueryCacheTestSuite) TestInvalidatesQueries() {
+	params := map[string]interface{}{"name": "alice"}
+	s.cache.Set(CacheKey("MATCH (user:User {name: $name}) RETURN user", params), cachedRecords, time.Minute)
+	s.cache.Set(CacheKey("MATCH (user:User) RETURN user", nil), cachedRecords, time.Minute)
+
+	s.driver.InvalidateQuery("MATCH (user:User {name: $name}) RETURN user", params)
+	s.Equal(1, s.cache.Len())
+	s.driver.InvalidateCache()
+	s.Equal(0, s.cache.Len())
+}
+
+func (s *QueryCacheTestSuite) TestEvictsLeastRecentlyUsedEntries() {
+	s.cache.Set("a", cachedRecords, time.Minute)
+	s.cache.Set("b", cachedRecords, time.Minute)
+	s.cache.Get("a")
+
+	s.cache.Set("c", cachedRecords, time.Minute)
+
+	_, a := s.cache.Get("a")
+	_, b := s.cache.Get("b")
+	s.True(a)
+	s.False(b)
+	s.Equal(2, s.cache.Len())
+}
@@ -0,0 +1,161 @@
+package driver
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"io"
+	"strconv"
+	"time"
+)
+
+// RecordEncoder writes the records of a result as they are read, see ExportQuery
+type RecordEncoder interface {
+	// Begin is called with the keys of the result before its first record
+	Begin(keys []string) error
+	Encode(record *neo4j.Record) error
+	// End completes and flushes the output once all the records are encoded
+	End() error
+}
+
+// ExportQuery streams the records of the query to encoder straight from the result cursor,
+// so that large exports are never held in memory, e.g. with NewCSVEncoder, NewJSONEncoder or NewNDJSONEncoder.
+// the query is not retried once records were encoded
+func (d *Driver) ExportQuery(ctx context.Context, query string, params map[string]interface{}, encoder RecordEncoder, opts ...QueryOption) error {
+	return d.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) error {
+		keys, err := result.Keys()
+		if err != nil {
+			return err
+		}
+		return encodeRecords(ctx, result, keys, encoder)
+	}, append([]QueryOption{WithAccessMode(neo4j.AccessModeRead)}, opts...)...)
+}
+
+func encodeRecords(ctx context.Context, result RecordIterator, keys []string, encoder RecordEncoder) error {
+	if err := encoder.Begin(keys); err != nil {
+		return err
+	}
+	var record *neo4j.Record
+	for result.NextRecord(ctx, &record) {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	if err := result.Err(); err != nil {
+		return err
+	}
+	return encoder.End()
+}
+
+// jsonEncoder writes the records as a JSON array of objects, or as newline delimited objects
+type jsonEncoder struct {
+	writer    *bufio.Writer
+	encoder   *json.Encoder
+	opts      []MapOption
+	delimited bool
+	written   bool
+}
+
+// NewJSONEncoder encodes the records as a JSON array of objects converted with RecordToMap.
+// temporal values are always encoded as strings, see WithTemporalAsString.
+func NewJSONEncoder(w io.Writer, opts ...MapOption) RecordEncoder {
+	buffered := bufio.NewWriter(w)
+	return &jsonEncoder{writer: buffered, encoder: json.NewEncoder(buffered), opts: jsonMapOptions(opts)}
+}
+
+// NewNDJSONEncoder encodes the records as newline delimited JSON objects, one per line, see NewJSONEncoder
+func NewNDJSONEncoder(w io.Writer, opts ...MapOption) RecordEncoder {
+	buffered := bufio.NewWriter(w)
+	return &jsonEncoder{writer: buffered, encoder: json.NewEncoder(buffered), opts: jsonMapOptions(opts), delimited: true}
+}
+
+func (e *jsonEncoder) Begin([]string) error {
+	if e.delimited {
+		return nil
+	}
+	_, err := e.writer.WriteString("[")
+	return err
+}
+
+func (e *jsonEncoder) Encode(record *neo4j.Record) error {
+	if e.written && !e.delimited {
+		if _, err := e.writer.WriteString(","); err != nil {
+			return err
+		}
+	}
+	e.written = true
+	// Encode terminates every value with a newline, which is valid JSON whitespace
+	return e.encoder.Encode(RecordToMap(record, e.opts...))
+}
+
+func (e *jsonEncoder) End() error {
+	if !e.delimited {
+		if _, err := e.writer.WriteString("]"); err != nil {
+			return err
+		}
+	}
+	return e.writer.Flush()
+}
+
+// csvEncoder writes the records as CSV rows under a header made of the keys of the result
+type csvEncoder struct {
+	writer *csv.Writer
+	opts   []MapOption
+	keys   []string
+	row    []string
+}
+
+// NewCSVEncoder encodes the records as CSV rows, with a header row listing the keys of the result.
+// scalars are formatted as is, temporal values as strings (see WithTemporalAsString), nulls as empty fields,
+// and the other values (lists, maps, nodes...) as JSON documents
+func NewCSVEncoder(w io.Writer, opts ...MapOption) RecordEncoder {
+	return &csvEncoder{writer: csv.NewWriter(w), opts: jsonMapOptions(opts)}
+}
+
+func (e *csvEncoder) Begin(keys []string) error {
+	e.keys = keys
+	e.row = make([]string, len(keys))
+	return e.writer.Write(keys)
+}
+
+func (e *csvEncoder) Encode(record *neo4j.Record) error {
+	for i, key := range e.keys {
+		value, _ := record.Get(key)
+		field, err := csvField(ToPlainValue(value, e.opts...))
+		if err != nil {
+			return err
+		}
+		e.row[i] = field
+	}
+	return e.writer.Write(e.row)
+}
+
+func (e *csvEncoder) End() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func csvField(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64), nil
+	case time.Time:
+		return value.Format(time.RFC3339Nano), nil
+	case fmt.Stringer:
+		return value.String(), nil
+	default:
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	}
+}
@@ -0,0 +1,101 @@
+package driver_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestQueryExport(t *testing.T) {
+	suite.Run(t, new(QueryExportTestSuite))
+}
+
+type QueryExportTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	restore func()
+}
+
+func (s *QueryExportTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.restore = UseDriverFactory((&fakeCluster{}).newDriver)
+}
+
+func (s *QueryExportTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *QueryExportTestSuite) TestStreamsTheResultToTheEncoder() {
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	var exported bytes.Buffer
+
+	err = driver.ExportQuery(s.ctx, "RETURN true AS ok", nil, NewCSVEncoder(&exported))
+
+	s.Require().NoError(err)
+	s.Equal("ok\ntrue\n", exported.String())
+}
+
+func (s *QueryExportTestSuite) TestEncodesCSVRows() {
+	var exported bytes.Buffer
+	encoder := NewCSVEncoder(&exported)
+	keys := []string{"name", "age", "born", "tags", "manager"}
+
+	s.Require().NoError(encoder.Begin(keys))
+	s.Require().NoError(encoder.Encode(&neo4j.Record{Keys: keys, Values: []any{
+		"Doe, Jane", int64(42), neo4j.DateOf(time.Date(1981, 3, 4, 0, 0, 0, 0, time.UTC)), []any{"a", "b"}, nil,
+	}}))
+	s.Require().NoError(encoder.End())
+
+	s.Equal("name,age,born,tags,manager\n\"Doe, Jane\",42,1981-03-04,\"[\"\"a\"\",\"\"b\"\"]\",\n", exported.String())
+}
+
+func (s *QueryExportTestSuite) TestEncodesJSONArrays() {
+	var empty, full bytes.Buffer
+	keys := []string{"id"}
+
+	s.Require().NoError(encodeAll(NewJSONEncoder(&empty), keys))
+	s.Require().NoError(encodeAll(NewJSONEncoder(&full), keys, []any{int64(1)}, []any{int64(2)}))
+
+	s.JSONEq(`[]`, empty.String())
+	s.JSONEq(`[{"id": 1}, {"id": 2}]`, full.String())
+}
+
+func (s *QueryExportTestSuite) TestEncodesNewlineDelimitedJSON() {
+	var exported bytes.Buffer
+
+	s.Require().NoError(encodeAll(NewNDJSONEncoder(&exported), []string{"id"}, []any{int64(1)}, []any{int64(2)}))
+
+	s.Equal("{\"id\":1}\n{\"id\":2}\n", exported.String())
+}
+
+func (s *QueryExportTestSuite) TestReportsEncodingFailures() {
+	encoder := NewCSVEncoder(failingWriter{})
+
+	s.Require().NoError(encoder.Begin([]string{"id"}))
+	s.EqualError(encoder.End(), "disk full")
+}
+
+func encodeAll(encoder RecordEncoder, keys []string, rows ...[]any) error {
+	if err := encoder.Begin(keys); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := encoder.Encode(&neo4j.Record{Keys: keys, Values: row}); err != nil {
+			return err
+		}
+	}
+	return encoder.End()
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
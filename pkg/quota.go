@@ -0,0 +1,213 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultQuotaIdleTimeout is the default QuotaConfig.IdleTimeout
+const defaultQuotaIdleTimeout = 10 * time.Minute
+
+// ErrQuotaExceeded matches every *QuotaExceededError with errors.Is
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaLimit tells which limit of a quota was exceeded
+type QuotaLimit string
+
+const (
+	QuotaLimitRate        QuotaLimit = "rate"
+	QuotaLimitConcurrency QuotaLimit = "concurrency"
+)
+
+// QuotaExceededError is returned by ExecuteQuery when the caller identity exceeded its quota, the query is not sent
+type QuotaExceededError struct {
+	Identity string
+	Limit    QuotaLimit
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s %s quota exceeded for identity %q", ErrQuotaExceeded.Error(), e.Limit, e.Identity)
+}
+
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// Quota limits the queries of a single caller identity. zero values mean unlimited
+type Quota struct {
+	// QueriesPerSecond is the sustained query rate
+	QueriesPerSecond float64
+	// Burst is the number of queries allowed at once above the sustained rate, defaults to 1
+	Burst int
+	// MaxConcurrent is the number of queries executing at the same time
+	MaxConcurrent int
+}
+
+// IdentityExtractorFn returns the identity of the caller of a query
+type IdentityExtractorFn func(ctx context.Context) string
+
+// QuotaConfig configures per-identity quotas, so a batch job sharing the Driver can't starve the user-facing API
+type QuotaConfig struct {
+	// Identity extracts the caller identity, defaults to IdentityFromContext
+	Identity IdentityExtractorFn
+	// Default applies to identities without a dedicated quota
+	Default Quota
+	// PerIdentity holds dedicated quotas
+	PerIdentity map[string]Quota
+	// IdleTimeout forgets the identities without queries for that long, once their rate is fully replenished,
+	// so that the limiter does not grow with every identity it ever saw. their usage is forgotten too. defaults to 10 minutes
+	IdleTimeout time.Duration
+}
+
+// QuotaUsage reports the activity of a single identity
+type QuotaUsage struct {
+	Admitted, RejectedRate, RejectedConcurrency int
+	InFlight                                    int
+}
+
+type identityContextKey struct{}
+
+// WithIdentity returns a context carrying the caller identity read by IdentityFromContext
+func WithIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
+
+// IdentityFromContext returns the identity set with WithIdentity, or an empty string
+func IdentityFromContext(ctx context.Context) string {
+	identity, _ := ctx.Value(identityContextKey{}).(string)
+	return identity
+}
+
+// QuotaLimiter enforces a QuotaConfig
+type QuotaLimiter struct {
+	config    QuotaConfig
+	now       func() time.Time
+	mutex     sync.Mutex
+	buckets   map[string]*quotaBucket
+	lastSweep time.Time
+}
+
+type quotaBucket struct {
+	quota      Quota
+	tokens     float64
+	lastRefill time.Time
+	lastUsed   time.Time
+	usage      QuotaUsage
+}
+
+// NewQuotaLimiter creates a limiter enforcing config
+func NewQuotaLimiter(config QuotaConfig) *QuotaLimiter {
+	if config.Identity == nil {
+		config.Identity = IdentityFromContext
+	}
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = defaultQuotaIdleTimeout
+	}
+	return &QuotaLimiter{config: config, now: time.Now, buckets: map[string]*quotaBucket{}, lastSweep: time.Now()}
+}
+
+// Acquire admits a query of the identity carried by ctx, or returns a *QuotaExceededError.
+// release must be called once the admitted query is done.
+func (l *QuotaLimiter) Acquire(ctx context.Context) (release func(), err error) {
+	identity := l.config.Identity(ctx)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := l.now()
+	l.sweep(now)
+	bucket := l.bucket(identity, now)
+	bucket.lastUsed = now
+	quota := bucket.quota
+
+	if quota.MaxConcurrent > 0 && bucket.usage.InFlight >= quota.MaxConcurrent {
+		bucket.usage.RejectedConcurrency++
+		return nil, &QuotaExceededError{Identity: identity, Limit: QuotaLimitConcurrency}
+	}
+	if quota.QueriesPerSecond > 0 {
+		bucket.tokens += now.Sub(bucket.lastRefill).Seconds() * quota.QueriesPerSecond
+		if burst := float64(quota.burst()); bucket.tokens > burst {
+			bucket.tokens = burst
+		}
+		bucket.lastRefill = now
+		if bucket.tokens < 1 {
+			bucket.usage.RejectedRate++
+			return nil, &QuotaExceededError{Identity: identity, Limit: QuotaLimitRate}
+		}
+		bucket.tokens--
+	}
+	bucket.usage.Admitted++
+	bucket.usage.InFlight++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mutex.Lock()
+			defer l.mutex.Unlock()
+			bucket.usage.InFlight--
+			bucket.lastUsed = l.now()
+		})
+	}, nil
+}
+
+// Usage returns a snapshot of the activity of every identity seen so far, but the ones forgotten after QuotaConfig.IdleTimeout
+func (l *QuotaLimiter) Usage() map[string]QuotaUsage {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	usage := make(map[string]QuotaUsage, len(l.buckets))
+	for identity, bucket := range l.buckets {
+		usage[identity] = bucket.usage
+	}
+	return usage
+}
+
+// bucket must be called with the mutex held
+func (l *QuotaLimiter) bucket(identity string, now time.Time) *quotaBucket {
+	bucket, found := l.buckets[identity]
+	if !found {
+		quota, dedicated := l.config.PerIdentity[identity]
+		if !dedicated {
+			quota = l.config.Default
+		}
+		bucket = &quotaBucket{quota: quota, tokens: float64(quota.burst()), lastRefill: now}
+		l.buckets[identity] = bucket
+	}
+	return bucket
+}
+
+// sweep forgets the idle identities, at most once per QuotaConfig.IdleTimeout. it must be called with the mutex held
+func (l *QuotaLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.config.IdleTimeout {
+		return
+	}
+	l.lastSweep = now
+	for identity, bucket := range l.buckets {
+		if bucket.idle(now, l.config.IdleTimeout) {
+			delete(l.buckets, identity)
+		}
+	}
+}
+
+// idle reports whether the bucket can be forgotten: a new one would admit the same queries
+func (b *quotaBucket) idle(now time.Time, timeout time.Duration) bool {
+	if b.usage.InFlight > 0 || now.Sub(b.lastUsed) < timeout {
+		return false
+	}
+	rate := b.quota.QueriesPerSecond
+	return rate <= 0 || b.tokens+now.Sub(b.lastRefill).Seconds()*rate >= float64(b.quota.burst())
+}
+
+func (q Quota) burst() int {
+	if q.Burst <= 0 {
+		return 1
+	}
+	return q.Burst
+}
+
+// QuotaUsage returns the per-identity quota activity, nil when Settings.Quotas is not set
+func (d *Driver) QuotaUsage() map[string]QuotaUsage {
+	if d.quotas == nil {
+		return nil
+	}
+	return d.quotas.Usage()
+}
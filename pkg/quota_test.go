@@ -0,0 +1,108 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestQuotas(t *testing.T) {
+	suite.Run(t, new(QuotaTestSuite))
+}
+
+type QuotaTestSuite struct {
+	suite.Suite
+	batchCtx, apiCtx context.Context
+}
+
+func (s *QuotaTestSuite) SetupTest() {
+	s.batchCtx = WithIdentity(context.Background(), "batch")
+	s.apiCtx = WithIdentity(context.Background(), "api")
+}
+
+func (s *QuotaTestSuite) TestRejectsConcurrentQueriesBeyondLimit() {
+	limiter := NewQuotaLimiter(QuotaConfig{PerIdentity: map[string]Quota{"batch": {MaxConcurrent: 1}}})
+
+	release, err := limiter.Acquire(s.batchCtx)
+	s.Require().NoError(err)
+	_, err = limiter.Acquire(s.batchCtx)
+	_, apiErr := limiter.Acquire(s.apiCtx)
+
+	s.Require().ErrorIs(err, ErrQuotaExceeded)
+	var quotaErr *QuotaExceededError
+	s.Require().True(errors.As(err, &quotaErr))
+	s.Equal(QuotaExceededError{Identity: "batch", Limit: QuotaLimitConcurrency}, *quotaErr)
+	s.NoError(apiErr)
+
+	release()
+	release()
+	_, err = limiter.Acquire(s.batchCtx)
+	s.NoError(err)
+}
+
+func (s *QuotaTestSuite) TestRejectsQueriesBeyondRate() {
+	limiter := NewQuotaLimiter(QuotaConfig{Default: Quota{QueriesPerSecond: 0.001, Burst: 2}})
+
+	_, err1 := limiter.Acquire(s.batchCtx)
+	_, err2 := limiter.Acquire(s.batchCtx)
+	_, err3 := limiter.Acquire(s.batchCtx)
+
+	s.NoError(err1)
+	s.NoError(err2)
+	var quotaErr *QuotaExceededError
+	s.Require().True(errors.As(err3, &quotaErr))
+	s.Equal(QuotaLimitRate, quotaErr.Limit)
+}
+
+func (s *QuotaTestSuite) TestAdmitsTheBurstOfNewIdentities() {
+	limiter := NewQuotaLimiter(QuotaConfig{Default: Quota{QueriesPerSecond: 0.001, Burst: 1}})
+	now := time.Now()
+	// the clock ticks at every reading
+	limiter.UseClock(func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	})
+
+	_, err := limiter.Acquire(s.batchCtx)
+
+	s.NoError(err)
+}
+
+func (s *QuotaTestSuite) TestReportsUsagePerIdentity() {
+	limiter := NewQuotaLimiter(QuotaConfig{Default: Quota{MaxConcurrent: 1}})
+
+	_, _ = limiter.Acquire(s.batchCtx)
+	_, _ = limiter.Acquire(s.batchCtx)
+	release, _ := limiter.Acquire(s.apiCtx)
+	release()
+
+	s.Equal(map[string]QuotaUsage{
+		"batch": {Admitted: 1, RejectedConcurrency: 1, InFlight: 1},
+		"api":   {Admitted: 1},
+	}, limiter.Usage())
+}
+
+func (s *QuotaTestSuite) TestForgetsIdleIdentities() {
+	now := time.Now()
+	limiter := NewQuotaLimiter(QuotaConfig{Default: Quota{QueriesPerSecond: 1, Burst: 1}, IdleTimeout: time.Minute})
+	limiter.UseClock(func() time.Time { return now })
+	for i := 0; i < 100; i++ {
+		release, err := limiter.Acquire(WithIdentity(s.apiCtx, fmt.Sprintf("user-%d", i)))
+		s.Require().NoError(err)
+		release()
+	}
+	running, err := limiter.Acquire(s.batchCtx)
+	s.Require().NoError(err)
+
+	now = now.Add(time.Minute)
+	_, err = limiter.Acquire(s.apiCtx)
+
+	s.Require().NoError(err)
+	s.Len(limiter.Usage(), 2, "only the identities with queries in flight are kept")
+	s.Contains(limiter.Usage(), "batch")
+	running()
+}
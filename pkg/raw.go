@@ -0,0 +1,30 @@
+package driver
+
+import (
+	"context"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// RawDriverFn uses the underlying neo4j driver, see Driver.Raw
+type RawDriverFn func(driver neo4j.DriverWithContext) error
+
+// Raw hands the underlying neo4j driver to fn, for the features this wrapper does not expose,
+// e.g. neo4j.ExecuteQuery with a bookmark manager, without maintaining a second connection pool.
+//
+// this is an advanced API: the wrapper's retries, reconnects, quotas, caching and instrumentation do not apply.
+// the driver must not be used once fn returns, as a reconnect replaces and closes it, and it must not be closed by fn.
+// fn holds the access lock in read mode, so Close waits for it, and Raw fails with ErrDriverClosed once the driver is closed.
+// every call is recorded by Settings.Audit, see AuditRaw
+func (d *Driver) Raw(fn RawDriverFn) (err error) {
+	if err := d.lifecycle.enter(); err != nil {
+		return err
+	}
+	defer d.lifecycle.exit()
+	options := newQueryOptions(nil)
+	defer func() {
+		d.audit(context.Background(), AuditRaw, "", nil, options, err)
+	}()
+	d.accessLock.RLock()
+	defer d.accessLock.RUnlock()
+	return fn(d.currentGeneration().driver)
+}
@@ -0,0 +1,62 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestRaw(t *testing.T) {
+	suite.Run(t, new(RawTestSuite))
+}
+
+type RawTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	restore func()
+}
+
+func (s *RawTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{unreachableDrivers: 1}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+}
+
+func (s *RawTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *RawTestSuite) TestHandsTheCurrentUnderlyingDriver() {
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver))
+
+	var raw neo4j.DriverWithContext
+	s.Require().NoError(driver.Raw(func(underlying neo4j.DriverWithContext) error {
+		raw = underlying
+		return underlying.VerifyConnectivity(s.ctx)
+	}))
+
+	s.Equal(2, s.cluster.drivers())
+	s.Require().NotNil(raw)
+	s.NoError(raw.VerifyConnectivity(s.ctx), "the driver handed after the reconnect is the reachable one")
+}
+
+func (s *RawTestSuite) TestFailsOnceClosed() {
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	driver.Close(s.ctx)
+
+	called := false
+	err = driver.Raw(func(neo4j.DriverWithContext) error {
+		called = true
+		return nil
+	})
+
+	s.ErrorIs(err, ErrDriverClosed)
+	s.False(called)
+}
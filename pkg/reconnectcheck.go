@@ -0,0 +1,39 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ErrReconnectVerificationFailed is returned when the server reached after a reconnect fails Settings.ReconnectVerification
+var ErrReconnectVerificationFailed = errors.New("reconnect verification failed")
+
+// ReconnectVerification checks the server reached after every reconnect before it serves any query, so that the driver
+// never silently switches to the wrong or a stale environment, e.g. when DNS points at an instance restored from a backup.
+// typically, Query reads a sentinel node and Verify compares its version with the expected one.
+type ReconnectVerification struct {
+	Query  string
+	Params map[string]interface{}
+	// Verify inspects the results of Query, and rejects the server by returning an error
+	Verify ResultsHookFn
+}
+
+// verifyReconnect runs Settings.ReconnectVerification with the new underlying driver
+func (d *Driver) verifyReconnect(ctx context.Context, driver neo4j.DriverWithContext) error {
+	verification := d.settings.ReconnectVerification
+	if verification == nil {
+		return nil
+	}
+	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+	result, err := session.Run(ctx, verification.Query, verification.Params)
+	if err == nil {
+		err = executeHook(verification.Verify, result)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrReconnectVerificationFailed, err)
+	}
+	return nil
+}
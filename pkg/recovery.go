@@ -0,0 +1,61 @@
+package driver
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// recoveryMutex serializes the recoveries like a sync.Mutex,
+// but lets the queries waiting for it give up once their ctx is done instead of piling up behind a slow reconnect
+type recoveryMutex chan struct{}
+
+func newRecoveryMutex() recoveryMutex {
+	return make(recoveryMutex, 1)
+}
+
+func (m recoveryMutex) Lock() {
+	m <- struct{}{}
+}
+
+func (m recoveryMutex) Unlock() {
+	<-m
+}
+
+// LockContext acquires the mutex, unless ctx is done first
+func (m recoveryMutex) LockContext(ctx context.Context) error {
+	select {
+	case m <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RecoveryUsage is a snapshot of the queries waiting for connectivity recoveries, see Driver.RecoveryUsage
+type RecoveryUsage struct {
+	// Waiting is the number of queries waiting for a recovery in progress
+	Waiting int64
+	// Cancelled is the number of queries whose ctx was done while they were waiting for a recovery or before retrying after it
+	Cancelled int64
+}
+
+type recoveryStats struct {
+	waiting, cancelled atomic.Int64
+}
+
+// RecoveryUsage returns the activity of the queries going through connectivity recoveries,
+// e.g. to check that the callers shed upstream during a reconnect storm actually give up their resources here
+func (d *Driver) RecoveryUsage() RecoveryUsage {
+	return RecoveryUsage{Waiting: d.recovery.waiting.Load(), Cancelled: d.recovery.cancelled.Load()}
+}
+
+// lockRecovery acquires recoveryLock, unless ctx is done first
+func (d *Driver) lockRecovery(ctx context.Context) error {
+	d.recovery.waiting.Add(1)
+	defer d.recovery.waiting.Add(-1)
+	if err := d.recoveryLock.LockContext(ctx); err != nil {
+		d.recovery.cancelled.Add(1)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestRecovery(t *testing.T) {
+	suite.Run(t, new(RecoveryTestSuite))
+}
+
+type RecoveryTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	restore func()
+}
+
+func (s *RecoveryTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+}
+
+func (s *RecoveryTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *RecoveryTestSuite) TestGivesUpWaitingForARecoveryOnceCancelled() {
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	s.cluster.disconnect()
+	release := driver.HoldRecoveryLock()
+	defer release()
+
+	ctx, cancel := context.WithTimeout(s.ctx, 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = executeSimpleQuery(ctx, driver)
+
+	s.ErrorIs(err, context.DeadlineExceeded)
+	s.Less(time.Since(start), time.Second)
+	s.Equal(RecoveryUsage{Waiting: 0, Cancelled: 1}, driver.RecoveryUsage())
+	s.Equal(1, s.cluster.drivers(), "the cancelled query must not reconnect")
+}
+
+func (s *RecoveryTestSuite) TestGivesUpRetryingOnceCancelled() {
+	s.cluster.unreachableDrivers = 100
+	settings := connectionSettings
+	settings.MaxAttempts = 100
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	ctx, cancel := context.WithTimeout(s.ctx, 100*time.Millisecond)
+	defer cancel()
+	err = executeSimpleQuery(ctx, driver)
+
+	s.ErrorIs(err, context.DeadlineExceeded)
+	s.Equal(int64(1), driver.RecoveryUsage().Cancelled)
+}
+
+func (s *RecoveryTestSuite) TestDriversDoNotBlockEachOther() {
+	closing, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	other, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer other.Close(s.ctx)
+	running, finish := make(chan struct{}), make(chan struct{})
+	go func() {
+		_ = closing.ExecuteQuery(s.ctx, "RETURN 1", nil, func(neo4j.ResultWithContext) error {
+			close(running)
+			<-finish
+			return nil
+		})
+	}()
+	<-running
+	closed := make(chan struct{})
+	go func() {
+		closing.Close(s.ctx)
+		close(closed)
+	}()
+	release := closing.HoldRecoveryLock()
+	defer release()
+	s.cluster.disconnect()
+
+	ctx, cancel := context.WithTimeout(s.ctx, time.Second)
+	defer cancel()
+	s.NoError(executeSimpleQuery(ctx, other), "the queries and the reconnects of the other driver go on")
+	s.Equal(uint64(1), other.Generation())
+	close(finish)
+	<-closed
+}
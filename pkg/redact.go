@@ -0,0 +1,50 @@
+package driver
+
+// RedactedValue replaces parameter values hidden by RedactAllParams
+const RedactedValue = "<redacted>"
+
+// ParamsRedactorFn returns a copy of the query parameters safe to be reported in logs and callbacks
+type ParamsRedactorFn func(params map[string]interface{}) map[string]interface{}
+
+// RedactAllParams keeps the parameter names but hides all their values, it is the default redactor
+func RedactAllParams(params map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(params))
+	for key := range params {
+		redacted[key] = RedactedValue
+	}
+	return redacted
+}
+
+// KeepAllParams reports the parameters as is, only suitable when they never hold sensitive data
+func KeepAllParams(params map[string]interface{}) map[string]interface{} {
+	kept := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		kept[key] = value
+	}
+	return kept
+}
+
+// RedactParams hides the values of the given parameter names and keeps the others
+func RedactParams(names ...string) ParamsRedactorFn {
+	hidden := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		hidden[name] = struct{}{}
+	}
+	return func(params map[string]interface{}) map[string]interface{} {
+		redacted := make(map[string]interface{}, len(params))
+		for key, value := range params {
+			if _, found := hidden[key]; found {
+				value = RedactedValue
+			}
+			redacted[key] = value
+		}
+		return redacted
+	}
+}
+
+func (d *Driver) redact(params map[string]interface{}) map[string]interface{} {
+	if d.settings.ParamsRedactor == nil {
+		return RedactAllParams(params)
+	}
+	return d.settings.ParamsRedactor(params)
+}
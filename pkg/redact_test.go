@@ -0,0 +1,32 @@
+package driver_test
+
+import (
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestRedaction(t *testing.T) {
+	suite.Run(t, new(RedactionTestSuite))
+}
+
+type RedactionTestSuite struct {
+	suite.Suite
+}
+
+var sensitiveParams = map[string]interface{}{"email": "jane@example.com", "id": 42}
+
+func (s *RedactionTestSuite) TestRedactAllParamsKeepsNamesOnly() {
+	s.Equal(map[string]interface{}{"email": RedactedValue, "id": RedactedValue}, RedactAllParams(sensitiveParams))
+}
+
+func (s *RedactionTestSuite) TestRedactParamsHidesSelectedNames() {
+	s.Equal(map[string]interface{}{"email": RedactedValue, "id": 42}, RedactParams("email")(sensitiveParams))
+}
+
+func (s *RedactionTestSuite) TestKeepAllParamsCopies() {
+	kept := KeepAllParams(sensitiveParams)
+	kept["id"] = 0
+
+	s.Equal(42, sensitiveParams["id"])
+}
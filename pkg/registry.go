@@ -0,0 +1,258 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknownQuery is returned when executing a name missing from the registry
+var ErrUnknownQuery = errors.New("unknown query")
+
+// QueryFileExtension is the extension of the files loaded by QueryRegistry.LoadFS
+const QueryFileExtension = ".cypher"
+
+// policyRetryDelay is the delay before the first retry of a QueryPolicy, doubled for every following one
+const policyRetryDelay = 50 * time.Millisecond
+
+// policyDirectivePrefix starts the header comment lines of .cypher files setting the policy of the query, e.g.
+//
+//	// @timeout 5s
+//	// @retries 3
+//	// @cache 1m
+//	// @mode read
+const policyDirectivePrefix = "// @"
+
+// QueryRegistry is a catalog of named Cypher statements, executed by name
+type QueryRegistry struct {
+	mutex    sync.RWMutex
+	queries  map[string]string
+	policies map[string]QueryPolicy
+}
+
+// QueryPolicy holds the operational settings of a registered query, applied by Execute before the options of the call site
+type QueryPolicy struct {
+	// Timeout bounds every execution, retries included. unbounded when zero
+	Timeout time.Duration
+	// Retries is how many times failures deemed retryable by neo4j.IsRetryable (e.g. deadlocks) are retried, with exponential backoff
+	Retries int
+	// CacheTTL caches the results of the query, see WithCache
+	CacheTTL time.Duration
+	// AccessMode routes the query, see WithAccessMode
+	AccessMode neo4j.AccessMode
+}
+
+func (p QueryPolicy) options() []QueryOption {
+	opts := []QueryOption{WithAccessMode(p.AccessMode)}
+	if p.CacheTTL > 0 {
+		opts = append(opts, WithCache(p.CacheTTL))
+	}
+	return opts
+}
+
+// InvalidQueriesError lists the registered queries the server refused to plan
+type InvalidQueriesError struct {
+	// Errors maps the name of every invalid query to the server error
+	Errors map[string]error
+}
+
+func (e *InvalidQueriesError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	messages := make([]string, len(names))
+	for i, name := range names {
+		messages[i] = fmt.Sprintf("%s: %v", name, e.Errors[name])
+	}
+	return fmt.Sprintf("%d invalid queries: %s", len(names), strings.Join(messages, "; "))
+}
+
+// NewQueryRegistry creates an empty registry
+func NewQueryRegistry() *QueryRegistry {
+	return &QueryRegistry{queries: map[string]string{}, policies: map[string]QueryPolicy{}}
+}
+
+// Register adds a named statement, names must be unique
+func (r *QueryRegistry) Register(name, cypher string) error {
+	return r.register(name, cypher, QueryPolicy{})
+}
+
+// register adds a named statement along with its policy
+func (r *QueryRegistry) register(name, cypher string, policy QueryPolicy) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, found := r.queries[name]; found {
+		return fmt.Errorf("query %q is already registered", name)
+	}
+	r.queries[name] = cypher
+	r.policies[name] = policy
+	return nil
+}
+
+// MustRegister is like Register but panics on duplicates, for package-level registrations
+func (r *QueryRegistry) MustRegister(name, cypher string) *QueryRegistry {
+	if err := r.Register(name, cypher); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// LoadFS registers every .cypher file of dir, e.g. from an embed.FS. queries are named after their file name without extension.
+// the header comment lines starting with "// @" set the policy of the query, see policyDirectivePrefix.
+func (r *QueryRegistry) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != QueryFileExtension {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(entry.Name(), QueryFileExtension)
+		cypher, policy, err := parsePolicyDirectives(string(content))
+		if err != nil {
+			return fmt.Errorf("query %q: %w", name, err)
+		}
+		if err := r.register(name, cypher, policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns the statement registered under name
+func (r *QueryRegistry) Get(name string) (string, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	cypher, found := r.queries[name]
+	return cypher, found
+}
+
+// Names returns the sorted names of the registered statements
+func (r *QueryRegistry) Names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	names := make([]string, 0, len(r.queries))
+	for name := range r.queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetPolicy sets the policy of the statement registered under name
+func (r *QueryRegistry) SetPolicy(name string, policy QueryPolicy) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, found := r.queries[name]; !found {
+		return fmt.Errorf("%w: %q", ErrUnknownQuery, name)
+	}
+	r.policies[name] = policy
+	return nil
+}
+
+// Policy returns the policy of the statement registered under name, the zero QueryPolicy if it has none
+func (r *QueryRegistry) Policy(name string) QueryPolicy {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.policies[name]
+}
+
+// Execute runs the statement registered under name according to its policy, with WithQueryName set to it
+func (r *QueryRegistry) Execute(ctx context.Context, runner QueryRunner, name string, params map[string]interface{}, onResults ResultsHookFn, opts ...QueryOption) error {
+	cypher, found := r.Get(name)
+	if !found {
+		return fmt.Errorf("%w: %q", ErrUnknownQuery, name)
+	}
+	policy := r.Policy(name)
+	if policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.Timeout)
+		defer cancel()
+	}
+	opts = append(append([]QueryOption{WithQueryName(name)}, policy.options()...), opts...)
+	delay := policyRetryDelay
+	for attempt := 0; ; attempt++ {
+		err := runner.ExecuteQuery(ctx, cypher, params, onResults, opts...)
+		if err == nil || attempt >= policy.Retries || !neo4j.IsRetryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// Validate asks the server to plan every registered statement with EXPLAIN, typically at startup.
+// it returns an *InvalidQueriesError listing the statements that do not parse or plan.
+func (r *QueryRegistry) Validate(ctx context.Context, runner QueryRunner) error {
+	invalid := map[string]error{}
+	for _, name := range r.Names() {
+		cypher, _ := r.Get(name)
+		err := runner.ExecuteQuery(ctx, "EXPLAIN "+cypher, nil, func(result neo4j.ResultWithContext) error {
+			_, err := result.Consume(ctx)
+			return err
+		}, WithQueryName(name))
+		if err != nil {
+			invalid[name] = err
+		}
+	}
+	if len(invalid) > 0 {
+		return &InvalidQueriesError{Errors: invalid}
+	}
+	return nil
+}
+
+// parsePolicyDirectives extracts the policy directives heading a .cypher file, and returns the statement without them
+func parsePolicyDirectives(content string) (string, QueryPolicy, error) {
+	policy := QueryPolicy{}
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	for len(lines) > 0 && strings.HasPrefix(strings.TrimSpace(lines[0]), policyDirectivePrefix) {
+		directive := strings.Fields(strings.TrimPrefix(strings.TrimSpace(lines[0]), policyDirectivePrefix))
+		lines = lines[1:]
+		if len(directive) != 2 {
+			return "", policy, fmt.Errorf("invalid policy directive %q", strings.Join(directive, " "))
+		}
+		var err error
+		switch key, value := directive[0], directive[1]; key {
+		case "timeout":
+			policy.Timeout, err = time.ParseDuration(value)
+		case "retries":
+			policy.Retries, err = strconv.Atoi(value)
+		case "cache":
+			policy.CacheTTL, err = time.ParseDuration(value)
+		case "mode":
+			switch value {
+			case "read":
+				policy.AccessMode = neo4j.AccessModeRead
+			case "write":
+				policy.AccessMode = neo4j.AccessModeWrite
+			default:
+				err = fmt.Errorf("unknown access mode %q", value)
+			}
+		default:
+			err = fmt.Errorf("unknown policy directive %q", key)
+		}
+		if err != nil {
+			return "", policy, err
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), policy, nil
+}
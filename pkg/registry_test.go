@@ -0,0 +1,114 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"runtime"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestQueryRegistry(t *testing.T) {
+	suite.Run(t, new(QueryRegistryTestSuite))
+}
+
+type QueryRegistryTestSuite struct {
+	suite.Suite
+	ctx      context.Context
+	registry *QueryRegistry
+}
+
+func (s *QueryRegistryTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.registry = NewQueryRegistry()
+}
+
+func (s *QueryRegistryTestSuite) TestLoadsCypherFiles() {
+	files := fstest.MapFS{
+		"queries/load-user.cypher":   {Data: []byte("MATCH (u:User {id: $id}) RETURN u\n")},
+		"queries/delete-user.cypher": {Data: []byte("MATCH (u:User {id: $id}) DETACH DELETE u")},
+		"queries/README.md":          {Data: []byte("not a query")},
+	}
+
+	s.Require().NoError(s.registry.LoadFS(files, "queries"))
+
+	s.Equal([]string{"delete-user", "load-user"}, s.registry.Names())
+	cypher, found := s.registry.Get("load-user")
+	s.True(found)
+	s.Equal("MATCH (u:User {id: $id}) RETURN u", cypher)
+}
+
+func (s *QueryRegistryTestSuite) TestRejectsDuplicates() {
+	s.Require().NoError(s.registry.Register("load-user", "RETURN 1"))
+
+	s.Error(s.registry.Register("load-user", "RETURN 2"))
+}
+
+func (s *QueryRegistryTestSuite) TestExecutesByName() {
+	runner := &fakeRunner{}
+	s.registry.MustRegister("load-user", "MATCH (u:User) RETURN u")
+
+	s.Require().NoError(s.registry.Execute(s.ctx, runner, "load-user", nil, func(neo4j.ResultWithContext) error { return nil }))
+	err := s.registry.Execute(s.ctx, runner, "unknown", nil, nil)
+
+	s.Equal([]string{"MATCH (u:User) RETURN u"}, runner.executed())
+	s.ErrorIs(err, ErrUnknownQuery)
+}
+
+func (s *QueryRegistryTestSuite) TestValidatesWithExplain() {
+	runner := &fakeRunner{err: errors.New("syntax error")}
+	s.registry.MustRegister("broken", "MATCH (u:User RETURN u")
+
+	err := s.registry.Validate(s.ctx, runner)
+
+	s.Equal([]string{"EXPLAIN MATCH (u:User RETURN u"}, runner.executed())
+	var invalid *InvalidQueriesError
+	s.Require().True(errors.As(err, &invalid))
+	s.EqualError(invalid.Errors["broken"], "syntax error")
+}
+
+func (s *QueryRegistryTestSuite) TestLoadsPolicyDirectives() {
+	files := fstest.MapFS{
+		"queries/load-user.cypher": {Data: []byte("// @timeout 5s\n// @retries 2\n// @cache 1m\n// @mode read\nMATCH (u:User {id: $id}) RETURN u\n")},
+	}
+	broken := fstest.MapFS{"queries/broken.cypher": {Data: []byte("// @retries many\nRETURN 1")}}
+
+	s.Require().NoError(s.registry.LoadFS(files, "queries"))
+	err := NewQueryRegistry().LoadFS(broken, "queries")
+
+	s.ErrorContains(err, `query "broken"`)
+	cypher, _ := s.registry.Get("load-user")
+	s.Equal("MATCH (u:User {id: $id}) RETURN u", cypher)
+	s.Equal(QueryPolicy{Timeout: 5 * time.Second, Retries: 2, CacheTTL: time.Minute, AccessMode: neo4j.AccessModeRead}, s.registry.Policy("load-user"))
+}
+
+func (s *QueryRegistryTestSuite) TestLoadsPoliciesWhileTheyAreRead() {
+	files := fstest.MapFS{"queries/load-user.cypher": {Data: []byte("// @retries 2\nMATCH (u:User {id: $id}) RETURN u\n")}}
+	loaded := make(chan error)
+
+	go func() {
+		loaded <- s.registry.LoadFS(files, "queries")
+	}()
+	for s.registry.Policy("load-user").Retries == 0 {
+		runtime.Gosched()
+	}
+
+	s.NoError(<-loaded)
+}
+
+func (s *QueryRegistryTestSuite) TestRetriesTransientFailuresPerPolicy() {
+	runner := &fakeRunner{err: &neo4j.Neo4jError{Code: "Neo.TransientError.Transaction.DeadlockDetected"}}
+	s.registry.MustRegister("update-user", "MATCH (u:User) SET u.seen = true")
+	s.registry.MustRegister("delete-user", "MATCH (u:User) DETACH DELETE u")
+	s.Require().NoError(s.registry.SetPolicy("update-user", QueryPolicy{Retries: 2}))
+
+	s.Error(s.registry.Execute(s.ctx, runner, "update-user", nil, nil))
+	s.Error(s.registry.Execute(s.ctx, runner, "delete-user", nil, nil))
+
+	s.Len(runner.executed(), 4)
+	s.ErrorIs(s.registry.SetPolicy("unknown", QueryPolicy{}), ErrUnknownQuery)
+}
@@ -0,0 +1,305 @@
+// Package repository is a minimal object-graph mapper persisting structs as nodes through the resilient driver:
+//
+//	type Company struct {
+//		ID   string `neo4j:"id,key"`
+//		Name string `neo4j:"name"`
+//	}
+//	type Person struct {
+//		ID       string   `neo4j:"id,key"`
+//		Name     string   `neo4j:"name"`
+//		Employer *Company `neo4j:"employer,rel=WORKS_AT"`
+//	}
+//	people, err := repository.New[Person](d)
+//	err = people.Save(ctx, &Person{ID: "1", Name: "alice", Employer: &Company{ID: "acme"}})
+//
+// every entity has one key property, tagged with the key option, that Save merges nodes on. the label is the
+// name of the struct type. fields tagged with rel=TYPE link the entity to the entities they hold, through
+// outgoing relationships, or incoming ones with the in option. linked entities are identified by their key only:
+// their other fields are neither saved nor loaded. properties are bound with driver.BindParams and decoded with driver.DecodeValue.
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"reflect"
+	"strings"
+)
+
+// ErrNoKey is returned for entity types without a field tagged with the key option
+var ErrNoKey = errors.New("entity has no key field")
+
+// Option customizes a Repository
+type Option func(*config)
+
+type config struct {
+	label     string
+	namespace driver.Namespace
+}
+
+// WithLabel overrides the label of the entities, which defaults to the name of their struct type
+func WithLabel(label string) Option {
+	return func(config *config) {
+		config.label = label
+	}
+}
+
+// WithNamespace prefixes the labels and relationship types, typically with the namespace of the driver
+func WithNamespace(namespace driver.Namespace) Option {
+	return func(config *config) {
+		config.namespace = namespace
+	}
+}
+
+// Repository saves, finds and deletes the entities of type T
+type Repository[T any] struct {
+	runner driver.QueryRunner
+	entity *entity
+}
+
+// entity is the mapping of a struct type
+type entity struct {
+	label    string
+	key      string
+	keyIndex []int
+	// fields are the names of the properties, which are bound and decoded with the driver
+	fields []string
+	links  []link
+}
+
+// link is a field holding the entities linked through relationships of one type
+type link struct {
+	index    []int
+	relType  string
+	incoming bool
+	many     bool
+	target   *entity
+}
+
+// New creates the repository of the entities of type T, persisted with runner, typically a *driver.Driver
+func New[T any](runner driver.QueryRunner, opts ...Option) (*Repository[T], error) {
+	config := &config{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	entityType := reflect.TypeOf((*T)(nil)).Elem()
+	mapping, err := newEntity(entityType, config.label, config.namespace, true)
+	if err != nil {
+		return nil, err
+	}
+	return &Repository[T]{runner: runner, entity: mapping}, nil
+}
+
+func newEntity(entityType reflect.Type, label string, namespace driver.Namespace, withLinks bool) (*entity, error) {
+	if entityType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("entities must be structs, got %s", entityType)
+	}
+	if label == "" {
+		label = entityType.Name()
+	}
+	mapping := &entity{label: namespace.Label(label)}
+	for _, field := range reflect.VisibleFields(entityType) {
+		tag := field.Tag.Get(driver.FieldTag)
+		name, options, _ := strings.Cut(tag, ",")
+		if !field.IsExported() || name == "-" || (field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		relType, incoming, isKey := parseOptions(options)
+		switch {
+		case relType != "":
+			if !withLinks {
+				continue
+			}
+			targetType, many := linkedType(field.Type)
+			target, err := newEntity(targetType, "", namespace, false)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			mapping.links = append(mapping.links, link{index: field.Index, relType: namespace.Label(relType), incoming: incoming, many: many, target: target})
+		case isKey:
+			mapping.key, mapping.keyIndex = name, field.Index
+			mapping.fields = append(mapping.fields, name)
+		default:
+			mapping.fields = append(mapping.fields, name)
+		}
+	}
+	if mapping.keyIndex == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoKey, entityType)
+	}
+	return mapping, nil
+}
+
+func parseOptions(options string) (relType string, incoming, isKey bool) {
+	for _, option := range strings.Split(options, ",") {
+		switch {
+		case option == "key":
+			isKey = true
+		case option == "in":
+			incoming = true
+		case strings.HasPrefix(option, "rel="):
+			relType = strings.TrimPrefix(option, "rel=")
+		}
+	}
+	return relType, incoming, isKey
+}
+
+// linkedType returns the struct type of the entities held by a link field: *U, []U or []*U
+func linkedType(fieldType reflect.Type) (reflect.Type, bool) {
+	many := fieldType.Kind() == reflect.Slice
+	if many {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType, many
+}
+
+// SaveQuery generates the query run by Save: the node is merged on its key, its properties are replaced
+// by the ones of the entity, and its relationships of the link types are replaced by the links of the entity
+func (r *Repository[T]) SaveQuery(value *T) (string, map[string]interface{}, error) {
+	bound, err := driver.BindParams(value)
+	if err != nil {
+		return "", nil, err
+	}
+	props := map[string]interface{}{}
+	for _, field := range r.entity.fields {
+		props[field] = bound[field]
+	}
+	source := reflect.ValueOf(value).Elem()
+	params := map[string]interface{}{"key": bound[r.entity.key], "props": props}
+	query := strings.Builder{}
+	query.WriteString(fmt.Sprintf("MERGE (n:%s {%s: $key}) SET n = $props", driver.QuoteIdentifier(r.entity.label), driver.QuoteIdentifier(r.entity.key)))
+	for i, link := range r.entity.links {
+		param := fmt.Sprintf("link%d", i)
+		params[param] = linkedKeys(source.FieldByIndex(link.index), link.target)
+		query.WriteString(fmt.Sprintf(" WITH n OPTIONAL MATCH %s DELETE r%d WITH DISTINCT n FOREACH (key IN $%s | MERGE (m%d:%s {%s: key}) MERGE %s)",
+			link.pattern(fmt.Sprintf("r%d", i), "()"), i, param, i, driver.QuoteIdentifier(link.target.label), driver.QuoteIdentifier(link.target.key),
+			link.pattern("", fmt.Sprintf("(m%d)", i))))
+	}
+	return query.String(), params, nil
+}
+
+// Save creates or updates the node of the entity, and links it to the entities of its link fields
+func (r *Repository[T]) Save(ctx context.Context, value *T, opts ...driver.QueryOption) error {
+	query, params, err := r.SaveQuery(value)
+	if err != nil {
+		return err
+	}
+	return r.runner.ExecuteQuery(ctx, query, params, consume(ctx), r.options("save", opts)...)
+}
+
+// FindByID loads the entity of the given key, along with the keys of its linked entities.
+// it returns driver.ErrNodeNotFound when no node has that key
+func (r *Repository[T]) FindByID(ctx context.Context, key interface{}, opts ...driver.QueryOption) (*T, error) {
+	query := strings.Builder{}
+	query.WriteString(fmt.Sprintf("MATCH (n:%s {%s: $key})", driver.QuoteIdentifier(r.entity.label), driver.QuoteIdentifier(r.entity.key)))
+	returned := []string{"n"}
+	for i, link := range r.entity.links {
+		variable := fmt.Sprintf("l%d", i)
+		query.WriteString(fmt.Sprintf(" OPTIONAL MATCH %s WITH %s, collect(%s) AS %s",
+			link.pattern("", fmt.Sprintf("(%s:%s)", variable, driver.QuoteIdentifier(link.target.label))), strings.Join(returned, ", "), variable, variable))
+		returned = append(returned, variable)
+	}
+	query.WriteString(" RETURN " + strings.Join(returned, ", "))
+
+	var found *T
+	err := r.runner.ExecuteQuery(ctx, query.String(), map[string]interface{}{"key": key}, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		if !result.NextRecord(ctx, &record) {
+			return result.Err()
+		}
+		value := new(T)
+		if err := driver.DecodeValue(record.Values[0], value); err != nil {
+			return err
+		}
+		target := reflect.ValueOf(value).Elem()
+		for i, link := range r.entity.links {
+			if err := link.decode(record.Values[i+1], target.FieldByIndex(link.index)); err != nil {
+				return err
+			}
+		}
+		found = value
+		return nil
+	}, r.options("find", opts)...)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s with %s %v", driver.ErrNodeNotFound, r.entity.label, r.entity.key, key)
+	}
+	return found, nil
+}
+
+// Delete deletes the node of the given key and its relationships, it does nothing when there is none
+func (r *Repository[T]) Delete(ctx context.Context, key interface{}, opts ...driver.QueryOption) error {
+	query := fmt.Sprintf("MATCH (n:%s {%s: $key}) DETACH DELETE n", driver.QuoteIdentifier(r.entity.label), driver.QuoteIdentifier(r.entity.key))
+	return r.runner.ExecuteQuery(ctx, query, map[string]interface{}{"key": key}, consume(ctx), r.options("delete", opts)...)
+}
+
+// options names the queries after the label and the operation, e.g. "Person.save"
+func (r *Repository[T]) options(operation string, opts []driver.QueryOption) []driver.QueryOption {
+	return append([]driver.QueryOption{driver.WithQueryName(r.entity.label + "." + operation)}, opts...)
+}
+
+// pattern returns the relationship pattern of the link from n to other
+func (l link) pattern(variable, other string) string {
+	rel := fmt.Sprintf("[%s:%s]", variable, driver.QuoteIdentifier(l.relType))
+	if l.incoming {
+		return fmt.Sprintf("(n)<-%s-%s", rel, other)
+	}
+	return fmt.Sprintf("(n)-%s->%s", rel, other)
+}
+
+// decode sets the linked entities of field from the collected nodes, only their key being decoded
+func (l link) decode(nodes interface{}, field reflect.Value) error {
+	list, _ := nodes.([]interface{})
+	keys := make([]interface{}, 0, len(list))
+	for _, node := range list {
+		if node, ok := node.(neo4j.Node); ok {
+			keys = append(keys, map[string]interface{}{l.target.key: node.Props[l.target.key]})
+		}
+	}
+	if l.many {
+		return driver.DecodeValue(keys, field.Addr().Interface())
+	}
+	if len(keys) == 0 {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	return driver.DecodeValue(keys[0], field.Addr().Interface())
+}
+
+// linkedKeys returns the keys of the entities held by a link field
+func linkedKeys(field reflect.Value, target *entity) []interface{} {
+	keys := []interface{}{}
+	appendKey := func(linked reflect.Value) {
+		if linked.Kind() == reflect.Pointer {
+			if linked.IsNil() {
+				return
+			}
+			linked = linked.Elem()
+		}
+		keys = append(keys, linked.FieldByIndex(target.keyIndex).Interface())
+	}
+	if field.Kind() == reflect.Slice {
+		for i := 0; i < field.Len(); i++ {
+			appendKey(field.Index(i))
+		}
+	} else {
+		appendKey(field)
+	}
+	return keys
+}
+
+func consume(ctx context.Context) driver.ResultsHookFn {
+	return func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(ctx)
+		return err
+	}
+}
@@ -0,0 +1,141 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg/repository"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestRepository(t *testing.T) {
+	suite.Run(t, new(RepositoryTestSuite))
+}
+
+type RepositoryTestSuite struct {
+	suite.Suite
+	ctx    context.Context
+	runner *fakeRunner
+	people *Repository[Person]
+}
+
+type Company struct {
+	ID   string `neo4j:"id,key"`
+	Name string `neo4j:"name"`
+}
+
+type Person struct {
+	ID       string    `neo4j:"id,key"`
+	Name     string    `neo4j:"name"`
+	Age      int64     `neo4j:"age,omitempty"`
+	Employer *Company  `neo4j:"employer,rel=WORKS_AT"`
+	Friends  []Person  `neo4j:"friends,rel=KNOWS"`
+	Managers []*Person `neo4j:"managers,rel=MANAGES,in"`
+}
+
+func (s *RepositoryTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.runner = &fakeRunner{}
+	people, err := New[Person](s.runner, WithNamespace(driver.Namespace{Prefix: "App"}))
+	s.Require().NoError(err)
+	s.people = people
+}
+
+func (s *RepositoryTestSuite) TestSavesByKeyAndReplacesLinks() {
+	alice := &Person{ID: "1", Name: "alice", Employer: &Company{ID: "acme", Name: "ignored"}, Friends: []Person{{ID: "2"}, {ID: "3"}}}
+
+	s.Require().NoError(s.people.Save(s.ctx, alice))
+
+	s.Equal("MERGE (n:`App_Person` {`id`: $key}) SET n = $props"+
+		" WITH n OPTIONAL MATCH (n)-[r0:`App_WORKS_AT`]->() DELETE r0 WITH DISTINCT n FOREACH (key IN $link0 | MERGE (m0:`App_Company` {`id`: key}) MERGE (n)-[:`App_WORKS_AT`]->(m0))"+
+		" WITH n OPTIONAL MATCH (n)-[r1:`App_KNOWS`]->() DELETE r1 WITH DISTINCT n FOREACH (key IN $link1 | MERGE (m1:`App_Person` {`id`: key}) MERGE (n)-[:`App_KNOWS`]->(m1))"+
+		" WITH n OPTIONAL MATCH (n)<-[r2:`App_MANAGES`]-() DELETE r2 WITH DISTINCT n FOREACH (key IN $link2 | MERGE (m2:`App_Person` {`id`: key}) MERGE (n)<-[:`App_MANAGES`]-(m2))",
+		s.runner.query)
+	s.Equal(map[string]interface{}{
+		"key":   "1",
+		"props": map[string]interface{}{"id": "1", "name": "alice", "age": nil},
+		"link0": []interface{}{"acme"},
+		"link1": []interface{}{"2", "3"},
+		"link2": []interface{}{},
+	}, s.runner.params)
+}
+
+func (s *RepositoryTestSuite) TestFindsByKeyWithLinkedKeys() {
+	s.runner.records = []*neo4j.Record{{
+		Keys: []string{"n", "l0", "l1", "l2"},
+		Values: []any{
+			neo4j.Node{Props: map[string]any{"id": "1", "name": "alice", "age": int64(42)}},
+			[]any{neo4j.Node{Props: map[string]any{"id": "acme", "name": "Acme"}}},
+			[]any{neo4j.Node{Props: map[string]any{"id": "2", "name": "bob"}}},
+			[]any{},
+		},
+	}}
+
+	alice, err := s.people.FindByID(s.ctx, "1")
+
+	s.Require().NoError(err)
+	s.Equal(&Person{ID: "1", Name: "alice", Age: 42, Employer: &Company{ID: "acme"}, Friends: []Person{{ID: "2"}}, Managers: []*Person{}}, alice)
+	s.Equal("MATCH (n:`App_Person` {`id`: $key})"+
+		" OPTIONAL MATCH (n)-[:`App_WORKS_AT`]->(l0:`App_Company`) WITH n, collect(l0) AS l0"+
+		" OPTIONAL MATCH (n)-[:`App_KNOWS`]->(l1:`App_Person`) WITH n, l0, collect(l1) AS l1"+
+		" OPTIONAL MATCH (n)<-[:`App_MANAGES`]-(l2:`App_Person`) WITH n, l0, l1, collect(l2) AS l2"+
+		" RETURN n, l0, l1, l2", s.runner.query)
+}
+
+func (s *RepositoryTestSuite) TestReportsMissingNodes() {
+	_, err := s.people.FindByID(s.ctx, "404")
+
+	s.ErrorIs(err, driver.ErrNodeNotFound)
+}
+
+func (s *RepositoryTestSuite) TestDeletesByKey() {
+	s.Require().NoError(s.people.Delete(s.ctx, "1"))
+
+	s.Equal("MATCH (n:`App_Person` {`id`: $key}) DETACH DELETE n", s.runner.query)
+	s.Equal(map[string]interface{}{"key": "1"}, s.runner.params)
+}
+
+func (s *RepositoryTestSuite) TestRequiresAKey() {
+	type keyless struct {
+		Name string
+	}
+
+	_, err := New[keyless](s.runner)
+
+	s.ErrorIs(err, ErrNoKey)
+}
+
+// fakeRunner records the last query it receives and hands its records to the hook
+type fakeRunner struct {
+	query   string
+	params  map[string]interface{}
+	records []*neo4j.Record
+}
+
+func (f *fakeRunner) ExecuteQuery(_ context.Context, query string, params map[string]interface{}, onResults driver.ResultsHookFn, _ ...driver.QueryOption) error {
+	f.query, f.params = query, params
+	return onResults(&fakeResult{records: f.records})
+}
+
+// fakeResult is an in-memory neo4j.ResultWithContext, the embedded interface is left nil
+type fakeResult struct {
+	neo4j.ResultWithContext
+	records []*neo4j.Record
+}
+
+func (f *fakeResult) NextRecord(_ context.Context, record **neo4j.Record) bool {
+	if len(f.records) == 0 {
+		return false
+	}
+	*record, f.records = f.records[0], f.records[1:]
+	return true
+}
+
+func (f *fakeResult) Err() error {
+	return nil
+}
+
+func (f *fakeResult) Consume(context.Context) (neo4j.ResultSummary, error) {
+	return nil, nil
+}
@@ -0,0 +1,54 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"net"
+	"strconv"
+)
+
+// lookupHost resolves the host names of the routers for Settings.ResolveOnReconnect
+var lookupHost = net.DefaultResolver.LookupHost
+
+// withAddressResolver makes the routing drivers resolve their initial address with resolver
+func withAddressResolver(resolver neo4j.ServerAddressResolver) func(*neo4j.Config) {
+	return func(config *neo4j.Config) {
+		config.AddressResolver = resolver
+	}
+}
+
+// reresolve resolves the routers of a routing driver anew, applying Settings.AddressResolver first,
+// and returns the configuration pinning the driver replacing the current one to the resolved addresses.
+// it returns nothing for the direct drivers, which resolve their host on every dial
+func (d *Driver) reresolve(ctx context.Context, config underlyingConfig) ([]func(*neo4j.Config), error) {
+	if !d.settings.ResolveOnReconnect || !config.info.Routed() {
+		return nil, nil
+	}
+	routers := []neo4j.ServerAddress{neo4j.NewServerAddress(config.info.Host, strconv.Itoa(config.info.Port))}
+	if d.settings.AddressResolver != nil {
+		routers = d.settings.AddressResolver(routers[0])
+	}
+	var resolved []neo4j.ServerAddress
+	for _, router := range routers {
+		ips, err := lookupHost(ctx, router.Hostname())
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve router %s: %w", router.Hostname(), err)
+		}
+		for _, ip := range ips {
+			resolved = append(resolved, neo4j.NewServerAddress(ip, router.Port()))
+		}
+	}
+	d.logger().Printf("[neo4j] resolved the routers of %s to %s", config.info.Address(), serverAddresses(resolved))
+	return []func(*neo4j.Config){withAddressResolver(func(neo4j.ServerAddress) []neo4j.ServerAddress {
+		return resolved
+	})}, nil
+}
+
+func serverAddresses(addresses []neo4j.ServerAddress) []string {
+	result := make([]string, len(addresses))
+	for i, address := range addresses {
+		result[i] = net.JoinHostPort(address.Hostname(), address.Port())
+	}
+	return result
+}
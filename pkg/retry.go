@@ -0,0 +1,87 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultMaxAttempts = 5
+	// retryBaseDelay is the upper bound of the delay before the first retry, doubled for every retry up to retryMaxDelay
+	retryBaseDelay = 20 * time.Millisecond
+	retryMaxDelay  = time.Second
+)
+
+// ErrMaxRetriesExceeded matches the *MaxRetriesError of queries that failed Settings.MaxAttempts times
+var ErrMaxRetriesExceeded = errors.New("max retries exceeded")
+
+// MaxRetriesError is returned by queries still failing after Settings.MaxAttempts attempts, it unwraps to the last error
+type MaxRetriesError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *MaxRetriesError) Error() string {
+	return fmt.Sprintf("%s after %d attempts: %v", ErrMaxRetriesExceeded, e.Attempts, e.Err)
+}
+
+func (e *MaxRetriesError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, ErrMaxRetriesExceeded) hold
+func (e *MaxRetriesError) Is(target error) bool {
+	return target == ErrMaxRetriesExceeded
+}
+
+// WithMaxAttempts overrides Settings.MaxAttempts for the query.
+// the recovery after its last attempt proceeds in the background, so WithMaxAttempts(1) fails fast like WithNoRetry
+// but with a *MaxRetriesError
+func WithMaxAttempts(attempts int) QueryOption {
+	return func(options *queryOptions) {
+		options.maxAttempts = attempts
+	}
+}
+
+// WithNoRetry makes the query fail fast with the connectivity or routing error of its first attempt,
+// for the latency-sensitive calls preferring an immediate error to waiting for a reconnect.
+// the recovery proceeds in the background, for the next queries
+func WithNoRetry() QueryOption {
+	return func(options *queryOptions) {
+		options.noRetry = true
+	}
+}
+
+// recoverInBackground runs the recovery a WithNoRetry query did not wait for. it holds accessLock like the queries,
+// so that Close waits for it
+func (d *Driver) recoverInBackground(kind string, recovery func(ctx context.Context) error) {
+	go func() {
+		d.accessLock.RLock()
+		defer d.accessLock.RUnlock()
+		if err := recovery(context.Background()); err != nil {
+			d.events.recordError(kind, err)
+			return
+		}
+		d.events.record(kind, "recovered in the background")
+	}()
+}
+
+// sleepWithJitter waits before the given retry for a random delay up to an exponentially growing bound,
+// so that the queries failing together do not retry in lockstep. it returns early with the error of ctx once it is done
+func sleepWithJitter(ctx context.Context, attempt int) error {
+	bound := retryBaseDelay << (attempt - 1)
+	if bound > retryMaxDelay || bound <= 0 {
+		bound = retryMaxDelay
+	}
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(bound)) + 1))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
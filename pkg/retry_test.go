@@ -0,0 +1,132 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	suite.Run(t, new(RetryTestSuite))
+}
+
+type RetryTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	restore func()
+}
+
+func (s *RetryTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+}
+
+func (s *RetryTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *RetryTestSuite) TestGivesUpOnFlappingConnectivity() {
+	s.cluster.unreachableDrivers = 100
+	settings := connectionSettings
+	settings.MaxAttempts = 3
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	stats := QueryStats{}
+
+	err = driver.ExecuteQuery(s.ctx, "RETURN 1", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	}, WithQueryStats(&stats))
+
+	s.ErrorIs(err, ErrMaxRetriesExceeded)
+	retriesErr := &MaxRetriesError{}
+	s.Require().ErrorAs(err, &retriesErr)
+	s.Equal(3, retriesErr.Attempts)
+	s.ErrorContains(retriesErr.Err, "ConnectivityError")
+	s.Equal(QueryStats{Attempts: 3, Reconnects: 2}, stats)
+}
+
+func (s *RetryTestSuite) TestStopsRetryingOnceTheContextIsDone() {
+	s.cluster.unreachableDrivers = 100
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	err = driver.ExecuteQuery(ctx, "RETURN 1", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	})
+
+	s.ErrorIs(err, context.Canceled)
+}
+
+func (s *RetryTestSuite) TestOverridesTheMaxAttemptsOfTheDriver() {
+	s.cluster.unreachableDrivers = 100
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	stats := QueryStats{}
+
+	err = driver.ExecuteQuery(s.ctx, "RETURN 1", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	}, WithMaxAttempts(2), WithQueryStats(&stats))
+
+	retriesErr := &MaxRetriesError{}
+	s.Require().ErrorAs(err, &retriesErr)
+	s.Equal(2, retriesErr.Attempts)
+	s.Equal(QueryStats{Attempts: 2, Reconnects: 1}, stats)
+}
+
+func (s *RetryTestSuite) TestLastAttemptFailsWithoutWaitingForTheReconnect() {
+	s.cluster.unreachableDrivers = 1
+	reconnecting := make(chan struct{})
+	s.restore()
+	s.restore = UseDriverFactory(func(target string, auth neo4j.AuthToken, configurers ...func(*neo4j.Config)) (neo4j.DriverWithContext, error) {
+		if s.cluster.drivers() > 0 {
+			<-reconnecting
+		}
+		return s.cluster.newDriver(target, auth, configurers...)
+	})
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	stats := QueryStats{}
+
+	err = driver.ExecuteQuery(s.ctx, "RETURN 1", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	}, WithMaxAttempts(1), WithQueryStats(&stats))
+
+	s.ErrorIs(err, ErrMaxRetriesExceeded)
+	s.ErrorContains(err, "ConnectivityError")
+	s.Equal(QueryStats{Attempts: 1}, stats)
+	s.Equal(1, s.cluster.drivers(), "the query returns while the reconnect is pending")
+	close(reconnecting)
+	s.Eventually(func() bool {
+		return s.cluster.drivers() == 2
+	}, time.Second, time.Millisecond, "the driver reconnects in the background")
+}
+
+func (s *RetryTestSuite) TestFailsFastWithoutWaitingForTheReconnect() {
+	s.cluster.unreachableDrivers = 1
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	stats := QueryStats{}
+
+	err = driver.ExecuteQuery(s.ctx, "RETURN 1", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	}, WithNoRetry(), WithQueryStats(&stats))
+
+	s.ErrorContains(err, "ConnectivityError")
+	s.NotErrorIs(err, ErrMaxRetriesExceeded)
+	s.Equal(QueryStats{Attempts: 1}, stats)
+	s.Eventually(func() bool {
+		return s.cluster.drivers() == 2
+	}, time.Second, time.Millisecond, "the driver reconnects in the background")
+}
@@ -0,0 +1,96 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// maxRoutingRefreshes bounds the routing refreshes of a single execution, so that a cluster without leader fails the query
+const maxRoutingRefreshes = 3
+
+// databaseUnavailable is reported by members that no longer host the database, e.g. while a leader switch completes
+const databaseUnavailable = "Neo.TransientError.General.DatabaseUnavailable"
+
+// WithAccessMode routes the query to the cluster members serving the given access mode.
+// queries are routed to writers by default, use neo4j.AccessModeRead to let read replicas and followers serve them.
+// routing policies are selected through the routing context of the connection string (neo4j://host?policy=eu).
+func WithAccessMode(mode neo4j.AccessMode) QueryOption {
+	return func(options *queryOptions) {
+		options.accessMode = mode
+	}
+}
+
+// WithResultSummary fills summary with the server-reported result summary once the hook returns.
+// the summary tells which cluster member served the query through summary.Server().Address().
+// whatever the hook left unconsumed in the result is discarded to retrieve it.
+func WithResultSummary(summary *neo4j.ResultSummary) QueryOption {
+	return func(options *queryOptions) {
+		options.summarySink = summary
+	}
+}
+
+// ServedBy returns the address of the server that executed the query, or an empty string when unknown
+func ServedBy(summary neo4j.ResultSummary) string {
+	if summary == nil || summary.Server() == nil {
+		return ""
+	}
+	return summary.Server().Address()
+}
+
+func (d *Driver) newSession(ctx context.Context, options *queryOptions) neo4j.SessionWithContext {
+	return d.newSessionOn(ctx, d.currentGeneration(), options)
+}
+
+// newSessionOn opens a session on the driver of generation, or reuses one of its idle sessions
+func (d *Driver) newSessionOn(ctx context.Context, generation *driverGeneration, options *queryOptions) neo4j.SessionWithContext {
+	if d.sessionPool != nil && options.poolable() {
+		if session := d.sessionPool.acquire(ctx, generation.driver, options.sessionKey()); session != nil {
+			return session
+		}
+	}
+	return generation.driver.NewSession(ctx, options.sessionConfig())
+}
+
+// resultSummary consumes the rest of the result once to retrieve its summary, nil if it could not be retrieved
+func (o *queryOptions) resultSummary(ctx context.Context, result neo4j.ResultWithContext) neo4j.ResultSummary {
+	if !o.summaryFetched {
+		o.summary, _ = result.Consume(ctx)
+		o.summaryFetched = true
+	}
+	return o.summary
+}
+
+// isTopologyChange reports whether err means the routing table is stale after a cluster topology change, e.g. a leader switch.
+// the server rejected the query without executing it, so it is safe to replay once the routing is refreshed.
+// the underlying driver reports the members that left the cluster, the expired sessions of the other drivers,
+// with a *neo4j.ConnectivityError: attemptQuery reconnects on the unwrapped ones, the wrapped ones refresh the routing.
+func isTopologyChange(err error) bool {
+	var connectivityErr *neo4j.ConnectivityError
+	if errors.As(err, &connectivityErr) {
+		return true
+	}
+	var neo4jErr *neo4j.Neo4jError
+	if !errors.As(err, &neo4jErr) {
+		return false
+	}
+	return neo4jErr.IsRetriableCluster() || neo4jErr.Code == databaseUnavailable
+}
+
+// refreshRouting replaces stale, the generation of the underlying driver that routed the failed query, with a new one
+// fetching fresh routing tables. concurrent queries failing on the same stale generation refresh it only once.
+// the underlying driver exposes no way to invalidate its routing tables alone, so the whole driver is rebuilt:
+// the connection pool of stale is closed and the idle sessions are discarded, as after a reconnection.
+func (d *Driver) refreshRouting(ctx context.Context, stale *driverGeneration) error {
+	if err := d.lockRecovery(ctx); err != nil {
+		return err
+	}
+	defer d.recoveryLock.Unlock()
+	if d.lifecycle.isClosed() {
+		return ErrDriverClosed
+	}
+	if d.currentGeneration() != stale {
+		return nil
+	}
+	return d.replaceUnderlying(ctx)
+}
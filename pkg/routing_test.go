@@ -0,0 +1,142 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestRouting(t *testing.T) {
+	suite.Run(t, new(RoutingTestSuite))
+}
+
+type RoutingTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	restore func()
+}
+
+func (s *RoutingTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+}
+
+func (s *RoutingTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *RoutingTestSuite) TestRefreshesRoutingAndReplaysAfterLeaderSwitch() {
+	s.cluster.staleDrivers = 1
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	stats := QueryStats{}
+
+	err = driver.ExecuteQuery(s.ctx, "CREATE (:Test)", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	}, WithQueryStats(&stats))
+
+	s.Require().NoError(err)
+	s.Equal(QueryStats{Attempts: 2, RoutingRefreshes: 1}, stats)
+	s.Equal(2, s.cluster.drivers())
+}
+
+func (s *RoutingTestSuite) TestRefreshesRoutingAndReplaysAfterWrappedConnectivityErrors() {
+	s.cluster.staleDrivers = 1
+	s.cluster.staleErr = fmt.Errorf("member left the cluster: %w", s.connectivityError())
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	stats := QueryStats{}
+
+	err = driver.ExecuteQuery(s.ctx, "CREATE (:Test)", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	}, WithQueryStats(&stats))
+
+	s.Require().NoError(err)
+	s.Equal(QueryStats{Attempts: 2, RoutingRefreshes: 1}, stats)
+}
+
+// connectivityError returns the *neo4j.ConnectivityError of a driver connecting to a closed port
+func (s *RoutingTestSuite) connectivityError() error {
+	driver, err := neo4j.NewDriverWithContext("bolt://127.0.0.1:1", neo4j.NoAuth())
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	err = driver.VerifyConnectivity(s.ctx)
+	s.Require().True(neo4j.IsConnectivityError(err))
+	return err
+}
+
+func (s *RoutingTestSuite) TestRebuildsTheUnderlyingDriverToRefreshRouting() {
+	s.cluster.staleDrivers = 1
+	settings := connectionSettings
+	settings.MaxIdleSessions = 1
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	err = driver.ExecuteQuery(s.ctx, "CREATE (:Test)", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	})
+
+	s.Require().NoError(err)
+	s.Equal(2, s.cluster.drivers())
+	s.Equal(1, s.cluster.closed, "the driver holding the stale routing table is closed")
+	s.Require().NoError(driver.ExecuteQuery(s.ctx, "CREATE (:Test)", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	}))
+	s.Equal(2, s.cluster.drivers(), "the queries go through the new driver")
+}
+
+func (s *RoutingTestSuite) TestGivesUpWhenTheClusterHasNoLeader() {
+	s.cluster.staleDrivers = 100
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	stats := QueryStats{}
+
+	err = driver.ExecuteQuery(s.ctx, "CREATE (:Test)", nil, func(neo4j.ResultWithContext) error {
+		return nil
+	}, WithQueryStats(&stats))
+
+	neo4jErr := &neo4j.Neo4jError{}
+	s.Require().ErrorAs(err, &neo4jErr)
+	s.Equal("Neo.ClientError.Cluster.NotALeader", neo4jErr.Code)
+	s.Equal(4, stats.Attempts)
+	s.Equal(3, stats.RoutingRefreshes)
+}
+
+func (s *RoutingTestSuite) TestVerifiesTheServerBeforeResumingTraffic() {
+	s.cluster.staleDrivers = 1
+	var verified []string
+	settings := connectionSettings
+	settings.ReconnectVerification = &ReconnectVerification{
+		Query: "MATCH (s:Sentinel) RETURN s.environment AS environment",
+		Verify: func(result neo4j.ResultWithContext) error {
+			record, err := result.Single(s.ctx)
+			if err != nil {
+				return err
+			}
+			verified = append(verified, record.Keys[0])
+			return errors.New("restored from backup")
+		},
+	}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	err = driver.ExecuteQuery(s.ctx, "CREATE (:Test)", nil, func(neo4j.ResultWithContext) error {
+		s.Fail("hook must not be called")
+		return nil
+	})
+
+	s.ErrorIs(err, ErrReconnectVerificationFailed)
+	s.ErrorContains(err, "restored from backup")
+	s.Equal([]string{"ok"}, verified)
+}
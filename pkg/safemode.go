@@ -0,0 +1,76 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrUnsafeStatement is returned in safe mode for destructive statements executed without the override token
+var ErrUnsafeStatement = errors.New("destructive statement blocked by safe mode")
+
+// destructiveStatements matches the statements blocked in safe mode, once literals and comments are removed
+var destructiveStatements = regexp.MustCompile(`(?i)\bDETACH\s+DELETE\b|\bDROP\b|\b(CREATE|ALTER|START|STOP)\s+(OR\s+REPLACE\s+)?(COMPOSITE\s+)?(DATABASE|ALIAS)\b`)
+
+// SafeModeConfig guards the tooling built on this package (CLI, REPL...) against accidental destructive statements:
+// DETACH DELETE, DROP and database management statements fail with ErrUnsafeStatement unless the call is
+// executed WithSafeModeOverride(OverrideToken). it is typically only set outside of production builds
+type SafeModeConfig struct {
+	// OverrideToken unlocks destructive statements for a single call. they cannot be unlocked when empty
+	OverrideToken string
+}
+
+// WithSafeMode blocks the destructive statements of this call as if the driver was in safe mode, for tooling sharing
+// a driver configured without Settings.SafeMode. they can only be unlocked with the OverrideToken of Settings.SafeMode
+func WithSafeMode() QueryOption {
+	return func(options *queryOptions) {
+		options.safeMode = true
+	}
+}
+
+// WithSafeModeOverride allows the destructive statements of this call in safe mode, when token is the configured OverrideToken
+func WithSafeModeOverride(token string) QueryOption {
+	return func(options *queryOptions) {
+		options.safeModeOverride = token
+	}
+}
+
+// IsDestructive reports whether the Cypher statements of query include a statement blocked in safe mode
+func IsDestructive(query string) bool {
+	for _, statement := range SplitScript(query) {
+		if destructiveStatements.MatchString(stripLiterals(statement)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSafeMode fails with ErrUnsafeStatement when query is destructive and not overridden
+func (d *Driver) checkSafeMode(query string, options *queryOptions) error {
+	config := d.settings.SafeMode
+	if config == nil && !options.safeMode {
+		return nil
+	}
+	if config != nil && config.OverrideToken != "" && options.safeModeOverride == config.OverrideToken {
+		return nil
+	}
+	if IsDestructive(query) {
+		return fmt.Errorf("%w: %s", ErrUnsafeStatement, query)
+	}
+	return nil
+}
+
+// stripLiterals removes the string literals and escaped identifiers of a statement without comments
+func stripLiterals(statement string) string {
+	stripped := strings.Builder{}
+	for i := 0; i < len(statement); i++ {
+		if char := statement[i]; char == '\'' || char == '"' || char == '`' {
+			stripped.WriteString(`""`)
+			i = closingQuote(statement, i) - 1
+			continue
+		}
+		stripped.WriteByte(statement[i])
+	}
+	return stripped.String()
+}
@@ -0,0 +1,74 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestSafeMode(t *testing.T) {
+	suite.Run(t, new(SafeModeTestSuite))
+}
+
+type SafeModeTestSuite struct {
+	suite.Suite
+	ctx    context.Context
+	driver *Driver
+}
+
+func (s *SafeModeTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	settings := connectionSettings
+	settings.SafeMode = &SafeModeConfig{OverrideToken: "i-know-what-i-am-doing"}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	s.driver = driver
+}
+
+func (s *SafeModeTestSuite) TearDownTest() {
+	s.driver.Close(s.ctx)
+}
+
+func (s *SafeModeTestSuite) TestDetectsDestructiveStatements() {
+	for query, destructive := range map[string]bool{
+		"MATCH (n) DETACH DELETE n":                           true,
+		"drop index `user_email`":                             true,
+		"CREATE OR REPLACE DATABASE reports":                  true,
+		"STOP DATABASE reports":                               true,
+		"CREATE ALIAS reporting FOR DATABASE reports":         true,
+		"CREATE (:User);\nMATCH (n:Temp) DETACH DELETE n":     true,
+		"MATCH (n:User) DELETE n":                             false,
+		"CREATE (:Note {text: 'please DROP the old tables'})": false,
+		"MATCH (n:`DROP`) RETURN n // DROP":                   false,
+		"SHOW DATABASES":                                      false,
+	} {
+		s.Equal(destructive, IsDestructive(query), query)
+	}
+}
+
+func (s *SafeModeTestSuite) TestBlocksDestructiveStatementsWithoutTheOverrideToken() {
+	hook := func(neo4j.ResultWithContext) error {
+		s.Fail("hook must not be called")
+		return nil
+	}
+
+	s.ErrorIs(s.driver.ExecuteQuery(s.ctx, "MATCH (n) DETACH DELETE n", nil, hook), ErrUnsafeStatement)
+	s.ErrorIs(s.driver.ExecuteQuery(s.ctx, "DROP DATABASE reports", nil, hook, WithSafeModeOverride("please")), ErrUnsafeStatement)
+	s.ErrorIs(s.driver.ExecuteScript(s.ctx, "CREATE (:User);\nDROP INDEX user_email;"), ErrUnsafeStatement)
+}
+
+func (s *SafeModeTestSuite) TestBlocksDestructiveStatementsOfSafeCallsWithoutSafeModeSettings() {
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	hook := func(neo4j.ResultWithContext) error {
+		s.Fail("hook must not be called")
+		return nil
+	}
+
+	s.ErrorIs(driver.ExecuteQuery(s.ctx, "MATCH (n) DETACH DELETE n", nil, hook, WithSafeMode()), ErrUnsafeStatement)
+	s.ErrorIs(driver.ExecuteQuery(s.ctx, "DROP DATABASE reports", nil, hook, WithSafeMode(), WithSafeModeOverride("")), ErrUnsafeStatement,
+		"the calls cannot be unlocked without an override token")
+}
@@ -0,0 +1,349 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupportedSchema is returned when the server version cannot express the requested index or constraint
+var ErrUnsupportedSchema = errors.New("unsupported schema definition")
+
+// ServerVersion is the major.minor version of a Neo4j server
+type ServerVersion struct {
+	Major, Minor int
+}
+
+// ParseServerVersion parses versions such as "5.3.0", "4.4.12-enterprise" or "Neo4j/4.4.0"
+func ParseServerVersion(version string) (ServerVersion, error) {
+	version = strings.TrimPrefix(version, "Neo4j/")
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return ServerVersion{}, fmt.Errorf("invalid server version %q", version)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return ServerVersion{}, fmt.Errorf("invalid server version %q: %w", version, err)
+	}
+	minor, err := strconv.Atoi(strings.TrimRightFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' }))
+	if err != nil {
+		return ServerVersion{}, fmt.Errorf("invalid server version %q: %w", version, err)
+	}
+	return ServerVersion{Major: major, Minor: minor}, nil
+}
+
+// AtLeast reports whether the version is major.minor or later
+func (v ServerVersion) AtLeast(major, minor int) bool {
+	return v.Major > major || (v.Major == major && v.Minor >= minor)
+}
+
+func (v ServerVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// IndexType is the kind of a node index
+type IndexType string
+
+const (
+	// IndexRange is the default index, backed by a B-tree before 5.0
+	IndexRange IndexType = "RANGE"
+	// IndexText requires Neo4j 4.4 or later
+	IndexText IndexType = "TEXT"
+	// IndexPoint requires Neo4j 5.0 or later
+	IndexPoint IndexType = "POINT"
+)
+
+// Index describes a node index. when Name is empty, a deterministic name is derived from the definition
+type Index struct {
+	Name       string
+	Label      string
+	Properties []string
+	Type       IndexType
+}
+
+// ConstraintType is the kind of a node constraint
+type ConstraintType string
+
+const (
+	ConstraintUnique  ConstraintType = "UNIQUE"
+	ConstraintNodeKey ConstraintType = "NODE KEY"
+	// ConstraintNotNull requires the enterprise edition
+	ConstraintNotNull ConstraintType = "NOT NULL"
+)
+
+// Constraint describes a node constraint. when Name is empty, a deterministic name is derived from the definition
+type Constraint struct {
+	Name       string
+	Label      string
+	Properties []string
+	Type       ConstraintType
+}
+
+// IndexInfo is an index as reported by the server
+type IndexInfo struct {
+	Name          string
+	Type          string
+	LabelsOrTypes []string
+	Properties    []string
+	State         string
+}
+
+// CreateQuery generates the idempotent DDL creating the index on the given server version
+func (i Index) CreateQuery(version ServerVersion) (string, error) {
+	if err := checkSchemaVersion(version); err != nil {
+		return "", err
+	}
+	if i.Label == "" || len(i.Properties) == 0 {
+		return "", fmt.Errorf("%w: index needs a label and at least one property", ErrUnsupportedSchema)
+	}
+	kind := ""
+	switch i.Type {
+	case "", IndexRange:
+		if version.AtLeast(5, 0) {
+			kind = "RANGE "
+		}
+	case IndexText:
+		if !version.AtLeast(4, 4) || len(i.Properties) > 1 {
+			return "", fmt.Errorf("%w: text index on %v for Neo4j %s", ErrUnsupportedSchema, i.Properties, version)
+		}
+		kind = "TEXT "
+	case IndexPoint:
+		if !version.AtLeast(5, 0) || len(i.Properties) > 1 {
+			return "", fmt.Errorf("%w: point index on %v for Neo4j %s", ErrUnsupportedSchema, i.Properties, version)
+		}
+		kind = "POINT "
+	default:
+		return "", fmt.Errorf("%w: index type %q", ErrUnsupportedSchema, i.Type)
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s IF NOT EXISTS FOR (n:%s) ON (%s)",
+		kind, QuoteIdentifier(i.name()), QuoteIdentifier(i.Label), strings.Join(qualifiedProperties("n", i.Properties), ", ")), nil
+}
+
+func (i Index) name() string {
+	if i.Name != "" {
+		return i.Name
+	}
+	kind := i.Type
+	if kind == "" {
+		kind = IndexRange
+	}
+	return schemaName(string(kind)+"_index", i.Label, i.Properties)
+}
+
+// CreateQuery generates the idempotent DDL creating the constraint on the given server version.
+// Neo4j 4.x uses the ON ... ASSERT syntax, 5.x the FOR ... REQUIRE one.
+func (c Constraint) CreateQuery(version ServerVersion) (string, error) {
+	if err := checkSchemaVersion(version); err != nil {
+		return "", err
+	}
+	if c.Label == "" || len(c.Properties) == 0 {
+		return "", fmt.Errorf("%w: constraint needs a label and at least one property", ErrUnsupportedSchema)
+	}
+	properties := qualifiedProperties("n", c.Properties)
+	subject := properties[0]
+	if len(properties) > 1 {
+		subject = "(" + strings.Join(properties, ", ") + ")"
+	}
+	var predicate string
+	switch c.Type {
+	case ConstraintUnique:
+		if len(properties) > 1 && !version.AtLeast(5, 0) {
+			return "", fmt.Errorf("%w: composite unique constraint for Neo4j %s", ErrUnsupportedSchema, version)
+		}
+		predicate = subject + " IS UNIQUE"
+	case ConstraintNodeKey:
+		predicate = subject + " IS NODE KEY"
+	case ConstraintNotNull:
+		if len(properties) > 1 {
+			return "", fmt.Errorf("%w: not null constraint on several properties", ErrUnsupportedSchema)
+		}
+		predicate = subject + " IS NOT NULL"
+		if !version.AtLeast(4, 4) {
+			predicate = "exists(" + subject + ")"
+		}
+	default:
+		return "", fmt.Errorf("%w: constraint type %q", ErrUnsupportedSchema, c.Type)
+	}
+	if version.AtLeast(5, 0) {
+		return fmt.Sprintf("CREATE CONSTRAINT %s IF NOT EXISTS FOR (n:%s) REQUIRE %s",
+			QuoteIdentifier(c.name()), QuoteIdentifier(c.Label), predicate), nil
+	}
+	return fmt.Sprintf("CREATE CONSTRAINT %s IF NOT EXISTS ON (n:%s) ASSERT %s",
+		QuoteIdentifier(c.name()), QuoteIdentifier(c.Label), predicate), nil
+}
+
+func (c Constraint) name() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return schemaName(strings.ReplaceAll(string(c.Type), " ", "_")+"_constraint", c.Label, c.Properties)
+}
+
+// checkSchemaVersion rejects the versions without IF NOT EXISTS / IF EXISTS support
+func checkSchemaVersion(version ServerVersion) error {
+	if !version.AtLeast(4, 1) {
+		return fmt.Errorf("%w: Neo4j %s does not support idempotent schema commands", ErrUnsupportedSchema, version)
+	}
+	return nil
+}
+
+func qualifiedProperties(variable string, properties []string) []string {
+	qualified := make([]string, len(properties))
+	for i, property := range properties {
+		qualified[i] = variable + "." + QuoteIdentifier(property)
+	}
+	return qualified
+}
+
+func schemaName(kind, label string, properties []string) string {
+	return strings.ToLower(strings.Join(append([]string{kind, label}, properties...), "_"))
+}
+
+// Schema converges indexes and constraints, generating the DDL matching the server version
+type Schema struct {
+	runner  QueryRunner
+	mutex   sync.Mutex
+	version *ServerVersion
+}
+
+// NewSchema creates a Schema detecting the server version on first use
+func NewSchema(runner QueryRunner) *Schema {
+	return &Schema{runner: runner}
+}
+
+// NewSchemaForVersion creates a Schema for a known server version, skipping detection
+func NewSchemaForVersion(runner QueryRunner, version ServerVersion) *Schema {
+	return &Schema{runner: runner, version: &version}
+}
+
+// Version returns the server version, querying dbms.components() the first time
+func (s *Schema) Version(ctx context.Context) (ServerVersion, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.version != nil {
+		return *s.version, nil
+	}
+	var version ServerVersion
+	err := s.runner.ExecuteQuery(ctx, "CALL dbms.components() YIELD name, versions WHERE name = 'Neo4j Kernel' RETURN versions[0] AS version", nil, func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(ctx)
+		if err != nil {
+			return err
+		}
+		raw, _ := record.Values[0].(string)
+		version, err = ParseServerVersion(raw)
+		return err
+	}, WithQueryName("schema.version"), WithAccessMode(neo4j.AccessModeRead))
+	if err != nil {
+		return ServerVersion{}, err
+	}
+	s.version = &version
+	return version, nil
+}
+
+// EnsureIndex creates the index unless it already exists
+func (s *Schema) EnsureIndex(ctx context.Context, index Index) error {
+	version, err := s.Version(ctx)
+	if err != nil {
+		return err
+	}
+	query, err := index.CreateQuery(version)
+	if err != nil {
+		return err
+	}
+	return s.run(ctx, "schema.ensureIndex", query)
+}
+
+// EnsureConstraint creates the constraint unless it already exists
+func (s *Schema) EnsureConstraint(ctx context.Context, constraint Constraint) error {
+	version, err := s.Version(ctx)
+	if err != nil {
+		return err
+	}
+	query, err := constraint.CreateQuery(version)
+	if err != nil {
+		return err
+	}
+	return s.run(ctx, "schema.ensureConstraint", query)
+}
+
+// DropIndex removes the named index, if it exists
+func (s *Schema) DropIndex(ctx context.Context, name string) error {
+	if err := s.checkVersion(ctx); err != nil {
+		return err
+	}
+	return s.run(ctx, "schema.dropIndex", "DROP INDEX "+QuoteIdentifier(name)+" IF EXISTS")
+}
+
+// DropConstraint removes the named constraint, if it exists
+func (s *Schema) DropConstraint(ctx context.Context, name string) error {
+	if err := s.checkVersion(ctx); err != nil {
+		return err
+	}
+	return s.run(ctx, "schema.dropConstraint", "DROP CONSTRAINT "+QuoteIdentifier(name)+" IF EXISTS")
+}
+
+// ListIndexes returns the indexes of the database, including the ones backing constraints
+func (s *Schema) ListIndexes(ctx context.Context) ([]IndexInfo, error) {
+	version, err := s.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := "SHOW INDEXES YIELD name, type, labelsOrTypes, properties, state"
+	if !version.AtLeast(4, 2) {
+		query = "CALL db.indexes() YIELD name, type, labelsOrTypes, properties, state"
+	}
+	query += " RETURN name, type, labelsOrTypes, properties, state ORDER BY name"
+	var indexes []IndexInfo
+	err = s.runner.ExecuteQuery(ctx, query, nil, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for result.NextRecord(ctx, &record) {
+			name, _ := record.Values[0].(string)
+			kind, _ := record.Values[1].(string)
+			state, _ := record.Values[4].(string)
+			indexes = append(indexes, IndexInfo{
+				Name:          name,
+				Type:          kind,
+				LabelsOrTypes: toStrings(record.Values[2]),
+				Properties:    toStrings(record.Values[3]),
+				State:         state,
+			})
+		}
+		return result.Err()
+	}, WithQueryName("schema.listIndexes"), WithAccessMode(neo4j.AccessModeRead))
+	return indexes, err
+}
+
+func (s *Schema) checkVersion(ctx context.Context) error {
+	version, err := s.Version(ctx)
+	if err != nil {
+		return err
+	}
+	return checkSchemaVersion(version)
+}
+
+func (s *Schema) run(ctx context.Context, name, query string) error {
+	return s.runner.ExecuteQuery(ctx, query, nil, func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(ctx)
+		return err
+	}, WithQueryName(name))
+}
+
+func toStrings(value interface{}) []string {
+	values, _ := value.([]interface{})
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
+
+// Schema returns a Schema helper bound to the driver
+func (d *Driver) Schema() *Schema {
+	return NewSchema(d)
+}
@@ -0,0 +1,107 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	suite.Run(t, new(SchemaTestSuite))
+}
+
+type SchemaTestSuite struct {
+	suite.Suite
+}
+
+var (
+	neo4j41 = ServerVersion{Major: 4, Minor: 1}
+	neo4j44 = ServerVersion{Major: 4, Minor: 4}
+	neo4j5  = ServerVersion{Major: 5, Minor: 3}
+)
+
+func (s *SchemaTestSuite) TestParsesServerVersions() {
+	for raw, expected := range map[string]ServerVersion{
+		"5.3.0":             {Major: 5, Minor: 3},
+		"4.4.12-enterprise": {Major: 4, Minor: 4},
+		"Neo4j/4.2.0":       {Major: 4, Minor: 2},
+		"5.10-aura":         {Major: 5, Minor: 10},
+	} {
+		version, err := ParseServerVersion(raw)
+		s.NoError(err, raw)
+		s.Equal(expected, version, raw)
+	}
+
+	_, err := ParseServerVersion("dev")
+	s.Error(err)
+}
+
+func (s *SchemaTestSuite) TestIndexSyntaxPerVersion() {
+	index := Index{Label: "Person", Properties: []string{"name"}}
+
+	query, err := index.CreateQuery(neo4j44)
+	s.NoError(err)
+	s.Equal("CREATE INDEX `range_index_person_name` IF NOT EXISTS FOR (n:`Person`) ON (n.`name`)", query)
+
+	query, err = index.CreateQuery(neo4j5)
+	s.NoError(err)
+	s.Equal("CREATE RANGE INDEX `range_index_person_name` IF NOT EXISTS FOR (n:`Person`) ON (n.`name`)", query)
+
+	query, err = Index{Name: "bio", Label: "Person", Properties: []string{"bio"}, Type: IndexText}.CreateQuery(neo4j44)
+	s.NoError(err)
+	s.Equal("CREATE TEXT INDEX `bio` IF NOT EXISTS FOR (n:`Person`) ON (n.`bio`)", query)
+
+	_, err = Index{Label: "Place", Properties: []string{"location"}, Type: IndexPoint}.CreateQuery(neo4j44)
+	s.ErrorIs(err, ErrUnsupportedSchema)
+}
+
+func (s *SchemaTestSuite) TestConstraintSyntaxPerVersion() {
+	unique := Constraint{Label: "Person", Properties: []string{"email"}, Type: ConstraintUnique}
+
+	query, err := unique.CreateQuery(neo4j44)
+	s.NoError(err)
+	s.Equal("CREATE CONSTRAINT `unique_constraint_person_email` IF NOT EXISTS ON (n:`Person`) ASSERT n.`email` IS UNIQUE", query)
+
+	query, err = unique.CreateQuery(neo4j5)
+	s.NoError(err)
+	s.Equal("CREATE CONSTRAINT `unique_constraint_person_email` IF NOT EXISTS FOR (n:`Person`) REQUIRE n.`email` IS UNIQUE", query)
+
+	query, err = Constraint{Name: "key", Label: "Person", Properties: []string{"first", "last"}, Type: ConstraintNodeKey}.CreateQuery(neo4j5)
+	s.NoError(err)
+	s.Equal("CREATE CONSTRAINT `key` IF NOT EXISTS FOR (n:`Person`) REQUIRE (n.`first`, n.`last`) IS NODE KEY", query)
+
+	query, err = Constraint{Name: "required", Label: "Person", Properties: []string{"name"}, Type: ConstraintNotNull}.CreateQuery(neo4j41)
+	s.NoError(err)
+	s.Equal("CREATE CONSTRAINT `required` IF NOT EXISTS ON (n:`Person`) ASSERT exists(n.`name`)", query)
+
+	_, err = unique.CreateQuery(ServerVersion{Major: 4, Minor: 0})
+	s.ErrorIs(err, ErrUnsupportedSchema)
+}
+
+func (s *SchemaTestSuite) TestDetectsServerVersionOnce() {
+	runner := &fakeRunner{records: []*neo4j.Record{{Keys: []string{"version"}, Values: []any{"5.3.0"}}}}
+	schema := NewSchema(runner)
+
+	s.NoError(schema.DropIndex(context.Background(), "obsolete"))
+	s.NoError(schema.DropConstraint(context.Background(), "obsolete"))
+
+	queries := runner.executed()
+	s.Len(queries, 3)
+	s.Contains(queries[0], "dbms.components()")
+	s.Equal([]string{"DROP INDEX `obsolete` IF EXISTS", "DROP CONSTRAINT `obsolete` IF EXISTS"}, queries[1:])
+}
+
+func (s *SchemaTestSuite) TestListsIndexes() {
+	runner := &fakeRunner{records: []*neo4j.Record{{
+		Keys:   []string{"name", "type", "labelsOrTypes", "properties", "state"},
+		Values: []any{"range_index_person_name", "RANGE", []any{"Person"}, []any{"name"}, "ONLINE"},
+	}}}
+
+	indexes, err := NewSchemaForVersion(runner, neo4j5).ListIndexes(context.Background())
+
+	s.NoError(err)
+	s.Equal([]IndexInfo{{Name: "range_index_person_name", Type: "RANGE", LabelsOrTypes: []string{"Person"}, Properties: []string{"name"}, State: "ONLINE"}}, indexes)
+	s.Contains(runner.executed()[0], "SHOW INDEXES")
+}
@@ -0,0 +1,165 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"strings"
+	"time"
+)
+
+// ScriptError reports the statement of a script that failed. the statements before it were executed,
+// and committed unless the script ran WithScriptTransaction
+type ScriptError struct {
+	// Index is the position of the failed statement, starting at 0
+	Index     int
+	Statement string
+	Err       error
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("script statement #%d failed: %v", e.Index+1, e.Err)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// WithScriptTransaction makes ExecuteScript run all the statements in one transaction, rolled back when one of them fails
+func WithScriptTransaction() QueryOption {
+	return func(options *queryOptions) {
+		options.scriptTransaction = true
+	}
+}
+
+// SplitScript splits a Cypher script into its statements, separated by semicolons.
+// semicolons within string literals, escaped identifiers and comments do not end statements. comments are removed,
+// and empty statements are skipped.
+func SplitScript(script string) []string {
+	var statements []string
+	current := strings.Builder{}
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+	for i := 0; i < len(script); i++ {
+		switch char := script[i]; {
+		case char == ';':
+			flush()
+		case char == '\'' || char == '"' || char == '`':
+			end := closingQuote(script, i)
+			current.WriteString(script[i:end])
+			i = end - 1
+		case strings.HasPrefix(script[i:], "//"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				i = len(script)
+				continue
+			}
+			i += end - 1
+		case strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+				continue
+			}
+			current.WriteByte(' ')
+			i += end + 3
+		default:
+			current.WriteByte(char)
+		}
+	}
+	flush()
+	return statements
+}
+
+// closingQuote returns the position following the literal opened at start.
+// backslashes escape characters in strings, and doubled backticks escape backticks in identifiers.
+func closingQuote(script string, start int) int {
+	quote := script[start]
+	for i := start + 1; i < len(script); i++ {
+		switch {
+		case script[i] == '\\' && quote != '`':
+			i++
+		case script[i] == quote && quote == '`' && i+1 < len(script) && script[i+1] == '`':
+			i++
+		case script[i] == quote:
+			return i + 1
+		}
+	}
+	return len(script)
+}
+
+// ExecuteScript runs the statements of a Cypher script in order within one session, see SplitScript.
+// it stops at the first failing statement and returns a *ScriptError identifying it.
+// statements are not retried on connectivity errors, since the script may be partially applied.
+// the script is admitted like a single query, within the quota of the caller and a single concurrency slot.
+func (d *Driver) ExecuteScript(ctx context.Context, script string, opts ...QueryOption) (err error) {
+	if err := d.lifecycle.enter(); err != nil {
+		return err
+	}
+	defer d.lifecycle.exit()
+	if d.quotas != nil {
+		release, err := d.quotas.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	options := newQueryOptions(opts)
+	if err := d.screen(ctx, script, options); err != nil {
+		return err
+	}
+	release, err := d.acquireConcurrency(ctx, options)
+	if err != nil {
+		return err
+	}
+	defer release()
+	d.accessLock.RLock()
+	defer d.accessLock.RUnlock()
+	defer func() {
+		options.report()
+		d.audit(ctx, AuditScript, script, nil, options, err)
+		if instrumented {
+			d.notifyObserver(ctx, script, options, time.Since(options.start), err)
+		}
+	}()
+
+	generation, done := d.acquireGeneration()
+	defer done()
+	session := d.newSessionOn(ctx, generation, options)
+	defer d.CloseSession(ctx, session)
+	options.stats.Attempts++
+	if !options.scriptTransaction {
+		return runScript(ctx, SplitScript(script), session.Run, options)
+	}
+	tx, err := session.BeginTransaction(ctx, options.txConfigurers()...)
+	if err != nil {
+		return err
+	}
+	defer tx.Close(ctx)
+	err = runScript(ctx, SplitScript(script), func(ctx context.Context, statement string, params map[string]interface{}, _ ...func(*neo4j.TransactionConfig)) (neo4j.ResultWithContext, error) {
+		return tx.Run(ctx, statement, params)
+	}, options)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+type statementRunnerFn func(ctx context.Context, statement string, params map[string]interface{}, configurers ...func(*neo4j.TransactionConfig)) (neo4j.ResultWithContext, error)
+
+func runScript(ctx context.Context, statements []string, run statementRunnerFn, options *queryOptions) error {
+	for i, statement := range statements {
+		result, err := run(ctx, statement, nil, options.txConfigurers()...)
+		if err == nil {
+			_, err = result.Consume(ctx)
+		}
+		if err != nil {
+			return &ScriptError{Index: i, Statement: statement, Err: err}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestScript(t *testing.T) {
+	suite.Run(t, new(ScriptTestSuite))
+}
+
+type ScriptTestSuite struct {
+	suite.Suite
+}
+
+func (s *ScriptTestSuite) TestSplitsOnSemicolons() {
+	statements := SplitScript("CREATE (:User {name: 'alice'});\nCREATE (:User {name: 'bob'})\n;;\n")
+
+	s.Equal([]string{"CREATE (:User {name: 'alice'})", "CREATE (:User {name: 'bob'})"}, statements)
+}
+
+func (s *ScriptTestSuite) TestIgnoresSemicolonsInLiteralsAndComments() {
+	script := `// seed; users
+CREATE (:Note {text: 'a;b', quote: "it\"s; fine"}); /* block; comment */
+MATCH (n:` + "`weird;``label`" + `) RETURN n // trailing; comment
+`
+
+	statements := SplitScript(script)
+
+	s.Equal([]string{
+		`CREATE (:Note {text: 'a;b', quote: "it\"s; fine"})`,
+		"MATCH (n:`weird;``label`) RETURN n",
+	}, statements)
+}
+
+func (s *ScriptTestSuite) TestReportsTheFailedStatement() {
+	cause := errors.New("syntax error")
+	var err error = &ScriptError{Index: 1, Statement: "CREAT (n)", Err: cause}
+
+	s.ErrorIs(err, cause)
+	s.EqualError(err, "script statement #2 failed: syntax error")
+}
+
+func (s *ScriptTestSuite) TestAdmitsScriptsWithinTheQuotaOfTheCaller() {
+	ctx := WithIdentity(context.Background(), "batch")
+	defer UseDriverFactory((&fakeCluster{}).newDriver)()
+	settings := connectionSettings
+	settings.Quotas = &QuotaConfig{Default: Quota{QueriesPerSecond: 0.001, Burst: 1}}
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(ctx)
+
+	s.Require().NoError(driver.ExecuteScript(ctx, "CREATE (:A);\nCREATE (:B);"))
+	err = driver.ExecuteScript(ctx, "CREATE (:A);\nCREATE (:B);")
+
+	s.ErrorIs(err, ErrQuotaExceeded)
+}
+
+func (s *ScriptTestSuite) TestWaitsForAConcurrencySlot() {
+	ctx := context.Background()
+	defer UseDriverFactory((&fakeCluster{}).newDriver)()
+	settings := connectionSettings
+	settings.MaxConcurrentQueries = 1
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(ctx)
+	running, finish := make(chan struct{}), make(chan struct{})
+	go func() {
+		_ = driver.ExecuteQuery(ctx, "RETURN 1", nil, func(neo4j.ResultWithContext) error {
+			close(running)
+			<-finish
+			return nil
+		})
+	}()
+	<-running
+	defer close(finish)
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+
+	err = driver.ExecuteScript(timeout, "CREATE (:A);\nCREATE (:B);")
+
+	s.ErrorIs(err, context.DeadlineExceeded)
+}
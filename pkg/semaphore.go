@@ -0,0 +1,172 @@
+package driver
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"sync"
+	"time"
+)
+
+// ErrInvalidWeight is returned for the weights that are not positive or exceed the capacity of the semaphore,
+// which could never be acquired, see WithQueryWeight
+var ErrInvalidWeight = errors.New("invalid weight")
+
+// Semaphore is a weighted semaphore granting its capacity to waiters in FIFO order
+type Semaphore struct {
+	mutex    sync.Mutex
+	capacity int64
+	used     int64
+	waiters  list.List
+}
+
+type semaphoreWaiter struct {
+	weight int64
+	ready  chan struct{}
+}
+
+// NewSemaphore creates a semaphore of the given capacity
+func NewSemaphore(capacity int64) *Semaphore {
+	return &Semaphore{capacity: capacity}
+}
+
+// Acquire blocks until weight is available or ctx is done
+func (s *Semaphore) Acquire(ctx context.Context, weight int64) error {
+	if weight <= 0 {
+		return fmt.Errorf("%w: weight %d is not positive", ErrInvalidWeight, weight)
+	}
+	if weight > s.capacity {
+		return fmt.Errorf("%w: weight %d exceeds the semaphore capacity %d", ErrInvalidWeight, weight, s.capacity)
+	}
+	s.mutex.Lock()
+	if s.waiters.Len() == 0 && s.used+weight <= s.capacity {
+		s.used += weight
+		s.mutex.Unlock()
+		return nil
+	}
+	waiter := semaphoreWaiter{weight: weight, ready: make(chan struct{})}
+	element := s.waiters.PushBack(waiter)
+	s.mutex.Unlock()
+
+	select {
+	case <-waiter.ready:
+		return nil
+	case <-ctx.Done():
+		s.mutex.Lock()
+		select {
+		case <-waiter.ready:
+			// acquired right when ctx was done, give it back
+			s.used -= weight
+			s.notifyWaiters()
+		default:
+			isFront := s.waiters.Front() == element
+			s.waiters.Remove(element)
+			if isFront {
+				s.notifyWaiters()
+			}
+		}
+		s.mutex.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Release gives weight back to the semaphore
+func (s *Semaphore) Release(weight int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.used -= weight
+	if s.used < 0 {
+		panic("semaphore released more than acquired")
+	}
+	s.notifyWaiters()
+}
+
+// notifyWaiters must be called with the mutex held
+func (s *Semaphore) notifyWaiters() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		waiter := front.Value.(semaphoreWaiter)
+		if s.used+waiter.weight > s.capacity {
+			return
+		}
+		s.used += waiter.weight
+		s.waiters.Remove(front)
+		close(waiter.ready)
+	}
+}
+
+// WithQueryWeight sets how much of Settings.MaxConcurrentQueries (or of the bound of its access mode) the query uses, 1 by default.
+// heavier queries (large exports, batch writes) can be given a larger weight. the query fails with ErrInvalidWeight
+// when weight is not positive, or exceeds the bound of its access mode
+func WithQueryWeight(weight int64) QueryOption {
+	return func(options *queryOptions) {
+		options.weight = weight
+	}
+}
+
+// SemaphoreUsage is a snapshot of the activity of a Semaphore
+type SemaphoreUsage struct {
+	Capacity, InUse int64
+	// Waiting is the number of acquisitions queued
+	Waiting int
+}
+
+// Usage returns the current activity of the semaphore
+func (s *Semaphore) Usage() SemaphoreUsage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return SemaphoreUsage{Capacity: s.capacity, InUse: s.used, Waiting: s.waiters.Len()}
+}
+
+// newExecutorPartitions maps every access mode to the semaphore bounding its queries, nil when unbounded
+func newExecutorPartitions(settings Settings) map[neo4j.AccessMode]*Semaphore {
+	var shared *Semaphore
+	if settings.MaxConcurrentQueries > 0 {
+		shared = NewSemaphore(settings.MaxConcurrentQueries)
+	}
+	partitions := map[neo4j.AccessMode]*Semaphore{neo4j.AccessModeRead: shared, neo4j.AccessModeWrite: shared}
+	if settings.MaxConcurrentReads > 0 {
+		partitions[neo4j.AccessModeRead] = NewSemaphore(settings.MaxConcurrentReads)
+	}
+	if settings.MaxConcurrentWrites > 0 {
+		partitions[neo4j.AccessModeWrite] = NewSemaphore(settings.MaxConcurrentWrites)
+	}
+	return partitions
+}
+
+// ConcurrencyUsage returns the activity of the semaphore bounding the queries of each access mode.
+// both modes report the same usage when they share Settings.MaxConcurrentQueries, and unbounded modes are left out
+func (d *Driver) ConcurrencyUsage() map[neo4j.AccessMode]SemaphoreUsage {
+	usage := make(map[neo4j.AccessMode]SemaphoreUsage, len(d.concurrency))
+	for mode, semaphore := range d.concurrency {
+		if semaphore != nil {
+			usage[mode] = semaphore.Usage()
+		}
+	}
+	return usage
+}
+
+// acquireConcurrency waits for the query to fit within the bound of its access mode and records the wait time
+func (d *Driver) acquireConcurrency(ctx context.Context, options *queryOptions) (release func(), err error) {
+	if options.weight <= 0 {
+		return nil, fmt.Errorf("%w: weight %d is not positive", ErrInvalidWeight, options.weight)
+	}
+	semaphore := d.concurrency[options.accessMode]
+	if semaphore == nil {
+		return func() {}, nil
+	}
+	start := time.Now()
+	err = semaphore.Acquire(ctx, options.weight)
+	options.stats.QueueWait = time.Since(start)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		semaphore.Release(options.weight)
+	}, nil
+}
@@ -0,0 +1,133 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestSemaphore(t *testing.T) {
+	suite.Run(t, new(SemaphoreTestSuite))
+}
+
+type SemaphoreTestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *SemaphoreTestSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+func (s *SemaphoreTestSuite) TestBlocksUntilWeightIsReleased() {
+	semaphore := NewSemaphore(2)
+	s.Require().NoError(semaphore.Acquire(s.ctx, 2))
+	acquired := make(chan struct{})
+
+	go func() {
+		s.NoError(semaphore.Acquire(s.ctx, 1))
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		s.Fail("acquired beyond capacity")
+	case <-time.After(20 * time.Millisecond):
+	}
+	semaphore.Release(2)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		s.Fail("not acquired after release")
+	}
+}
+
+func (s *SemaphoreTestSuite) TestGivesUpWhenContextIsDone() {
+	semaphore := NewSemaphore(1)
+	s.Require().NoError(semaphore.Acquire(s.ctx, 1))
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Millisecond)
+	defer cancel()
+
+	err := semaphore.Acquire(ctx, 1)
+
+	s.ErrorIs(err, context.DeadlineExceeded)
+	semaphore.Release(1)
+	s.NoError(semaphore.Acquire(s.ctx, 1))
+}
+
+func (s *SemaphoreTestSuite) TestCancelledHeadWaiterDoesNotBlockOthers() {
+	semaphore := NewSemaphore(2)
+	s.Require().NoError(semaphore.Acquire(s.ctx, 1))
+	ctx, cancel := context.WithCancel(s.ctx)
+	heavyDone := make(chan error)
+	go func() {
+		heavyDone <- semaphore.Acquire(ctx, 2)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	lightDone := make(chan error)
+	go func() {
+		lightDone <- semaphore.Acquire(s.ctx, 1)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+
+	s.ErrorIs(<-heavyDone, context.Canceled)
+	select {
+	case err := <-lightDone:
+		s.NoError(err)
+	case <-time.After(time.Second):
+		s.Fail("light waiter stayed blocked behind a cancelled one")
+	}
+}
+
+func (s *SemaphoreTestSuite) TestRejectsWeightAboveCapacity() {
+	s.ErrorIs(NewSemaphore(1).Acquire(s.ctx, 2), ErrInvalidWeight)
+}
+
+func (s *SemaphoreTestSuite) TestRejectsInvalidQueryWeights() {
+	defer UseDriverFactory((&fakeCluster{}).newDriver)()
+	settings := connectionSettings
+	settings.MaxConcurrentQueries = 4
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	for _, weight := range []int64{0, -1, 5} {
+		s.ErrorIs(executeSimpleQuery(s.ctx, driver, WithQueryWeight(weight)), ErrInvalidWeight, "weight %d", weight)
+	}
+	s.NoError(executeSimpleQuery(s.ctx, driver, WithQueryWeight(4)))
+	s.Equal(SemaphoreUsage{Capacity: 4}, driver.ConcurrencyUsage()[neo4j.AccessModeWrite])
+}
+
+func (s *SemaphoreTestSuite) TestReportsUsage() {
+	semaphore := NewSemaphore(3)
+	s.Require().NoError(semaphore.Acquire(s.ctx, 2))
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	go func() {
+		_ = semaphore.Acquire(ctx, 2)
+	}()
+
+	s.Eventually(func() bool {
+		return semaphore.Usage().Waiting == 1
+	}, time.Second, time.Millisecond)
+	s.Equal(SemaphoreUsage{Capacity: 3, InUse: 2, Waiting: 1}, semaphore.Usage())
+}
+
+func (s *SemaphoreTestSuite) TestPartitionsQueriesPerAccessMode() {
+	settings := connectionSettings
+	settings.MaxConcurrentQueries = 8
+	settings.MaxConcurrentReads = 2
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	s.Equal(map[neo4j.AccessMode]SemaphoreUsage{
+		neo4j.AccessModeRead:  {Capacity: 2},
+		neo4j.AccessModeWrite: {Capacity: 8},
+	}, driver.ConcurrencyUsage())
+}
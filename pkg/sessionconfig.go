@@ -0,0 +1,27 @@
+package driver
+
+import "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+
+// WithSessionConfig customizes the session the query runs in, for the settings without a dedicated option,
+// e.g. the bookmarks. configure runs after the access mode, the database, the impersonated user and the fetch size
+// of the other options are set, and may override them: the logs only know about the latter, while the query cache
+// keeps the results apart by the database and impersonated user of the session.
+// the query never reuses a pooled session, see Settings.MaxIdleSessions
+func WithSessionConfig(configure func(*neo4j.SessionConfig)) QueryOption {
+	return func(options *queryOptions) {
+		options.sessionConfigurers = append(options.sessionConfigurers, configure)
+	}
+}
+
+func (o *queryOptions) sessionConfig() neo4j.SessionConfig {
+	config := neo4j.SessionConfig{AccessMode: o.accessMode, DatabaseName: o.database, ImpersonatedUser: o.impersonated, FetchSize: o.sessionFetchSize()}
+	for _, configure := range o.sessionConfigurers {
+		configure(&config)
+	}
+	return config
+}
+
+// poolable reports whether the query may run in a pooled session, whose configuration only depends on sessionKey
+func (o *queryOptions) poolable() bool {
+	return len(o.sessionConfigurers) == 0
+}
@@ -0,0 +1,107 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestSessionConfig(t *testing.T) {
+	suite.Run(t, new(SessionConfigTestSuite))
+}
+
+type SessionConfigTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	restore func()
+}
+
+func (s *SessionConfigTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+}
+
+func (s *SessionConfigTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *SessionConfigTestSuite) TestCustomizesTheSessionOfTheQuery() {
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	bookmarks := neo4j.Bookmarks{"FB:42"}
+
+	err = driver.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook, WithDatabase("movies"), WithAccessMode(neo4j.AccessModeRead), WithSessionConfig(func(config *neo4j.SessionConfig) {
+		config.FetchSize = 10
+		config.Bookmarks = bookmarks
+		config.ImpersonatedUser = "jane"
+	}))
+
+	s.Require().NoError(err)
+	s.Require().Len(s.cluster.sessionConfigs, 1)
+	s.Equal(neo4j.SessionConfig{
+		AccessMode:       neo4j.AccessModeRead,
+		DatabaseName:     "movies",
+		FetchSize:        10,
+		Bookmarks:        bookmarks,
+		ImpersonatedUser: "jane",
+	}, s.cluster.sessionConfigs[0])
+}
+
+func (s *SessionConfigTestSuite) TestOverridesTheOtherOptions() {
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	err = driver.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook, WithSessionConfig(func(config *neo4j.SessionConfig) {
+		config.DatabaseName = "archive"
+	}), WithDatabase("movies"))
+
+	s.Require().NoError(err)
+	s.Equal("archive", s.cluster.sessionConfigs[0].DatabaseName)
+}
+
+func (s *SessionConfigTestSuite) TestNeverReusesPooledSessions() {
+	settings := connectionSettings
+	settings.MaxIdleSessions = 1
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	fetchAll := WithSessionConfig(func(config *neo4j.SessionConfig) {
+		config.FetchSize = neo4j.FetchAll
+	})
+
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver))
+	s.Require().NoError(driver.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook, fetchAll))
+	s.Require().NoError(driver.ExecuteQuery(s.ctx, "RETURN 1", nil, noopHook, fetchAll))
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver))
+
+	s.Equal(3, s.cluster.sessions(), "one pooled session and one per customized query")
+	s.Equal(neo4j.FetchAll, s.cluster.sessionConfigs[2].FetchSize)
+}
+
+func (s *SessionConfigTestSuite) TestKeepsTheCachedResultsOfTheConfiguredSessionsApart() {
+	settings := connectionSettings
+	settings.QueryCache = NewMemoryCache()
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	onDatabase := func(database, user string) QueryOption {
+		return WithSessionConfig(func(config *neo4j.SessionConfig) {
+			config.DatabaseName = database
+			config.ImpersonatedUser = user
+		})
+	}
+
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver, WithCache(time.Minute), WithAccessMode(neo4j.AccessModeRead), WithDatabase("movies")))
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver, WithCache(time.Minute), WithAccessMode(neo4j.AccessModeRead), WithDatabase("movies"), onDatabase("tenant-1", "")))
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver, WithCache(time.Minute), WithAccessMode(neo4j.AccessModeRead), onDatabase("tenant-1", "alice")))
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver, WithCache(time.Minute), WithAccessMode(neo4j.AccessModeRead), onDatabase("tenant-1", "")))
+
+	s.Equal(3, s.cluster.sessions(), "the last query is served from the cache of the second one")
+}
@@ -0,0 +1,104 @@
+package driver
+
+import (
+	"context"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"sync"
+)
+
+// sessionPool keeps the sessions of the queries that succeeded for reuse by the next ones, per access mode and database,
+// instead of creating and closing a session per query. see Settings.MaxIdleSessions
+type sessionPool struct {
+	mutex   sync.Mutex
+	maxIdle int
+	idle    map[sessionKey][]pooledSession
+}
+
+// sessionKey is the configuration a session is created with, pooled sessions are only reused for the same one
+type sessionKey struct {
+	mode         neo4j.AccessMode
+	database     string
+	impersonated string
+	fetchSize    int
+}
+
+// pooledSession is an idle session along with the underlying driver that created it
+type pooledSession struct {
+	session neo4j.SessionWithContext
+	driver  neo4j.DriverWithContext
+}
+
+func newSessionPool(settings Settings) *sessionPool {
+	if settings.MaxIdleSessions <= 0 {
+		return nil
+	}
+	return &sessionPool{maxIdle: settings.MaxIdleSessions, idle: map[sessionKey][]pooledSession{}}
+}
+
+// acquire returns an idle session created by driver, nil if there is none.
+// the idle sessions of the drivers replaced since they were released are closed on the way
+func (p *sessionPool) acquire(ctx context.Context, driver neo4j.DriverWithContext, key sessionKey) neo4j.SessionWithContext {
+	var stale []pooledSession
+	defer func() {
+		for _, pooled := range stale {
+			pooled.session.Close(ctx)
+		}
+	}()
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for idle := p.idle[key]; len(idle) > 0; idle = p.idle[key] {
+		pooled := idle[len(idle)-1]
+		p.idle[key] = idle[:len(idle)-1]
+		if pooled.driver == driver {
+			return pooled.session
+		}
+		stale = append(stale, pooled)
+	}
+	return nil
+}
+
+// release keeps session for reuse, or closes it when maxIdle sessions of its configuration are already idle
+func (p *sessionPool) release(ctx context.Context, driver neo4j.DriverWithContext, key sessionKey, session neo4j.SessionWithContext) {
+	p.mutex.Lock()
+	if len(p.idle[key]) < p.maxIdle {
+		p.idle[key] = append(p.idle[key], pooledSession{session: session, driver: driver})
+		p.mutex.Unlock()
+		return
+	}
+	p.mutex.Unlock()
+	session.Close(ctx)
+}
+
+// size is the number of idle sessions
+func (p *sessionPool) size() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	size := 0
+	for _, sessions := range p.idle {
+		size += len(sessions)
+	}
+	return size
+}
+
+// clear closes all the idle sessions, e.g. before their underlying driver is closed
+func (p *sessionPool) clear(ctx context.Context) {
+	p.mutex.Lock()
+	idle := p.idle
+	p.idle = map[sessionKey][]pooledSession{}
+	p.mutex.Unlock()
+	for _, sessions := range idle {
+		for _, pooled := range sessions {
+			pooled.session.Close(ctx)
+		}
+	}
+}
+
+// releaseSession pools the session of a query that succeeded and whose result was fully consumed, and closes the others,
+// so that sessions that went through an error, e.g. a connectivity loss, are never reused
+func (d *Driver) releaseSession(ctx context.Context, driver neo4j.DriverWithContext, session neo4j.SessionWithContext, options *queryOptions, reusable bool) {
+	if !reusable || d.sessionPool == nil || !options.poolable() || d.lifecycle.isClosed() {
+		d.CloseSession(ctx, session)
+		return
+	}
+	d.sessionPool.release(ctx, driver, options.sessionKey(), session)
+}
@@ -0,0 +1,73 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestSessionPool(t *testing.T) {
+	suite.Run(t, new(SessionPoolTestSuite))
+}
+
+type SessionPoolTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	restore func()
+}
+
+func (s *SessionPoolTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{}
+	s.restore = UseDriverFactory(s.cluster.newDriver)
+}
+
+func (s *SessionPoolTestSuite) TearDownTest() {
+	s.restore()
+}
+
+func (s *SessionPoolTestSuite) TestReusesTheSessionsOfSuccessfulQueries() {
+	settings := connectionSettings
+	settings.MaxIdleSessions = 1
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	for i := 0; i < 3; i++ {
+		s.Require().NoError(executeSimpleQuery(s.ctx, driver))
+	}
+	s.Require().NoError(driver.ExecuteQuery(s.ctx, "RETURN true AS ok", nil, noopHook, WithAccessMode(neo4j.AccessModeRead)))
+
+	s.Equal(2, s.cluster.sessions(), "one session per access mode")
+}
+
+func (s *SessionPoolTestSuite) TestCreatesASessionPerQueryByDefault() {
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+
+	for i := 0; i < 3; i++ {
+		s.Require().NoError(executeSimpleQuery(s.ctx, driver))
+	}
+
+	s.Equal(3, s.cluster.sessions())
+}
+
+func (s *SessionPoolTestSuite) TestDropsTheIdleSessionsAfterAConnectivityLoss() {
+	settings := connectionSettings
+	settings.MaxIdleSessions = 1
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver))
+
+	s.cluster.disconnect()
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver))
+	s.Require().NoError(executeSimpleQuery(s.ctx, driver))
+
+	s.Equal(2, s.cluster.drivers())
+	s.Equal(2, s.cluster.sessions(), "the pooled session of the first driver is replaced once by a session of the second one")
+}
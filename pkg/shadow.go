@@ -0,0 +1,245 @@
+package driver
+
+import (
+	"context"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"sync"
+	"time"
+)
+
+const defaultShadowMaxRecords = 10000
+
+// ReadShadowConfig configures the shadowing of reads to a secondary cluster, to validate a migration before cutover
+type ReadShadowConfig struct {
+	// Shadow receives the shadowed reads, typically a *Driver connected to the migration target
+	Shadow QueryRunner
+	// Designated decides whether a successful read is shadowed.
+	// name is the logical name set with WithQueryName. when nil, only queries executed with WithReadShadow are shadowed.
+	Designated func(name, query string) bool
+	// QueueSize bounds the number of reads waiting to be shadowed, reads are dropped beyond it. defaults to 1024
+	QueueSize int
+	// MaxRecords bounds the number of records kept for comparison, larger primary results are skipped
+	// and larger shadow results are reported as mismatches without being read further. defaults to 10000
+	MaxRecords int
+	// Timeout bounds every shadowed execution, no timeout when zero
+	Timeout time.Duration
+	// OnMismatch, if set, is called for every read whose results differ between both clusters
+	OnMismatch func(Mismatch)
+	// ParamsRedactor hides the parameters of the mismatches, defaults to the Settings.ParamsRedactor of the driver
+	// with EnableReadShadowing, to RedactAllParams otherwise
+	ParamsRedactor ParamsRedactorFn
+}
+
+// Mismatch describes a read that returned different results on the primary and on the shadow cluster
+type Mismatch struct {
+	Name  string
+	Query string
+	// Params are redacted with ReadShadowConfig.ParamsRedactor
+	Params       map[string]interface{}
+	PrimaryCount int
+	ShadowCount  int
+	// Err is set when the shadowed execution failed
+	Err error
+	At  time.Time
+}
+
+// ReadShadowReport summarizes the shadowing activity
+type ReadShadowReport struct {
+	Compared, Mismatched, Failed, Dropped, Skipped int
+	// Mismatches holds the most recent mismatches, oldest first
+	Mismatches []Mismatch
+}
+
+// MismatchRate returns the ratio of compared reads that did not match
+func (r ReadShadowReport) MismatchRate() float64 {
+	if r.Compared == 0 {
+		return 0
+	}
+	return float64(r.Mismatched) / float64(r.Compared)
+}
+
+// ReadShadower replays designated reads against a shadow QueryRunner and compares their normalized results asynchronously.
+// results are compared regardless of record order, and nodes and relationships by labels, types and properties only.
+type ReadShadower struct {
+	config ReadShadowConfig
+	queue  chan shadowedRead
+	done   chan struct{}
+	mutex  sync.Mutex
+	report ReadShadowReport
+	closed bool
+}
+
+type shadowedRead struct {
+	name    string
+	query   string
+	params  map[string]interface{}
+	records []*neo4j.Record
+}
+
+// WithReadShadow designates the query for shadowing when read shadowing is enabled without a Designated predicate
+func WithReadShadow() QueryOption {
+	return func(options *queryOptions) {
+		options.readShadow = true
+	}
+}
+
+// NewReadShadower starts a ReadShadower and its background comparison goroutine
+func NewReadShadower(config ReadShadowConfig) *ReadShadower {
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultMirrorQueueSize
+	}
+	if config.MaxRecords <= 0 {
+		config.MaxRecords = defaultShadowMaxRecords
+	}
+	if config.ParamsRedactor == nil {
+		config.ParamsRedactor = RedactAllParams
+	}
+	shadower := &ReadShadower{
+		config: config,
+		queue:  make(chan shadowedRead, config.QueueSize),
+		done:   make(chan struct{}),
+	}
+	go shadower.run()
+	return shadower
+}
+
+// EnableReadShadowing shadows the designated reads of this driver to config.Shadow until the returned ReadShadower is stopped
+func (d *Driver) EnableReadShadowing(config ReadShadowConfig) *ReadShadower {
+	if config.ParamsRedactor == nil {
+		config.ParamsRedactor = d.redact
+	}
+	shadower := NewReadShadower(config)
+	d.accessLock.Lock()
+	defer d.accessLock.Unlock()
+	d.readShadower = shadower
+	return shadower
+}
+
+// Shadow queues a read that succeeded on the primary along with the records it returned, it never blocks
+func (r *ReadShadower) Shadow(name, query string, params map[string]interface{}, records []*neo4j.Record) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.closed {
+		return
+	}
+	if len(records) > r.config.MaxRecords {
+		r.report.Skipped++
+		return
+	}
+	select {
+	case r.queue <- shadowedRead{name: name, query: query, params: params, records: records}:
+	default:
+		r.report.Dropped++
+	}
+}
+
+// Report returns a snapshot of the shadowing activity
+func (r *ReadShadower) Report() ReadShadowReport {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	report := r.report
+	report.Mismatches = append([]Mismatch(nil), r.report.Mismatches...)
+	return report
+}
+
+// Stop stops accepting reads and waits for the queued ones to be compared, or for ctx to be done
+func (r *ReadShadower) Stop(ctx context.Context) error {
+	r.mutex.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+	}
+	r.mutex.Unlock()
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *ReadShadower) designated(name, query string, options *queryOptions) bool {
+	if r.config.Designated == nil {
+		return options.readShadow
+	}
+	return options.readShadow || r.config.Designated(name, query)
+}
+
+// tee wraps onResults so that the records of the primary execution are captured into records.
+// at most one record past ReadShadowConfig.MaxRecords is captured, for Shadow to skip the read
+func (r *ReadShadower) tee(ctx context.Context, onResults ResultsHookFn, records *[]*neo4j.Record) ResultsHookFn {
+	return func(result neo4j.ResultWithContext) error {
+		tee := &teeResult{ResultWithContext: result, limit: r.config.MaxRecords}
+		if err := onResults(tee); err != nil {
+			return err
+		}
+		if err := tee.drain(ctx); err != nil {
+			return err
+		}
+		*records = tee.records
+		return nil
+	}
+}
+
+func (r *ReadShadower) run() {
+	defer close(r.done)
+	for read := range r.queue {
+		shadowRecords, err := r.execute(read)
+		primary, shadow := normalizeRecords(read.records), normalizeRecords(shadowRecords)
+		mismatch := Mismatch{Name: read.name, Query: read.query, Params: r.config.ParamsRedactor(read.params), PrimaryCount: len(primary), ShadowCount: len(shadow), Err: err, At: time.Now()}
+
+		r.mutex.Lock()
+		matched := false
+		if err != nil {
+			r.report.Failed++
+		} else {
+			r.report.Compared++
+			matched = equalStrings(primary, shadow)
+			if !matched {
+				r.report.Mismatched++
+			}
+		}
+		if !matched {
+			r.report.Mismatches = append(r.report.Mismatches, mismatch)
+			if len(r.report.Mismatches) > maxRecordedDivergences {
+				r.report.Mismatches = r.report.Mismatches[1:]
+			}
+		}
+		r.mutex.Unlock()
+
+		if !matched && r.config.OnMismatch != nil {
+			r.config.OnMismatch(mismatch)
+		}
+	}
+}
+
+func (r *ReadShadower) execute(read shadowedRead) ([]*neo4j.Record, error) {
+	ctx := context.Background()
+	if r.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.config.Timeout)
+		defer cancel()
+	}
+	var records []*neo4j.Record
+	err := r.config.Shadow.ExecuteQuery(ctx, read.query, read.params, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		// one record past the limit tells the results apart, as the primary one has at most MaxRecords
+		for len(records) <= r.config.MaxRecords && result.NextRecord(ctx, &record) {
+			records = append(records, record)
+		}
+		return result.Err()
+	}, WithQueryName(read.name), WithAccessMode(neo4j.AccessModeRead))
+	return records, err
+}
+
+func equalStrings(left, right []string) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for i := range left {
+		if left[i] != right[i] {
+			return false
+		}
+	}
+	return true
+}
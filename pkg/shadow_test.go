@@ -0,0 +1,137 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestReadShadowing(t *testing.T) {
+	suite.Run(t, new(ReadShadowTestSuite))
+}
+
+type ReadShadowTestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *ReadShadowTestSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+func userRecord(elementId, name string) *neo4j.Record {
+	return &neo4j.Record{
+		Keys:   []string{"user"},
+		Values: []any{neo4j.Node{ElementId: elementId, Labels: []string{"User"}, Props: map[string]any{"name": name}}},
+	}
+}
+
+func (s *ReadShadowTestSuite) TestMatchingResultsRegardlessOfOrderAndIds() {
+	shadow := &fakeRunner{records: []*neo4j.Record{userRecord("shadow:2", "bob"), userRecord("shadow:1", "alice")}}
+	shadower := NewReadShadower(ReadShadowConfig{Shadow: shadow})
+
+	shadower.Shadow("list-users", "MATCH (user:User) RETURN user", nil, []*neo4j.Record{userRecord("4:1", "alice"), userRecord("4:2", "bob")})
+	s.Require().NoError(shadower.Stop(s.ctx))
+
+	report := shadower.Report()
+	s.Equal(1, report.Compared)
+	s.Equal(0, report.Mismatched)
+	s.Equal(0.0, report.MismatchRate())
+}
+
+func (s *ReadShadowTestSuite) TestReportsMismatches() {
+	shadow := &fakeRunner{records: []*neo4j.Record{userRecord("shadow:1", "alice")}}
+	var notified []Mismatch
+	shadower := NewReadShadower(ReadShadowConfig{Shadow: shadow, OnMismatch: func(mismatch Mismatch) {
+		notified = append(notified, mismatch)
+	}})
+
+	shadower.Shadow("list-users", "MATCH (user:User) RETURN user", nil, []*neo4j.Record{userRecord("4:1", "alice"), userRecord("4:2", "bob")})
+	shadower.Shadow("list-users", "MATCH (user:User) RETURN user", nil, []*neo4j.Record{userRecord("4:1", "alice")})
+	s.Require().NoError(shadower.Stop(s.ctx))
+
+	report := shadower.Report()
+	s.Equal(2, report.Compared)
+	s.Equal(1, report.Mismatched)
+	s.Equal(0.5, report.MismatchRate())
+	s.Require().Len(report.Mismatches, 1)
+	s.Equal(2, report.Mismatches[0].PrimaryCount)
+	s.Equal(1, report.Mismatches[0].ShadowCount)
+	s.Equal(report.Mismatches, notified)
+}
+
+func (s *ReadShadowTestSuite) TestCountsShadowFailuresSeparately() {
+	shadow := &fakeRunner{err: errors.New("shadow unavailable")}
+	shadower := NewReadShadower(ReadShadowConfig{Shadow: shadow})
+
+	shadower.Shadow("list-users", "MATCH (user:User) RETURN user", nil, nil)
+	s.Require().NoError(shadower.Stop(s.ctx))
+
+	report := shadower.Report()
+	s.Equal(0, report.Compared)
+	s.Equal(1, report.Failed)
+}
+
+func (s *ReadShadowTestSuite) TestSkipsLargeResults() {
+	shadow := &fakeRunner{}
+	shadower := NewReadShadower(ReadShadowConfig{Shadow: shadow, MaxRecords: 1})
+
+	shadower.Shadow("list-users", "MATCH (user:User) RETURN user", nil, []*neo4j.Record{userRecord("4:1", "alice"), userRecord("4:2", "bob")})
+	s.Require().NoError(shadower.Stop(s.ctx))
+
+	s.Equal(1, shadower.Report().Skipped)
+	s.Empty(shadow.executed())
+}
+
+func (s *ReadShadowTestSuite) TestRedactsTheParamsOfMismatches() {
+	shadow := &fakeRunner{}
+	shadower := NewReadShadower(ReadShadowConfig{Shadow: shadow})
+
+	shadower.Shadow("get-user", "MATCH (user:User {email: $email}) RETURN user", map[string]interface{}{"email": "alice@example.com"}, []*neo4j.Record{userRecord("4:1", "alice")})
+	s.Require().NoError(shadower.Stop(s.ctx))
+
+	report := shadower.Report()
+	s.Require().Len(report.Mismatches, 1)
+	s.Equal(map[string]interface{}{"email": RedactedValue}, report.Mismatches[0].Params)
+}
+
+func (s *ReadShadowTestSuite) TestStopsReadingLargeShadowResults() {
+	shadow := &fakeRunner{records: []*neo4j.Record{userRecord("shadow:1", "alice"), userRecord("shadow:2", "bob"), userRecord("shadow:3", "carol")}}
+	shadower := NewReadShadower(ReadShadowConfig{Shadow: shadow, MaxRecords: 1})
+
+	shadower.Shadow("list-users", "MATCH (user:User) RETURN user", nil, []*neo4j.Record{userRecord("4:1", "alice")})
+	s.Require().NoError(shadower.Stop(s.ctx))
+
+	report := shadower.Report()
+	s.Require().Len(report.Mismatches, 1)
+	s.Equal(2, report.Mismatches[0].ShadowCount, "the shadow records past the limit must not be read")
+}
+
+func (s *ReadShadowTestSuite) TestSkipsLargeResultsOfTheDriver() {
+	cluster := &fakeCluster{streamed: 1_000_000}
+	restore := UseDriverFactory(cluster.newDriver)
+	defer restore()
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	defer driver.Close(s.ctx)
+	shadow := &fakeRunner{}
+	shadower := driver.EnableReadShadowing(ReadShadowConfig{Shadow: shadow, MaxRecords: 10})
+
+	var captured []*neo4j.Record
+	err = driver.ExecuteQuery(s.ctx, "MATCH (n) RETURN n", nil, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		for len(captured) < 100 && result.NextRecord(s.ctx, &record) {
+			captured = append(captured, record)
+		}
+		return result.Err()
+	}, WithAccessMode(neo4j.AccessModeRead), WithReadShadow())
+	s.Require().NoError(err)
+	s.Require().NoError(shadower.Stop(s.ctx))
+
+	s.Len(captured, 100, "the hook reads the whole results it asks for")
+	s.Equal(1, shadower.Report().Skipped)
+	s.Empty(shadow.executed())
+}
@@ -0,0 +1,22 @@
+package driver
+
+import "context"
+
+// Shutdown stops accepting new queries immediately, they fail with ErrDriverClosed.
+// it then waits for in-flight queries until ctx is done, and closes the underlying connections.
+// if ctx is done before in-flight queries complete, connections are force-closed and ctx.Err() is returned.
+func (d *Driver) Shutdown(ctx context.Context) error {
+	drained := d.lifecycle.close()
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	d.recoveryLock.Lock()
+	defer d.recoveryLock.Unlock()
+	d.nonblockClose(context.Background())
+	d.closeStandby(context.Background())
+	d.persistCache()
+	return err
+}
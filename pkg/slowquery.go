@@ -0,0 +1,52 @@
+package driver
+
+import (
+	"context"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"time"
+)
+
+// SlowQuery describes a query execution that exceeded Settings.SlowQueryThreshold
+type SlowQuery struct {
+	Name  string
+	Query string
+	// Params are redacted with Settings.ParamsRedactor
+	Params   map[string]interface{}
+	Duration time.Duration
+	// Retries is the number of attempts beyond the first one
+	Retries int
+	// Summary is the server-reported result summary, nil if it could not be retrieved
+	Summary neo4j.ResultSummary
+	// Tags are extracted from the context by Settings.ContextTagger
+	Tags map[string]interface{}
+}
+
+// SlowQueryHandlerFn is called for every slow query
+type SlowQueryHandlerFn func(ctx context.Context, slowQuery SlowQuery)
+
+// detectSlowQuery reports the query if it exceeded the threshold. it must be called once the hook is done with the result,
+// as it consumes what's left of it to retrieve the summary.
+func (d *Driver) detectSlowQuery(ctx context.Context, query string, params map[string]interface{}, result neo4j.ResultWithContext, options *queryOptions) {
+	threshold := d.settings.SlowQueryThreshold
+	if threshold <= 0 {
+		return
+	}
+	duration := time.Since(options.start)
+	if duration < threshold {
+		return
+	}
+	summary := options.resultSummary(ctx, result)
+	slowQuery := SlowQuery{
+		Name:     options.name,
+		Query:    query,
+		Params:   d.redact(params),
+		Duration: duration,
+		Retries:  options.stats.Attempts - 1,
+		Summary:  summary,
+		Tags:     options.tags,
+	}
+	d.logger().Printf("[neo4j] slow query %q took %s (threshold %s, retries %d), params: %v%s", displayName(options.name, query), duration, threshold, slowQuery.Retries, slowQuery.Params, options.tagSuffix())
+	if d.settings.OnSlowQuery != nil {
+		d.settings.OnSlowQuery(ctx, slowQuery)
+	}
+}
@@ -0,0 +1,95 @@
+package driver
+
+import (
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"strconv"
+	"strings"
+)
+
+// Spatial reference identifiers of the coordinate systems supported by Neo4j
+const (
+	SRIDCartesian   uint32 = 7203
+	SRIDCartesian3D uint32 = 9157
+	SRIDWGS84       uint32 = 4326
+	SRIDWGS843D     uint32 = 4979
+)
+
+// CartesianPoint returns a 2D point in the cartesian coordinate system
+func CartesianPoint(x, y float64) neo4j.Point2D {
+	return neo4j.Point2D{X: x, Y: y, SpatialRefId: SRIDCartesian}
+}
+
+// CartesianPoint3D returns a 3D point in the cartesian coordinate system
+func CartesianPoint3D(x, y, z float64) neo4j.Point3D {
+	return neo4j.Point3D{X: x, Y: y, Z: z, SpatialRefId: SRIDCartesian3D}
+}
+
+// GeographicPoint returns a WGS-84 point, x being the longitude and y the latitude
+func GeographicPoint(longitude, latitude float64) neo4j.Point2D {
+	return neo4j.Point2D{X: longitude, Y: latitude, SpatialRefId: SRIDWGS84}
+}
+
+// GeographicPoint3D returns a WGS-84 point with a height in meters
+func GeographicPoint3D(longitude, latitude, height float64) neo4j.Point3D {
+	return neo4j.Point3D{X: longitude, Y: latitude, Z: height, SpatialRefId: SRIDWGS843D}
+}
+
+// ParsePoint parses a WKT point, e.g. POINT(2.35 48.85) or POINT Z(1 2 3), and returns either a neo4j.Point2D or a neo4j.Point3D.
+// srid defaults to the cartesian coordinate system of the matching dimension when zero
+func ParsePoint(wkt string, srid uint32) (interface{}, error) {
+	body := strings.TrimSpace(wkt)
+	if !strings.HasPrefix(strings.ToUpper(body), "POINT") {
+		return nil, fmt.Errorf("invalid WKT point %q", wkt)
+	}
+	body = strings.TrimSpace(body[len("POINT"):])
+	if strings.HasPrefix(strings.ToUpper(body), "Z") {
+		body = strings.TrimSpace(body[1:])
+	}
+	if !strings.HasPrefix(body, "(") || !strings.HasSuffix(body, ")") {
+		return nil, fmt.Errorf("invalid WKT point %q", wkt)
+	}
+	fields := strings.Fields(body[1 : len(body)-1])
+	coordinates := make([]float64, len(fields))
+	for i, field := range fields {
+		coordinate, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WKT point %q: %w", wkt, err)
+		}
+		coordinates[i] = coordinate
+	}
+	switch len(coordinates) {
+	case 2:
+		if srid == 0 {
+			srid = SRIDCartesian
+		}
+		return neo4j.Point2D{X: coordinates[0], Y: coordinates[1], SpatialRefId: srid}, nil
+	case 3:
+		if srid == 0 {
+			srid = SRIDCartesian3D
+		}
+		return neo4j.Point3D{X: coordinates[0], Y: coordinates[1], Z: coordinates[2], SpatialRefId: srid}, nil
+	default:
+		return nil, fmt.Errorf("invalid WKT point %q: expected 2 or 3 coordinates", wkt)
+	}
+}
+
+// PointToWKT formats a neo4j.Point2D or neo4j.Point3D as WKT, the inverse of ParsePoint
+func PointToWKT(point interface{}) (string, error) {
+	switch point := point.(type) {
+	case neo4j.Point2D:
+		return "POINT(" + formatCoordinates(point.X, point.Y) + ")", nil
+	case neo4j.Point3D:
+		return "POINT Z(" + formatCoordinates(point.X, point.Y, point.Z) + ")", nil
+	default:
+		return "", fmt.Errorf("%T is not a point", point)
+	}
+}
+
+func formatCoordinates(coordinates ...float64) string {
+	formatted := make([]string, len(coordinates))
+	for i, coordinate := range coordinates {
+		formatted[i] = strconv.FormatFloat(coordinate, 'f', -1, 64)
+	}
+	return strings.Join(formatted, " ")
+}
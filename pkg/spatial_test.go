@@ -0,0 +1,49 @@
+package driver_test
+
+import (
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestSpatial(t *testing.T) {
+	suite.Run(t, new(SpatialTestSuite))
+}
+
+type SpatialTestSuite struct {
+	suite.Suite
+}
+
+func (s *SpatialTestSuite) TestBuildsPoints() {
+	s.Equal(neo4j.Point2D{X: 2.35, Y: 48.85, SpatialRefId: 4326}, GeographicPoint(2.35, 48.85))
+	s.Equal(neo4j.Point3D{X: 1, Y: 2, Z: 3, SpatialRefId: 9157}, CartesianPoint3D(1, 2, 3))
+}
+
+func (s *SpatialTestSuite) TestParsesWKTPoints() {
+	point, err := ParsePoint("POINT(2.35 48.85)", SRIDWGS84)
+	s.NoError(err)
+	s.Equal(GeographicPoint(2.35, 48.85), point)
+
+	point, err = ParsePoint(" point z (1 2 3) ", 0)
+	s.NoError(err)
+	s.Equal(CartesianPoint3D(1, 2, 3), point)
+
+	for _, invalid := range []string{"LINESTRING(1 2, 3 4)", "POINT(1)", "POINT(a b)", "POINT 1 2"} {
+		_, err := ParsePoint(invalid, 0)
+		s.Error(err, invalid)
+	}
+}
+
+func (s *SpatialTestSuite) TestFormatsWKTPoints() {
+	wkt, err := PointToWKT(GeographicPoint(2.35, 48.85))
+	s.NoError(err)
+	s.Equal("POINT(2.35 48.85)", wkt)
+
+	wkt, err = PointToWKT(CartesianPoint3D(1, 2, 3))
+	s.NoError(err)
+	s.Equal("POINT Z(1 2 3)", wkt)
+
+	_, err = PointToWKT("POINT(1 2)")
+	s.Error(err)
+}
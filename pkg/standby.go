@@ -0,0 +1,125 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNoStandby is returned by Cutover when no standby was prepared with PrepareStandby
+var ErrNoStandby = errors.New("no standby driver prepared")
+
+// cutoverDrainPollInterval is the delay between two checks of the queries still running on the previous driver
+const cutoverDrainPollInterval = 10 * time.Millisecond
+
+// StandbyConfig describes the target of a blue/green database cutover, see Driver.PrepareStandby
+type StandbyConfig struct {
+	// ConnectionString is the new target, with the same format and options as Settings.ConnectionString.
+	// the credentials of Settings take precedence over the ones it embeds, like for NewDriver
+	ConnectionString string
+	// WarmUp configures the connections established before PrepareStandby returns, only connectivity is verified by default
+	WarmUp WarmUpConfig
+}
+
+// standby is the pre-warmed driver Cutover switches traffic to
+type standby struct {
+	mutex      sync.Mutex
+	generation *driverGeneration
+}
+
+// PrepareStandby creates and warms up a driver against a new target while the current one keeps serving the queries,
+// so that Cutover switches traffic without paying the connection establishment. preparing again replaces the standby
+func (d *Driver) PrepareStandby(ctx context.Context, config StandbyConfig) error {
+	if d.lifecycle.isClosed() {
+		return ErrDriverClosed
+	}
+	settings := d.settings
+	settings.ConnectionString = config.ConnectionString
+	underlying, err := newUnderlyingConfig(settings, d.identity)
+	if err != nil {
+		return err
+	}
+	driver, err := underlying.newDriver()
+	if err != nil {
+		return err
+	}
+	if err := d.warmUpDriver(ctx, driver, config.WarmUp); err != nil {
+		driver.Close(ctx)
+		return err
+	}
+	d.standby.mutex.Lock()
+	previous := d.standby.generation
+	d.standby.generation = &driverGeneration{driver: driver, config: underlying}
+	d.standby.mutex.Unlock()
+	if previous != nil {
+		previous.driver.Close(ctx)
+	}
+	return nil
+}
+
+// Cutover switches the traffic to the standby prepared with PrepareStandby: queries started afterwards, and the reconnects,
+// use the new target. the previous driver is closed once the queries still running on it are done, or once ctx is done,
+// in which case they are cut and ctx.Err() is returned
+func (d *Driver) Cutover(ctx context.Context) error {
+	d.standby.mutex.Lock()
+	next := d.standby.generation
+	d.standby.generation = nil
+	d.standby.mutex.Unlock()
+	if next == nil {
+		return ErrNoStandby
+	}
+	if err := d.lockRecovery(ctx); err != nil {
+		d.restoreStandby(ctx, next)
+		return err
+	}
+	if d.lifecycle.isClosed() {
+		d.recoveryLock.Unlock()
+		next.driver.Close(ctx)
+		return ErrDriverClosed
+	}
+	previous := d.swapDriver(next.driver, next.config)
+	d.recoveryLock.Unlock()
+	d.events.record("cutover", "switched traffic from %s to %s", previous.config.info.Address(), next.config.info.Address())
+
+	err := drain(ctx, previous)
+	d.closeGeneration(ctx, previous)
+	return err
+}
+
+// restoreStandby puts back a standby Cutover could not switch to, unless another one was prepared meanwhile
+func (d *Driver) restoreStandby(ctx context.Context, generation *driverGeneration) {
+	d.standby.mutex.Lock()
+	if d.standby.generation == nil {
+		d.standby.generation = generation
+		generation = nil
+	}
+	d.standby.mutex.Unlock()
+	if generation != nil {
+		generation.driver.Close(ctx)
+	}
+}
+
+// drain waits for the in-flight queries of generation to be done, or for ctx to be done
+func drain(ctx context.Context, generation *driverGeneration) error {
+	ticker := time.NewTicker(cutoverDrainPollInterval)
+	defer ticker.Stop()
+	for generation.inFlight.Load() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (d *Driver) closeStandby(ctx context.Context) {
+	d.standby.mutex.Lock()
+	generation := d.standby.generation
+	d.standby.generation = nil
+	d.standby.mutex.Unlock()
+	if generation != nil {
+		generation.driver.Close(ctx)
+	}
+}
@@ -0,0 +1,116 @@
+package driver_test
+
+import (
+	"context"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStandby(t *testing.T) {
+	suite.Run(t, new(StandbyTestSuite))
+}
+
+type StandbyTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	cluster *fakeCluster
+	mutex   sync.Mutex
+	targets []string
+	restore func()
+	driver  *Driver
+}
+
+func (s *StandbyTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.cluster = &fakeCluster{}
+	s.targets = nil
+	s.restore = UseDriverFactory(func(target string, auth neo4j.AuthToken, configurers ...func(*neo4j.Config)) (neo4j.DriverWithContext, error) {
+		s.mutex.Lock()
+		s.targets = append(s.targets, target)
+		s.mutex.Unlock()
+		return s.cluster.newDriver(target, auth, configurers...)
+	})
+	settings := connectionSettings
+	settings.ConnectionString = "neo4j://blue.example.com"
+	driver, err := NewDriver(settings)
+	s.Require().NoError(err)
+	s.driver = driver
+}
+
+func (s *StandbyTestSuite) TearDownTest() {
+	s.driver.Close(s.ctx)
+	s.restore()
+}
+
+func (s *StandbyTestSuite) TestSwitchesTrafficToTheStandby() {
+	s.Require().NoError(s.driver.PrepareStandby(s.ctx, StandbyConfig{ConnectionString: "neo4j://green.example.com"}))
+	s.Equal("blue.example.com", s.driver.ConnectionInfo().Host, "traffic must not switch before the cutover")
+
+	s.Require().NoError(s.driver.Cutover(s.ctx))
+	s.cluster.disconnect()
+	s.Require().NoError(executeSimpleQuery(s.ctx, s.driver))
+
+	s.Equal("green.example.com", s.driver.ConnectionInfo().Host)
+	s.Equal([]string{"neo4j://blue.example.com", "neo4j://green.example.com", "neo4j://green.example.com"}, s.targets,
+		"the reconnects after the cutover must target the new endpoint")
+}
+
+func (s *StandbyTestSuite) TestDrainsTheQueriesOfThePreviousDriver() {
+	s.Require().NoError(s.driver.PrepareStandby(s.ctx, StandbyConfig{ConnectionString: "neo4j://green.example.com"}))
+	started, release := make(chan struct{}), make(chan struct{})
+	queryDone := make(chan error)
+	go func() {
+		queryDone <- s.driver.ExecuteQuery(s.ctx, "RETURN true AS ok", nil, func(neo4j.ResultWithContext) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	cutoverDone := make(chan error)
+	go func() {
+		cutoverDone <- s.driver.Cutover(s.ctx)
+	}()
+	s.Require().Eventually(func() bool {
+		return s.driver.ConnectionInfo().Host == "green.example.com"
+	}, time.Second, time.Millisecond)
+	s.Require().NoError(executeSimpleQuery(s.ctx, s.driver), "new queries must not wait for the drain")
+	select {
+	case <-cutoverDone:
+		s.Fail("the cutover must wait for the in-flight query")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	s.NoError(<-queryDone)
+	s.NoError(<-cutoverDone)
+}
+
+func (s *StandbyTestSuite) TestCutsTheQueriesStillRunningOnceCancelled() {
+	s.Require().NoError(s.driver.PrepareStandby(s.ctx, StandbyConfig{ConnectionString: "neo4j://green.example.com"}))
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	go func() {
+		_ = s.driver.ExecuteQuery(s.ctx, "RETURN true AS ok", nil, func(neo4j.ResultWithContext) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(s.ctx, 20*time.Millisecond)
+	defer cancel()
+
+	s.ErrorIs(s.driver.Cutover(ctx), context.DeadlineExceeded)
+	s.Equal("green.example.com", s.driver.ConnectionInfo().Host)
+}
+
+func (s *StandbyTestSuite) TestRequiresAStandby() {
+	s.ErrorIs(s.driver.Cutover(s.ctx), ErrNoStandby)
+}
@@ -0,0 +1,24 @@
+package driver
+
+import "time"
+
+// QueryStats reports how a single query execution went.
+// it lets integration tests assert the resilience behavior, e.g. that a query succeeded on its first attempt
+// or required exactly one reconnect.
+type QueryStats struct {
+	// Attempts is the number of times the query was sent to the server
+	Attempts int
+	// Reconnects is the number of connection recoveries the execution went through before its last attempt
+	Reconnects int
+	// RoutingRefreshes is the number of times the query was replayed after a cluster topology change, e.g. a leader switch
+	RoutingRefreshes int
+	// QueueWait is the time spent waiting for a slot when Settings.MaxConcurrentQueries is set
+	QueueWait time.Duration
+}
+
+// WithQueryStats fills stats once the query execution ends, whether it succeeded or not
+func WithQueryStats(stats *QueryStats) QueryOption {
+	return func(options *queryOptions) {
+		options.statsSink = stats
+	}
+}
@@ -0,0 +1,140 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"reflect"
+	"time"
+)
+
+// RecordChangeKind tells how a record of a subscribed query changed between two executions
+type RecordChangeKind int
+
+const (
+	RecordAdded RecordChangeKind = iota
+	RecordRemoved
+	RecordChanged
+)
+
+func (k RecordChangeKind) String() string {
+	switch k {
+	case RecordAdded:
+		return "added"
+	case RecordRemoved:
+		return "removed"
+	case RecordChanged:
+		return "changed"
+	}
+	return fmt.Sprintf("RecordChangeKind(%d)", int(k))
+}
+
+// RecordChange is a difference between two executions of a subscribed query
+type RecordChange struct {
+	Kind RecordChangeKind
+	// Key is the value identifying the record, see Subscribe
+	Key interface{}
+	// Record is the current record, nil when removed. Previous is the former record, nil when added
+	Record, Previous *neo4j.Record
+}
+
+// SubscriptionHandlerFn processes the changes of an execution of a subscribed query, in the order of the records
+// followed by the removals. an error stops the subscription
+type SubscriptionHandlerFn func(ctx context.Context, changes []RecordChange) error
+
+// Subscribe runs the read query every interval until ctx is done, and hands the records added, removed or changed
+// since the previous execution to handler, all of them being added on the first execution. handler is not called
+// when nothing changed.
+// records are identified by the value of their first column, e.g. an id, or the element id of a node or a relationship,
+// and changed when any of their other values differs. records sharing a key are collapsed into the last one.
+// it returns the error of handler or of a failed execution, or ctx.Err()
+func (d *Driver) Subscribe(ctx context.Context, query string, params map[string]interface{}, interval time.Duration, handler SubscriptionHandlerFn, opts ...QueryOption) error {
+	return SubscribeWith(ctx, d, query, params, interval, handler, opts...)
+}
+
+// SubscribeWith subscribes to the query run by runner, e.g. a transaction-bound or a mock runner, see Subscribe
+func SubscribeWith(ctx context.Context, runner QueryRunner, query string, params map[string]interface{}, interval time.Duration, handler SubscriptionHandlerFn, opts ...QueryOption) error {
+	opts = append([]QueryOption{WithAccessMode(neo4j.AccessModeRead)}, opts...)
+	var previous *recordSnapshot
+	for {
+		var current *recordSnapshot
+		err := runner.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) error {
+			var err error
+			current, err = snapshotRecords(ctx, result)
+			return err
+		}, opts...)
+		if err != nil {
+			return err
+		}
+		if changes := current.diff(previous); len(changes) > 0 {
+			if err := handler(ctx, changes); err != nil {
+				return err
+			}
+		}
+		previous = current
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// recordSnapshot holds the records of an execution by key, in the order they were read
+type recordSnapshot struct {
+	keys    []interface{}
+	records map[interface{}]*neo4j.Record
+}
+
+func snapshotRecords(ctx context.Context, result RecordIterator) (*recordSnapshot, error) {
+	snapshot := &recordSnapshot{records: map[interface{}]*neo4j.Record{}}
+	var record *neo4j.Record
+	for result.NextRecord(ctx, &record) {
+		if len(record.Values) == 0 {
+			continue
+		}
+		key := recordKey(record.Values[0])
+		if _, found := snapshot.records[key]; !found {
+			snapshot.keys = append(snapshot.keys, key)
+		}
+		snapshot.records[key] = record
+	}
+	return snapshot, result.Err()
+}
+
+func (s *recordSnapshot) diff(previous *recordSnapshot) []RecordChange {
+	if previous == nil {
+		previous = &recordSnapshot{}
+	}
+	var changes []RecordChange
+	for _, key := range s.keys {
+		record := s.records[key]
+		former, found := previous.records[key]
+		switch {
+		case !found:
+			changes = append(changes, RecordChange{Kind: RecordAdded, Key: key, Record: record})
+		case !reflect.DeepEqual(record.Values, former.Values):
+			changes = append(changes, RecordChange{Kind: RecordChanged, Key: key, Record: record, Previous: former})
+		}
+	}
+	for _, key := range previous.keys {
+		if _, found := s.records[key]; !found {
+			changes = append(changes, RecordChange{Kind: RecordRemoved, Key: key, Previous: previous.records[key]})
+		}
+	}
+	return changes
+}
+
+// recordKey returns a comparable key for the value identifying a record
+func recordKey(value interface{}) interface{} {
+	switch value := value.(type) {
+	case neo4j.Node:
+		return value.ElementId
+	case neo4j.Relationship:
+		return value.ElementId
+	}
+	if value == nil || reflect.TypeOf(value).Comparable() {
+		return value
+	}
+	return fmt.Sprintf("%#v", value)
+}
@@ -0,0 +1,88 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	suite.Run(t, new(SubscribeTestSuite))
+}
+
+type SubscribeTestSuite struct {
+	suite.Suite
+	ctx  context.Context
+	mock *MockDriver
+	keys []string
+}
+
+func (s *SubscribeTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mock = NewMockDriver()
+	s.keys = []string{"id", "status"}
+}
+
+func (s *SubscribeTestSuite) TestEmitsTheDifferencesBetweenExecutions() {
+	query := "MATCH (o:Order) RETURN o.id AS id, o.status AS status"
+	s.mock.ExpectQuery(`^MATCH \(o:Order\)`).WillReturn(s.keys, []any{int64(1), "new"}, []any{int64(2), "new"}).Times(2)
+	s.mock.ExpectQuery(`^MATCH \(o:Order\)`).WillReturn(s.keys, []any{int64(2), "paid"}, []any{int64(3), "new"})
+	var executions [][]RecordChange
+
+	err := SubscribeWith(s.ctx, s.mock, query, nil, time.Millisecond, func(_ context.Context, changes []RecordChange) error {
+		executions = append(executions, changes)
+		return nil
+	})
+
+	s.ErrorIs(err, ErrUnexpectedQuery)
+	s.Require().Len(executions, 2)
+	s.Equal([]RecordChangeKind{RecordAdded, RecordAdded}, kinds(executions[0]))
+	s.Equal([]interface{}{int64(1), int64(2)}, changedKeys(executions[0]))
+	s.Equal([]RecordChangeKind{RecordChanged, RecordAdded, RecordRemoved}, kinds(executions[1]))
+	s.Equal([]interface{}{int64(2), int64(3), int64(1)}, changedKeys(executions[1]))
+	s.Equal([]any{int64(2), "new"}, executions[1][0].Previous.Values)
+	s.Equal([]any{int64(2), "paid"}, executions[1][0].Record.Values)
+	s.Nil(executions[1][2].Record)
+}
+
+func (s *SubscribeTestSuite) TestStopsWhenTheHandlerFails() {
+	s.mock.ExpectQuery("RETURN").WillReturn(s.keys, []any{int64(1), "new"})
+
+	err := SubscribeWith(s.ctx, s.mock, "RETURN 1 AS id, 'new' AS status", nil, time.Hour, func(context.Context, []RecordChange) error {
+		return errors.New("dashboard closed")
+	})
+
+	s.EqualError(err, "dashboard closed")
+}
+
+func (s *SubscribeTestSuite) TestStopsWhenTheContextIsDone() {
+	s.mock.ExpectQuery("RETURN").WillReturn(s.keys).Times(2)
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Millisecond)
+	defer cancel()
+
+	err := SubscribeWith(ctx, s.mock, "RETURN 1 AS id, 'new' AS status LIMIT 0", nil, time.Hour, func(context.Context, []RecordChange) error {
+		s.Fail("nothing changed")
+		return nil
+	})
+
+	s.ErrorIs(err, context.DeadlineExceeded)
+}
+
+func kinds(changes []RecordChange) []RecordChangeKind {
+	result := make([]RecordChangeKind, len(changes))
+	for i, change := range changes {
+		result[i] = change.Kind
+	}
+	return result
+}
+
+func changedKeys(changes []RecordChange) []interface{} {
+	result := make([]interface{}, len(changes))
+	for i, change := range changes {
+		result[i] = change.Key
+	}
+	return result
+}
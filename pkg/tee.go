@@ -0,0 +1,65 @@
+package driver
+
+import (
+	"context"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// teeResult keeps a copy of every record the hook reads from the wrapped result
+type teeResult struct {
+	neo4j.ResultWithContext
+	records []*neo4j.Record
+	// limit, if positive, bounds the copy: it stops growing once it holds more than limit records
+	limit int
+}
+
+// keep appends records to the copy, up to one record past the limit
+func (t *teeResult) keep(records ...*neo4j.Record) {
+	if t.limit > 0 && len(t.records)+len(records) > t.limit+1 {
+		records = records[:t.limit+1-len(t.records)]
+	}
+	t.records = append(t.records, records...)
+}
+
+// full reports whether the copy stopped growing, the result having more records than the limit
+func (t *teeResult) full() bool {
+	return t.limit > 0 && len(t.records) > t.limit
+}
+
+func (t *teeResult) NextRecord(ctx context.Context, record **neo4j.Record) bool {
+	if !t.ResultWithContext.NextRecord(ctx, record) {
+		return false
+	}
+	t.keep(*record)
+	return true
+}
+
+func (t *teeResult) Next(ctx context.Context) bool {
+	if !t.ResultWithContext.Next(ctx) {
+		return false
+	}
+	t.keep(t.ResultWithContext.Record())
+	return true
+}
+
+func (t *teeResult) Collect(ctx context.Context) ([]*neo4j.Record, error) {
+	records, err := t.ResultWithContext.Collect(ctx)
+	t.keep(records...)
+	return records, err
+}
+
+func (t *teeResult) Single(ctx context.Context) (*neo4j.Record, error) {
+	record, err := t.ResultWithContext.Single(ctx)
+	if record != nil {
+		t.keep(record)
+	}
+	return record, err
+}
+
+// drain reads the records the hook left behind, so that the copy covers the whole result, or until the copy is full
+func (t *teeResult) drain(ctx context.Context) error {
+	var record *neo4j.Record
+	for !t.full() && t.NextRecord(ctx, &record) {
+	}
+	return t.Err()
+}
@@ -0,0 +1,42 @@
+package driver
+
+import (
+	"errors"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"time"
+)
+
+// ErrAmbiguousDuration is returned when converting a Neo4j duration with months to a time.Duration, months having no fixed length
+var ErrAmbiguousDuration = errors.New("duration with months has no fixed length")
+
+// DurationOf converts a time.Duration to a Neo4j duration made of seconds and nanoseconds
+func DurationOf(duration time.Duration) neo4j.Duration {
+	return neo4j.DurationOf(0, 0, int64(duration/time.Second), int(duration%time.Second))
+}
+
+// ToDuration converts a Neo4j duration to a time.Duration, days counting for 24 hours.
+// it returns ErrAmbiguousDuration when the duration has months.
+func ToDuration(duration neo4j.Duration) (time.Duration, error) {
+	if duration.Months != 0 {
+		return 0, ErrAmbiguousDuration
+	}
+	return time.Duration(duration.Days)*24*time.Hour + time.Duration(duration.Seconds)*time.Second + time.Duration(duration.Nanos), nil
+}
+
+// ParseDate parses a Neo4j date formatted with DateLayout
+func ParseDate(value string) (neo4j.Date, error) {
+	parsed, err := time.Parse(DateLayout, value)
+	return neo4j.DateOf(parsed), err
+}
+
+// ParseLocalDateTime parses a Neo4j local date time formatted with LocalDateTimeLayout
+func ParseLocalDateTime(value string) (neo4j.LocalDateTime, error) {
+	parsed, err := time.Parse(LocalDateTimeLayout, value)
+	return neo4j.LocalDateTimeOf(parsed), err
+}
+
+// ParseZonedDateTime parses a Neo4j zoned date time formatted with DateTimeLayout (RFC3339).
+// zoned date times are represented by time.Time
+func ParseZonedDateTime(value string) (time.Time, error) {
+	return time.Parse(DateTimeLayout, value)
+}
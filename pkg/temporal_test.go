@@ -0,0 +1,72 @@
+package driver_test
+
+import (
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestTemporal(t *testing.T) {
+	suite.Run(t, new(TemporalTestSuite))
+}
+
+type TemporalTestSuite struct {
+	suite.Suite
+}
+
+func (s *TemporalTestSuite) TestConvertsDurations() {
+	duration := 90*time.Minute + 250*time.Millisecond
+
+	converted := DurationOf(duration)
+	back, err := ToDuration(converted)
+
+	s.Equal(neo4j.DurationOf(0, 0, 5400, 250000000), converted)
+	s.NoError(err)
+	s.Equal(duration, back)
+
+	back, err = ToDuration(neo4j.DurationOf(0, 2, 30, 0))
+	s.NoError(err)
+	s.Equal(48*time.Hour+30*time.Second, back)
+
+	_, err = ToDuration(neo4j.DurationOf(1, 0, 0, 0))
+	s.ErrorIs(err, ErrAmbiguousDuration)
+}
+
+func (s *TemporalTestSuite) TestParsesTemporalValues() {
+	date, err := ParseDate("2023-03-14")
+	s.NoError(err)
+	s.Equal(neo4j.DateOf(time.Date(2023, 3, 14, 0, 0, 0, 0, time.UTC)), date)
+
+	local, err := ParseLocalDateTime("2023-03-14T15:09:26.5")
+	s.NoError(err)
+	s.Equal(neo4j.LocalDateTimeOf(time.Date(2023, 3, 14, 15, 9, 26, 500000000, time.UTC)), local)
+
+	zoned, err := ParseZonedDateTime("2023-03-14T15:09:26+01:00")
+	s.NoError(err)
+	s.True(zoned.Equal(time.Date(2023, 3, 14, 14, 9, 26, 0, time.UTC)))
+
+	_, err = ParseDate("14/03/2023")
+	s.Error(err)
+}
+
+func (s *TemporalTestSuite) TestCoercesDurationParams() {
+	params := map[string]interface{}{"ttl": time.Second, "nested": []interface{}{map[string]interface{}{"timeout": time.Minute}}, "name": "alice"}
+
+	coerced := CoerceParams(params)
+
+	s.Equal(map[string]interface{}{
+		"ttl":    neo4j.DurationOf(0, 0, 1, 0),
+		"nested": []interface{}{map[string]interface{}{"timeout": neo4j.DurationOf(0, 0, 60, 0)}},
+		"name":   "alice",
+	}, coerced)
+	s.Equal(time.Second, params["ttl"], "params are copied, not mutated")
+}
+
+func (s *TemporalTestSuite) TestKeepsParamsWithoutDurations() {
+	params := map[string]interface{}{"name": "alice"}
+
+	s.Equal(params, CoerceParams(params))
+	s.Nil(CoerceParams(nil))
+}
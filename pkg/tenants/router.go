@@ -0,0 +1,134 @@
+package tenants
+
+import (
+	"context"
+	"errors"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"sync"
+)
+
+// ErrNoTenant is returned by the Router for the queries whose context carries no tenant
+var ErrNoTenant = errors.New("no tenant in context")
+
+type tenantContextKey struct{}
+
+// WithTenant returns a context carrying the tenant read by TenantFromContext
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set with WithTenant, or an empty string
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenant
+}
+
+// RouterConfig configures how a Router finds the database of a query
+type RouterConfig struct {
+	// Tenant extracts the tenant of a query, defaults to TenantFromContext
+	Tenant driver.IdentityExtractorFn
+	// Database maps a tenant to the name of its database, defaults to the tenant itself
+	Database func(tenant string) string
+	// Provisioner, if set, creates the database of a tenant the first time the router executes one of its queries,
+	// see Provisioner.CreateTenant
+	Provisioner *Provisioner
+}
+
+// Router is a driver.QueryExecutor executing every query against the database of the tenant of its context:
+//
+//	router := tenants.NewRouter(d, tenants.RouterConfig{Database: func(tenant string) string { return "tenant-" + tenant }})
+//	err := router.ExecuteQuery(tenants.WithTenant(ctx, "acme"), "MATCH (u:User) RETURN u", nil, hook)
+//
+// the database of the tenant overrides any WithDatabase option, so that a query never reaches the database of another tenant
+type Router struct {
+	executor    driver.QueryExecutor
+	config      RouterConfig
+	mutex       sync.Mutex
+	provisioned map[string]*provisioning
+}
+
+// provisioning is the creation of the database of a tenant, done is closed once it completed
+type provisioning struct {
+	done chan struct{}
+	err  error
+}
+
+// NewRouter creates a router running the queries through executor, typically a *driver.Driver
+func NewRouter(executor driver.QueryExecutor, config RouterConfig) *Router {
+	if config.Tenant == nil {
+		config.Tenant = TenantFromContext
+	}
+	if config.Database == nil {
+		config.Database = func(tenant string) string { return tenant }
+	}
+	return &Router{executor: executor, config: config, provisioned: map[string]*provisioning{}}
+}
+
+// Database returns the database of the tenant of ctx, creating it first when the router has a Provisioner
+func (r *Router) Database(ctx context.Context) (string, error) {
+	tenant := r.config.Tenant(ctx)
+	if tenant == "" {
+		return "", ErrNoTenant
+	}
+	database := r.config.Database(tenant)
+	if r.config.Provisioner == nil {
+		return database, nil
+	}
+	return database, r.provision(ctx, database)
+}
+
+// ExecuteQuery executes the query against the database of the tenant of ctx
+func (r *Router) ExecuteQuery(ctx context.Context, query string, params map[string]interface{}, onResults driver.ResultsHookFn, opts ...driver.QueryOption) error {
+	database, err := r.Database(ctx)
+	if err != nil {
+		return err
+	}
+	return r.executor.ExecuteQuery(ctx, query, params, onResults, append(opts[:len(opts):len(opts)], driver.WithDatabase(database))...)
+}
+
+// ExecuteScript runs the script against the database of the tenant of ctx
+func (r *Router) ExecuteScript(ctx context.Context, script string, opts ...driver.QueryOption) error {
+	database, err := r.Database(ctx)
+	if err != nil {
+		return err
+	}
+	return r.executor.ExecuteScript(ctx, script, append(opts[:len(opts):len(opts)], driver.WithDatabase(database))...)
+}
+
+// VerifyConnectivity checks that the server can be reached
+func (r *Router) VerifyConnectivity(ctx context.Context) error {
+	return r.executor.VerifyConnectivity(ctx)
+}
+
+// Close closes the underlying executor
+func (r *Router) Close(ctx context.Context) {
+	r.executor.Close(ctx)
+}
+
+// provision creates the database once, the concurrent queries of the tenant wait for it.
+// a failed creation is attempted again by the next query
+func (r *Router) provision(ctx context.Context, database string) error {
+	r.mutex.Lock()
+	current, found := r.provisioned[database]
+	if !found {
+		current = &provisioning{done: make(chan struct{})}
+		r.provisioned[database] = current
+	}
+	r.mutex.Unlock()
+	if found {
+		select {
+		case <-current.done:
+			return current.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	current.err = r.config.Provisioner.CreateTenant(ctx, database)
+	if current.err != nil {
+		r.mutex.Lock()
+		delete(r.provisioned, database)
+		r.mutex.Unlock()
+	}
+	close(current.done)
+	return current.err
+}
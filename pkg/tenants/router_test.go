@@ -0,0 +1,65 @@
+package tenants_test
+
+import (
+	"context"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg/tenants"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestRouter(t *testing.T) {
+	suite.Run(t, new(RouterTestSuite))
+}
+
+type RouterTestSuite struct {
+	suite.Suite
+	ctx  context.Context
+	mock *driver.MockDriver
+}
+
+func (s *RouterTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mock = driver.NewMockDriver()
+}
+
+func (s *RouterTestSuite) TestRoutesQueriesToTheDatabaseOfTheTenant() {
+	s.mock.ExpectQuery(`^MATCH \(u:User\)`).OnDatabase("tenant-acme")
+	s.mock.ExpectQuery(`^MATCH \(u:User\)`).OnDatabase("tenant-globex")
+	router := NewRouter(s.mock, RouterConfig{Database: func(tenant string) string { return "tenant-" + tenant }})
+
+	s.NoError(router.ExecuteQuery(WithTenant(s.ctx, "acme"), "MATCH (u:User) RETURN u", nil, consumeAll(s.ctx)))
+	s.NoError(router.ExecuteQuery(WithTenant(s.ctx, "globex"), "MATCH (u:User) RETURN u", nil, consumeAll(s.ctx), driver.WithDatabase("tenant-acme")))
+
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *RouterTestSuite) TestRejectsQueriesWithoutTenant() {
+	router := NewRouter(s.mock, RouterConfig{})
+
+	err := router.ExecuteQuery(s.ctx, "MATCH (u:User) RETURN u", nil, consumeAll(s.ctx))
+
+	s.ErrorIs(err, ErrNoTenant)
+}
+
+func (s *RouterTestSuite) TestCreatesTheDatabaseOnFirstUse() {
+	s.mock.ExpectQuery(`^CREATE DATABASE \$name IF NOT EXISTS$`).OnDatabase("system").WithParams(map[string]interface{}{"name": "acme"})
+	s.mock.ExpectQuery(`^SHOW DATABASES`).OnDatabase("system").WillReturn([]string{"currentStatus"}, []any{"online"})
+	s.mock.ExpectQuery(`^MATCH \(u:User\)`).OnDatabase("acme").Times(2)
+	router := NewRouter(s.mock, RouterConfig{Provisioner: New(s.mock, Config{PollInterval: time.Millisecond})})
+	ctx := WithTenant(s.ctx, "acme")
+
+	s.NoError(router.ExecuteQuery(ctx, "MATCH (u:User) RETURN u", nil, consumeAll(ctx)))
+	s.NoError(router.ExecuteQuery(ctx, "MATCH (u:User) RETURN u", nil, consumeAll(ctx)))
+
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func consumeAll(ctx context.Context) driver.ResultsHookFn {
+	return func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(ctx)
+		return err
+	}
+}
@@ -0,0 +1,178 @@
+// Package tenants provisions a database per tenant through the resilient driver:
+//
+//	provisioner := tenants.New(d, tenants.Config{
+//		Migrations:  migrations,
+//		Constraints: []driver.Constraint{{Label: "User", Properties: []string{"id"}, Type: driver.ConstraintUnique}},
+//		Seed:        "MERGE (:Settings {plan: 'free'})",
+//	})
+//	err := provisioner.CreateTenant(ctx, "acme")
+//
+// CreateTenant creates the database of the tenant, waits for it to be online, converges its indexes and constraints,
+// applies its migrations and seeds its baseline data. every step is idempotent, so that a provisioning interrupted
+// halfway is completed by calling CreateTenant again. creating databases requires Neo4j Enterprise Edition.
+// Router then executes every query against the database of the tenant of its context, see WithTenant.
+package tenants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg/migrations"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"regexp"
+	"time"
+)
+
+// ErrInvalidTenantName is returned for names that are not valid database names
+var ErrInvalidTenantName = errors.New("invalid tenant name")
+
+// ErrTenantOffline is returned when the database of a tenant is not online before Config.OnlineTimeout
+var ErrTenantOffline = errors.New("tenant database is not online")
+
+// tenantNamePattern follows the database naming rules: 3 to 63 lowercase ASCII letters, digits, dots and dashes, starting with a letter
+var tenantNamePattern = regexp.MustCompile(`^[a-z][a-z0-9.-]{2,62}$`)
+
+// Config describes the baseline of every tenant database
+type Config struct {
+	// Indexes and Constraints are converged with driver.Schema before the migrations run
+	Indexes     []driver.Index
+	Constraints []driver.Constraint
+	// Migrations are applied with a migrations.Migrator configured with MigrationOptions
+	Migrations       []migrations.Migration
+	MigrationOptions []migrations.Option
+	// Seed is a Cypher script creating the baseline data, see driver.SplitScript. it should MERGE rather than CREATE,
+	// as it runs again when CreateTenant is retried
+	Seed string
+	// OnlineTimeout bounds the wait for a new database to be online, defaults to 2 minutes
+	OnlineTimeout time.Duration
+	// PollInterval is the delay between two checks of the database status, defaults to 500ms
+	PollInterval time.Duration
+}
+
+// Provisioner creates tenant databases
+type Provisioner struct {
+	executor driver.QueryExecutor
+	config   Config
+}
+
+// New creates a provisioner running its queries through executor, typically a *driver.Driver
+func New(executor driver.QueryExecutor, config Config) *Provisioner {
+	if config.OnlineTimeout <= 0 {
+		config.OnlineTimeout = 2 * time.Minute
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 500 * time.Millisecond
+	}
+	return &Provisioner{executor: executor, config: config}
+}
+
+// Runner returns a QueryRunner executing its queries against the database of the tenant
+func (p *Provisioner) Runner(name string) driver.QueryRunner {
+	return driver.OnDatabase(p.executor, name)
+}
+
+// CreateTenant creates the database of the tenant if it does not exist yet, waits for it to be online,
+// converges its schema, applies the pending migrations and seeds it
+func (p *Provisioner) CreateTenant(ctx context.Context, name string) error {
+	if !tenantNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidTenantName, name)
+	}
+	if err := p.createDatabase(ctx, name); err != nil {
+		return fmt.Errorf("provisioning tenant %q: creating database: %w", name, err)
+	}
+	if err := p.awaitOnline(ctx, name); err != nil {
+		return fmt.Errorf("provisioning tenant %q: %w", name, err)
+	}
+	if err := p.convergeSchema(ctx, name); err != nil {
+		return fmt.Errorf("provisioning tenant %q: converging schema: %w", name, err)
+	}
+	if err := p.migrate(ctx, name); err != nil {
+		return fmt.Errorf("provisioning tenant %q: migrating: %w", name, err)
+	}
+	if p.config.Seed != "" {
+		if err := p.executor.ExecuteScript(ctx, p.config.Seed, driver.WithDatabase(name), driver.WithQueryName("tenants-seed")); err != nil {
+			return fmt.Errorf("provisioning tenant %q: seeding: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (p *Provisioner) createDatabase(ctx context.Context, name string) error {
+	return p.executor.ExecuteQuery(ctx, "CREATE DATABASE $name IF NOT EXISTS", map[string]interface{}{"name": name},
+		consume(ctx), driver.WithDatabase(driver.SystemDatabase), driver.WithQueryName("tenants-create-database"))
+}
+
+// awaitOnline polls the status of the database on every cluster member until they all report it online
+func (p *Provisioner) awaitOnline(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.config.OnlineTimeout)
+	defer cancel()
+	for {
+		online, status, err := p.databaseStatus(ctx, name)
+		if err != nil {
+			return fmt.Errorf("checking database status: %w", err)
+		}
+		if online {
+			return nil
+		}
+		select {
+		case <-time.After(p.config.PollInterval):
+		case <-ctx.Done():
+			return fmt.Errorf("%w after %s, status: %s", ErrTenantOffline, p.config.OnlineTimeout, status)
+		}
+	}
+}
+
+func (p *Provisioner) databaseStatus(ctx context.Context, name string) (online bool, status string, err error) {
+	err = p.executor.ExecuteQuery(ctx, "SHOW DATABASES YIELD name, currentStatus WHERE name = $name RETURN currentStatus",
+		map[string]interface{}{"name": name}, func(result neo4j.ResultWithContext) error {
+			online, status = false, "unknown"
+			var record *neo4j.Record
+			for result.NextRecord(ctx, &record) {
+				status, _ = record.Values[0].(string)
+				online = status == "online"
+				if !online {
+					break
+				}
+			}
+			return result.Err()
+		}, driver.WithDatabase(driver.SystemDatabase), driver.WithQueryName("tenants-database-status"))
+	return online, status, err
+}
+
+func (p *Provisioner) convergeSchema(ctx context.Context, name string) error {
+	if len(p.config.Indexes) == 0 && len(p.config.Constraints) == 0 {
+		return nil
+	}
+	schema := driver.NewSchema(p.Runner(name))
+	for _, constraint := range p.config.Constraints {
+		if err := schema.EnsureConstraint(ctx, constraint); err != nil {
+			return err
+		}
+	}
+	for _, index := range p.config.Indexes {
+		if err := schema.EnsureIndex(ctx, index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Provisioner) migrate(ctx context.Context, name string) error {
+	if len(p.config.Migrations) == 0 {
+		return nil
+	}
+	migrator, err := migrations.New(p.Runner(name), p.config.Migrations, p.config.MigrationOptions...)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.Up(ctx)
+	return err
+}
+
+func consume(ctx context.Context) driver.ResultsHookFn {
+	return func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(ctx)
+		return err
+	}
+}
@@ -0,0 +1,70 @@
+package tenants_test
+
+import (
+	"context"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/fbiville/neo4j-go-driver-issue-451/pkg/migrations"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg/tenants"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestTenants(t *testing.T) {
+	suite.Run(t, new(TenantsTestSuite))
+}
+
+type TenantsTestSuite struct {
+	suite.Suite
+	ctx  context.Context
+	mock *driver.MockDriver
+}
+
+func (s *TenantsTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mock = driver.NewMockDriver()
+}
+
+func (s *TenantsTestSuite) TestProvisionsTheDatabaseOfTheTenant() {
+	s.mock.ExpectQuery(`^CREATE DATABASE \$name IF NOT EXISTS$`).OnDatabase("system").WithParams(map[string]interface{}{"name": "acme"})
+	s.mock.ExpectQuery(`^SHOW DATABASES`).OnDatabase("system").WillReturn([]string{"currentStatus"}, []any{"online"}, []any{"starting"})
+	s.mock.ExpectQuery(`^SHOW DATABASES`).OnDatabase("system").WillReturn([]string{"currentStatus"}, []any{"online"}, []any{"online"})
+	s.mock.ExpectQuery(`dbms.components`).OnDatabase("acme").WillReturn([]string{"version"}, []any{"5.5.0"})
+	s.mock.ExpectQuery(`^CREATE CONSTRAINT`).OnDatabase("acme")
+	s.mock.ExpectQuery(`^MATCH \(v:SchemaVersion`).OnDatabase("acme")
+	s.mock.ExpectQuery(`^CREATE CONSTRAINT schema_lock_name`).OnDatabase("acme")
+	s.mock.ExpectQuery(`^MERGE \(l:SchemaLock`).OnDatabase("acme").WillReturn([]string{"acquired"}, []any{int64(1)})
+	s.mock.ExpectQuery(`^MERGE \(v:SchemaVersion`).OnDatabase("acme").Times(2)
+	s.mock.ExpectQuery(`^CREATE INDEX user_name`).OnDatabase("acme")
+	s.mock.ExpectQuery(`^MATCH \(l:SchemaLock`).OnDatabase("acme")
+	s.mock.ExpectQuery(`^MERGE \(:Settings`).OnDatabase("acme")
+	provisioner := New(s.mock, Config{
+		Constraints:  []driver.Constraint{{Label: "User", Properties: []string{"id"}, Type: driver.ConstraintUnique}},
+		Migrations:   []migrations.Migration{{Version: 1, Name: "users", Up: []string{"CREATE INDEX user_name IF NOT EXISTS FOR (u:User) ON (u.name)"}}},
+		Seed:         "MERGE (:Settings {plan: 'free'});",
+		PollInterval: time.Millisecond,
+	})
+
+	s.Require().NoError(provisioner.CreateTenant(s.ctx, "acme"))
+
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *TenantsTestSuite) TestRejectsInvalidNames() {
+	provisioner := New(s.mock, Config{})
+
+	s.ErrorIs(provisioner.CreateTenant(s.ctx, "Acme Corp"), ErrInvalidTenantName)
+	s.ErrorIs(provisioner.CreateTenant(s.ctx, "a"), ErrInvalidTenantName)
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *TenantsTestSuite) TestGivesUpWhenTheDatabaseStaysOffline() {
+	s.mock.ExpectQuery(`^CREATE DATABASE`).OnDatabase("system")
+	s.mock.ExpectQuery(`^SHOW DATABASES`).OnDatabase("system").WillReturn([]string{"currentStatus"}, []any{"starting"}).Times(1000)
+	provisioner := New(s.mock, Config{OnlineTimeout: 20 * time.Millisecond, PollInterval: time.Millisecond})
+
+	err := provisioner.CreateTenant(s.ctx, "acme")
+
+	s.ErrorIs(err, ErrTenantOffline)
+	s.ErrorContains(err, "status: starting")
+}
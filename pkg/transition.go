@@ -0,0 +1,117 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"sort"
+	"strings"
+)
+
+// ErrIllegalTransition matches every *IllegalTransitionError with errors.Is
+var ErrIllegalTransition = errors.New("illegal status transition")
+
+// ErrNodeNotFound is returned when no node matches the given criteria
+var ErrNodeNotFound = errors.New("node not found")
+
+// IllegalTransitionError is returned when the current status of a node does not allow the requested transition
+type IllegalTransitionError struct {
+	From, To string
+}
+
+func (e *IllegalTransitionError) Error() string {
+	return fmt.Sprintf("%s from %q to %q", ErrIllegalTransition.Error(), e.From, e.To)
+}
+
+func (e *IllegalTransitionError) Is(target error) bool {
+	return target == ErrIllegalTransition
+}
+
+// StatusMachine encodes the allowed transitions of a node status property
+type StatusMachine struct {
+	label, property string
+	// sources maps every target status to the statuses allowed to reach it
+	sources map[string][]string
+}
+
+// NewStatusMachine creates a machine for the property of label nodes, transitions mapping every status to its allowed next statuses
+func NewStatusMachine(label, property string, transitions map[string][]string) *StatusMachine {
+	sources := map[string][]string{}
+	for from, targets := range transitions {
+		for _, to := range targets {
+			sources[to] = append(sources[to], from)
+		}
+	}
+	for to := range sources {
+		sort.Strings(sources[to])
+	}
+	return &StatusMachine{label: label, property: property, sources: sources}
+}
+
+// Allowed reports whether the machine allows moving from one status to another
+func (m *StatusMachine) Allowed(from, to string) bool {
+	for _, source := range m.sources[to] {
+		if source == from {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionQuery generates the guarded update moving the matched node to the given status.
+// the current status is checked once the write lock of the node is held, so that a single concurrent transition applies
+func (m *StatusMachine) TransitionQuery(match map[string]interface{}, to string) (string, map[string]interface{}) {
+	params := map[string]interface{}{"from": m.sources[to], "to": to}
+	status := "n." + QuoteIdentifier(m.property)
+	query := fmt.Sprintf("MATCH (n:%s)", QuoteIdentifier(m.label))
+	if predicates := propertyPredicates("n", "match", match, params); len(predicates) > 0 {
+		query += " WHERE " + strings.Join(predicates, " AND ")
+	}
+	query += lockedPredicates("n", []string{status + " IN $from"})
+	return query + fmt.Sprintf(" SET %s = $to RETURN count(n) AS applied", status), params
+}
+
+// Transition moves the node matching match to the given status.
+// it returns an *IllegalTransitionError if its current status does not allow it, or ErrNodeNotFound.
+func (d *Driver) Transition(ctx context.Context, machine *StatusMachine, match map[string]interface{}, to string, opts ...QueryOption) error {
+	query, params := machine.TransitionQuery(match, to)
+	applied := false
+	err := d.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) error {
+		record, err := result.Single(ctx)
+		if err != nil {
+			return err
+		}
+		count, _ := record.Values[0].(int64)
+		applied = count > 0
+		return nil
+	}, opts...)
+	if err != nil || applied {
+		return err
+	}
+	return d.illegalTransition(ctx, machine, match, to, opts)
+}
+
+// illegalTransition explains why a transition did not apply
+func (d *Driver) illegalTransition(ctx context.Context, machine *StatusMachine, match map[string]interface{}, to string, opts []QueryOption) error {
+	params := map[string]interface{}{}
+	predicates := propertyPredicates("n", "match", match, params)
+	query := fmt.Sprintf("MATCH (n:%s)", QuoteIdentifier(machine.label))
+	if len(predicates) > 0 {
+		query += " WHERE " + strings.Join(predicates, " AND ")
+	}
+	query += fmt.Sprintf(" RETURN n.%s AS status LIMIT 1", QuoteIdentifier(machine.property))
+	var transitionErr error = ErrNodeNotFound
+	err := d.ExecuteQuery(ctx, query, params, func(result neo4j.ResultWithContext) error {
+		var record *neo4j.Record
+		if result.NextRecord(ctx, &record) {
+			current, _ := record.Values[0].(string)
+			transitionErr = &IllegalTransitionError{From: current, To: to}
+		}
+		return result.Err()
+	}, opts...)
+	if err != nil {
+		return err
+	}
+	return transitionErr
+}
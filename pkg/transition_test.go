@@ -0,0 +1,46 @@
+package driver_test
+
+import (
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestStatusMachine(t *testing.T) {
+	suite.Run(t, new(StatusMachineTestSuite))
+}
+
+type StatusMachineTestSuite struct {
+	suite.Suite
+	machine *StatusMachine
+}
+
+func (s *StatusMachineTestSuite) SetupTest() {
+	s.machine = NewStatusMachine("Order", "status", map[string][]string{
+		"pending":   {"paid", "cancelled"},
+		"paid":      {"shipped", "cancelled"},
+		"shipped":   {"delivered"},
+		"cancelled": {},
+	})
+}
+
+func (s *StatusMachineTestSuite) TestAllowedTransitions() {
+	s.True(s.machine.Allowed("pending", "paid"))
+	s.True(s.machine.Allowed("paid", "cancelled"))
+	s.False(s.machine.Allowed("shipped", "cancelled"))
+	s.False(s.machine.Allowed("cancelled", "pending"))
+}
+
+func (s *StatusMachineTestSuite) TestGeneratesGuardedUpdate() {
+	query, params := s.machine.TransitionQuery(map[string]interface{}{"id": 42}, "cancelled")
+
+	s.Equal("MATCH (n:`Order`) WHERE n.`id` = $match0 SET n._lock = true REMOVE n._lock WITH n WHERE n.`status` IN $from SET n.`status` = $to RETURN count(n) AS applied", query)
+	s.Equal(map[string]interface{}{"match0": 42, "from": []string{"paid", "pending"}, "to": "cancelled"}, params)
+}
+
+func (s *StatusMachineTestSuite) TestIllegalTransitionErrorsAreTyped() {
+	var err error = &IllegalTransitionError{From: "shipped", To: "cancelled"}
+
+	s.ErrorIs(err, ErrIllegalTransition)
+	s.EqualError(err, `illegal status transition from "shipped" to "cancelled"`)
+}
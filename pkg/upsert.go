@@ -0,0 +1,109 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"strings"
+)
+
+// UpsertRow is one of the nodes written by UpsertBatch, see Upsert
+type UpsertRow struct {
+	Match, Create, Update map[string]interface{}
+}
+
+// UpsertQuery generates the MERGE run by Upsert
+func UpsertQuery(label string, matchProps, createProps, updateProps map[string]interface{}) (string, map[string]interface{}) {
+	params := map[string]interface{}{}
+	keys := sortedKeys(matchProps)
+	for i, key := range keys {
+		params[fmt.Sprintf("match%d", i)] = matchProps[key]
+	}
+	query := upsertMerge(label, keys, func(i int, _ string) string {
+		return fmt.Sprintf("$match%d", i)
+	})
+	return query + upsertSets(createProps, updateProps, params), params
+}
+
+// UpsertBatchQuery generates the UNWIND run by UpsertBatch. all the rows must match nodes on the same properties
+func UpsertBatchQuery(label string, rows []UpsertRow) (string, map[string]interface{}, error) {
+	if len(rows) == 0 {
+		return "", nil, errors.New("upsert batch requires rows")
+	}
+	keys := sortedKeys(rows[0].Match)
+	batch := make([]interface{}, len(rows))
+	hasCreate, hasUpdate := false, false
+	for i, row := range rows {
+		if strings.Join(sortedKeys(row.Match), ",") != strings.Join(keys, ",") {
+			return "", nil, fmt.Errorf("upsert batch row #%d matches on %v instead of %v", i+1, sortedKeys(row.Match), keys)
+		}
+		batch[i] = map[string]interface{}{"match": row.Match, "create": emptyIfNil(row.Create), "update": emptyIfNil(row.Update)}
+		hasCreate = hasCreate || len(row.Create) > 0
+		hasUpdate = hasUpdate || len(row.Update) > 0
+	}
+	query := "UNWIND $rows AS row " + upsertMerge(label, keys, func(_ int, key string) string {
+		return "row.match." + QuoteIdentifier(key)
+	})
+	if hasCreate {
+		query += " ON CREATE SET n += row.create"
+	}
+	if hasUpdate {
+		query += " ON MATCH SET n += row.update"
+	}
+	return query, map[string]interface{}{"rows": batch}, nil
+}
+
+// Upsert creates the node of label matching matchProps with createProps, or updates it with updateProps if it exists.
+// the match properties should be backed by a uniqueness constraint, so that concurrent upserts cannot create duplicates
+func (d *Driver) Upsert(ctx context.Context, label string, matchProps, createProps, updateProps map[string]interface{}, opts ...QueryOption) error {
+	if len(matchProps) == 0 {
+		return errors.New("upsert requires properties to match")
+	}
+	query, params := UpsertQuery(label, matchProps, createProps, updateProps)
+	return d.ExecuteQuery(ctx, query, params, consumeResult(ctx), opts...)
+}
+
+// UpsertBatch upserts all the rows in one query, see Upsert
+func (d *Driver) UpsertBatch(ctx context.Context, label string, rows []UpsertRow, opts ...QueryOption) error {
+	query, params, err := UpsertBatchQuery(label, rows)
+	if err != nil {
+		return err
+	}
+	return d.ExecuteQuery(ctx, query, params, consumeResult(ctx), opts...)
+}
+
+func upsertMerge(label string, keys []string, value func(i int, key string) string) string {
+	properties := make([]string, len(keys))
+	for i, key := range keys {
+		properties[i] = QuoteIdentifier(key) + ": " + value(i, key)
+	}
+	return fmt.Sprintf("MERGE (n:%s {%s})", QuoteIdentifier(label), strings.Join(properties, ", "))
+}
+
+func upsertSets(createProps, updateProps map[string]interface{}, params map[string]interface{}) string {
+	sets := ""
+	if len(createProps) > 0 {
+		params["create"] = createProps
+		sets += " ON CREATE SET n += $create"
+	}
+	if len(updateProps) > 0 {
+		params["update"] = updateProps
+		sets += " ON MATCH SET n += $update"
+	}
+	return sets
+}
+
+func emptyIfNil(props map[string]interface{}) map[string]interface{} {
+	if props == nil {
+		return map[string]interface{}{}
+	}
+	return props
+}
+
+func consumeResult(ctx context.Context) ResultsHookFn {
+	return func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(ctx)
+		return err
+	}
+}
@@ -0,0 +1,57 @@
+package driver_test
+
+import (
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestUpsert(t *testing.T) {
+	suite.Run(t, new(UpsertTestSuite))
+}
+
+type UpsertTestSuite struct {
+	suite.Suite
+}
+
+func (s *UpsertTestSuite) TestMergesOnMatchPropertiesWithConflictSets() {
+	query, params := UpsertQuery("User", map[string]interface{}{"tenant": "t1", "email": "a@example.com"},
+		map[string]interface{}{"created": true}, map[string]interface{}{"seen": 2})
+
+	s.Equal("MERGE (n:`User` {`email`: $match0, `tenant`: $match1}) ON CREATE SET n += $create ON MATCH SET n += $update", query)
+	s.Equal(map[string]interface{}{
+		"match0": "a@example.com",
+		"match1": "t1",
+		"create": map[string]interface{}{"created": true},
+		"update": map[string]interface{}{"seen": 2},
+	}, params)
+}
+
+func (s *UpsertTestSuite) TestOmitsEmptySets() {
+	query, _ := UpsertQuery("User", map[string]interface{}{"id": 1}, nil, map[string]interface{}{"seen": 2})
+
+	s.Equal("MERGE (n:`User` {`id`: $match0}) ON MATCH SET n += $update", query)
+}
+
+func (s *UpsertTestSuite) TestUnwindsBatches() {
+	query, params, err := UpsertBatchQuery("User", []UpsertRow{
+		{Match: map[string]interface{}{"id": 1}, Create: map[string]interface{}{"name": "alice"}},
+		{Match: map[string]interface{}{"id": 2}, Update: map[string]interface{}{"name": "bob"}},
+	})
+
+	s.Require().NoError(err)
+	s.Equal("UNWIND $rows AS row MERGE (n:`User` {`id`: row.match.`id`}) ON CREATE SET n += row.create ON MATCH SET n += row.update", query)
+	s.Equal(map[string]interface{}{"rows": []interface{}{
+		map[string]interface{}{"match": map[string]interface{}{"id": 1}, "create": map[string]interface{}{"name": "alice"}, "update": map[string]interface{}{}},
+		map[string]interface{}{"match": map[string]interface{}{"id": 2}, "create": map[string]interface{}{}, "update": map[string]interface{}{"name": "bob"}},
+	}}, params)
+}
+
+func (s *UpsertTestSuite) TestRejectsBatchesMatchingOnDifferentProperties() {
+	_, _, err := UpsertBatchQuery("User", []UpsertRow{
+		{Match: map[string]interface{}{"id": 1}},
+		{Match: map[string]interface{}{"email": "a@example.com"}},
+	})
+
+	s.ErrorContains(err, "row #2 matches on [email] instead of [id]")
+}
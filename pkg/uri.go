@@ -0,0 +1,191 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidConnectionString is returned by NewDriver and Settings.Validate for connection strings the driver cannot use
+var ErrInvalidConnectionString = errors.New("invalid connection string")
+
+// defaultBoltPort is the port of connection strings without one
+const defaultBoltPort = 7687
+
+// supportedSchemes are the schemes of the connection strings. neo4j schemes route queries across a cluster,
+// bolt schemes connect to a single server. +s encrypts with certificates signed by a trusted authority,
+// +ssc accepts self-signed certificates.
+var supportedSchemes = []string{"neo4j", "neo4j+s", "neo4j+ssc", "bolt", "bolt+s", "bolt+ssc"}
+
+// ConnectionInfo holds the components of a connection string, e.g. to label logs and metrics. it never includes credentials
+type ConnectionInfo struct {
+	Scheme string
+	Host   string
+	Port   int
+	// RoutingContext is sent to the cluster when fetching routing tables, see Settings.RoutingContext
+	RoutingContext map[string]string
+}
+
+// Routed reports whether queries are routed across the members of a cluster
+func (i ConnectionInfo) Routed() bool {
+	return strings.HasPrefix(i.Scheme, "neo4j")
+}
+
+// Encrypted reports whether connections use TLS
+func (i ConnectionInfo) Encrypted() bool {
+	return strings.Contains(i.Scheme, "+")
+}
+
+// Address returns host:port
+func (i ConnectionInfo) Address() string {
+	return fmt.Sprintf("%s:%d", i.Host, i.Port)
+}
+
+// Labels returns the components as metric labels
+func (i ConnectionInfo) Labels() map[string]string {
+	return map[string]string{"scheme": i.Scheme, "host": i.Host, "port": strconv.Itoa(i.Port)}
+}
+
+// ParseConnectionInfo validates a connection string and the routing context overriding its own, see Settings
+func ParseConnectionInfo(connectionString string, routingContext map[string]string) (ConnectionInfo, error) {
+	parsed, err := url.Parse(connectionString)
+	if err != nil {
+		return ConnectionInfo{}, fmt.Errorf("%w: %v", ErrInvalidConnectionString, err)
+	}
+	info := ConnectionInfo{Scheme: strings.ToLower(parsed.Scheme), Host: parsed.Hostname(), Port: defaultBoltPort}
+	if !isSupportedScheme(info.Scheme) {
+		return info, fmt.Errorf("%w: unsupported scheme %q in %q, use one of %s", ErrInvalidConnectionString, parsed.Scheme, redactedConnectionString(parsed), strings.Join(supportedSchemes, ", "))
+	}
+	if info.Host == "" {
+		return info, fmt.Errorf("%w: missing host in %q, e.g. %s://localhost:%d", ErrInvalidConnectionString, redactedConnectionString(parsed), info.Scheme, defaultBoltPort)
+	}
+	if port := parsed.Port(); port != "" {
+		if info.Port, err = strconv.Atoi(port); err != nil || info.Port <= 0 || info.Port > 65535 {
+			return info, fmt.Errorf("%w: invalid port %q in %q", ErrInvalidConnectionString, port, redactedConnectionString(parsed))
+		}
+	}
+	info.RoutingContext = map[string]string{}
+	for key, values := range parsed.Query() {
+		if _, found := uriOptions[strings.ToLower(key)]; !found {
+			info.RoutingContext[key] = values[len(values)-1]
+		}
+	}
+	for key, value := range routingContext {
+		info.RoutingContext[key] = value
+	}
+	if len(info.RoutingContext) > 0 && !info.Routed() {
+		return info, fmt.Errorf("%w: Routing context is not supported by %s:// direct connections, use the matching neo4j scheme", ErrInvalidConnectionString, info.Scheme)
+	}
+	return info, nil
+}
+
+func isSupportedScheme(scheme string) bool {
+	for _, supported := range supportedSchemes {
+		if scheme == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedConnectionString returns the connection string without its credentials, to be reported in errors
+func redactedConnectionString(parsed *url.URL) string {
+	redacted := *parsed
+	redacted.User = nil
+	return redacted.String()
+}
+
+// redactConnectionString parses the connection string and returns it without its credentials, e.g. for the debug bundles
+func redactConnectionString(connectionString string) string {
+	parsed, err := url.Parse(connectionString)
+	if err != nil {
+		return ""
+	}
+	return redactedConnectionString(parsed)
+}
+
+// configurerFn parses the value of a connection string option into a configurer of the underlying driver
+type configurerFn func(value string) (func(*neo4j.Config), error)
+
+// uriOptions are the driver options accepted as connection string query parameters.
+// every other parameter is left in the connection string and becomes part of the routing context (e.g. policy tags),
+// which only neo4j:// schemes support.
+var uriOptions = map[string]configurerFn{
+	"connection_timeout": durationOption(func(config *neo4j.Config, value time.Duration) {
+		config.SocketConnectTimeout = value
+	}),
+	"connection_acquisition_timeout": durationOption(func(config *neo4j.Config, value time.Duration) {
+		config.ConnectionAcquisitionTimeout = value
+	}),
+	"max_connection_lifetime": durationOption(func(config *neo4j.Config, value time.Duration) {
+		config.MaxConnectionLifetime = value
+	}),
+	"max_transaction_retry_time": durationOption(func(config *neo4j.Config, value time.Duration) {
+		config.MaxTransactionRetryTime = value
+	}),
+	"max_connection_pool_size": intOption(func(config *neo4j.Config, value int) {
+		config.MaxConnectionPoolSize = value
+	}),
+	"fetch_size": intOption(func(config *neo4j.Config, value int) {
+		config.FetchSize = value
+	}),
+	"socket_keepalive": func(value string) (func(*neo4j.Config), error) {
+		keepalive, err := strconv.ParseBool(value)
+		return func(config *neo4j.Config) { config.SocketKeepalive = keepalive }, err
+	},
+	"user_agent": func(value string) (func(*neo4j.Config), error) {
+		return func(config *neo4j.Config) { config.UserAgent = value }, nil
+	},
+}
+
+// parseConnectionString splits a connection string into the target handed to the underlying driver,
+// the configurers of the driver options it embeds and the credentials of its user info, if any.
+// routingContext is added to the query parameters of the target, overriding the ones with the same key.
+// durations are either Go durations (e.g. 5s) or milliseconds.
+func parseConnectionString(connectionString string, routingContext map[string]string) (target string, configurers []func(*neo4j.Config), user *url.Userinfo, err error) {
+	parsed, err := url.Parse(connectionString)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	user, parsed.User = parsed.User, nil
+	query := parsed.Query()
+	for key, values := range query {
+		option, found := uriOptions[strings.ToLower(key)]
+		if !found {
+			continue
+		}
+		value := values[len(values)-1]
+		configurer, err := option(value)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("invalid connection string option %s=%q: %w", key, value, err)
+		}
+		configurers = append(configurers, configurer)
+		query.Del(key)
+	}
+	for key, value := range routingContext {
+		query.Set(key, value)
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), configurers, user, nil
+}
+
+func durationOption(set func(*neo4j.Config, time.Duration)) configurerFn {
+	return func(value string) (func(*neo4j.Config), error) {
+		duration, err := time.ParseDuration(value)
+		if millis, parseErr := strconv.ParseInt(value, 10, 64); parseErr == nil {
+			duration, err = time.Duration(millis)*time.Millisecond, nil
+		}
+		return func(config *neo4j.Config) { set(config, duration) }, err
+	}
+}
+
+func intOption(set func(*neo4j.Config, int)) configurerFn {
+	return func(value string) (func(*neo4j.Config), error) {
+		parsed, err := strconv.Atoi(value)
+		return func(config *neo4j.Config) { set(config, parsed) }, err
+	}
+}
@@ -0,0 +1,76 @@
+package driver
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DriverUsage is a snapshot of the ExecuteQuery calls of a driver, see Driver.Usage
+type DriverUsage struct {
+	// Queries is the number of completed calls, successful or not
+	Queries int64
+	// Failures counts the failed calls by category, see ErrorToStatus
+	Failures map[ErrorCategory]int64
+	// Reconnects and RoutingRefreshes count the recoveries the calls went through
+	Reconnects, RoutingRefreshes int64
+	// InFlight is the number of calls in progress
+	InFlight int64
+	// AverageLatency is the mean duration of the completed calls, queueing and retries included
+	AverageLatency time.Duration
+	// LastError is the message of the last failure, LastErrorAt its time. they are empty until a call fails
+	LastError   string
+	LastErrorAt time.Time
+}
+
+type usageStats struct {
+	inFlight     atomic.Int64
+	mutex        sync.Mutex
+	queries      int64
+	failures     map[ErrorCategory]int64
+	reconnects   int64
+	refreshes    int64
+	totalLatency time.Duration
+	lastErr      string
+	lastErrAt    time.Time
+}
+
+// Usage returns the number of queries, failures, recoveries and the average latency of the driver since it was created,
+// e.g. to expose its health on a debug endpoint without a metrics stack. see Stats for the size of the graph
+func (d *Driver) Usage() DriverUsage {
+	d.usage.mutex.Lock()
+	defer d.usage.mutex.Unlock()
+	usage := DriverUsage{
+		Queries:          d.usage.queries,
+		Failures:         make(map[ErrorCategory]int64, len(d.usage.failures)),
+		Reconnects:       d.usage.reconnects,
+		RoutingRefreshes: d.usage.refreshes,
+		InFlight:         d.usage.inFlight.Load(),
+		LastError:        d.usage.lastErr,
+		LastErrorAt:      d.usage.lastErrAt,
+	}
+	for category, count := range d.usage.failures {
+		usage.Failures[category] = count
+	}
+	if d.usage.queries > 0 {
+		usage.AverageLatency = d.usage.totalLatency / time.Duration(d.usage.queries)
+	}
+	return usage
+}
+
+func (s *usageStats) record(options *queryOptions, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.queries++
+	s.totalLatency += time.Since(options.start)
+	s.reconnects += int64(options.stats.Reconnects)
+	s.refreshes += int64(options.stats.RoutingRefreshes)
+	if err == nil {
+		return
+	}
+	if s.failures == nil {
+		s.failures = map[ErrorCategory]int64{}
+	}
+	s.failures[ErrorToStatus(err).Category]++
+	s.lastErr, s.lastErrAt = err.Error(), time.Now()
+}
@@ -0,0 +1,64 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestUsage(t *testing.T) {
+	suite.Run(t, new(UsageTestSuite))
+}
+
+type UsageTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	restore func()
+	driver  *Driver
+}
+
+func (s *UsageTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.restore = UseDriverFactory((&fakeCluster{}).newDriver)
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	s.driver = driver
+}
+
+func (s *UsageTestSuite) TearDownTest() {
+	s.driver.Close(s.ctx)
+	s.restore()
+}
+
+func (s *UsageTestSuite) TestCountsTheQueriesAndTheirFailures() {
+	var inFlight int64
+	s.Require().NoError(s.driver.ExecuteQuery(s.ctx, "RETURN 1", nil, func(neo4j.ResultWithContext) error {
+		inFlight = s.driver.Usage().InFlight
+		return nil
+	}))
+	err := s.driver.ExecuteQuery(s.ctx, "RETURN 1", nil, func(neo4j.ResultWithContext) error {
+		return errors.New("unexpected record")
+	})
+
+	usage := s.driver.Usage()
+
+	s.Error(err)
+	s.Equal(int64(1), inFlight)
+	s.Equal(int64(2), usage.Queries)
+	s.Equal(int64(0), usage.InFlight)
+	s.Equal(map[ErrorCategory]int64{CategoryInternal: 1}, usage.Failures)
+	s.Equal("unexpected record", usage.LastError)
+	s.False(usage.LastErrorAt.IsZero())
+}
+
+func (s *UsageTestSuite) TestIsEmptyBeforeTheFirstQuery() {
+	usage := s.driver.Usage()
+
+	s.Zero(usage.Queries)
+	s.Zero(usage.AverageLatency)
+	s.Empty(usage.Failures)
+	s.Empty(usage.LastError)
+}
@@ -0,0 +1,85 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"sync"
+	"time"
+)
+
+const defaultWarmUpTimeout = 30 * time.Second
+const warmUpRetryDelay = 250 * time.Millisecond
+
+// WarmUpConfig configures the connections NewDriver establishes before returning,
+// so that the first queries do not pay the connection establishment and routing table cold start
+type WarmUpConfig struct {
+	// Connections is the number of pooled connections opened concurrently once the server is reachable.
+	// only connectivity is verified when zero
+	Connections int
+	// Timeout bounds the warm-up, retries included. defaults to 30s
+	Timeout time.Duration
+	// Retries is how many times a failed warm-up is retried before NewDriver fails, e.g. while the server starts
+	Retries int
+}
+
+// warmUp verifies connectivity, then opens config.Connections connections at once so that they are all kept in the pool
+func (d *Driver) warmUp(config WarmUpConfig) error {
+	return d.warmUpDriver(context.Background(), d.currentGeneration().driver, config)
+}
+
+// warmUpDriver warms driver up, see warmUp
+func (d *Driver) warmUpDriver(ctx context.Context, driver neo4j.DriverWithContext, config WarmUpConfig) error {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultWarmUpTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = openConnections(ctx, driver, config.Connections); err == nil {
+			return nil
+		}
+		if attempt >= config.Retries {
+			break
+		}
+		d.logger().Printf("[neo4j warm-up] attempt %d failed, retrying: %v", attempt+1, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("warming up connections: %w", err)
+		case <-time.After(warmUpRetryDelay):
+		}
+	}
+	return fmt.Errorf("warming up connections: %w", err)
+}
+
+func openConnections(ctx context.Context, driver neo4j.DriverWithContext, count int) error {
+	if err := driver.VerifyConnectivity(ctx); err != nil {
+		return err
+	}
+	errs := make(chan error, count)
+	wg := &sync.WaitGroup{}
+	wg.Add(count)
+	for i := 0; i < count; i++ {
+		go func() {
+			defer wg.Done()
+			// concurrent sessions cannot share a connection, so each one leaves its own in the pool
+			session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+			defer session.Close(ctx)
+			result, err := session.Run(ctx, "RETURN 1", nil)
+			if err == nil {
+				_, err = result.Consume(ctx)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
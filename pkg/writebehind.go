@@ -0,0 +1,495 @@
+package driver
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrWriteBehindDisabled is returned by EnqueueWrite when EnableWriteBehind was not called
+var ErrWriteBehindDisabled = errors.New("write-behind queue is not enabled")
+
+// ErrWriteQueueStopped is returned when enqueuing writes once the write-behind queue is stopped,
+// or once it stopped delivering because its driver was closed
+var ErrWriteQueueStopped = errors.New("write-behind queue is stopped")
+
+const (
+	defaultWriteRetryDelay    = 100 * time.Millisecond
+	defaultMaxWriteRetryDelay = 30 * time.Second
+	writeBehindBatchSize      = 100
+	queuedWriteFileExtension  = ".write"
+)
+
+func init() {
+	gob.Register([]string{})
+	gob.Register([]int64{})
+	gob.Register([]float64{})
+}
+
+// QueuedWrite is a write waiting in a WriteStore to be delivered
+type QueuedWrite struct {
+	// ID is assigned by the store, in enqueuing order
+	ID         uint64
+	Query      string
+	Params     map[string]interface{}
+	EnqueuedAt time.Time
+}
+
+// WriteStore holds the queued writes until they are delivered. MemoryWriteStore and FileWriteStore implement it,
+// other local stores, e.g. bbolt, can be plugged in by implementing it
+type WriteStore interface {
+	// Append stores the write and returns it with its ID
+	Append(write QueuedWrite) (QueuedWrite, error)
+	// Pending returns at most limit writes, oldest first
+	Pending(limit int) ([]QueuedWrite, error)
+	// Ack removes a delivered write
+	Ack(id uint64) error
+}
+
+// WriteBehindConfig configures the write-behind queue of EnableWriteBehind
+type WriteBehindConfig struct {
+	// Store holds the writes until they are delivered, defaults to a MemoryWriteStore. use a FileWriteStore
+	// for the writes to survive restarts
+	Store WriteStore
+	// RetryDelay is the delay before the first retry of a failed delivery, doubled for every retry up to MaxRetryDelay.
+	// defaults to 100ms and 30s
+	RetryDelay, MaxRetryDelay time.Duration
+	// Timeout bounds every delivery attempt, no timeout when zero
+	Timeout time.Duration
+	// MaxAttempts bounds the delivery attempts of every write, unbounded when zero
+	MaxAttempts int
+	// DeadLetters, if set, receives the writes the server rejected for good, e.g. because of a syntax error,
+	// and the ones whose attempts were exhausted. EnableWriteBehind defaults it to Settings.DeadLetterHandler
+	DeadLetters DeadLetterHandler
+}
+
+// WriteBehindReport summarizes the activity of a write-behind queue
+type WriteBehindReport struct {
+	Enqueued, Delivered, Dropped, Retries int
+	// LastErr is the error of the last failed delivery attempt, nil once a delivery succeeds
+	LastErr error
+}
+
+// WriteBehindQueue delivers the writes of its store in order, at least once: a write is only removed from the store
+// once the server acknowledged it, so writes may be replayed after a crash and should be idempotent, e.g. MERGE.
+// failed deliveries are retried with an exponential backoff as long as the error is not a client error,
+// which keeps the writes during long outages while ExecuteQuery reconnects.
+type WriteBehindQueue struct {
+	runner QueryRunner
+	config WriteBehindConfig
+	wake   chan struct{}
+	done   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	mutex  sync.Mutex
+	report WriteBehindReport
+	closed bool
+}
+
+// NewWriteBehindQueue starts a WriteBehindQueue delivering to runner, beginning with the writes left in the store
+func NewWriteBehindQueue(runner QueryRunner, config WriteBehindConfig) *WriteBehindQueue {
+	if config.Store == nil {
+		config.Store = NewMemoryWriteStore()
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaultWriteRetryDelay
+	}
+	if config.MaxRetryDelay <= 0 {
+		config.MaxRetryDelay = defaultMaxWriteRetryDelay
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	queue := &WriteBehindQueue{
+		runner: runner,
+		config: config,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	go queue.run()
+	return queue
+}
+
+// EnableWriteBehind delivers the writes of EnqueueWrite through this driver until the returned WriteBehindQueue is stopped
+func (d *Driver) EnableWriteBehind(config WriteBehindConfig) *WriteBehindQueue {
+	if config.DeadLetters == nil {
+		config.DeadLetters = d.settings.DeadLetterHandler
+	}
+	queue := NewWriteBehindQueue(d, config)
+	d.accessLock.Lock()
+	defer d.accessLock.Unlock()
+	d.writeBehind = queue
+	return queue
+}
+
+// EnqueueWrite stores the write in the write-behind queue and returns without waiting for its delivery, see EnableWriteBehind
+func (d *Driver) EnqueueWrite(query string, params map[string]interface{}) error {
+	d.accessLock.RLock()
+	queue := d.writeBehind
+	d.accessLock.RUnlock()
+	if queue == nil {
+		return ErrWriteBehindDisabled
+	}
+	return queue.Enqueue(query, params)
+}
+
+// Enqueue stores the write, it is delivered in the background
+func (q *WriteBehindQueue) Enqueue(query string, params map[string]interface{}) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return ErrWriteQueueStopped
+	}
+	if _, err := q.config.Store.Append(QueuedWrite{Query: query, Params: CoerceParams(params), EnqueuedAt: time.Now()}); err != nil {
+		return fmt.Errorf("storing write: %w", err)
+	}
+	q.report.Enqueued++
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Report returns a snapshot of the queue activity
+func (q *WriteBehindQueue) Report() WriteBehindReport {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.report
+}
+
+// Stop stops accepting writes and waits for the queued ones to be delivered, or for ctx to be done.
+// the writes left undelivered stay in the store, to be delivered by the next queue using it
+func (q *WriteBehindQueue) Stop(ctx context.Context) error {
+	q.mutex.Lock()
+	q.closed = true
+	q.mutex.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-q.done
+		return ctx.Err()
+	}
+}
+
+func (q *WriteBehindQueue) run() {
+	defer close(q.done)
+	defer q.cancel()
+	// nothing delivers the writes enqueued once the delivery stopped, e.g. after the driver was closed
+	defer func() {
+		q.mutex.Lock()
+		q.closed = true
+		q.mutex.Unlock()
+	}()
+	for {
+		writes, err := q.config.Store.Pending(writeBehindBatchSize)
+		if err != nil {
+			q.recordFailure(fmt.Errorf("reading pending writes: %w", err))
+			if !q.sleep(q.config.RetryDelay) {
+				return
+			}
+			continue
+		}
+		if len(writes) == 0 {
+			if q.isClosed() {
+				return
+			}
+			select {
+			case <-q.wake:
+			case <-q.ctx.Done():
+				return
+			}
+			continue
+		}
+		for _, write := range writes {
+			if !q.deliver(write) {
+				return
+			}
+		}
+	}
+}
+
+// deliver executes the write until it succeeds or fails for good, it returns false when the queue must stop
+func (q *WriteBehindQueue) deliver(write QueuedWrite) bool {
+	delay := q.config.RetryDelay
+	for attempts := 1; ; attempts++ {
+		err := q.execute(write)
+		if errors.Is(err, ErrDriverClosed) || q.ctx.Err() != nil {
+			return false
+		}
+		exhausted := q.config.MaxAttempts > 0 && attempts >= q.config.MaxAttempts
+		if err != nil && !isPermanentWriteError(err) && !exhausted {
+			q.recordFailure(err)
+			if !q.sleep(delay) {
+				return false
+			}
+			if delay *= 2; delay > q.config.MaxRetryDelay {
+				delay = q.config.MaxRetryDelay
+			}
+			continue
+		}
+		if ackErr := q.config.Store.Ack(write.ID); ackErr != nil {
+			q.recordFailure(fmt.Errorf("acknowledging write %d: %w", write.ID, ackErr))
+			return q.sleep(delay)
+		}
+		q.mutex.Lock()
+		if err != nil {
+			q.report.Dropped++
+		} else {
+			q.report.Delivered++
+		}
+		q.report.LastErr = err
+		q.mutex.Unlock()
+		if err != nil && q.config.DeadLetters != nil {
+			q.config.DeadLetters(q.ctx, DeadLetter{Query: write.Query, Params: write.Params, Err: err, Attempts: attempts, At: time.Now()})
+		}
+		return true
+	}
+}
+
+func (q *WriteBehindQueue) execute(write QueuedWrite) error {
+	ctx := q.ctx
+	if q.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.config.Timeout)
+		defer cancel()
+	}
+	return q.runner.ExecuteQuery(ctx, write.Query, write.Params, func(result neo4j.ResultWithContext) error {
+		_, err := result.Consume(ctx)
+		return err
+	})
+}
+
+func (q *WriteBehindQueue) recordFailure(err error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.report.Retries++
+	q.report.LastErr = err
+}
+
+func (q *WriteBehindQueue) isClosed() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.closed
+}
+
+// sleep waits for delay, it returns false if the queue was cancelled meanwhile
+func (q *WriteBehindQueue) sleep(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-q.ctx.Done():
+		return false
+	}
+}
+
+// isPermanentWriteError tells the writes the server rejected, which would fail again if retried
+func isPermanentWriteError(err error) bool {
+	neo4jErr := &neo4j.Neo4jError{}
+	return errors.As(err, &neo4jErr) && neo4jErr.Classification() == "ClientError" && !neo4j.IsRetryable(err)
+}
+
+// MemoryWriteStore holds the queued writes in memory, they are lost when the process exits
+type MemoryWriteStore struct {
+	mutex  sync.Mutex
+	writes []QueuedWrite
+	nextID uint64
+}
+
+// NewMemoryWriteStore creates an empty MemoryWriteStore
+func NewMemoryWriteStore() *MemoryWriteStore {
+	return &MemoryWriteStore{}
+}
+
+// Append stores the write and returns it with its ID
+func (s *MemoryWriteStore) Append(write QueuedWrite) (QueuedWrite, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.nextID++
+	write.ID = s.nextID
+	s.writes = append(s.writes, write)
+	return write, nil
+}
+
+// Pending returns at most limit writes, oldest first
+func (s *MemoryWriteStore) Pending(limit int) ([]QueuedWrite, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if limit > len(s.writes) {
+		limit = len(s.writes)
+	}
+	return append([]QueuedWrite(nil), s.writes[:limit]...), nil
+}
+
+// Ack removes a delivered write, unknown ids are ignored
+func (s *MemoryWriteStore) Ack(id uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, write := range s.writes {
+		if write.ID == id {
+			s.writes = append(s.writes[:i], s.writes[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// FileWriteStore holds every queued write in its own gob file of a directory, synced to disk before Append returns,
+// so that the writes survive restarts. the lists of maps of the params, e.g. the batches of UNWIND, are read back
+// as []interface{}
+type FileWriteStore struct {
+	mutex  sync.Mutex
+	dir    string
+	nextID uint64
+}
+
+// NewFileWriteStore creates the store of dir, creating dir if needed, and resumes after the writes it holds
+func NewFileWriteStore(dir string) (*FileWriteStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	store := &FileWriteStore{dir: dir}
+	ids, err := store.ids()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		store.nextID = ids[len(ids)-1]
+	}
+	return store, nil
+}
+
+// Append writes the write to a temporary file renamed once synced, so that partial writes are never read
+func (s *FileWriteStore) Append(write QueuedWrite) (QueuedWrite, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	write.ID = s.nextID + 1
+	temporary, err := os.CreateTemp(s.dir, "pending-*")
+	if err != nil {
+		return write, err
+	}
+	defer os.Remove(temporary.Name())
+	stored := write
+	if write.Params != nil {
+		stored.Params = toStorable(write.Params).(map[string]interface{})
+	}
+	err = gob.NewEncoder(temporary).Encode(stored)
+	if err == nil {
+		err = temporary.Sync()
+	}
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temporary.Name(), s.path(write.ID))
+	}
+	if err != nil {
+		return write, err
+	}
+	s.nextID = write.ID
+	return write, nil
+}
+
+// Pending returns at most limit writes, oldest first
+func (s *FileWriteStore) Pending(limit int) ([]QueuedWrite, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+	if limit > len(ids) {
+		limit = len(ids)
+	}
+	writes := make([]QueuedWrite, limit)
+	for i, id := range ids[:limit] {
+		if err := s.read(id, &writes[i]); err != nil {
+			return nil, fmt.Errorf("reading write %d: %w", id, err)
+		}
+	}
+	return writes, nil
+}
+
+// Ack removes the file of a delivered write, unknown ids are ignored
+func (s *FileWriteStore) Ack(id uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileWriteStore) path(id uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", id, queuedWriteFileExtension))
+}
+
+func (s *FileWriteStore) read(id uint64, write *QueuedWrite) error {
+	file, err := os.Open(s.path(id))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := gob.NewDecoder(file).Decode(write); err != nil {
+		return err
+	}
+	if write.Params != nil {
+		write.Params = fromSpillable(write.Params).(map[string]interface{})
+	}
+	return nil
+}
+
+// toStorable replaces the values of the params gob cannot encode, like toSpillable,
+// and turns the lists of maps into lists, as gob only decodes the types it knows
+func toStorable(value interface{}) interface{} {
+	switch value := value.(type) {
+	case []map[string]interface{}:
+		list := make([]interface{}, len(value))
+		for i, element := range value {
+			list[i] = toStorable(element)
+		}
+		return list
+	case neo4j.Date, neo4j.LocalTime, neo4j.LocalDateTime, neo4j.Time:
+		return toSpillable(value)
+	default:
+		return mapValues(value, toStorable)
+	}
+}
+
+// ids returns the ids of the stored writes in ascending order
+func (s *FileWriteStore) ids() ([]uint64, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, queuedWriteFileExtension) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, queuedWriteFileExtension), 10, 64)
+		if err == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
@@ -0,0 +1,166 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/fbiville/neo4j-go-driver-issue-451/pkg"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/suite"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteBehind(t *testing.T) {
+	suite.Run(t, new(WriteBehindTestSuite))
+}
+
+type WriteBehindTestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *WriteBehindTestSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+func (s *WriteBehindTestSuite) TestDeliversWritesInOrderAcrossOutages() {
+	runner := &flakyRunner{failures: 3, err: errors.New("ConnectivityError: connection refused")}
+	queue := NewWriteBehindQueue(runner, WriteBehindConfig{RetryDelay: time.Millisecond})
+
+	s.Require().NoError(queue.Enqueue("CREATE (:Event {i: $i})", map[string]interface{}{"i": 1}))
+	s.Require().NoError(queue.Enqueue("CREATE (:Event {i: $i})", map[string]interface{}{"i": 2}))
+	s.Require().NoError(queue.Stop(s.ctx))
+
+	s.Equal([]interface{}{1, 1, 1, 1, 2}, runner.executedParams("i"))
+	report := queue.Report()
+	s.Equal(2, report.Delivered)
+	s.Equal(3, report.Retries)
+	s.NoError(report.LastErr)
+	s.ErrorIs(queue.Enqueue("CREATE (:Event)", nil), ErrWriteQueueStopped)
+}
+
+func (s *WriteBehindTestSuite) TestRejectsWritesOnceTheDriverIsClosed() {
+	restore := UseDriverFactory((&fakeCluster{}).newDriver)
+	defer restore()
+	driver, err := NewDriver(connectionSettings)
+	s.Require().NoError(err)
+	driver.EnableWriteBehind(WriteBehindConfig{RetryDelay: time.Millisecond})
+	driver.Close(s.ctx)
+
+	s.Require().NoError(driver.EnqueueWrite("CREATE (:Event)", nil))
+
+	s.Eventually(func() bool {
+		return errors.Is(driver.EnqueueWrite("CREATE (:Event)", nil), ErrWriteQueueStopped)
+	}, time.Second, time.Millisecond, "the writes enqueued once the delivery stopped would never be delivered")
+}
+
+func (s *WriteBehindTestSuite) TestDropsWritesRejectedByTheServer() {
+	rejection := &neo4j.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError", Msg: "invalid input"}
+	runner := &flakyRunner{failures: 1, err: rejection}
+	var dropped []DeadLetter
+	queue := NewWriteBehindQueue(runner, WriteBehindConfig{RetryDelay: time.Millisecond, DeadLetters: func(_ context.Context, letter DeadLetter) {
+		dropped = append(dropped, letter)
+	}})
+
+	s.Require().NoError(queue.Enqueue("CREATE (:Event", nil))
+	s.Require().NoError(queue.Enqueue("CREATE (:Event)", nil))
+	s.Require().NoError(queue.Stop(s.ctx))
+
+	s.Require().Len(dropped, 1)
+	s.Equal("CREATE (:Event", dropped[0].Query)
+	s.Equal(1, dropped[0].Attempts)
+	s.ErrorIs(dropped[0].Err, rejection)
+	s.Equal(WriteBehindReport{Enqueued: 2, Delivered: 1, Dropped: 1}, queue.Report())
+}
+
+func (s *WriteBehindTestSuite) TestStoresDeadLettersOnceAttemptsAreExhausted() {
+	runner := &flakyRunner{failures: 1000, err: errors.New("ConnectivityError: connection refused")}
+	deadLetters := NewMemoryWriteStore()
+	queue := NewWriteBehindQueue(runner, WriteBehindConfig{RetryDelay: time.Millisecond, MaxAttempts: 3, DeadLetters: DeadLettersTo(deadLetters, nil)})
+
+	s.Require().NoError(queue.Enqueue("CREATE (:Event {i: $i})", map[string]interface{}{"i": 1}))
+	s.Require().NoError(queue.Stop(s.ctx))
+
+	s.Len(runner.executedParams("i"), 3)
+	letters, err := deadLetters.Pending(10)
+	s.Require().NoError(err)
+	s.Require().Len(letters, 1)
+	s.Equal(map[string]interface{}{"i": 1}, letters[0].Params)
+	replayed := &flakyRunner{}
+	s.Require().NoError(NewWriteBehindQueue(replayed, WriteBehindConfig{Store: deadLetters}).Stop(s.ctx))
+	s.Equal([]interface{}{1}, replayed.executedParams("i"))
+}
+
+func (s *WriteBehindTestSuite) TestKeepsUndeliveredWritesInTheFileStore() {
+	dir := s.T().TempDir()
+	store, err := NewFileWriteStore(dir)
+	s.Require().NoError(err)
+	down := &flakyRunner{failures: 1000, err: errors.New("ConnectivityError: connection refused")}
+	queue := NewWriteBehindQueue(down, WriteBehindConfig{Store: store, RetryDelay: time.Millisecond})
+	s.Require().NoError(queue.Enqueue("CREATE (:Event {tags: $tags})", map[string]interface{}{"tags": []string{"a"}}))
+	ctx, cancel := context.WithTimeout(s.ctx, 20*time.Millisecond)
+	defer cancel()
+	s.ErrorIs(queue.Stop(ctx), context.DeadlineExceeded)
+
+	restarted, err := NewFileWriteStore(dir)
+	s.Require().NoError(err)
+	up := &flakyRunner{}
+	s.Require().NoError(NewWriteBehindQueue(up, WriteBehindConfig{Store: restarted}).Stop(s.ctx))
+
+	s.Equal([]interface{}{[]string{"a"}}, up.executedParams("tags"))
+	pending, err := restarted.Pending(10)
+	s.Require().NoError(err)
+	s.Empty(pending)
+}
+
+func (s *WriteBehindTestSuite) TestStoresBatchesAndTemporalParamsInTheFileStore() {
+	store, err := NewFileWriteStore(s.T().TempDir())
+	s.Require().NoError(err)
+	day := neo4j.DateOf(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	at := neo4j.LocalDateTimeOf(time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC))
+	params := map[string]interface{}{
+		"day":    day,
+		"events": []map[string]interface{}{{"id": int64(1), "at": at}, {"id": int64(2), "at": at}},
+	}
+
+	_, err = store.Append(QueuedWrite{Query: "UNWIND $events AS event CREATE (:Event {id: event.id, at: event.at, day: $day})", Params: params})
+	s.Require().NoError(err)
+
+	pending, err := store.Pending(10)
+	s.Require().NoError(err)
+	s.Require().Len(pending, 1)
+	s.Equal(map[string]interface{}{
+		"day":    day,
+		"events": []interface{}{map[string]interface{}{"id": int64(1), "at": at}, map[string]interface{}{"id": int64(2), "at": at}},
+	}, pending[0].Params)
+}
+
+// flakyRunner fails the first failures executions with err, and records the params of all of them
+type flakyRunner struct {
+	mutex    sync.Mutex
+	failures int
+	err      error
+	params   []map[string]interface{}
+}
+
+func (f *flakyRunner) ExecuteQuery(_ context.Context, _ string, params map[string]interface{}, onResults ResultsHookFn, _ ...QueryOption) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.params = append(f.params, params)
+	if f.failures > 0 {
+		f.failures--
+		return f.err
+	}
+	return onResults(newFakeResult(nil))
+}
+
+func (f *flakyRunner) executedParams(key string) []interface{} {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	values := make([]interface{}, len(f.params))
+	for i, params := range f.params {
+		values[i] = params[key]
+	}
+	return values
+}